@@ -9,10 +9,18 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/api/option"
 
+	"github.com/vinylhousegarage/idpproxy/internal/auth/backchannel"
+	firesessionstore "github.com/vinylhousegarage/idpproxy/internal/auth/sessionstore/firestore"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	authorizestore "github.com/vinylhousegarage/idpproxy/internal/authorize/store"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
 	"github.com/vinylhousegarage/idpproxy/internal/config"
 	"github.com/vinylhousegarage/idpproxy/internal/deps"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/idpproxy/endsession"
 	"github.com/vinylhousegarage/idpproxy/internal/router"
 	"github.com/vinylhousegarage/idpproxy/internal/server"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
 	"github.com/vinylhousegarage/idpproxy/public"
 )
 
@@ -54,9 +62,60 @@ func main() {
 
 	systemDeps := deps.NewSystemDeps(config.GoogleOIDCMetadataURL, httpClient, logger)
 
+	oidcCfg, err := config.LoadOIDCConfig()
+	if err != nil {
+		logger.Fatal("failed to load OIDC config", zap.Error(err))
+	}
+
+	oidcDeps := deps.NewOIDCDeps(oidcCfg, logger)
+	oidcDeps.HTTPClient = httpClient
+
+	signingKeys, err := config.LoadSigningKeyRingConfig()
+	if err != nil {
+		logger.Warn("signing key is not configured; JWKS endpoint is disabled", zap.Error(err))
+	} else {
+		ring, closer, err := newSigningKeyRing(ctx, signingKeys)
+		if err != nil {
+			logger.Fatal("failed to load signing keys", zap.Error(err))
+		}
+		ring.Logger = logger
+		if closer != nil {
+			defer func() { _ = closer.Close() }()
+		}
+		logger.Info("signing key ring loaded", zap.String("active_kid", ring.KeyID()), zap.Any("states", ring.States()))
+		oidcDeps.Signer = ring
+	}
+
+	fsClient, err := app.Firestore(ctx)
+	if err != nil {
+		logger.Fatal("failed to initialize Firestore client", zap.Error(err))
+	}
+	defer func() { _ = fsClient.Close() }()
+
+	oidcDeps.ProxyCodes = authcodestore.NewFirestoreStore(fsClient)
+	tokenRepo := store.NewRepo(fsClient)
+	oidcDeps.RefreshTokens = tokenRepo
+	oidcDeps.AccessGenerations = tokenRepo
+	oidcDeps.AccessDenylist = tokenRepo
+	oidcDeps.BackchannelDeliveries = tokenRepo
+
+	oidcDeps.Clients = client.NewFirestoreRegistry(fsClient)
+	oidcDeps.Authorizations = authorizestore.NewFirestoreStore(fsClient)
+	oidcDeps.Users = users.NewFirestoreRepository(fsClient)
+	oidcDeps.Sessions = firesessionstore.NewRepository(fsClient, "sessions")
+
+	var onShutdown []func()
+	if oidcDeps.Signer != nil {
+		queue := backchannel.NewQueue(endsession.NewBackchannelNotifier(oidcDeps), logger,
+			backchannel.DefaultQueueSize, backchannel.DefaultQueueWorkers)
+		oidcDeps.Backchannel = queue
+		onShutdown = append(onShutdown, queue.Close)
+	}
+
 	d := router.NewRouterDeps(public.PublicFS, githubAPIDeps, githubOAuthDeps, googleDeps, logger, systemDeps)
+	d.OIDC = oidcDeps
 	r := router.NewRouter(d)
 
 	logger.Info("starting idpproxy (dev)", zap.String("addr", ":"+config.GetPort()))
-	server.StartServer(r, logger)
+	server.StartServer(r, logger, onShutdown...)
 }
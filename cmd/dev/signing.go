@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"io"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/kms"
+)
+
+func newSigningKeyRing(ctx context.Context, keys []config.SigningKeyConfig) (*signer.KeyRing, io.Closer, error) {
+	var (
+		kmsClient kms.SigningClient
+		closer    io.Closer
+	)
+
+	ring := make(signer.StaticKeySource, 0, len(keys))
+	for _, k := range keys {
+		var (
+			s   signer.Signer
+			err error
+		)
+
+		if k.KMSKeyVersion != "" {
+			if kmsClient == nil {
+				cli, err := kms.NewClient(ctx, config.LoadServiceAccountConfig().ImpersonateSA)
+				if err != nil {
+					return nil, nil, err
+				}
+				kmsClient, closer = cli, cli
+			}
+			s, err = signer.NewKMSSigner(ctx, kmsClient, k.KMSKeyVersion)
+		} else {
+			s, err = signer.NewSignerFromPEM(k.PrivateKeyPEM, k.KeyID)
+		}
+		if err != nil {
+			if closer != nil {
+				_ = closer.Close()
+			}
+			return nil, nil, err
+		}
+
+		ring = append(ring, signer.RingKey{
+			Signer:     s,
+			State:      signer.KeyState(k.State),
+			ActivateAt: k.ActivateAt,
+			RetireAt:   k.RetireAt,
+			RemoveAt:   k.RemoveAt,
+		})
+	}
+
+	r, err := signer.NewKeyRing(ctx, ring, 0)
+	if err != nil {
+		if closer != nil {
+			_ = closer.Close()
+		}
+		return nil, nil, err
+	}
+
+	return r, closer, nil
+}
@@ -0,0 +1,13 @@
+package accesstoken
+
+import "errors"
+
+var (
+	ErrInvalidAudience = errors.New("accesstoken: invalid audience")
+	ErrInvalidClient   = errors.New("accesstoken: missing client_id")
+	ErrInvalidIssuer   = errors.New("accesstoken: invalid issuer")
+	ErrInvalidSubject  = errors.New("accesstoken: invalid subject")
+	ErrInvalidToken    = errors.New("accesstoken: invalid token")
+	ErrMissingToken    = errors.New("accesstoken: missing bearer token")
+	ErrRevoked         = errors.New("accesstoken: revoked by generation")
+)
@@ -0,0 +1,32 @@
+package accesstoken
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+)
+
+type GenerationSource interface {
+	Get(ctx context.Context, userID string) (*store.AccessGenerationRecord, error)
+}
+
+type DenylistSource interface {
+	IsDenied(ctx context.Context, jti string) (bool, error)
+}
+
+func currentGeneration(ctx context.Context, src GenerationSource, userID string) (int, error) {
+	if src == nil {
+		return 0, nil
+	}
+
+	rec, err := src.Get(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return rec.Gen, nil
+}
@@ -0,0 +1,11 @@
+package accesstoken
+
+import "time"
+
+type AccessTokenInput struct {
+	UserID   string
+	ClientID string
+	Scope    string
+	Now      time.Time
+	TTL      time.Duration
+}
@@ -0,0 +1,62 @@
+package accesstoken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IssueAccessTokenUsecase struct {
+	Issuer string
+	Signer Signer
+
+	// optional
+	Generations GenerationSource
+}
+
+func (uc *IssueAccessTokenUsecase) Issue(ctx context.Context, in *AccessTokenInput) (token string, kid string, err error) {
+	if uc == nil || uc.Signer == nil || uc.Issuer == "" {
+		return "", "", errors.New("accesstoken: invalid usecase configuration")
+	}
+	if in == nil {
+		return "", "", errors.New("accesstoken: nil input")
+	}
+	if in.TTL <= 0 {
+		return "", "", errors.New("accesstoken: TTL must be > 0")
+	}
+	if in.UserID == "" {
+		return "", "", errors.New("accesstoken: empty subject")
+	}
+	if in.ClientID == "" {
+		return "", "", errors.New("accesstoken: empty client_id")
+	}
+
+	now := in.Now.UTC()
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+
+	gen, err := currentGeneration(ctx, uc.Generations, in.UserID)
+	if err != nil {
+		return "", "", fmt.Errorf("accesstoken: load generation: %w", err)
+	}
+
+	payload := map[string]any{
+		"iss":       uc.Issuer,
+		"sub":       in.UserID,
+		"aud":       uc.Issuer,
+		"client_id": in.ClientID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(in.TTL).Unix(),
+		"jti":       uuid.NewString(),
+		"gen":       gen,
+	}
+	if in.Scope != "" {
+		payload["scope"] = in.Scope
+	}
+
+	return uc.Signer.SignJWT(ctx, payload)
+}
@@ -0,0 +1,126 @@
+package accesstoken
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSigner struct {
+	got map[string]any
+	err error
+}
+
+func (f *fakeSigner) SignJWT(_ context.Context, payload map[string]any) (string, string, error) {
+	f.got = payload
+	if f.err != nil {
+		return "", "", f.err
+	}
+	return "jwt.mock", "kid-1", nil
+}
+
+func TestIssue(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_800_000_000, 0).UTC()
+	validInput := func() *AccessTokenInput {
+		return &AccessTokenInput{
+			UserID:   "github:user-123",
+			ClientID: "client-abc",
+			Scope:    "openid profile",
+			Now:      now,
+			TTL:      15 * time.Minute,
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		s := &fakeSigner{}
+		uc := &IssueAccessTokenUsecase{Issuer: "https://idpproxy.com", Signer: s}
+
+		token, kid, err := uc.Issue(context.Background(), validInput())
+		require.NoError(t, err)
+		require.Equal(t, "jwt.mock", token)
+		require.Equal(t, "kid-1", kid)
+
+		require.Equal(t, "https://idpproxy.com", s.got["iss"])
+		require.Equal(t, "https://idpproxy.com", s.got["aud"])
+		require.Equal(t, "github:user-123", s.got["sub"])
+		require.Equal(t, "client-abc", s.got["client_id"])
+		require.Equal(t, "openid profile", s.got["scope"])
+		require.EqualValues(t, now.Unix(), s.got["iat"])
+		require.EqualValues(t, now.Add(15*time.Minute).Unix(), s.got["exp"])
+		require.NotEmpty(t, s.got["jti"])
+		require.Equal(t, 0, s.got["gen"])
+	})
+
+	t.Run("embeds the current generation", func(t *testing.T) {
+		t.Parallel()
+
+		s := &fakeSigner{}
+		uc := &IssueAccessTokenUsecase{
+			Issuer:      "https://idpproxy.com",
+			Signer:      s,
+			Generations: &fakeGenerations{gens: map[string]int{"github:user-123": 7}},
+		}
+		_, _, err := uc.Issue(context.Background(), validInput())
+		require.NoError(t, err)
+		require.Equal(t, 7, s.got["gen"])
+
+		uc.Generations = &fakeGenerations{err: errors.New("firestore down")}
+		_, _, err = uc.Issue(context.Background(), validInput())
+		require.Error(t, err)
+	})
+
+	t.Run("jti is unique per token", func(t *testing.T) {
+		t.Parallel()
+
+		s := &fakeSigner{}
+		uc := &IssueAccessTokenUsecase{Issuer: "https://idpproxy.com", Signer: s}
+
+		_, _, err := uc.Issue(context.Background(), validInput())
+		require.NoError(t, err)
+		first := s.got["jti"]
+
+		_, _, err = uc.Issue(context.Background(), validInput())
+		require.NoError(t, err)
+		require.NotEqual(t, first, s.got["jti"])
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name   string
+			uc     *IssueAccessTokenUsecase
+			mutate func(in *AccessTokenInput) *AccessTokenInput
+		}{
+			{"missing issuer", &IssueAccessTokenUsecase{Signer: &fakeSigner{}}, nil},
+			{"missing signer", &IssueAccessTokenUsecase{Issuer: "https://idpproxy.com"}, nil},
+			{"nil input", &IssueAccessTokenUsecase{Issuer: "https://idpproxy.com", Signer: &fakeSigner{}}, func(*AccessTokenInput) *AccessTokenInput { return nil }},
+			{"zero ttl", &IssueAccessTokenUsecase{Issuer: "https://idpproxy.com", Signer: &fakeSigner{}}, func(in *AccessTokenInput) *AccessTokenInput { in.TTL = 0; return in }},
+			{"empty subject", &IssueAccessTokenUsecase{Issuer: "https://idpproxy.com", Signer: &fakeSigner{}}, func(in *AccessTokenInput) *AccessTokenInput { in.UserID = ""; return in }},
+			{"empty client", &IssueAccessTokenUsecase{Issuer: "https://idpproxy.com", Signer: &fakeSigner{}}, func(in *AccessTokenInput) *AccessTokenInput { in.ClientID = ""; return in }},
+			{"signer error", &IssueAccessTokenUsecase{Issuer: "https://idpproxy.com", Signer: &fakeSigner{err: errors.New("boom")}}, nil},
+		}
+
+		for _, tc := range tests {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				in := validInput()
+				if tc.mutate != nil {
+					in = tc.mutate(in)
+				}
+
+				_, _, err := tc.uc.Issue(context.Background(), in)
+				require.Error(t, err)
+			})
+		}
+	})
+}
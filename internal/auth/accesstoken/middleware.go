@@ -0,0 +1,85 @@
+package accesstoken
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const claimsContextKey = "idpproxy.access_token_claims"
+
+type ClaimsVerifier interface {
+	Verify(ctx context.Context, token string) (*Claims, error)
+}
+
+// Middleware rejects requests without a valid bearer access token and stores its claims on the context.
+func Middleware(v ClaimsVerifier, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := BearerToken(c.Request)
+		if err != nil {
+			abortUnauthorized(c, err)
+
+			return
+		}
+
+		claims, err := v.Verify(c.Request.Context(), token)
+		if err != nil {
+			if logger != nil {
+				logger.Info("access token rejected", zap.String("path", c.Request.URL.Path), zap.Error(err))
+			}
+			abortUnauthorized(c, ErrInvalidToken)
+
+			return
+		}
+
+		c.Set(claimsContextKey, claims)
+		c.Next()
+	}
+}
+
+func ClaimsFromContext(c *gin.Context) (*Claims, bool) {
+	v, ok := c.Get(claimsContextKey)
+	if !ok {
+		return nil, false
+	}
+
+	claims, ok := v.(*Claims)
+	return claims, ok
+}
+
+func BearerToken(r *http.Request) (string, error) {
+	authz := r.Header.Get("Authorization")
+	if authz == "" {
+		return "", ErrMissingToken
+	}
+
+	scheme, token, ok := strings.Cut(authz, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", ErrInvalidToken
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", ErrInvalidToken
+	}
+
+	return token, nil
+}
+
+func abortUnauthorized(c *gin.Context, err error) {
+	c.Header("Cache-Control", "no-store")
+
+	if errors.Is(err, ErrMissingToken) {
+		c.Header("WWW-Authenticate", `Bearer`)
+		c.AbortWithStatus(http.StatusUnauthorized)
+
+		return
+	}
+
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+}
@@ -0,0 +1,66 @@
+package accesstoken
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeClaimsVerifier struct{}
+
+func (fakeClaimsVerifier) Verify(_ context.Context, token string) (*Claims, error) {
+	if token != "good-token" {
+		return nil, ErrInvalidToken
+	}
+	return &Claims{Subject: "github:1", ClientID: "client-1"}, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/protected", Middleware(fakeClaimsVerifier{}, zap.NewNop()), func(c *gin.Context) {
+		claims, ok := ClaimsFromContext(c)
+		require.True(t, ok)
+		c.String(http.StatusOK, claims.Subject)
+	})
+
+	tests := []struct {
+		name       string
+		authz      string
+		wantStatus int
+		wantAuthn  string
+	}{
+		{name: "valid token", authz: "Bearer good-token", wantStatus: http.StatusOK},
+		{name: "scheme is case insensitive", authz: "bearer good-token", wantStatus: http.StatusOK},
+		{name: "missing token", wantStatus: http.StatusUnauthorized, wantAuthn: "Bearer"},
+		{name: "invalid token", authz: "Bearer bad-token", wantStatus: http.StatusUnauthorized, wantAuthn: `Bearer error="invalid_token"`},
+		{name: "wrong scheme", authz: "Basic Zm9vOmJhcg==", wantStatus: http.StatusUnauthorized, wantAuthn: `Bearer error="invalid_token"`},
+		{name: "empty bearer", authz: "Bearer ", wantStatus: http.StatusUnauthorized, wantAuthn: `Bearer error="invalid_token"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			if tt.authz != "" {
+				req.Header.Set("Authorization", tt.authz)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			require.Equal(t, tt.wantAuthn, w.Header().Get("WWW-Authenticate"))
+			if tt.wantStatus == http.StatusOK {
+				require.Equal(t, "github:1", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package accesstoken
+
+import "context"
+
+type Signer interface {
+	SignJWT(ctx context.Context, payload map[string]any) (jwt string, kid string, err error)
+}
@@ -0,0 +1,32 @@
+package accesstoken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+)
+
+// SignerAdapter signs access tokens with the RFC 9068 "at+jwt" typ header.
+type SignerAdapter struct {
+	Signer signer.Signer
+}
+
+func NewSignerAdapter(s signer.Signer) *SignerAdapter {
+	return &SignerAdapter{Signer: s}
+}
+
+func (a *SignerAdapter) SignJWT(ctx context.Context, payload map[string]any) (string, string, error) {
+	ts, ok := a.Signer.(signer.TypedSigner)
+	if !ok {
+		return "", "", fmt.Errorf("accesstoken: %w", signer.ErrUnsupportedTyp)
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", "", fmt.Errorf("accesstoken: marshal payload: %w", err)
+	}
+
+	return ts.SignWithType(ctx, signer.TypeAccessToken, b)
+}
@@ -0,0 +1,131 @@
+package accesstoken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+)
+
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string, opt *signer.VerifyOptions) (*signer.VerifyResult, error)
+}
+
+type Claims struct {
+	Subject   string
+	ClientID  string
+	Scope     string
+	JTI       string
+	Gen       int
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type Verifier struct {
+	Issuer   string
+	Verifier TokenVerifier
+
+	// optional; tokens older than the user's current generation are rejected
+	Generations GenerationSource
+
+	// optional; tokens whose jti is listed are rejected
+	Denylist DenylistSource
+
+	// defaults to time.Now
+	Now func() time.Time
+}
+
+func NewVerifier(issuer string, v TokenVerifier) *Verifier {
+	return &Verifier{Issuer: issuer, Verifier: v}
+}
+
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	if v == nil || v.Verifier == nil || v.Issuer == "" {
+		return nil, errors.New("accesstoken: invalid verifier configuration")
+	}
+
+	opt := &signer.VerifyOptions{Now: v.Now, RequireTyp: true, ExpectTyp: signer.TypeAccessToken}
+	if opt.Now == nil {
+		opt.Now = time.Now
+	}
+
+	res, err := v.Verifier.Verify(ctx, token, opt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	c := res.Claims
+
+	if iss, _ := c.GetIssuer(); iss != v.Issuer {
+		return nil, ErrInvalidIssuer
+	}
+
+	aud, _ := c.GetAudience()
+	if !containsAudience(aud, v.Issuer) {
+		return nil, ErrInvalidAudience
+	}
+
+	sub, _ := c.GetSubject()
+	if sub == "" {
+		return nil, ErrInvalidSubject
+	}
+
+	clientID, _ := c["client_id"].(string)
+	if clientID == "" {
+		return nil, ErrInvalidClient
+	}
+
+	out := &Claims{
+		Subject:  sub,
+		ClientID: clientID,
+	}
+	out.Scope, _ = c["scope"].(string)
+	out.JTI, _ = c["jti"].(string)
+	out.IssuedAt = numericDate(c.GetIssuedAt())
+	out.ExpiresAt = numericDate(c.GetExpirationTime())
+	if gen, ok := c["gen"].(float64); ok {
+		out.Gen = int(gen)
+	}
+
+	current, err := currentGeneration(ctx, v.Generations, sub)
+	if err != nil {
+		return nil, fmt.Errorf("accesstoken: load generation: %w", err)
+	}
+	if out.Gen < current {
+		return nil, ErrRevoked
+	}
+
+	if v.Denylist != nil && out.JTI != "" {
+		denied, err := v.Denylist.IsDenied(ctx, out.JTI)
+		if err != nil {
+			return nil, fmt.Errorf("accesstoken: load denylist: %w", err)
+		}
+		if denied {
+			return nil, ErrRevoked
+		}
+	}
+
+	return out, nil
+}
+
+func containsAudience(aud jwt.ClaimStrings, want string) bool {
+	for _, a := range aud {
+		if a == want {
+			return true
+		}
+	}
+
+	return false
+}
+
+func numericDate(d *jwt.NumericDate, _ error) time.Time {
+	if d == nil {
+		return time.Time{}
+	}
+
+	return d.Time
+}
@@ -0,0 +1,211 @@
+package accesstoken
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/idtoken"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+)
+
+func newTestSigner(t *testing.T) signer.Signer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	s, err := signer.NewEd25519Signer(key, "ed-1")
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	t.Parallel()
+
+	const issuer = "https://idpproxy.com"
+
+	ctx := context.Background()
+	s := newTestSigner(t)
+	uc := &IssueAccessTokenUsecase{Issuer: issuer, Signer: NewSignerAdapter(s)}
+	v := NewVerifier(issuer, s)
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Now().UTC().Truncate(time.Second)
+		tok, _, err := uc.Issue(ctx, &AccessTokenInput{
+			UserID:   "github:1",
+			ClientID: "client-1",
+			Scope:    "openid email",
+			Now:      now,
+			TTL:      time.Minute,
+		})
+		require.NoError(t, err)
+
+		c, err := v.Verify(ctx, tok)
+		require.NoError(t, err)
+		require.Equal(t, "github:1", c.Subject)
+		require.Equal(t, "client-1", c.ClientID)
+		require.Equal(t, "openid email", c.Scope)
+		require.NotEmpty(t, c.JTI)
+		require.Zero(t, c.Gen)
+		require.True(t, c.IssuedAt.Equal(now))
+		require.True(t, c.ExpiresAt.Equal(now.Add(time.Minute)))
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		t.Parallel()
+
+		tok, _, err := uc.Issue(ctx, &AccessTokenInput{
+			UserID:   "github:1",
+			ClientID: "client-1",
+			Now:      time.Now().Add(-time.Hour),
+			TTL:      time.Minute,
+		})
+		require.NoError(t, err)
+
+		_, err = v.Verify(ctx, tok)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		t.Parallel()
+
+		other := &IssueAccessTokenUsecase{Issuer: "https://evil.example.com", Signer: NewSignerAdapter(s)}
+		tok, _, err := other.Issue(ctx, &AccessTokenInput{UserID: "github:1", ClientID: "client-1", TTL: time.Minute})
+		require.NoError(t, err)
+
+		_, err = v.Verify(ctx, tok)
+		require.ErrorIs(t, err, ErrInvalidIssuer)
+	})
+
+	t.Run("id token is not accepted", func(t *testing.T) {
+		t.Parallel()
+
+		idt := &idtoken.IssueIDTokenUsecase{Issuer: issuer, Signer: idtoken.NewSignerAdapter(s)}
+		tok, _, err := idt.Issue(ctx, &idtoken.IDTokenInput{UserID: "github:1", ClientID: "client-1", TTL: time.Minute})
+		require.NoError(t, err)
+
+		_, err = v.Verify(ctx, tok)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("token signed by another key", func(t *testing.T) {
+		t.Parallel()
+
+		foreign := &IssueAccessTokenUsecase{Issuer: issuer, Signer: NewSignerAdapter(newTestSigner(t))}
+		tok, _, err := foreign.Issue(ctx, &AccessTokenInput{UserID: "github:1", ClientID: "client-1", TTL: time.Minute})
+		require.NoError(t, err)
+
+		_, err = v.Verify(ctx, tok)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+type fakeGenerations struct {
+	mu   sync.Mutex
+	gens map[string]int
+	err  error
+}
+
+func (f *fakeGenerations) Get(_ context.Context, userID string) (*store.AccessGenerationRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+	gen, ok := f.gens[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &store.AccessGenerationRecord{UserID: userID, Gen: gen}, nil
+}
+
+func (f *fakeGenerations) bump(userID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.gens[userID]++
+}
+
+func TestVerifier_Generation(t *testing.T) {
+	t.Parallel()
+
+	const issuer = "https://idpproxy.com"
+
+	ctx := context.Background()
+	s := newTestSigner(t)
+	gens := &fakeGenerations{gens: map[string]int{"github:1": 3}}
+	uc := &IssueAccessTokenUsecase{Issuer: issuer, Signer: NewSignerAdapter(s), Generations: gens}
+	v := &Verifier{Issuer: issuer, Verifier: s, Generations: gens}
+
+	tok, _, err := uc.Issue(ctx, &AccessTokenInput{UserID: "github:1", ClientID: "client-1", TTL: time.Minute})
+	require.NoError(t, err)
+
+	c, err := v.Verify(ctx, tok)
+	require.NoError(t, err)
+	require.Equal(t, 3, c.Gen)
+
+	gens.bump("github:1")
+
+	_, err = v.Verify(ctx, tok)
+	require.ErrorIs(t, err, ErrRevoked)
+
+	fresh, _, err := uc.Issue(ctx, &AccessTokenInput{UserID: "github:1", ClientID: "client-1", TTL: time.Minute})
+	require.NoError(t, err)
+
+	c, err = v.Verify(ctx, fresh)
+	require.NoError(t, err)
+	require.Equal(t, 4, c.Gen)
+
+	unknown, _, err := uc.Issue(ctx, &AccessTokenInput{UserID: "github:2", ClientID: "client-1", TTL: time.Minute})
+	require.NoError(t, err)
+
+	c, err = v.Verify(ctx, unknown)
+	require.NoError(t, err)
+	require.Zero(t, c.Gen)
+
+	failing := &Verifier{Issuer: issuer, Verifier: s, Generations: &fakeGenerations{err: errors.New("firestore down")}}
+	_, err = failing.Verify(ctx, tok)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrRevoked)
+}
+
+type fakeDenylist map[string]bool
+
+func (f fakeDenylist) IsDenied(_ context.Context, jti string) (bool, error) {
+	return f[jti], nil
+}
+
+func TestVerifier_Denylist(t *testing.T) {
+	t.Parallel()
+
+	const issuer = "https://idpproxy.com"
+
+	ctx := context.Background()
+	s := newTestSigner(t)
+	denylist := fakeDenylist{}
+	uc := &IssueAccessTokenUsecase{Issuer: issuer, Signer: NewSignerAdapter(s)}
+	v := &Verifier{Issuer: issuer, Verifier: s, Denylist: denylist}
+
+	tok, _, err := uc.Issue(ctx, &AccessTokenInput{UserID: "github:1", ClientID: "client-1", TTL: time.Minute})
+	require.NoError(t, err)
+
+	c, err := v.Verify(ctx, tok)
+	require.NoError(t, err)
+
+	denylist[c.JTI] = true
+
+	_, err = v.Verify(ctx, tok)
+	require.ErrorIs(t, err, ErrRevoked)
+}
@@ -0,0 +1,55 @@
+package backchannel
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// MemoryDeliveryLog keeps one record per jti, like the Firestore log.
+type MemoryDeliveryLog struct {
+	mu      sync.Mutex
+	records []store.BackchannelDeliveryRecord
+}
+
+var _ store.BackchannelDeliveryLog = (*MemoryDeliveryLog)(nil)
+
+func NewMemoryDeliveryLog() *MemoryDeliveryLog {
+	return &MemoryDeliveryLog{}
+}
+
+func (l *MemoryDeliveryLog) LogDelivery(_ context.Context, rec *store.BackchannelDeliveryRecord) error {
+	if rec == nil {
+		return store.ErrInvalidArgument
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range l.records {
+		if l.records[i].JTI == rec.JTI {
+			l.records[i] = *rec
+			return nil
+		}
+	}
+	l.records = append(l.records, *rec)
+
+	return nil
+}
+
+func (l *MemoryDeliveryLog) Records() []store.BackchannelDeliveryRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := make([]store.BackchannelDeliveryRecord, len(l.records))
+	copy(out, l.records)
+
+	return out
+}
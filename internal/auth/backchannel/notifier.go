@@ -0,0 +1,249 @@
+package backchannel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+)
+
+const (
+	EventLogout = "http://schemas.openid.net/event/backchannel-logout"
+
+	DefaultTokenTTL     = 2 * time.Minute
+	DefaultMaxAttempts  = 3
+	DefaultRetryBackoff = 500 * time.Millisecond
+	DefaultHTTPTimeout  = 5 * time.Second
+)
+
+var (
+	ErrInvalidNotifierConfig = errors.New("backchannel: invalid notifier configuration")
+	ErrDeliveryFailed        = errors.New("backchannel: delivery failed")
+)
+
+type ClientRegistry interface {
+	Get(ctx context.Context, clientID string) (*client.Client, error)
+}
+
+// Notifier posts OIDC Back-Channel Logout tokens to every client that
+// received tokens within a session.
+type Notifier struct {
+	Issuer  string
+	Signer  Signer
+	Clients ClientRegistry
+
+	// optional
+	Log        store.BackchannelDeliveryLog
+	HTTPClient *http.Client
+
+	TokenTTL     time.Duration
+	MaxAttempts  int
+	RetryBackoff time.Duration
+
+	// defaults to time.Now
+	Now func() time.Time
+}
+
+func NewNotifier(issuer string, s Signer, clients ClientRegistry) *Notifier {
+	return &Notifier{
+		Issuer:  issuer,
+		Signer:  s,
+		Clients: clients,
+	}
+}
+
+func (n *Notifier) Notify(ctx context.Context, s *session.Session) error {
+	if n == nil || n.Issuer == "" || n.Signer == nil || n.Clients == nil {
+		return ErrInvalidNotifierConfig
+	}
+	if s == nil || len(s.ClientIDs) == 0 {
+		return nil
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for _, clientID := range s.ClientIDs {
+		cl, err := n.Clients.Get(ctx, clientID)
+		if err != nil {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("backchannel: load client %q: %w", clientID, err))
+			mu.Unlock()
+			continue
+		}
+		if cl.BackchannelLogoutURI == "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := n.deliver(ctx, cl, s); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) deliver(ctx context.Context, cl *client.Client, s *session.Session) error {
+	now := n.now()
+	jti := uuid.NewString()
+
+	token, _, err := n.Signer.SignJWT(ctx, map[string]any{
+		"iss":    n.Issuer,
+		"sub":    s.UserID,
+		"aud":    cl.ID,
+		"iat":    now.Unix(),
+		"exp":    now.Add(durationOr(n.TokenTTL, DefaultTokenTTL)).Unix(),
+		"jti":    jti,
+		"sid":    s.SessionID,
+		"events": map[string]any{EventLogout: map[string]any{}},
+	})
+	if err != nil {
+		return fmt.Errorf("backchannel: sign logout token: %w", err)
+	}
+
+	rec := &store.BackchannelDeliveryRecord{
+		JTI:       jti,
+		SessionID: s.SessionID,
+		UserID:    s.UserID,
+		ClientID:  cl.ID,
+		URI:       cl.BackchannelLogoutURI,
+		Status:    StatusPending,
+		CreatedAt: now,
+	}
+
+	// recorded up front so a delivery cut short by a restart is still visible
+	if n.Log != nil {
+		if err := n.Log.LogDelivery(ctx, rec); err != nil {
+			return fmt.Errorf("backchannel: log delivery: %w", err)
+		}
+	}
+
+	deliveryErr := n.post(ctx, rec, token)
+
+	rec.Status = StatusDelivered
+	if deliveryErr != nil {
+		rec.Status = StatusFailed
+		rec.LastError = deliveryErr.Error()
+	}
+	rec.CompletedAt = n.now()
+
+	if n.Log != nil {
+		if err := n.Log.LogDelivery(ctx, rec); err != nil {
+			deliveryErr = errors.Join(deliveryErr, fmt.Errorf("backchannel: log delivery: %w", err))
+		}
+	}
+
+	return deliveryErr
+}
+
+func (n *Notifier) post(ctx context.Context, rec *store.BackchannelDeliveryRecord, token string) error {
+	maxAttempts := n.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	backoff := durationOr(n.RetryBackoff, DefaultRetryBackoff)
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(lastErr, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		rec.Attempts = attempt
+
+		status, err := n.send(ctx, rec.URI, token)
+		rec.StatusCode = status
+		if err == nil {
+			return nil
+		}
+
+		lastErr = fmt.Errorf("%w: client %q: %w", ErrDeliveryFailed, rec.ClientID, err)
+		if !retryable(status) {
+			return lastErr
+		}
+	}
+
+	return lastErr
+}
+
+func (n *Notifier) send(ctx context.Context, uri, token string) (int, error) {
+	form := url.Values{"logout_token": {token}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := n.httpClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether a failed attempt may succeed later: transport
+// errors (status 0), throttling and server errors.
+func retryable(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+func (n *Notifier) httpClient() *http.Client {
+	if n.HTTPClient != nil {
+		return n.HTTPClient
+	}
+
+	return &http.Client{Timeout: DefaultHTTPTimeout}
+}
+
+func (n *Notifier) now() time.Time {
+	if n.Now != nil {
+		return n.Now().UTC()
+	}
+
+	return time.Now().UTC()
+}
+
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+
+	return fallback
+}
@@ -0,0 +1,164 @@
+package backchannel
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+)
+
+const testIssuer = "https://idp.example.com"
+
+type receiver struct {
+	mu       sync.Mutex
+	tokens   []string
+	statuses []int
+	calls    atomic.Int32
+}
+
+func newReceiver(t *testing.T, statuses ...int) (*receiver, *httptest.Server) {
+	t.Helper()
+
+	rcv := &receiver{statuses: statuses}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(rcv.calls.Add(1))
+
+		require.NoError(t, r.ParseForm())
+		rcv.mu.Lock()
+		rcv.tokens = append(rcv.tokens, r.PostForm.Get("logout_token"))
+		rcv.mu.Unlock()
+
+		status := http.StatusOK
+		if n <= len(rcv.statuses) {
+			status = rcv.statuses[n-1]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return rcv, srv
+}
+
+func newTestNotifier(t *testing.T, clients ...*client.Client) (*Notifier, signer.Signer, *MemoryDeliveryLog) {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	s, err := signer.NewEd25519Signer(key, "kid-1")
+	require.NoError(t, err)
+
+	log := NewMemoryDeliveryLog()
+	n := NewNotifier(testIssuer, NewSignerAdapter(s), client.NewMemoryRegistry(clients...))
+	n.Log = log
+	n.RetryBackoff = time.Millisecond
+
+	return n, s, log
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sess := &session.Session{
+		SessionID: "sid-1",
+		UserID:    "github:12345",
+		ClientIDs: []string{"client-1"},
+	}
+
+	t.Run("posts a signed logout token to each registered uri", func(t *testing.T) {
+		t.Parallel()
+
+		rcv, srv := newReceiver(t)
+		n, s, log := newTestNotifier(t,
+			&client.Client{ID: "client-1", BackchannelLogoutURI: srv.URL},
+			&client.Client{ID: "client-2"},
+		)
+
+		both := *sess
+		both.ClientIDs = []string{"client-1", "client-2"}
+
+		require.NoError(t, n.Notify(ctx, &both))
+		require.Len(t, rcv.tokens, 1)
+
+		res, err := s.Verify(ctx, rcv.tokens[0], &signer.VerifyOptions{RequireTyp: true, ExpectTyp: signer.TypeLogoutToken})
+		require.NoError(t, err)
+		require.Equal(t, testIssuer, res.Claims["iss"])
+		require.Equal(t, "client-1", res.Claims["aud"])
+		require.Equal(t, "github:12345", res.Claims["sub"])
+		require.Equal(t, "sid-1", res.Claims["sid"])
+		require.NotEmpty(t, res.Claims["jti"])
+		require.Contains(t, res.Claims["events"], EventLogout)
+		require.NotContains(t, res.Claims, "nonce")
+
+		records := log.Records()
+		require.Len(t, records, 1)
+		require.Equal(t, StatusDelivered, records[0].Status)
+		require.Equal(t, "client-1", records[0].ClientID)
+		require.Equal(t, 1, records[0].Attempts)
+		require.Equal(t, res.Claims["jti"], records[0].JTI)
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		t.Parallel()
+
+		rcv, srv := newReceiver(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+		n, _, log := newTestNotifier(t, &client.Client{ID: "client-1", BackchannelLogoutURI: srv.URL})
+
+		require.NoError(t, n.Notify(ctx, sess))
+		require.EqualValues(t, 3, rcv.calls.Load())
+
+		records := log.Records()
+		require.Len(t, records, 1)
+		require.Equal(t, StatusDelivered, records[0].Status)
+		require.Equal(t, 3, records[0].Attempts)
+		require.Equal(t, http.StatusOK, records[0].StatusCode)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		t.Parallel()
+
+		rcv, srv := newReceiver(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+		n, _, log := newTestNotifier(t, &client.Client{ID: "client-1", BackchannelLogoutURI: srv.URL})
+
+		err := n.Notify(ctx, sess)
+		require.ErrorIs(t, err, ErrDeliveryFailed)
+		require.EqualValues(t, 3, rcv.calls.Load())
+
+		records := log.Records()
+		require.Len(t, records, 1)
+		require.Equal(t, StatusFailed, records[0].Status)
+		require.Equal(t, http.StatusInternalServerError, records[0].StatusCode)
+		require.NotEmpty(t, records[0].LastError)
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		t.Parallel()
+
+		rcv, srv := newReceiver(t, http.StatusBadRequest)
+		n, _, log := newTestNotifier(t, &client.Client{ID: "client-1", BackchannelLogoutURI: srv.URL})
+
+		require.ErrorIs(t, n.Notify(ctx, sess), ErrDeliveryFailed)
+		require.EqualValues(t, 1, rcv.calls.Load())
+		require.Equal(t, StatusFailed, log.Records()[0].Status)
+	})
+
+	t.Run("session without clients is a no-op", func(t *testing.T) {
+		t.Parallel()
+
+		n, _, log := newTestNotifier(t)
+
+		require.NoError(t, n.Notify(ctx, &session.Session{SessionID: "sid-2", UserID: "github:1"}))
+		require.Empty(t, log.Records())
+	})
+}
@@ -0,0 +1,103 @@
+package backchannel
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+)
+
+const (
+	DefaultQueueSize    = 256
+	DefaultQueueWorkers = 4
+)
+
+var (
+	ErrQueueFull   = errors.New("backchannel: delivery queue is full")
+	ErrQueueClosed = errors.New("backchannel: delivery queue is closed")
+)
+
+// Queue hands sessions to background workers so logout does not wait for
+// relying parties; each delivery is still recorded in the notifier's log.
+type Queue struct {
+	notifier *Notifier
+	logger   *zap.Logger
+
+	jobs   chan session.Session
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewQueue(n *Notifier, logger *zap.Logger, size, workers int) *Queue {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	if workers <= 0 {
+		workers = DefaultQueueWorkers
+	}
+
+	q := &Queue{
+		notifier: n,
+		logger:   logger,
+		jobs:     make(chan session.Session, size),
+	}
+
+	q.wg.Add(workers)
+	for range workers {
+		go q.work()
+	}
+
+	return q
+}
+
+// Notify enqueues the session without blocking. The request context is not
+// kept, as deliveries outlive the logout request.
+func (q *Queue) Notify(_ context.Context, s *session.Session) error {
+	if s == nil || len(s.ClientIDs) == 0 {
+		return nil
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	select {
+	case q.jobs <- *s:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting sessions and waits for queued deliveries to finish.
+// Owners call it on shutdown so queued logouts are not lost.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	q.wg.Wait()
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	for s := range q.jobs {
+		if err := q.notifier.Notify(context.Background(), &s); err != nil {
+			q.logger.Warn("backchannel: logout delivery failed",
+				zap.String("session_id", s.SessionID),
+				zap.Error(err),
+			)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package backchannel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+)
+
+func TestQueue_Notify(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("delivers in the background and records the outcome", func(t *testing.T) {
+		t.Parallel()
+
+		rcv, srv := newReceiver(t, http.StatusServiceUnavailable)
+		n, _, log := newTestNotifier(t, &client.Client{ID: "client-1", BackchannelLogoutURI: srv.URL})
+
+		q := NewQueue(n, zap.NewNop(), 1, 1)
+		require.NoError(t, q.Notify(ctx, &session.Session{SessionID: "sid-1", UserID: "user-1", ClientIDs: []string{"client-1"}}))
+		q.Close()
+
+		require.EqualValues(t, 2, rcv.calls.Load())
+
+		records := log.Records()
+		require.Len(t, records, 1)
+		require.Equal(t, StatusDelivered, records[0].Status)
+		require.Equal(t, 2, records[0].Attempts)
+	})
+
+	t.Run("rejects sessions when the queue is full", func(t *testing.T) {
+		t.Parallel()
+
+		n, _, _ := newTestNotifier(t)
+		q := &Queue{notifier: n, logger: zap.NewNop(), jobs: make(chan session.Session, 1)}
+
+		s := &session.Session{SessionID: "sid-1", ClientIDs: []string{"client-1"}}
+		require.NoError(t, q.Notify(ctx, s))
+		require.ErrorIs(t, q.Notify(ctx, s), ErrQueueFull)
+	})
+
+	t.Run("close drains queued deliveries and rejects later ones", func(t *testing.T) {
+		t.Parallel()
+
+		rcv, srv := newReceiver(t, http.StatusOK)
+		n, _, log := newTestNotifier(t, &client.Client{ID: "client-1", BackchannelLogoutURI: srv.URL})
+
+		q := NewQueue(n, zap.NewNop(), 4, 1)
+		for _, sid := range []string{"sid-1", "sid-2", "sid-3"} {
+			require.NoError(t, q.Notify(ctx, &session.Session{SessionID: sid, UserID: "user-1", ClientIDs: []string{"client-1"}}))
+		}
+		q.Close()
+		q.Close()
+
+		require.EqualValues(t, 3, rcv.calls.Load())
+		require.Len(t, log.Records(), 3)
+
+		err := q.Notify(ctx, &session.Session{SessionID: "sid-4", ClientIDs: []string{"client-1"}})
+		require.ErrorIs(t, err, ErrQueueClosed)
+	})
+
+	t.Run("session without clients is not queued", func(t *testing.T) {
+		t.Parallel()
+
+		q := &Queue{jobs: make(chan session.Session)}
+		require.NoError(t, q.Notify(ctx, &session.Session{SessionID: "sid-1"}))
+	})
+}
@@ -0,0 +1,36 @@
+package backchannel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+)
+
+type Signer interface {
+	SignJWT(ctx context.Context, payload map[string]any) (jwt string, kid string, err error)
+}
+
+// SignerAdapter signs logout tokens with the "logout+jwt" typ header.
+type SignerAdapter struct {
+	Signer signer.Signer
+}
+
+func NewSignerAdapter(s signer.Signer) *SignerAdapter {
+	return &SignerAdapter{Signer: s}
+}
+
+func (a *SignerAdapter) SignJWT(ctx context.Context, payload map[string]any) (string, string, error) {
+	ts, ok := a.Signer.(signer.TypedSigner)
+	if !ok {
+		return "", "", fmt.Errorf("backchannel: %w", signer.ErrUnsupportedTyp)
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", "", fmt.Errorf("backchannel: marshal payload: %w", err)
+	}
+
+	return ts.SignWithType(ctx, signer.TypeLogoutToken, b)
+}
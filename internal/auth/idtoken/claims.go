@@ -13,6 +13,7 @@ type IDTokenClaims struct {
 	AMR      []string `json:"amr,omitempty"`
 	AtHash   string   `json:"at_hash,omitempty"`
 	Azp      string   `json:"azp,omitempty"`
+	Sid      string   `json:"sid,omitempty"`
 }
 
 func (c *IDTokenClaims) Validate() error {
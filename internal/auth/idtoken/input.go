@@ -13,6 +13,10 @@ type IDTokenInput struct {
 	AccessToken string
 	SignAlg     string
 
-	Nonce string
-	Azp   string
+	Nonce     string
+	Azp       string
+	SessionID string
+
+	// additional claims; never replace the ones set above
+	Claims map[string]any
 }
@@ -3,9 +3,13 @@ package idtoken
 import (
 	"context"
 	"errors"
+	"slices"
 	"time"
 )
 
+// reservedClaims are set by Issue and cannot come from IDTokenInput.Claims.
+var reservedClaims = []string{"iss", "sub", "aud", "iat", "exp", "auth_time", "amr", "nonce", "at_hash", "azp", "sid"}
+
 type IssueIDTokenUsecase struct {
 	Issuer string
 	Signer Signer
@@ -45,6 +49,9 @@ func (uc *IssueIDTokenUsecase) Issue(ctx context.Context, in *IDTokenInput) (tok
 	if in.Azp != "" {
 		claims.Azp = in.Azp
 	}
+	if in.SessionID != "" {
+		claims.Sid = in.SessionID
+	}
 
 	if in.AccessToken != "" {
 		alg := in.SignAlg
@@ -67,13 +74,17 @@ func (uc *IssueIDTokenUsecase) Issue(ctx context.Context, in *IDTokenInput) (tok
 		return "", "", err
 	}
 
-	payload := map[string]any{
-		"iss": claims.Iss,
-		"sub": claims.Sub,
-		"aud": claims.Aud,
-		"iat": claims.Iat,
-		"exp": claims.Exp,
+	payload := map[string]any{}
+	for k, v := range in.Claims {
+		if !slices.Contains(reservedClaims, k) {
+			payload[k] = v
+		}
 	}
+	payload["iss"] = claims.Iss
+	payload["sub"] = claims.Sub
+	payload["aud"] = claims.Aud
+	payload["iat"] = claims.Iat
+	payload["exp"] = claims.Exp
 	if claims.AuthTime != 0 {
 		payload["auth_time"] = claims.AuthTime
 	}
@@ -89,6 +100,9 @@ func (uc *IssueIDTokenUsecase) Issue(ctx context.Context, in *IDTokenInput) (tok
 	if claims.Azp != "" {
 		payload["azp"] = claims.Azp
 	}
+	if claims.Sid != "" {
+		payload["sid"] = claims.Sid
+	}
 
 	return uc.Signer.SignJWT(ctx, payload)
 }
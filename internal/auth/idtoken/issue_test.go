@@ -82,27 +82,51 @@ func TestIssue(t *testing.T) {
 		require.ElementsMatch(t, []string{"pwd", "mfa"}, s.got["amr"])
 	})
 
-	t.Run("success/with nonce and azp", func(t *testing.T) {
+	t.Run("success/with nonce, azp and sid", func(t *testing.T) {
 		t.Parallel()
 
 		s := &fakeSigner{}
 		uc := &IssueIDTokenUsecase{Issuer: "https://idpproxy.com", Signer: s}
 
 		now := time.Unix(2_000_000_100, 0).UTC()
+		in := &IDTokenInput{
+			UserID:    "u",
+			ClientID:  "c",
+			Now:       now,
+			TTL:       10 * time.Minute,
+			Nonce:     "nonce-xyz",
+			Azp:       "client-azp",
+			SessionID: "sid-1",
+		}
+
+		_, _, err := uc.Issue(context.Background(), in)
+		require.NoError(t, err)
+
+		require.Equal(t, "nonce-xyz", s.got["nonce"])
+		require.Equal(t, "client-azp", s.got["azp"])
+		require.Equal(t, "sid-1", s.got["sid"])
+	})
+
+	t.Run("success/with additional claims", func(t *testing.T) {
+		t.Parallel()
+
+		s := &fakeSigner{}
+		uc := &IssueIDTokenUsecase{Issuer: "https://idpproxy.com", Signer: s}
+
 		in := &IDTokenInput{
 			UserID:   "u",
 			ClientID: "c",
-			Now:      now,
+			Now:      time.Unix(2_000_000_200, 0).UTC(),
 			TTL:      10 * time.Minute,
-			Nonce:    "nonce-xyz",
-			Azp:      "client-azp",
+			Claims:   map[string]any{"groups": []string{"acme"}, "sub": "spoofed", "sid": "spoofed"},
 		}
 
 		_, _, err := uc.Issue(context.Background(), in)
 		require.NoError(t, err)
 
-		require.Equal(t, "nonce-xyz", s.got["nonce"])
-		require.Equal(t, "client-azp", s.got["azp"])
+		require.Equal(t, []string{"acme"}, s.got["groups"])
+		require.Equal(t, "u", s.got["sub"])
+		require.NotContains(t, s.got, "sid")
 	})
 
 	t.Run("success/with at_hash (RS256)", func(t *testing.T) {
@@ -0,0 +1,30 @@
+package idtoken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+)
+
+type SignerAdapter struct {
+	Signer signer.Signer
+}
+
+func NewSignerAdapter(s signer.Signer) *SignerAdapter {
+	return &SignerAdapter{Signer: s}
+}
+
+func (a *SignerAdapter) SignJWT(ctx context.Context, payload map[string]any) (string, string, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", "", fmt.Errorf("idtoken: marshal payload: %w", err)
+	}
+
+	return a.Signer.Sign(ctx, b)
+}
+
+func (a *SignerAdapter) Algorithm() string {
+	return a.Signer.Alg()
+}
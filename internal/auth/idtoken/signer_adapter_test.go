@@ -0,0 +1,36 @@
+package idtoken
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+)
+
+func TestSignerAdapter(t *testing.T) {
+	t.Parallel()
+
+	s := signer.NewHMACSigner([]byte("secret"), "kid-1")
+	uc := &IssueIDTokenUsecase{Issuer: "https://idpproxy.com", Signer: NewSignerAdapter(s)}
+
+	now := time.Now().UTC()
+	token, kid, err := uc.Issue(context.Background(), &IDTokenInput{
+		UserID:      "user-123",
+		ClientID:    "client-abc",
+		Now:         now,
+		TTL:         time.Hour,
+		AccessToken: "abc123",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "kid-1", kid)
+
+	got, err := s.Verify(context.Background(), token, &signer.VerifyOptions{ExpectKID: "kid-1"})
+	require.NoError(t, err)
+	require.Equal(t, "user-123", got.Claims["sub"])
+	require.Equal(t, "client-abc", got.Claims["aud"])
+	require.EqualValues(t, now.Add(time.Hour).Unix(), got.Claims["exp"])
+	require.Equal(t, "bKE9UspwyIPg8LsQHkJaiQ", got.Claims["at_hash"])
+}
@@ -21,7 +21,7 @@ func computeAtHash(alg, accessToken string) (string, error) {
 	case "RS384", "PS384", "ES384", "HS384":
 		s := sha512.Sum384([]byte(accessToken))
 		sum = s[:]
-	case "RS512", "PS512", "ES512", "HS512":
+	case "RS512", "PS512", "ES512", "HS512", "EDDSA":
 		s := sha512.Sum512([]byte(accessToken))
 		sum = s[:]
 	default:
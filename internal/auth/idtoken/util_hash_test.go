@@ -38,6 +38,13 @@ func TestComputeAtHash(t *testing.T) {
 			want:        "xwtd2ev7b1HQnUEytxcMnSB1CnhS8AaA9lZY8DEOgQA",
 			wantErr:     false,
 		},
+		{
+			name:        "EdDSA uses SHA-512",
+			alg:         "EdDSA",
+			accessToken: "abc123",
+			want:        "xwtd2ev7b1HQnUEytxcMnSB1CnhS8AaA9lZY8DEOgQA",
+			wantErr:     false,
+		},
 		{
 			name:        "lowercase alg is accepted",
 			alg:         "rs256",
@@ -0,0 +1,18 @@
+package pkce
+
+import "errors"
+
+// Authorization request
+var (
+	ErrInvalidChallenge  = errors.New("pkce: invalid code_challenge")
+	ErrMissingChallenge  = errors.New("pkce: code_challenge_method without code_challenge")
+	ErrUnsupportedMethod = errors.New("pkce: unsupported code_challenge_method")
+)
+
+// Token request
+var (
+	ErrInvalidVerifier    = errors.New("pkce: invalid code_verifier")
+	ErrMismatch           = errors.New("pkce: code_verifier does not match code_challenge")
+	ErrMissingVerifier    = errors.New("pkce: missing code_verifier")
+	ErrUnexpectedVerifier = errors.New("pkce: code_verifier without code_challenge")
+)
@@ -0,0 +1,94 @@
+package pkce
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+const (
+	MethodPlain = "plain"
+	MethodS256  = "S256"
+
+	minLen = 43
+	maxLen = 128
+)
+
+func isUnreserved(c byte) bool {
+	return (c >= 'A' && c <= 'Z') ||
+		(c >= 'a' && c <= 'z') ||
+		(c >= '0' && c <= '9') ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func validFormat(s string) bool {
+	if len(s) < minLen || len(s) > maxLen {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isUnreserved(s[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func NormalizeChallenge(challenge, method string) (string, string, error) {
+	if challenge == "" {
+		if method != "" {
+			return "", "", ErrMissingChallenge
+		}
+		return "", "", nil
+	}
+
+	if method == "" {
+		method = MethodPlain
+	}
+	if method != MethodPlain && method != MethodS256 {
+		return "", "", ErrUnsupportedMethod
+	}
+	if !validFormat(challenge) {
+		return "", "", ErrInvalidChallenge
+	}
+
+	return challenge, method, nil
+}
+
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func Verify(verifier, challenge, method string) error {
+	if challenge == "" {
+		if verifier != "" {
+			return ErrUnexpectedVerifier
+		}
+		return nil
+	}
+
+	if verifier == "" {
+		return ErrMissingVerifier
+	}
+	if !validFormat(verifier) {
+		return ErrInvalidVerifier
+	}
+
+	var computed string
+	switch method {
+	case MethodS256:
+		computed = S256Challenge(verifier)
+	case MethodPlain, "":
+		computed = verifier
+	default:
+		return ErrUnsupportedMethod
+	}
+
+	if subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) != 1 {
+		return ErrMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package pkce
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// RFC 7636 Appendix B
+	rfcVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	rfcChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestS256Challenge(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, rfcChallenge, S256Challenge(rfcVerifier))
+}
+
+func TestNormalizeChallenge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		challenge  string
+		method     string
+		wantMethod string
+		wantErr    error
+	}{
+		{"no pkce", "", "", "", nil},
+		{"s256", rfcChallenge, "S256", MethodS256, nil},
+		{"plain", rfcVerifier, "plain", MethodPlain, nil},
+		{"method defaults to plain", rfcVerifier, "", MethodPlain, nil},
+		{"method without challenge", "", "S256", "", ErrMissingChallenge},
+		{"lowercase s256 is unsupported", rfcChallenge, "s256", "", ErrUnsupportedMethod},
+		{"too short", "abc", "S256", "", ErrInvalidChallenge},
+		{"too long", strings.Repeat("a", 129), "plain", "", ErrInvalidChallenge},
+		{"invalid characters", strings.Repeat("a", 42) + "+", "plain", "", ErrInvalidChallenge},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, method, err := NormalizeChallenge(tt.challenge, tt.method)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantMethod, method)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		method    string
+		wantErr   error
+	}{
+		{"s256 match", rfcVerifier, rfcChallenge, MethodS256, nil},
+		{"plain match", rfcVerifier, rfcVerifier, MethodPlain, nil},
+		{"no pkce", "", "", "", nil},
+		{"s256 mismatch", strings.Repeat("a", 43), rfcChallenge, MethodS256, ErrMismatch},
+		{"plain mismatch", strings.Repeat("a", 43), rfcVerifier, MethodPlain, ErrMismatch},
+		{"missing verifier", "", rfcChallenge, MethodS256, ErrMissingVerifier},
+		{"verifier without challenge", rfcVerifier, "", "", ErrUnexpectedVerifier},
+		{"short verifier", "abc", rfcChallenge, MethodS256, ErrInvalidVerifier},
+		{"unknown method", rfcVerifier, rfcChallenge, "S512", ErrUnsupportedMethod},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := Verify(tt.verifier, tt.challenge, tt.method)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -5,3 +5,11 @@ const (
 	refreshTokenPrefix = "rt1."
 	refreshTokenRawLen = 32
 )
+
+const (
+	RevokeReasonReuse      = "refresh_token_reuse"
+	RevokeReasonCodeReplay = "authorization_code_replay"
+	RevokeReasonClient     = "client_revocation"
+	RevokeReasonLogout     = "logout"
+	RevokeReasonUserMoved  = "user_moved"
+)
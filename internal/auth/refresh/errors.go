@@ -7,6 +7,12 @@ var (
 	ErrRandFailure = errors.New("rand failure")
 )
 
+// Parse + Verify
+var (
+	ErrDigestMismatch = errors.New("refresh token digest mismatch")
+	ErrMalformedToken = errors.New("refresh token malformed")
+)
+
 // Validation
 var (
 	ErrEmptyUserID  = errors.New("userID empty")
@@ -0,0 +1,59 @@
+package refresh
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+)
+
+func ParseRefreshToken(token string) (refreshID string, secretRaw []byte, err error) {
+	rest, ok := strings.CutPrefix(token, refreshTokenPrefix)
+	if !ok {
+		return "", nil, ErrMalformedToken
+	}
+
+	idB64, secretB64, ok := strings.Cut(rest, ".")
+	if !ok || idB64 == "" || secretB64 == "" {
+		return "", nil, ErrMalformedToken
+	}
+
+	idRaw, err := base64.RawURLEncoding.DecodeString(idB64)
+	if err != nil || len(idRaw) != refreshIDRawLen {
+		return "", nil, ErrMalformedToken
+	}
+
+	secretRaw, err = base64.RawURLEncoding.DecodeString(secretB64)
+	if err != nil || len(secretRaw) != refreshTokenRawLen {
+		return "", nil, ErrMalformedToken
+	}
+
+	return idB64, secretRaw, nil
+}
+
+func VerifyRefreshSecret(rec *store.RefreshTokenRecord, secretRaw []byte) error {
+	if rec == nil {
+		return ErrDigestMismatch
+	}
+
+	want, err := base64.RawURLEncoding.DecodeString(rec.DigestB64)
+	if err != nil {
+		return ErrDigestMismatch
+	}
+
+	key, err := getPepperKeyMaterial(rec.KeyID)
+	if err != nil {
+		return fmt.Errorf("pepper key material: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(secretRaw)
+	if !hmac.Equal(mac.Sum(nil), want) {
+		return ErrDigestMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package refresh
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	id := base64.RawURLEncoding.EncodeToString(make([]byte, refreshIDRawLen))
+	secret := base64.RawURLEncoding.EncodeToString(make([]byte, refreshTokenRawLen))
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"ok", refreshTokenPrefix + id + "." + secret, false},
+		{"missing prefix", id + "." + secret, true},
+		{"wrong prefix", "rt2." + id + "." + secret, true},
+		{"missing secret", refreshTokenPrefix + id, true},
+		{"empty id", refreshTokenPrefix + "." + secret, true},
+		{"short id", refreshTokenPrefix + "abc." + secret, true},
+		{"short secret", refreshTokenPrefix + id + ".abc", true},
+		{"extra segment", refreshTokenPrefix + id + "." + secret + ".x", true},
+		{"not base64", refreshTokenPrefix + id + "." + strings.Repeat("!", 43), true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotID, gotSecret, err := ParseRefreshToken(tt.token)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrMalformedToken)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, id, gotID)
+			require.Len(t, gotSecret, refreshTokenRawLen)
+		})
+	}
+}
+
+func TestVerifyRefreshSecret(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "test-hmac-k1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-for-test")
+
+	rec, token, err := GenerateRefreshToken(context.Background(), testUserID1, time.Hour, 2*time.Hour)
+	require.NoError(t, err)
+
+	id, secret, err := ParseRefreshToken(token)
+	require.NoError(t, err)
+	require.Equal(t, rec.RefreshID, id)
+
+	t.Run("matching secret", func(t *testing.T) {
+		require.NoError(t, VerifyRefreshSecret(rec, secret))
+	})
+
+	t.Run("tampered secret", func(t *testing.T) {
+		bad := append([]byte(nil), secret...)
+		bad[0] ^= 0xff
+		require.ErrorIs(t, VerifyRefreshSecret(rec, bad), ErrDigestMismatch)
+	})
+
+	t.Run("rotated pepper", func(t *testing.T) {
+		t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "other-pepper")
+		require.ErrorIs(t, VerifyRefreshSecret(rec, secret), ErrDigestMismatch)
+	})
+
+	t.Run("nil record", func(t *testing.T) {
+		require.ErrorIs(t, VerifyRefreshSecret(nil, secret), ErrDigestMismatch)
+	})
+}
@@ -0,0 +1,48 @@
+package securityevent
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const TypeAuthorizationCodeReplay = "authorization_code_replay"
+
+type Event struct {
+	Type     string
+	UserID   string
+	ClientID string
+	FamilyID string
+	Revoked  int
+	At       time.Time
+}
+
+type Recorder interface {
+	Record(ctx context.Context, ev Event)
+}
+
+type LogRecorder struct {
+	Logger *zap.Logger
+}
+
+var _ Recorder = (*LogRecorder)(nil)
+
+func NewLogRecorder(logger *zap.Logger) *LogRecorder {
+	return &LogRecorder{Logger: logger}
+}
+
+func (r *LogRecorder) Record(_ context.Context, ev Event) {
+	if r == nil || r.Logger == nil {
+		return
+	}
+
+	r.Logger.Warn("security event",
+		zap.String("event_type", ev.Type),
+		zap.String("user_id", ev.UserID),
+		zap.String("client_id", ev.ClientID),
+		zap.String("family_id", ev.FamilyID),
+		zap.Int("revoked", ev.Revoked),
+		zap.Time("at", ev.At),
+	)
+}
@@ -0,0 +1,43 @@
+package securityevent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogRecorder_Record(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zap.WarnLevel)
+	r := NewLogRecorder(zap.New(core))
+
+	r.Record(context.Background(), Event{
+		Type:     TypeAuthorizationCodeReplay,
+		UserID:   "user-1",
+		ClientID: "client-1",
+		FamilyID: "fam-1",
+		Revoked:  2,
+		At:       time.Unix(1_725_000_000, 0).UTC(),
+	})
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+
+	fields := entries[0].ContextMap()
+	require.Equal(t, TypeAuthorizationCodeReplay, fields["event_type"])
+	require.Equal(t, "fam-1", fields["family_id"])
+	require.Equal(t, int64(2), fields["revoked"])
+}
+
+func TestLogRecorder_NilLogger(t *testing.T) {
+	t.Parallel()
+
+	require.NotPanics(t, func() {
+		NewLogRecorder(nil).Record(context.Background(), Event{})
+	})
+}
@@ -4,6 +4,7 @@ import "errors"
 
 // Usecase validation
 var (
+	ErrEmptyClientID        = errors.New("session: empty clientID")
 	ErrEmptySessionID       = errors.New("session: empty sessionID")
 	ErrEmptyUserID          = errors.New("session: empty userID")
 	ErrInvalidUsecaseConfig = errors.New("session: invalid usecase configuration")
@@ -12,6 +13,7 @@ var (
 // Domain validation
 var (
 	ErrExpiredSession  = errors.New("session: expired")
+	ErrIdleSession     = errors.New("session: idle timeout exceeded")
 	ErrInactiveSession = errors.New("session: inactive")
 )
 
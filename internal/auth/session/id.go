@@ -0,0 +1,13 @@
+package session
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const DefaultTTL = 24 * time.Hour
+
+func NewID() (string, error) {
+	return uuid.NewString(), nil
+}
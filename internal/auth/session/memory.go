@@ -0,0 +1,100 @@
+package session
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+var _ Repository = (*MemoryRepository)(nil)
+
+type MemoryRepository struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{sessions: make(map[string]Session)}
+}
+
+func (r *MemoryRepository) Create(_ context.Context, s *Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions[s.SessionID] = *s
+	return nil
+}
+
+func (r *MemoryRepository) FindByID(_ context.Context, sessionID string) (*Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &s, nil
+}
+
+func (r *MemoryRepository) Update(_ context.Context, s *Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sessions[s.SessionID]; !ok {
+		return ErrNotFound
+	}
+	r.sessions[s.SessionID] = *s
+	return nil
+}
+
+func (r *MemoryRepository) AddClientID(_ context.Context, sessionID, clientID string, updatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[sessionID]
+	if !ok {
+		return ErrNotFound
+	}
+	if !slices.Contains(s.ClientIDs, clientID) {
+		s.ClientIDs = append(slices.Clone(s.ClientIDs), clientID)
+	}
+	s.UpdatedAt = &updatedAt
+	r.sessions[sessionID] = s
+
+	return nil
+}
+
+func (r *MemoryRepository) Touch(_ context.Context, sessionID string, lastUsed, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[sessionID]
+	if !ok {
+		return ErrNotFound
+	}
+	if s.Status != "active" {
+		return ErrInactiveSession
+	}
+	s.LastUsed = &lastUsed
+	s.UpdatedAt = &lastUsed
+	s.ExpiresAt = expiresAt
+	r.sessions[sessionID] = s
+
+	return nil
+}
+
+func (r *MemoryRepository) PurgeExpired(_ context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for id, s := range r.sessions {
+		if s.ExpiresAt.Before(before) {
+			delete(r.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
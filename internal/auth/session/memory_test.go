@@ -0,0 +1,60 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0).UTC()
+	repo := NewMemoryRepository()
+
+	require.NoError(t, repo.Create(ctx, &Session{SessionID: "s1", UserID: "u1", Status: "active", ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, repo.Create(ctx, &Session{SessionID: "s2", UserID: "u1", Status: "active", ExpiresAt: now.Add(-time.Hour)}))
+
+	got, err := repo.FindByID(ctx, "s1")
+	require.NoError(t, err)
+	got.Status = "inactive"
+
+	again, err := repo.FindByID(ctx, "s1")
+	require.NoError(t, err)
+	require.Equal(t, "active", again.Status)
+
+	require.NoError(t, repo.Update(ctx, got))
+	again, err = repo.FindByID(ctx, "s1")
+	require.NoError(t, err)
+	require.Equal(t, "inactive", again.Status)
+
+	require.ErrorIs(t, repo.Update(ctx, &Session{SessionID: "missing"}), ErrNotFound)
+
+	require.NoError(t, repo.AddClientID(ctx, "s1", "client-a", now))
+	require.NoError(t, repo.AddClientID(ctx, "s1", "client-b", now))
+	require.NoError(t, repo.AddClientID(ctx, "s1", "client-a", now))
+	again, err = repo.FindByID(ctx, "s1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"client-a", "client-b"}, again.ClientIDs)
+	require.ErrorIs(t, repo.AddClientID(ctx, "missing", "client-a", now), ErrNotFound)
+
+	require.ErrorIs(t, repo.Touch(ctx, "s1", now, now.Add(time.Hour)), ErrInactiveSession, "touch must not revive a logged-out session")
+
+	require.NoError(t, repo.Create(ctx, &Session{SessionID: "s3", UserID: "u1", Status: "active", ExpiresAt: now.Add(time.Minute)}))
+	require.NoError(t, repo.AddClientID(ctx, "s3", "client-a", now))
+	require.NoError(t, repo.Touch(ctx, "s3", now, now.Add(time.Hour)))
+	again, err = repo.FindByID(ctx, "s3")
+	require.NoError(t, err)
+	require.Equal(t, now.Add(time.Hour), again.ExpiresAt)
+	require.Equal(t, []string{"client-a"}, again.ClientIDs, "touch keeps concurrently added clients")
+
+	n, err := repo.PurgeExpired(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	_, err = repo.FindByID(ctx, "s2")
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -10,4 +10,7 @@ type Session struct {
 	CreatedAt time.Time  `firestore:"created_at"`
 	UpdatedAt *time.Time `firestore:"updated_at,omitempty"`
 	LastUsed  *time.Time `firestore:"last_used,omitempty"`
+
+	// clients that received tokens within this session
+	ClientIDs []string `firestore:"client_ids,omitempty"`
 }
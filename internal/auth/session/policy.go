@@ -0,0 +1,47 @@
+package session
+
+import "time"
+
+// Policy bounds how long a login stays usable. IdleTimeout expires a session
+// (or refresh token) that has not been used for that long; MaxLifetime caps
+// sliding renewals relative to when the login started. Zero disables either.
+type Policy struct {
+	IdleTimeout time.Duration
+	MaxLifetime time.Duration
+}
+
+// ExpiresAt returns the expiry for a grant started at createdAt and renewed
+// at now. ttl is the lifetime to use when no idle timeout is configured, and
+// is also respected when it is shorter than the idle timeout.
+func (p Policy) ExpiresAt(createdAt, now time.Time, ttl time.Duration) time.Time {
+	d := ttl
+	if p.IdleTimeout > 0 && (d <= 0 || p.IdleTimeout < d) {
+		d = p.IdleTimeout
+	}
+
+	exp := now.Add(d)
+	if p.MaxLifetime > 0 {
+		if limit := createdAt.Add(p.MaxLifetime); limit.Before(exp) {
+			exp = limit
+		}
+	}
+
+	return exp
+}
+
+func (p Policy) isIdle(s *Session, now time.Time) bool {
+	if p.IdleTimeout <= 0 {
+		return false
+	}
+
+	lastActive := s.CreatedAt
+	if s.LastUsed != nil && s.LastUsed.After(lastActive) {
+		lastActive = *s.LastUsed
+	}
+
+	return !now.Before(lastActive.Add(p.IdleTimeout))
+}
+
+func (p Policy) pastLifetime(s *Session, now time.Time) bool {
+	return p.MaxLifetime > 0 && !now.Before(s.CreatedAt.Add(p.MaxLifetime))
+}
@@ -0,0 +1,38 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_ExpiresAt(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	now := created.Add(2 * time.Hour)
+
+	tests := []struct {
+		name   string
+		policy Policy
+		ttl    time.Duration
+		want   time.Time
+	}{
+		{"zero policy uses ttl", Policy{}, 24 * time.Hour, now.Add(24 * time.Hour)},
+		{"idle timeout shorter than ttl", Policy{IdleTimeout: time.Hour}, 24 * time.Hour, now.Add(time.Hour)},
+		{"ttl shorter than idle timeout", Policy{IdleTimeout: 48 * time.Hour}, 24 * time.Hour, now.Add(24 * time.Hour)},
+		{"idle timeout without ttl", Policy{IdleTimeout: time.Hour}, 0, now.Add(time.Hour)},
+		{"capped by max lifetime", Policy{IdleTimeout: time.Hour, MaxLifetime: 150 * time.Minute}, 24 * time.Hour, created.Add(150 * time.Minute)},
+		{"max lifetime beyond renewal", Policy{MaxLifetime: 72 * time.Hour}, 24 * time.Hour, now.Add(24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, tt.policy.ExpiresAt(created, now, tt.ttl))
+		})
+	}
+}
@@ -9,5 +9,11 @@ type Repository interface {
 	Create(ctx context.Context, s *Session) error
 	FindByID(ctx context.Context, sessionID string) (*Session, error)
 	Update(ctx context.Context, s *Session) error
+	// AddClientID records a client without rewriting the rest of the session,
+	// so concurrent token exchanges and logouts do not overwrite each other.
+	AddClientID(ctx context.Context, sessionID, clientID string, updatedAt time.Time) error
+	// Touch slides an active session's activity and expiry without rewriting
+	// the rest of it; it returns ErrInactiveSession once the session ended.
+	Touch(ctx context.Context, sessionID string, lastUsed, expiresAt time.Time) error
 	PurgeExpired(ctx context.Context, before time.Time) (int, error)
 }
@@ -2,6 +2,7 @@ package session
 
 import (
 	"context"
+	"slices"
 	"time"
 )
 
@@ -19,6 +20,28 @@ type Usecase struct {
 	Now         func() time.Time
 	TTL         time.Duration
 	IDGenerator func() (string, error)
+
+	// optional; zero keeps the fixed TTL with no idle expiry
+	Policy Policy
+}
+
+// checkUsable reports idleness before the stored deadline: with an idle
+// timeout configured, ExpiresAt is the idle deadline itself.
+func (uc *Usecase) checkUsable(s *Session, now time.Time) error {
+	if uc.Policy.pastLifetime(s, now) {
+		return ErrExpiredSession
+	}
+	if uc.Policy.isIdle(s, now) {
+		return ErrIdleSession
+	}
+	if !s.ExpiresAt.After(now) {
+		return ErrExpiredSession
+	}
+	if s.Status != "active" {
+		return ErrInactiveSession
+	}
+
+	return nil
 }
 
 func (uc *Usecase) Start(ctx context.Context, userID string) (*Session, error) {
@@ -30,7 +53,7 @@ func (uc *Usecase) Start(ctx context.Context, userID string) (*Session, error) {
 	}
 
 	now := safeNowUTC(uc.Now)
-	expiresAt := now.Add(uc.TTL)
+	expiresAt := uc.Policy.ExpiresAt(now, now, uc.TTL)
 
 	sessionID, err := uc.IDGenerator()
 	if err != nil {
@@ -78,11 +101,8 @@ func (uc *Usecase) Validate(ctx context.Context, sessionID string) (*Session, er
 
 	now := safeNowUTC(uc.Now)
 
-	if !s.ExpiresAt.After(now) {
-		return nil, ErrExpiredSession
-	}
-	if s.Status != "active" {
-		return nil, ErrInactiveSession
+	if err := uc.checkUsable(s, now); err != nil {
+		return nil, err
 	}
 
 	return s, nil
@@ -136,20 +156,54 @@ func (uc *Usecase) Touch(ctx context.Context, sessionID string) (*Session, error
 
 	now := safeNowUTC(uc.Now)
 
-	if !s.ExpiresAt.After(now) {
-		return nil, ErrExpiredSession
+	if err := uc.checkUsable(s, now); err != nil {
+		return nil, err
 	}
-	if s.Status != "active" {
-		return nil, ErrInactiveSession
+
+	expiresAt := s.ExpiresAt
+	if uc.Policy != (Policy{}) {
+		expiresAt = uc.Policy.ExpiresAt(s.CreatedAt, now, uc.TTL)
+	}
+
+	if err := uc.Repo.Touch(ctx, sessionID, now, expiresAt); err != nil {
+		return nil, err
 	}
 
 	s.UpdatedAt = &now
 	s.LastUsed = &now
+	s.ExpiresAt = expiresAt
 
-	if err := uc.Repo.Update(ctx, s); err != nil {
+	return s, nil
+}
+
+func (uc *Usecase) AddClient(ctx context.Context, sessionID, clientID string) (*Session, error) {
+	if uc == nil || uc.Repo == nil || uc.Now == nil {
+		return nil, ErrInvalidUsecaseConfig
+	}
+	if sessionID == "" {
+		return nil, ErrEmptySessionID
+	}
+	if clientID == "" {
+		return nil, ErrEmptyClientID
+	}
+
+	s, err := uc.Validate(ctx, sessionID)
+	if err != nil {
 		return nil, err
 	}
 
+	if slices.Contains(s.ClientIDs, clientID) {
+		return s, nil
+	}
+
+	now := safeNowUTC(uc.Now)
+	if err := uc.Repo.AddClientID(ctx, sessionID, clientID, now); err != nil {
+		return nil, err
+	}
+
+	s.ClientIDs = append(s.ClientIDs, clientID)
+	s.UpdatedAt = &now
+
 	return s, nil
 }
 
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsecase_AddClient(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	newSession := func(status string, expiresAt time.Time, clients ...string) *Session {
+		return &Session{
+			SessionID: "session-123",
+			UserID:    "user-123",
+			Status:    status,
+			ExpiresAt: expiresAt,
+			ClientIDs: clients,
+		}
+	}
+
+	t.Run("invalid_config", func(t *testing.T) {
+		t.Parallel()
+
+		var uc *Usecase
+		got, err := uc.AddClient(context.Background(), "session-123", "client-1")
+
+		require.Nil(t, got)
+		require.ErrorIs(t, err, ErrInvalidUsecaseConfig)
+	})
+
+	t.Run("empty_ids", func(t *testing.T) {
+		t.Parallel()
+
+		uc := &Usecase{Repo: newFakeRepository(), Now: time.Now}
+
+		_, err := uc.AddClient(context.Background(), "", "client-1")
+		require.ErrorIs(t, err, ErrEmptySessionID)
+
+		_, err = uc.AddClient(context.Background(), "session-123", "")
+		require.ErrorIs(t, err, ErrEmptyClientID)
+	})
+
+	t.Run("inactive_session", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newFakeRepositoryWithSession(newSession("inactive", now.Add(time.Hour)))
+		uc := &Usecase{Repo: repo, Now: func() time.Time { return now }}
+
+		got, err := uc.AddClient(context.Background(), "session-123", "client-1")
+
+		require.Nil(t, got)
+		require.ErrorIs(t, err, ErrInactiveSession)
+		require.Empty(t, repo.updated)
+	})
+
+	t.Run("appends_new_client", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newFakeRepositoryWithSession(newSession("active", now.Add(time.Hour), "client-1"))
+		uc := &Usecase{Repo: repo, Now: func() time.Time { return now }}
+
+		got, err := uc.AddClient(context.Background(), "session-123", "client-2")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"client-1", "client-2"}, got.ClientIDs)
+		require.Equal(t, []string{"session-123:client-2"}, repo.added)
+		require.Empty(t, repo.updated, "the session document is not rewritten")
+		require.Equal(t, now, *got.UpdatedAt)
+	})
+
+	t.Run("known_client_is_not_rewritten", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newFakeRepositoryWithSession(newSession("active", now.Add(time.Hour), "client-1"))
+		uc := &Usecase{Repo: repo, Now: func() time.Time { return now }}
+
+		got, err := uc.AddClient(context.Background(), "session-123", "client-1")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"client-1"}, got.ClientIDs)
+		require.Empty(t, repo.added)
+	})
+
+	t.Run("update_error", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newFakeRepositoryWithSession(newSession("active", now.Add(time.Hour)))
+		repo.updateErr = errors.New("update failed")
+		uc := &Usecase{Repo: repo, Now: func() time.Time { return now }}
+
+		_, err := uc.AddClient(context.Background(), "session-123", "client-1")
+		require.ErrorIs(t, err, repo.updateErr)
+	})
+}
@@ -27,6 +27,14 @@ func (f *fakePurgeRepository) Update(_ context.Context, _ *Session) error {
 	return nil
 }
 
+func (f *fakePurgeRepository) AddClientID(_ context.Context, _, _ string, _ time.Time) error {
+	return nil
+}
+
+func (f *fakePurgeRepository) Touch(_ context.Context, _ string, _, _ time.Time) error {
+	return nil
+}
+
 func (f *fakePurgeRepository) PurgeExpired(_ context.Context, before time.Time) (int, error) {
 	f.purgeBeforeCalls = append(f.purgeBeforeCalls, before)
 
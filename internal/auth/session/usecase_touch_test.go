@@ -116,7 +116,7 @@ func TestUsecase_Touch(t *testing.T) {
 
 		require.Nil(t, got)
 		require.ErrorIs(t, err, ErrExpiredSession)
-		require.Len(t, repo.updated, 0, "expired session must not be updated")
+		require.Empty(t, repo.touched, "expired session must not be touched")
 	})
 
 	t.Run("inactive_session", func(t *testing.T) {
@@ -145,7 +145,7 @@ func TestUsecase_Touch(t *testing.T) {
 
 		require.Nil(t, got)
 		require.ErrorIs(t, err, ErrInactiveSession)
-		require.Len(t, repo.updated, 0, "inactive session must not be updated")
+		require.Empty(t, repo.touched, "inactive session must not be touched")
 	})
 
 	t.Run("update_error", func(t *testing.T) {
@@ -176,7 +176,7 @@ func TestUsecase_Touch(t *testing.T) {
 
 		require.Nil(t, got)
 		require.ErrorIs(t, err, repo.updateErr)
-		require.Len(t, repo.updated, 0, "on update error, updated slice should not be appended")
+		require.Empty(t, repo.touched, "on update error, touched slice should not be appended")
 	})
 
 	t.Run("success", func(t *testing.T) {
@@ -217,9 +217,77 @@ func TestUsecase_Touch(t *testing.T) {
 
 		require.Equal(t, future, got.ExpiresAt)
 
-		require.Len(t, repo.updated, 1)
-		require.Equal(t, got, repo.updated[0])
+		require.Equal(t, []string{"session-123"}, repo.touched)
+		require.Empty(t, repo.updated, "touch must not rewrite the whole session")
 
 		require.Equal(t, "session-123", repo.lastFindID)
 	})
+
+	t.Run("slides_expiry_up_to_max_lifetime", func(t *testing.T) {
+		t.Parallel()
+
+		created := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+		policy := Policy{IdleTimeout: time.Hour, MaxLifetime: 12 * time.Hour}
+
+		s := &Session{
+			SessionID: "session-123",
+			UserID:    "user-123",
+			Status:    "active",
+			CreatedAt: created,
+			ExpiresAt: created.Add(time.Hour),
+		}
+
+		repo := newFakeRepositoryWithSession(s)
+		now := created.Add(30 * time.Minute)
+		uc := &Usecase{
+			Repo:   repo,
+			Now:    func() time.Time { return now },
+			TTL:    24 * time.Hour,
+			Policy: policy,
+		}
+		ctx := context.Background()
+
+		got, err := uc.Touch(ctx, "session-123")
+		require.NoError(t, err)
+		require.Equal(t, now.Add(time.Hour), got.ExpiresAt)
+
+		// kept alive by regular use until close to the cap
+		lastUsed := created.Add(11 * time.Hour)
+		s.LastUsed = &lastUsed
+		s.ExpiresAt = lastUsed.Add(time.Hour)
+		now = created.Add(11*time.Hour + 30*time.Minute)
+
+		got, err = uc.Touch(ctx, "session-123")
+		require.NoError(t, err)
+		require.Equal(t, created.Add(12*time.Hour), got.ExpiresAt)
+	})
+
+	t.Run("idle_session", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+		lastUsed := now.Add(-2 * time.Hour)
+
+		s := &Session{
+			SessionID: "session-123",
+			UserID:    "user-123",
+			Status:    "active",
+			CreatedAt: now.Add(-3 * time.Hour),
+			LastUsed:  &lastUsed,
+			ExpiresAt: now.Add(time.Hour),
+		}
+
+		repo := newFakeRepositoryWithSession(s)
+		uc := &Usecase{
+			Repo:   repo,
+			Now:    func() time.Time { return now },
+			Policy: Policy{IdleTimeout: time.Hour},
+		}
+
+		got, err := uc.Touch(context.Background(), "session-123")
+
+		require.Nil(t, got)
+		require.ErrorIs(t, err, ErrIdleSession)
+		require.Empty(t, repo.touched, "idle session must not be touched")
+	})
 }
@@ -162,4 +162,61 @@ func TestUsecase_Validate(t *testing.T) {
 		require.NoError(t, err)
 		require.Same(t, active, got)
 	})
+
+	t.Run("idle_session", func(t *testing.T) {
+		t.Parallel()
+
+		now := fixedNow
+		uc := &Usecase{
+			Repo:        NewMemoryRepository(),
+			Now:         func() time.Time { return now },
+			TTL:         8 * time.Hour,
+			IDGenerator: func() (string, error) { return "session-123", nil },
+			Policy:      Policy{IdleTimeout: 30 * time.Minute},
+		}
+		ctx := context.Background()
+
+		_, err := uc.Start(ctx, "user-123")
+		require.NoError(t, err)
+
+		now = now.Add(20 * time.Minute)
+		_, err = uc.Touch(ctx, "session-123")
+		require.NoError(t, err)
+
+		now = now.Add(20 * time.Minute)
+		_, err = uc.Validate(ctx, "session-123")
+		require.NoError(t, err, "touch slides the idle window")
+
+		now = now.Add(31 * time.Minute)
+		got, err := uc.Validate(ctx, "session-123")
+
+		require.Nil(t, got)
+		require.ErrorIs(t, err, ErrIdleSession)
+	})
+
+	t.Run("past_max_lifetime", func(t *testing.T) {
+		t.Parallel()
+
+		old := &Session{
+			SessionID: "session-123",
+			UserID:    "user-123",
+			Status:    "active",
+			CreatedAt: fixedNow.Add(-13 * time.Hour),
+			ExpiresAt: fixedNow.Add(10 * time.Minute),
+		}
+
+		repo := newFakeRepository()
+		repo.findMap["session-123"] = old
+
+		uc := &Usecase{
+			Repo:   repo,
+			Now:    func() time.Time { return fixedNow },
+			Policy: Policy{MaxLifetime: 12 * time.Hour},
+		}
+
+		got, err := uc.Validate(context.Background(), "session-123")
+
+		require.Nil(t, got)
+		require.ErrorIs(t, err, ErrExpiredSession)
+	})
 }
@@ -9,6 +9,8 @@ type fakeRepository struct {
 	created []*Session
 	findMap map[string]*Session
 	updated []*Session
+	added   []string
+	touched []string
 
 	createErr error
 	findErr   error
@@ -69,6 +71,34 @@ func (f *fakeRepository) Update(_ context.Context, s *Session) error {
 	return nil
 }
 
+func (f *fakeRepository) AddClientID(_ context.Context, sessionID, clientID string, updatedAt time.Time) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	if f.findMap[sessionID] == nil {
+		return ErrNotFound
+	}
+	f.added = append(f.added, sessionID+":"+clientID)
+
+	return nil
+}
+
+func (f *fakeRepository) Touch(_ context.Context, sessionID string, lastUsed, expiresAt time.Time) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	s := f.findMap[sessionID]
+	if s == nil {
+		return ErrNotFound
+	}
+	if s.Status != "active" {
+		return ErrInactiveSession
+	}
+	f.touched = append(f.touched, sessionID)
+
+	return nil
+}
+
 func (f *fakeRepository) PurgeExpired(_ context.Context, before time.Time) (int, error) {
 	if f.purgeErr != nil {
 		return 0, f.purgeErr
@@ -52,6 +52,47 @@ func (r *Repository) Update(ctx context.Context, s *session.Session) error {
 	return err
 }
 
+// AddClientID uses ArrayUnion so concurrent exchanges in the same session
+// cannot drop each other's client or undo a logout.
+func (r *Repository) AddClientID(ctx context.Context, sessionID, clientID string, updatedAt time.Time) error {
+	_, err := r.collection.Doc(sessionID).Update(ctx, []firestore.Update{
+		{Path: "client_ids", Value: firestore.ArrayUnion(clientID)},
+		{Path: "updated_at", Value: updatedAt},
+	})
+	if status.Code(err) == codes.NotFound {
+		return session.ErrNotFound
+	}
+
+	return err
+}
+
+// Touch writes only the activity fields, inside a transaction so a session
+// that was just logged out is not brought back as active.
+func (r *Repository) Touch(ctx context.Context, sessionID string, lastUsed, expiresAt time.Time) error {
+	ref := r.collection.Doc(sessionID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return session.ErrNotFound
+			}
+			return err
+		}
+
+		st, err := doc.DataAt("status")
+		if err != nil || st != "active" {
+			return session.ErrInactiveSession
+		}
+
+		return tx.Update(ref, []firestore.Update{
+			{Path: "last_used", Value: lastUsed},
+			{Path: "expires_at", Value: expiresAt},
+			{Path: "updated_at", Value: lastUsed},
+		})
+	})
+}
+
 func (r *Repository) PurgeExpired(ctx context.Context, before time.Time) (int, error) {
 	iter := r.collection.
 		Where("expires_at", "<", before).
@@ -0,0 +1,60 @@
+package firesessionstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/stretchr/testify/require"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+)
+
+func TestRepository_Touch(t *testing.T) {
+	t.Parallel()
+
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("FIRESTORE_EMULATOR_HOST is not set (Firestore emulator required)")
+	}
+
+	ctx := context.Background()
+
+	client, err := firestore.NewClient(ctx, "idpproxy-test")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	repo := NewRepository(client, fmt.Sprintf("sessions_%d", time.Now().UnixNano()))
+
+	now := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("keeps_clients_added_concurrently", func(t *testing.T) {
+		sessionID := fmt.Sprintf("session-%d", time.Now().UnixNano())
+		require.NoError(t, repo.Create(ctx, &session.Session{SessionID: sessionID, UserID: "user-1", Status: "active", ExpiresAt: now}))
+		require.NoError(t, repo.AddClientID(ctx, sessionID, "client-a", now))
+
+		require.NoError(t, repo.Touch(ctx, sessionID, now, now.Add(time.Hour)))
+
+		got, err := repo.FindByID(ctx, sessionID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"client-a"}, got.ClientIDs)
+		require.True(t, got.ExpiresAt.Equal(now.Add(time.Hour)))
+		require.NotNil(t, got.LastUsed)
+	})
+
+	t.Run("does_not_revive_inactive_session", func(t *testing.T) {
+		sessionID := fmt.Sprintf("session-%d", time.Now().UnixNano())
+		require.NoError(t, repo.Create(ctx, &session.Session{SessionID: sessionID, UserID: "user-1", Status: "inactive", ExpiresAt: now}))
+
+		require.ErrorIs(t, repo.Touch(ctx, sessionID, now, now.Add(time.Hour)), session.ErrInactiveSession)
+
+		got, err := repo.FindByID(ctx, sessionID)
+		require.NoError(t, err)
+		require.Equal(t, "inactive", got.Status)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		require.ErrorIs(t, repo.Touch(ctx, "missing", now, now), session.ErrNotFound)
+	})
+}
@@ -0,0 +1,103 @@
+package signer
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type asymmetricSigner struct {
+	method jwt.SigningMethod
+	key    crypto.Signer
+	keyID  string
+	now    func() time.Time
+}
+
+func newAsymmetricSigner(method jwt.SigningMethod, key crypto.Signer, keyID string) asymmetricSigner {
+	return asymmetricSigner{
+		method: method,
+		key:    key,
+		keyID:  keyID,
+		now:    time.Now,
+	}
+}
+
+func (s *asymmetricSigner) Alg() string { return s.method.Alg() }
+
+func (s *asymmetricSigner) KeyID() string { return s.keyID }
+
+func (s *asymmetricSigner) Public() crypto.PublicKey { return s.key.Public() }
+
+func (s *asymmetricSigner) Now() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+
+	return time.Now()
+}
+
+func (s *asymmetricSigner) Sign(ctx context.Context, payload []byte) (string, string, error) {
+	return s.SignWithType(ctx, TypeJWT, payload)
+}
+
+func (s *asymmetricSigner) SignWithType(ctx context.Context, typ string, payload []byte) (string, string, error) {
+	_ = ctx
+
+	if s.key == nil {
+		return "", "", ErrNilPrivateKey
+	}
+
+	claims, err := buildClaims(payload, s.Now())
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := signToken(claims, s.method, s.key, s.keyID, typ)
+	if err != nil {
+		return "", "", fmt.Errorf("sign jwt: %w", err)
+	}
+
+	return token, s.keyID, nil
+}
+
+func (s *asymmetricSigner) Verify(ctx context.Context, token string, opt *VerifyOptions) (*VerifyResult, error) {
+	_ = ctx
+	if s.key == nil {
+		return nil, ErrNilPrivateKey
+	}
+	if token == "" {
+		return nil, ErrEmptyToken
+	}
+
+	o := normalizeVerifyOptions(opt)
+
+	nowFn := s.Now
+	if o.Now != nil {
+		nowFn = o.Now
+	}
+
+	tok, claims, err := parseAndVerify(token, s.method, s.key.Public(), nowFn, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildVerifyResult(tok, claims, o)
+}
+
+func (s *asymmetricSigner) PublicJWKs(ctx context.Context) ([]JWK, error) {
+	_ = ctx
+
+	if s.key == nil {
+		return nil, ErrNilPrivateKey
+	}
+
+	jwk, err := NewPublicJWK(s.key.Public(), s.Alg(), s.keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return []JWK{jwk}, nil
+}
@@ -0,0 +1,145 @@
+package signer
+
+import (
+	"context"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsymmetricSigners_SignVerify(t *testing.T) {
+	t.Parallel()
+
+	rsaSigner, err := NewRSASigner(newTestRSAKey(t), "rsa-1")
+	require.NoError(t, err)
+
+	es256Signer, err := NewECDSASigner(newTestECDSAKey(t, elliptic.P256()), "ec-256")
+	require.NoError(t, err)
+
+	es384Signer, err := NewECDSASigner(newTestECDSAKey(t, elliptic.P384()), "ec-384")
+	require.NoError(t, err)
+
+	edSigner, err := NewEd25519Signer(newTestEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		signer  Signer
+		wantAlg string
+	}{
+		{"rsa", rsaSigner, AlgRS256},
+		{"ecdsa-p256", es256Signer, AlgES256},
+		{"ecdsa-p384", es384Signer, AlgES384},
+		{"ed25519", edSigner, AlgEdDSA},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.wantAlg, tt.signer.Alg())
+
+			token, kid, err := tt.signer.Sign(context.Background(), []byte(`{"sub":"u1"}`))
+			require.NoError(t, err)
+			require.Equal(t, tt.signer.KeyID(), kid)
+
+			got, err := tt.signer.Verify(context.Background(), token, &VerifyOptions{
+				ExpectKID:  tt.signer.KeyID(),
+				RequireTyp: true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantAlg, got.Alg)
+			require.Equal(t, "JWT", got.Typ)
+			require.Equal(t, tt.signer.KeyID(), got.KID)
+			require.Equal(t, "u1", got.Claims["sub"])
+		})
+	}
+
+	t.Run("token from another key is rejected", func(t *testing.T) {
+		t.Parallel()
+
+		other, err := NewRSASigner(newTestRSAKey(t), "rsa-1")
+		require.NoError(t, err)
+
+		token, _, err := other.Sign(context.Background(), nil)
+		require.NoError(t, err)
+
+		_, err = rsaSigner.Verify(context.Background(), token, nil)
+		require.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	})
+
+	t.Run("alg confusion with HS256 is rejected", func(t *testing.T) {
+		t.Parallel()
+
+		hs := NewHMACSigner([]byte(testKey), "rsa-1")
+		token, _, err := hs.Sign(context.Background(), nil)
+		require.NoError(t, err)
+
+		_, err = rsaSigner.Verify(context.Background(), token, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("expired token is rejected", func(t *testing.T) {
+		t.Parallel()
+
+		s, err := NewECDSASigner(newTestECDSAKey(t, elliptic.P256()), "ec")
+		require.NoError(t, err)
+		s.now = fixedNow(time.Unix(1_700_000_000, 0))
+
+		token, _, err := s.Sign(context.Background(), nil)
+		require.NoError(t, err)
+
+		s.now = fixedNow(time.Unix(1_700_000_000, 0).Add(25 * time.Hour))
+		_, err = s.Verify(context.Background(), token, nil)
+		require.ErrorIs(t, err, jwt.ErrTokenExpired)
+	})
+
+	t.Run("empty token", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := edSigner.Verify(context.Background(), "", nil)
+		require.ErrorIs(t, err, ErrEmptyToken)
+	})
+}
+
+func TestAsymmetricSigners_Constructors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rsa nil key", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewRSASigner(nil, "k")
+		require.ErrorIs(t, err, ErrNilPrivateKey)
+	})
+
+	t.Run("rsa weak key", func(t *testing.T) {
+		t.Parallel()
+
+		weak, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+
+		_, err = NewRSASigner(weak, "k")
+		require.ErrorIs(t, err, ErrWeakRSAKey)
+	})
+
+	t.Run("ecdsa unsupported curve", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewECDSASigner(newTestECDSAKey(t, elliptic.P224()), "k")
+		require.ErrorIs(t, err, ErrUnsupportedCurve)
+	})
+
+	t.Run("ed25519 empty key", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewEd25519Signer(nil, "k")
+		require.ErrorIs(t, err, ErrNilPrivateKey)
+	})
+}
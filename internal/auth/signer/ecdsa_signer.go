@@ -0,0 +1,46 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgES256 = "ES256"
+	AlgES384 = "ES384"
+	AlgES512 = "ES512"
+)
+
+type ECDSASigner struct {
+	asymmetricSigner
+}
+
+func ecdsaMethodForCurve(curve elliptic.Curve) (jwt.SigningMethod, error) {
+	switch curve {
+	case elliptic.P256():
+		return jwt.SigningMethodES256, nil
+	case elliptic.P384():
+		return jwt.SigningMethodES384, nil
+	case elliptic.P521():
+		return jwt.SigningMethodES512, nil
+	default:
+		return nil, ErrUnsupportedCurve
+	}
+}
+
+func NewECDSASigner(key *ecdsa.PrivateKey, keyID string) (*ECDSASigner, error) {
+	if key == nil {
+		return nil, ErrNilPrivateKey
+	}
+
+	method, err := ecdsaMethodForCurve(key.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ECDSASigner{
+		asymmetricSigner: newAsymmetricSigner(method, key, keyID),
+	}, nil
+}
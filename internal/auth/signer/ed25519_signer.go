@@ -0,0 +1,26 @@
+package signer
+
+import (
+	"crypto/ed25519"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const AlgEdDSA = "EdDSA"
+
+type Ed25519Signer struct {
+	asymmetricSigner
+}
+
+func NewEd25519Signer(key ed25519.PrivateKey, keyID string) (*Ed25519Signer, error) {
+	if len(key) == 0 {
+		return nil, ErrNilPrivateKey
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, ErrUnsupportedKey
+	}
+
+	return &Ed25519Signer{
+		asymmetricSigner: newAsymmetricSigner(jwt.SigningMethodEdDSA, key, keyID),
+	}, nil
+}
@@ -10,6 +10,7 @@ var (
 // Sign
 var (
 	ErrInvalidPayload = errors.New("hmacsigner: invalid payload json")
+	ErrUnsupportedTyp = errors.New("signer: typ header not supported")
 )
 
 // Verify
@@ -20,3 +21,34 @@ var (
 	ErrInvalidTyp    = errors.New("hmacsigner: invalid typ")
 	ErrUnexpectedKID = errors.New("hmacsigner: unexpected kid")
 )
+
+// Asymmetric
+var (
+	ErrInvalidJWK       = errors.New("signer: invalid jwk")
+	ErrInvalidPEM       = errors.New("signer: invalid pem")
+	ErrNilPrivateKey    = errors.New("signer: nil private key")
+	ErrUnsupportedCurve = errors.New("signer: unsupported curve")
+	ErrUnsupportedKey   = errors.New("signer: unsupported key type")
+	ErrWeakRSAKey       = errors.New("signer: rsa key too small")
+)
+
+// KMS
+var (
+	ErrInvalidECSignature = errors.New("signer: invalid ecdsa signature")
+	ErrInvalidKeyVersion  = errors.New("signer: invalid kms key version name")
+	ErrKMSIntegrity       = errors.New("signer: kms response integrity check failed")
+	ErrKMSPublicKeyFailed = errors.New("signer: kms get public key failed")
+	ErrKMSSignFailed      = errors.New("signer: kms sign failed")
+	ErrNilKMSClient       = errors.New("signer: nil kms client")
+	ErrUnsupportedKMSAlg  = errors.New("signer: unsupported kms algorithm")
+)
+
+// Key ring
+var (
+	ErrDuplicateKID = errors.New("signer: duplicate kid in key ring")
+	ErrEmptyKID     = errors.New("signer: empty kid in key ring")
+	ErrNilKeySource = errors.New("signer: nil key source")
+	ErrNilSigner    = errors.New("signer: nil signer in key ring")
+	ErrNoActiveKey  = errors.New("signer: no active signing key")
+	ErrUnknownKID   = errors.New("signer: unknown kid")
+)
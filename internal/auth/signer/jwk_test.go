@@ -0,0 +1,141 @@
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPublicJWK(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rsa", func(t *testing.T) {
+		t.Parallel()
+
+		key := newTestRSAKey(t)
+		jwk, err := NewPublicJWK(&key.PublicKey, AlgRS256, "rsa-1")
+		require.NoError(t, err)
+
+		require.Equal(t, "RSA", jwk.Kty)
+		require.Equal(t, "sig", jwk.Use)
+		require.Equal(t, AlgRS256, jwk.Alg)
+		require.Equal(t, "rsa-1", jwk.Kid)
+		require.Equal(t, "AQAB", jwk.E)
+
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		require.NoError(t, err)
+		require.Equal(t, 0, new(big.Int).SetBytes(n).Cmp(key.N))
+	})
+
+	t.Run("ecdsa coordinates are padded to curve size", func(t *testing.T) {
+		t.Parallel()
+
+		key := newTestECDSAKey(t, elliptic.P521())
+		jwk, err := NewPublicJWK(&key.PublicKey, AlgES512, "ec-1")
+		require.NoError(t, err)
+
+		require.Equal(t, "EC", jwk.Kty)
+		require.Equal(t, "P-521", jwk.Crv)
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		require.NoError(t, err)
+		require.Len(t, x, 66)
+
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		require.NoError(t, err)
+		require.Len(t, y, 66)
+
+		pub := &ecdsa.PublicKey{Curve: elliptic.P521(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		require.True(t, pub.Equal(&key.PublicKey))
+	})
+
+	t.Run("ed25519", func(t *testing.T) {
+		t.Parallel()
+
+		key := newTestEd25519Key(t)
+		jwk, err := NewPublicJWK(key.Public(), AlgEdDSA, "ed-1")
+		require.NoError(t, err)
+
+		require.Equal(t, "OKP", jwk.Kty)
+		require.Equal(t, "Ed25519", jwk.Crv)
+		require.Empty(t, jwk.Y)
+	})
+
+	t.Run("unsupported key", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewPublicJWK([]byte("secret"), AlgHS256, "k")
+		require.ErrorIs(t, err, ErrUnsupportedKey)
+	})
+
+	t.Run("signer publishes its public key", func(t *testing.T) {
+		t.Parallel()
+
+		key := newTestRSAKey(t)
+		s, err := NewRSASigner(key, "rsa-pub")
+		require.NoError(t, err)
+
+		keys, err := s.PublicJWKs(context.Background())
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.Equal(t, "rsa-pub", keys[0].Kid)
+
+		pub, ok := s.Public().(*rsa.PublicKey)
+		require.True(t, ok)
+		require.True(t, pub.Equal(&key.PublicKey))
+	})
+}
+
+func TestParsePublicJWK(t *testing.T) {
+	t.Parallel()
+
+	rsaKey := newTestRSAKey(t)
+	ecKey := newTestECDSAKey(t, elliptic.P256())
+	edKey := newTestEd25519Key(t)
+
+	for _, tc := range []struct {
+		name string
+		pub  crypto.PublicKey
+		alg  string
+	}{
+		{"rsa", &rsaKey.PublicKey, AlgRS256},
+		{"ecdsa", &ecKey.PublicKey, AlgES256},
+		{"ed25519", edKey.Public(), AlgEdDSA},
+	} {
+		t.Run(tc.name+" round trip", func(t *testing.T) {
+			t.Parallel()
+
+			jwk, err := NewPublicJWK(tc.pub, tc.alg, "k")
+			require.NoError(t, err)
+
+			got, err := ParsePublicJWK(jwk)
+			require.NoError(t, err)
+			require.True(t, got.(interface{ Equal(crypto.PublicKey) bool }).Equal(tc.pub))
+		})
+	}
+
+	t.Run("rejects point off curve", func(t *testing.T) {
+		t.Parallel()
+
+		jwk, err := NewPublicJWK(&ecKey.PublicKey, AlgES256, "k")
+		require.NoError(t, err)
+		jwk.Y = jwk.X
+
+		_, err = ParsePublicJWK(jwk)
+		require.ErrorIs(t, err, ErrInvalidJWK)
+	})
+
+	t.Run("rejects unsupported kty", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ParsePublicJWK(JWK{Kty: "oct"})
+		require.ErrorIs(t, err, ErrUnsupportedKey)
+	})
+}
@@ -0,0 +1,317 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+type KeyState string
+
+const (
+	KeyStatePending  KeyState = "pending"
+	KeyStateActive   KeyState = "active"
+	KeyStateRetiring KeyState = "retiring"
+	KeyStateRetired  KeyState = "retired"
+)
+
+type RingKey struct {
+	Signer Signer
+
+	// State overrides the schedule below when set.
+	State KeyState
+
+	ActivateAt time.Time
+	RetireAt   time.Time
+	RemoveAt   time.Time
+}
+
+func (k RingKey) StateAt(now time.Time) KeyState {
+	if k.State != "" {
+		return k.State
+	}
+
+	switch {
+	case !k.RemoveAt.IsZero() && !now.Before(k.RemoveAt):
+		return KeyStateRetired
+	case !k.RetireAt.IsZero() && !now.Before(k.RetireAt):
+		return KeyStateRetiring
+	case now.Before(k.ActivateAt):
+		return KeyStatePending
+	default:
+		return KeyStateActive
+	}
+}
+
+type KeySource interface {
+	Keys(ctx context.Context) ([]RingKey, error)
+}
+
+type StaticKeySource []RingKey
+
+func (s StaticKeySource) Keys(ctx context.Context) ([]RingKey, error) {
+	_ = ctx
+
+	return s, nil
+}
+
+type KeyRing struct {
+	src     KeySource
+	refresh time.Duration
+	now     func() time.Time
+
+	// optional; reports periodic reloads that failed
+	Logger *zap.Logger
+
+	mu       sync.RWMutex
+	keys     []RingKey
+	loadedAt time.Time
+}
+
+func NewKeyRing(ctx context.Context, src KeySource, refresh time.Duration) (*KeyRing, error) {
+	if src == nil {
+		return nil, ErrNilKeySource
+	}
+
+	r := &KeyRing{
+		src:     src,
+		refresh: refresh,
+		now:     time.Now,
+	}
+	if err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *KeyRing) Now() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+
+	return time.Now()
+}
+
+func (r *KeyRing) Reload(ctx context.Context) error {
+	keys, err := r.src.Keys(ctx)
+	if err != nil {
+		return fmt.Errorf("load signing keys: %w", err)
+	}
+
+	seen := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		if k.Signer == nil {
+			return ErrNilSigner
+		}
+		kid := k.Signer.KeyID()
+		if kid == "" {
+			return ErrEmptyKID
+		}
+		if _, ok := seen[kid]; ok {
+			return fmt.Errorf("%w: %q", ErrDuplicateKID, kid)
+		}
+		seen[kid] = struct{}{}
+	}
+
+	sorted := append([]RingKey(nil), keys...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActivateAt.After(sorted[j].ActivateAt)
+	})
+
+	r.mu.Lock()
+	r.keys = sorted
+	r.loadedAt = r.Now()
+	r.mu.Unlock()
+
+	return nil
+}
+
+// snapshot returns the keys, reloading them once the refresh interval has
+// passed. A failed reload keeps the cached keys in service until the next
+// interval, so a source outage does not stop signing or verification.
+func (r *KeyRing) snapshot(ctx context.Context) ([]RingKey, error) {
+	r.mu.RLock()
+	keys, loadedAt := r.keys, r.loadedAt
+	r.mu.RUnlock()
+
+	if r.refresh <= 0 || r.Now().Sub(loadedAt) < r.refresh {
+		return keys, nil
+	}
+
+	if err := r.Reload(ctx); err != nil {
+		if len(keys) == 0 {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		r.loadedAt = r.Now()
+		r.mu.Unlock()
+
+		if r.Logger != nil {
+			r.Logger.Warn("signing key reload failed; serving cached keys", zap.Error(err))
+		}
+
+		return keys, nil
+	}
+
+	return r.cached(), nil
+}
+
+func (r *KeyRing) cached() []RingKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.keys
+}
+
+func activeKey(keys []RingKey, now time.Time) (Signer, bool) {
+	for _, k := range keys {
+		if k.StateAt(now) == KeyStateActive {
+			return k.Signer, true
+		}
+	}
+
+	return nil, false
+}
+
+func (r *KeyRing) Alg() string {
+	s, ok := activeKey(r.cached(), r.Now())
+	if !ok {
+		return ""
+	}
+
+	return s.Alg()
+}
+
+func (r *KeyRing) KeyID() string {
+	s, ok := activeKey(r.cached(), r.Now())
+	if !ok {
+		return ""
+	}
+
+	return s.KeyID()
+}
+
+func (r *KeyRing) Algs() []string {
+	now := r.Now()
+	var out []string
+	for _, k := range r.cached() {
+		if k.StateAt(now) == KeyStateRetired {
+			continue
+		}
+		if alg := k.Signer.Alg(); !slices.Contains(out, alg) {
+			out = append(out, alg)
+		}
+	}
+
+	return out
+}
+
+func (r *KeyRing) States() map[string]KeyState {
+	now := r.Now()
+	out := make(map[string]KeyState)
+	for _, k := range r.cached() {
+		out[k.Signer.KeyID()] = k.StateAt(now)
+	}
+
+	return out
+}
+
+func (r *KeyRing) Sign(ctx context.Context, payload []byte) (string, string, error) {
+	return r.SignWithType(ctx, TypeJWT, payload)
+}
+
+func (r *KeyRing) SignWithType(ctx context.Context, typ string, payload []byte) (string, string, error) {
+	keys, err := r.snapshot(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	s, ok := activeKey(keys, r.Now())
+	if !ok {
+		return "", "", ErrNoActiveKey
+	}
+
+	if typ == TypeJWT {
+		return s.Sign(ctx, payload)
+	}
+
+	ts, ok := s.(TypedSigner)
+	if !ok {
+		return "", "", fmt.Errorf("%w: %q", ErrUnsupportedTyp, typ)
+	}
+
+	return ts.SignWithType(ctx, typ, payload)
+}
+
+func (r *KeyRing) Verify(ctx context.Context, token string, opt *VerifyOptions) (*VerifyResult, error) {
+	if token == "" {
+		return nil, ErrEmptyToken
+	}
+
+	keys, err := r.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	unverified, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	kid, _ := unverified.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownKID
+	}
+
+	now := r.Now()
+	for _, k := range keys {
+		if k.Signer.KeyID() != kid {
+			continue
+		}
+
+		switch k.StateAt(now) {
+		case KeyStateActive, KeyStateRetiring:
+			return k.Signer.Verify(ctx, token, opt)
+		default:
+			return nil, fmt.Errorf("%w: %q is not accepted for verification", ErrUnknownKID, kid)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKID, kid)
+}
+
+func (r *KeyRing) PublicJWKs(ctx context.Context) ([]JWK, error) {
+	keys, err := r.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.Now()
+	out := make([]JWK, 0, len(keys))
+	for _, k := range keys {
+		if k.StateAt(now) == KeyStateRetired {
+			continue
+		}
+
+		src, ok := k.Signer.(PublicKeySource)
+		if !ok {
+			continue
+		}
+
+		jwks, err := src.PublicJWKs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, jwks...)
+	}
+
+	return out, nil
+}
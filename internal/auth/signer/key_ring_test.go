@@ -0,0 +1,272 @@
+package signer
+
+import (
+	"context"
+	"crypto/elliptic"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type countingKeySource struct {
+	keys  []RingKey
+	err   error
+	calls int
+}
+
+func (s *countingKeySource) Keys(context.Context) ([]RingKey, error) {
+	s.calls++
+
+	return s.keys, s.err
+}
+
+func TestRingKey_StateAt(t *testing.T) {
+	t.Parallel()
+
+	base := time.Unix(1_700_000_000, 0)
+	k := RingKey{
+		ActivateAt: base,
+		RetireAt:   base.Add(time.Hour),
+		RemoveAt:   base.Add(2 * time.Hour),
+	}
+
+	require.Equal(t, KeyStatePending, k.StateAt(base.Add(-time.Second)))
+	require.Equal(t, KeyStateActive, k.StateAt(base))
+	require.Equal(t, KeyStateRetiring, k.StateAt(base.Add(time.Hour)))
+	require.Equal(t, KeyStateRetired, k.StateAt(base.Add(2*time.Hour)))
+
+	k.State = KeyStateRetiring
+	require.Equal(t, KeyStateRetiring, k.StateAt(base.Add(-time.Second)))
+}
+
+func TestKeyRing_Rotation(t *testing.T) {
+	t.Parallel()
+
+	base := time.Unix(1_700_000_000, 0)
+
+	oldKey, err := NewRSASigner(newTestRSAKey(t), "old")
+	require.NoError(t, err)
+	newKey, err := NewECDSASigner(newTestECDSAKey(t, elliptic.P256()), "new")
+	require.NoError(t, err)
+	nextKey, err := NewEd25519Signer(newTestEd25519Key(t), "next")
+	require.NoError(t, err)
+
+	oldKey.now = fixedNow(base)
+	newKey.now = fixedNow(base)
+	nextKey.now = fixedNow(base)
+
+	ring, err := NewKeyRing(context.Background(), StaticKeySource{
+		{Signer: oldKey, RetireAt: base.Add(-time.Minute), RemoveAt: base.Add(time.Hour)},
+		{Signer: newKey, ActivateAt: base.Add(-time.Minute)},
+		{Signer: nextKey, ActivateAt: base.Add(time.Hour)},
+	}, 0)
+	require.NoError(t, err)
+	ring.now = fixedNow(base)
+
+	require.Equal(t, "new", ring.KeyID())
+	require.Equal(t, AlgES256, ring.Alg())
+	require.ElementsMatch(t, []string{AlgRS256, AlgES256, AlgEdDSA}, ring.Algs())
+	require.Equal(t, map[string]KeyState{
+		"old":  KeyStateRetiring,
+		"new":  KeyStateActive,
+		"next": KeyStatePending,
+	}, ring.States())
+
+	t.Run("signs with the active key", func(t *testing.T) {
+		token, kid, err := ring.Sign(context.Background(), []byte(`{"sub":"u1"}`))
+		require.NoError(t, err)
+		require.Equal(t, "new", kid)
+
+		got, err := ring.Verify(context.Background(), token, nil)
+		require.NoError(t, err)
+		require.Equal(t, "new", got.KID)
+	})
+
+	t.Run("signs with an explicit typ header", func(t *testing.T) {
+		token, kid, err := ring.SignWithType(context.Background(), TypeAccessToken, []byte(`{"sub":"u1"}`))
+		require.NoError(t, err)
+		require.Equal(t, "new", kid)
+
+		got, err := ring.Verify(context.Background(), token, &VerifyOptions{RequireTyp: true, ExpectTyp: TypeAccessToken})
+		require.NoError(t, err)
+		require.Equal(t, TypeAccessToken, got.Typ)
+
+		_, err = ring.Verify(context.Background(), token, &VerifyOptions{RequireTyp: true})
+		require.ErrorIs(t, err, ErrInvalidTyp)
+	})
+
+	t.Run("verifies tokens from a retiring key", func(t *testing.T) {
+		token, _, err := oldKey.Sign(context.Background(), nil)
+		require.NoError(t, err)
+
+		got, err := ring.Verify(context.Background(), token, nil)
+		require.NoError(t, err)
+		require.Equal(t, "old", got.KID)
+	})
+
+	t.Run("rejects tokens from a pending key", func(t *testing.T) {
+		token, _, err := nextKey.Sign(context.Background(), nil)
+		require.NoError(t, err)
+
+		_, err = ring.Verify(context.Background(), token, nil)
+		require.ErrorIs(t, err, ErrUnknownKID)
+	})
+
+	t.Run("rejects unknown kid", func(t *testing.T) {
+		other, err := NewRSASigner(newTestRSAKey(t), "other")
+		require.NoError(t, err)
+		other.now = fixedNow(base)
+
+		token, _, err := other.Sign(context.Background(), nil)
+		require.NoError(t, err)
+
+		_, err = ring.Verify(context.Background(), token, nil)
+		require.ErrorIs(t, err, ErrUnknownKID)
+	})
+
+	t.Run("rejects a forged token reusing a known kid", func(t *testing.T) {
+		forger, err := NewECDSASigner(newTestECDSAKey(t, elliptic.P256()), "new")
+		require.NoError(t, err)
+		forger.now = fixedNow(base)
+
+		token, _, err := forger.Sign(context.Background(), nil)
+		require.NoError(t, err)
+
+		_, err = ring.Verify(context.Background(), token, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("publishes every key that is not retired", func(t *testing.T) {
+		keys, err := ring.PublicJWKs(context.Background())
+		require.NoError(t, err)
+
+		var kids []string
+		for _, k := range keys {
+			kids = append(kids, k.Kid)
+		}
+		require.ElementsMatch(t, []string{"old", "new", "next"}, kids)
+	})
+
+	t.Run("retired keys are dropped", func(t *testing.T) {
+		ring := &KeyRing{src: ring.src, now: fixedNow(base.Add(2 * time.Hour))}
+		require.NoError(t, ring.Reload(context.Background()))
+
+		require.Equal(t, "next", ring.KeyID())
+		require.Equal(t, KeyStateRetired, ring.States()["old"])
+
+		keys, err := ring.PublicJWKs(context.Background())
+		require.NoError(t, err)
+		for _, k := range keys {
+			require.NotEqual(t, "old", k.Kid)
+		}
+	})
+}
+
+func TestKeyRing_NoActiveKey(t *testing.T) {
+	t.Parallel()
+
+	s := NewHMACSigner([]byte(testKey), testKid123)
+	ring, err := NewKeyRing(context.Background(), StaticKeySource{
+		{Signer: s, State: KeyStatePending},
+	}, 0)
+	require.NoError(t, err)
+
+	require.Empty(t, ring.KeyID())
+
+	_, _, err = ring.Sign(context.Background(), nil)
+	require.ErrorIs(t, err, ErrNoActiveKey)
+
+	keys, err := ring.PublicJWKs(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
+
+func TestKeyRing_Reload(t *testing.T) {
+	t.Parallel()
+
+	base := time.Unix(1_700_000_000, 0)
+	now := base
+
+	a := NewHMACSigner([]byte("a"), "a")
+	b := NewHMACSigner([]byte("b"), "b")
+
+	src := &countingKeySource{keys: []RingKey{{Signer: a}}}
+	ring, err := NewKeyRing(context.Background(), src, time.Minute)
+	require.NoError(t, err)
+	ring.now = func() time.Time { return now }
+	ring.loadedAt = base
+	require.Equal(t, 1, src.calls)
+
+	src.keys = []RingKey{{Signer: a, State: KeyStateRetiring}, {Signer: b}}
+
+	_, kid, err := ring.Sign(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "a", kid)
+	require.Equal(t, 1, src.calls)
+
+	now = base.Add(time.Minute)
+	_, kid, err = ring.Sign(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "b", kid)
+	require.Equal(t, 2, src.calls)
+
+	src.err = errors.New("store unavailable")
+	now = base.Add(3 * time.Minute)
+	_, kid, err = ring.Sign(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "b", kid)
+	require.Equal(t, 3, src.calls)
+
+	_, _, err = ring.Sign(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, src.calls, "a failed reload waits for the next interval")
+
+	src.err = nil
+	src.keys = []RingKey{{Signer: b}}
+	now = base.Add(4 * time.Minute)
+	_, kid, err = ring.Sign(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "b", kid)
+	require.Equal(t, 4, src.calls)
+}
+
+func TestKeyRing_ReloadWithoutKeys(t *testing.T) {
+	t.Parallel()
+
+	base := time.Unix(1_700_000_000, 0)
+	now := base
+
+	src := &countingKeySource{}
+	ring, err := NewKeyRing(context.Background(), src, time.Minute)
+	require.NoError(t, err)
+	ring.now = func() time.Time { return now }
+	ring.loadedAt = base
+
+	src.err = errors.New("store unavailable")
+	now = base.Add(time.Minute)
+	_, err = ring.PublicJWKs(context.Background())
+	require.ErrorContains(t, err, "store unavailable")
+}
+
+func TestNewKeyRing_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewKeyRing(context.Background(), nil, 0)
+	require.ErrorIs(t, err, ErrNilKeySource)
+
+	_, err = NewKeyRing(context.Background(), StaticKeySource{{}}, 0)
+	require.ErrorIs(t, err, ErrNilSigner)
+
+	_, err = NewKeyRing(context.Background(), StaticKeySource{
+		{Signer: NewHMACSigner([]byte("a"), "")},
+	}, 0)
+	require.ErrorIs(t, err, ErrEmptyKID)
+
+	_, err = NewKeyRing(context.Background(), StaticKeySource{
+		{Signer: NewHMACSigner([]byte("a"), "dup")},
+		{Signer: NewHMACSigner([]byte("b"), "dup")},
+	}, 0)
+	require.ErrorIs(t, err, ErrDuplicateKID)
+}
@@ -0,0 +1,246 @@
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+	"regexp"
+	"time"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/vinylhousegarage/idpproxy/internal/kms"
+)
+
+var reKeyVersion = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/([^/]+)/cryptoKeys/([^/]+)/cryptoKeyVersions/([^/]+)$`)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+type KMSSigner struct {
+	client     kms.SigningClient
+	keyVersion string
+	keyID      string
+	method     jwt.SigningMethod
+	public     crypto.PublicKey
+	now        func() time.Time
+}
+
+func NewKMSSigner(ctx context.Context, client kms.SigningClient, keyVersion string) (*KMSSigner, error) {
+	if client == nil {
+		return nil, ErrNilKMSClient
+	}
+
+	keyID, err := KMSKeyID(keyVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{Name: keyVersion})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrKMSPublicKeyFailed, err)
+	}
+
+	if resp.GetName() != keyVersion {
+		return nil, fmt.Errorf("%w: unexpected key name %q", ErrKMSIntegrity, resp.GetName())
+	}
+	if crc := resp.GetPemCrc32C(); crc != nil && int64(crc32c([]byte(resp.GetPem()))) != crc.GetValue() {
+		return nil, fmt.Errorf("%w: public key crc32c mismatch", ErrKMSIntegrity)
+	}
+
+	method, err := kmsSigningMethod(resp.GetAlgorithm())
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := parsePublicKeyPEM([]byte(resp.GetPem()))
+	if err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case jwt.SigningMethodRS256:
+		if _, ok := pub.(*rsa.PublicKey); !ok {
+			return nil, ErrUnsupportedKey
+		}
+	case jwt.SigningMethodES256:
+		if _, ok := pub.(*ecdsa.PublicKey); !ok {
+			return nil, ErrUnsupportedKey
+		}
+	}
+
+	return &KMSSigner{
+		client:     client,
+		keyVersion: keyVersion,
+		keyID:      keyID,
+		method:     method,
+		public:     pub,
+		now:        time.Now,
+	}, nil
+}
+
+func KMSKeyID(keyVersion string) (string, error) {
+	m := reKeyVersion.FindStringSubmatch(keyVersion)
+	if m == nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKeyVersion, keyVersion)
+	}
+
+	return fmt.Sprintf("%s.%s.v%s", m[1], m[2], m[3]), nil
+}
+
+func kmsSigningMethod(alg kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm) (jwt.SigningMethod, error) {
+	switch alg {
+	case kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_2048_SHA256,
+		kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_3072_SHA256,
+		kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_4096_SHA256:
+		return jwt.SigningMethodRS256, nil
+	case kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256:
+		return jwt.SigningMethodES256, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKMSAlg, alg)
+	}
+}
+
+func parsePublicKeyPEM(pemBytes []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, ErrInvalidPEM
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func crc32c(b []byte) uint32 {
+	return crc32.Checksum(b, crc32cTable)
+}
+
+func (s *KMSSigner) Alg() string { return s.method.Alg() }
+
+func (s *KMSSigner) KeyID() string { return s.keyID }
+
+func (s *KMSSigner) KeyVersion() string { return s.keyVersion }
+
+func (s *KMSSigner) Public() crypto.PublicKey { return s.public }
+
+func (s *KMSSigner) Now() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+
+	return time.Now()
+}
+
+func (s *KMSSigner) Sign(ctx context.Context, payload []byte) (string, string, error) {
+	return s.SignWithType(ctx, TypeJWT, payload)
+}
+
+func (s *KMSSigner) SignWithType(ctx context.Context, typ string, payload []byte) (string, string, error) {
+	claims, err := buildClaims(payload, s.Now())
+	if err != nil {
+		return "", "", err
+	}
+
+	tok := jwt.NewWithClaims(s.method, claims)
+	tok.Header["typ"] = typ
+	tok.Header["kid"] = s.keyID
+
+	signingString, err := tok.SigningString()
+	if err != nil {
+		return "", "", fmt.Errorf("sign jwt: %w", err)
+	}
+
+	sig, err := s.signDigest(ctx, []byte(signingString))
+	if err != nil {
+		return "", "", err
+	}
+
+	return signingString + "." + base64.RawURLEncoding.EncodeToString(sig), s.keyID, nil
+}
+
+func (s *KMSSigner) signDigest(ctx context.Context, data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+
+	resp, err := s.client.AsymmetricSign(ctx, &kmspb.AsymmetricSignRequest{
+		Name:         s.keyVersion,
+		Digest:       &kmspb.Digest{Digest: &kmspb.Digest_Sha256{Sha256: digest[:]}},
+		DigestCrc32C: wrapperspb.Int64(int64(crc32c(digest[:]))),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrKMSSignFailed, err)
+	}
+
+	if resp.GetName() != s.keyVersion || !resp.GetVerifiedDigestCrc32C() {
+		return nil, fmt.Errorf("%w: request corrupted in transit", ErrKMSIntegrity)
+	}
+	if int64(crc32c(resp.GetSignature())) != resp.GetSignatureCrc32C().GetValue() {
+		return nil, fmt.Errorf("%w: signature crc32c mismatch", ErrKMSIntegrity)
+	}
+
+	if s.method == jwt.SigningMethodES256 {
+		return ecdsaDERToJWS(resp.GetSignature(), 32)
+	}
+
+	return resp.GetSignature(), nil
+}
+
+func ecdsaDERToJWS(der []byte, size int) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+
+	rest, err := asn1.Unmarshal(der, &sig)
+	if err != nil || len(rest) != 0 || sig.R == nil || sig.S == nil {
+		return nil, ErrInvalidECSignature
+	}
+	if sig.R.BitLen() > size*8 || sig.S.BitLen() > size*8 {
+		return nil, ErrInvalidECSignature
+	}
+
+	out := make([]byte, 2*size)
+	sig.R.FillBytes(out[:size])
+	sig.S.FillBytes(out[size:])
+
+	return out, nil
+}
+
+func (s *KMSSigner) Verify(ctx context.Context, token string, opt *VerifyOptions) (*VerifyResult, error) {
+	_ = ctx
+	if token == "" {
+		return nil, ErrEmptyToken
+	}
+
+	o := normalizeVerifyOptions(opt)
+
+	nowFn := s.Now
+	if o.Now != nil {
+		nowFn = o.Now
+	}
+
+	tok, claims, err := parseAndVerify(token, s.method, s.public, nowFn, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildVerifyResult(tok, claims, o)
+}
+
+func (s *KMSSigner) PublicJWKs(ctx context.Context) ([]JWK, error) {
+	_ = ctx
+
+	jwk, err := NewPublicJWK(s.public, s.Alg(), s.keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return []JWK{jwk}, nil
+}
@@ -0,0 +1,208 @@
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const testKeyVersion = "projects/p/locations/global/keyRings/r/cryptoKeys/id-token/cryptoKeyVersions/3"
+
+type fakeKMSSigning struct {
+	key       crypto.Signer
+	algorithm kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm
+
+	signErr     error
+	getPubErr   error
+	corruptSig  bool
+	getPubCalls int
+	signCalls   int
+	lastSignReq *kmspb.AsymmetricSignRequest
+}
+
+func (f *fakeKMSSigning) AsymmetricSign(ctx context.Context, req *kmspb.AsymmetricSignRequest, _ ...gax.CallOption) (*kmspb.AsymmetricSignResponse, error) {
+	f.signCalls++
+	f.lastSignReq = req
+	if f.signErr != nil {
+		return nil, f.signErr
+	}
+
+	digest := req.GetDigest().GetSha256()
+	sig, err := f.key.Sign(rand.Reader, digest, crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	crc := int64(crc32c(sig))
+	if f.corruptSig {
+		crc++
+	}
+
+	return &kmspb.AsymmetricSignResponse{
+		Name:                 req.GetName(),
+		Signature:            sig,
+		SignatureCrc32C:      wrapperspb.Int64(crc),
+		VerifiedDigestCrc32C: int64(crc32c(digest)) == req.GetDigestCrc32C().GetValue(),
+	}, nil
+}
+
+func (f *fakeKMSSigning) GetPublicKey(ctx context.Context, req *kmspb.GetPublicKeyRequest, _ ...gax.CallOption) (*kmspb.PublicKey, error) {
+	f.getPubCalls++
+	if f.getPubErr != nil {
+		return nil, f.getPubErr
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(f.key.Public())
+	if err != nil {
+		return nil, err
+	}
+	p := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	return &kmspb.PublicKey{
+		Name:      req.GetName(),
+		Pem:       p,
+		PemCrc32C: wrapperspb.Int64(int64(crc32c([]byte(p)))),
+		Algorithm: f.algorithm,
+	}, nil
+}
+
+func TestKMSSigner_SignVerify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		fake    func(t *testing.T) *fakeKMSSigning
+		wantAlg string
+	}{
+		{
+			name: "rsa",
+			fake: func(t *testing.T) *fakeKMSSigning {
+				return &fakeKMSSigning{key: newTestRSAKey(t), algorithm: kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_2048_SHA256}
+			},
+			wantAlg: AlgRS256,
+		},
+		{
+			name: "ecdsa",
+			fake: func(t *testing.T) *fakeKMSSigning {
+				return &fakeKMSSigning{key: newTestECDSAKey(t, elliptic.P256()), algorithm: kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256}
+			},
+			wantAlg: AlgES256,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := tt.fake(t)
+			s, err := NewKMSSigner(context.Background(), fake, testKeyVersion)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantAlg, s.Alg())
+			require.Equal(t, "r.id-token.v3", s.KeyID())
+
+			token, kid, err := s.Sign(context.Background(), []byte(`{"sub":"u1"}`))
+			require.NoError(t, err)
+			require.Equal(t, "r.id-token.v3", kid)
+			require.Equal(t, testKeyVersion, fake.lastSignReq.GetName())
+
+			got, err := s.Verify(context.Background(), token, &VerifyOptions{ExpectKID: kid})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantAlg, got.Alg)
+			require.Equal(t, "u1", got.Claims["sub"])
+
+			keys, err := s.PublicJWKs(context.Background())
+			require.NoError(t, err)
+			require.Len(t, keys, 1)
+			require.Equal(t, kid, keys[0].Kid)
+			require.Equal(t, tt.wantAlg, keys[0].Alg)
+
+			_, _, err = s.Sign(context.Background(), nil)
+			require.NoError(t, err)
+			require.Equal(t, 1, fake.getPubCalls)
+			require.Equal(t, 2, fake.signCalls)
+		})
+	}
+}
+
+func TestKMSSigner_Errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil client", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewKMSSigner(context.Background(), nil, testKeyVersion)
+		require.ErrorIs(t, err, ErrNilKMSClient)
+	})
+
+	t.Run("crypto key without version", func(t *testing.T) {
+		t.Parallel()
+
+		fake := &fakeKMSSigning{key: newTestRSAKey(t), algorithm: kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_2048_SHA256}
+		_, err := NewKMSSigner(context.Background(), fake, "projects/p/locations/global/keyRings/r/cryptoKeys/k")
+		require.ErrorIs(t, err, ErrInvalidKeyVersion)
+		require.Zero(t, fake.getPubCalls)
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		t.Parallel()
+
+		fake := &fakeKMSSigning{key: newTestRSAKey(t), algorithm: kmspb.CryptoKeyVersion_RSA_SIGN_PSS_2048_SHA256}
+		_, err := NewKMSSigner(context.Background(), fake, testKeyVersion)
+		require.ErrorIs(t, err, ErrUnsupportedKMSAlg)
+	})
+
+	t.Run("get public key fails", func(t *testing.T) {
+		t.Parallel()
+
+		fake := &fakeKMSSigning{getPubErr: errors.New("permission denied")}
+		_, err := NewKMSSigner(context.Background(), fake, testKeyVersion)
+		require.ErrorIs(t, err, ErrKMSPublicKeyFailed)
+	})
+
+	t.Run("sign fails", func(t *testing.T) {
+		t.Parallel()
+
+		fake := &fakeKMSSigning{key: newTestECDSAKey(t, elliptic.P256()), algorithm: kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256}
+		s, err := NewKMSSigner(context.Background(), fake, testKeyVersion)
+		require.NoError(t, err)
+
+		fake.signErr = errors.New("unavailable")
+		_, _, err = s.Sign(context.Background(), nil)
+		require.ErrorIs(t, err, ErrKMSSignFailed)
+	})
+
+	t.Run("corrupted signature", func(t *testing.T) {
+		t.Parallel()
+
+		fake := &fakeKMSSigning{key: newTestRSAKey(t), algorithm: kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_2048_SHA256}
+		s, err := NewKMSSigner(context.Background(), fake, testKeyVersion)
+		require.NoError(t, err)
+
+		fake.corruptSig = true
+		_, _, err = s.Sign(context.Background(), nil)
+		require.ErrorIs(t, err, ErrKMSIntegrity)
+	})
+}
+
+func TestKMSKeyID(t *testing.T) {
+	t.Parallel()
+
+	kid, err := KMSKeyID("projects/p/locations/asia-northeast1/keyRings/idp/cryptoKeys/sig/cryptoKeyVersions/12")
+	require.NoError(t, err)
+	require.Equal(t, "idp.sig.v12", kid)
+
+	_, err = KMSKeyID("")
+	require.ErrorIs(t, err, ErrInvalidKeyVersion)
+}
@@ -0,0 +1,46 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+func parsePrivateKeyPEM(pemBytes []byte) (any, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: block type %q", ErrInvalidPEM, block.Type)
+	}
+}
+
+func NewSignerFromPEM(pemBytes []byte, keyID string) (Signer, error) {
+	key, err := parsePrivateKeyPEM(pemBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return NewRSASigner(k, keyID)
+	case *ecdsa.PrivateKey:
+		return NewECDSASigner(k, keyID)
+	case ed25519.PrivateKey:
+		return NewEd25519Signer(k, keyID)
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
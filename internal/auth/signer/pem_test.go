@@ -0,0 +1,70 @@
+package signer
+
+import (
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSignerFromPEM(t *testing.T) {
+	t.Parallel()
+
+	pkcs8 := func(t *testing.T, key any) []byte {
+		t.Helper()
+
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+
+	t.Run("pkcs1 rsa", func(t *testing.T) {
+		t.Parallel()
+
+		key := newTestRSAKey(t)
+		b := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+		s, err := NewSignerFromPEM(b, "k1")
+		require.NoError(t, err)
+		require.Equal(t, AlgRS256, s.Alg())
+		require.Equal(t, "k1", s.KeyID())
+	})
+
+	t.Run("sec1 ecdsa", func(t *testing.T) {
+		t.Parallel()
+
+		der, err := x509.MarshalECPrivateKey(newTestECDSAKey(t, elliptic.P256()))
+		require.NoError(t, err)
+		b := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+		s, err := NewSignerFromPEM(b, "k2")
+		require.NoError(t, err)
+		require.Equal(t, AlgES256, s.Alg())
+	})
+
+	t.Run("pkcs8 ed25519", func(t *testing.T) {
+		t.Parallel()
+
+		s, err := NewSignerFromPEM(pkcs8(t, newTestEd25519Key(t)), "k3")
+		require.NoError(t, err)
+		require.Equal(t, AlgEdDSA, s.Alg())
+	})
+
+	t.Run("not pem", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewSignerFromPEM([]byte("garbage"), "k")
+		require.ErrorIs(t, err, ErrInvalidPEM)
+	})
+
+	t.Run("unexpected block type", func(t *testing.T) {
+		t.Parallel()
+
+		b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{0x00}})
+		_, err := NewSignerFromPEM(b, "k")
+		require.ErrorIs(t, err, ErrInvalidPEM)
+	})
+}
@@ -0,0 +1,30 @@
+package signer
+
+import (
+	"crypto/rsa"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgRS256 = "RS256"
+
+	minRSAKeyBits = 2048
+)
+
+type RSASigner struct {
+	asymmetricSigner
+}
+
+func NewRSASigner(key *rsa.PrivateKey, keyID string) (*RSASigner, error) {
+	if key == nil {
+		return nil, ErrNilPrivateKey
+	}
+	if key.N.BitLen() < minRSAKeyBits {
+		return nil, ErrWeakRSAKey
+	}
+
+	return &RSASigner{
+		asymmetricSigner: newAsymmetricSigner(jwt.SigningMethodRS256, key, keyID),
+	}, nil
+}
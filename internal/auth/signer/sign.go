@@ -36,9 +36,9 @@ func buildClaims(payload []byte, now time.Time) (jwt.MapClaims, error) {
 	return claims, nil
 }
 
-func signToken(claims jwt.Claims, key []byte, kid string) (string, error) {
-	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tok.Header["typ"] = "JWT"
+func signToken(claims jwt.Claims, method jwt.SigningMethod, key any, kid, typ string) (string, error) {
+	tok := jwt.NewWithClaims(method, claims)
+	tok.Header["typ"] = typ
 	if kid != "" {
 		tok.Header["kid"] = kid
 	}
@@ -47,6 +47,10 @@ func signToken(claims jwt.Claims, key []byte, kid string) (string, error) {
 }
 
 func (s *HMACSigner) Sign(ctx context.Context, payload []byte) (string, string, error) {
+	return s.SignWithType(ctx, TypeJWT, payload)
+}
+
+func (s *HMACSigner) SignWithType(ctx context.Context, typ string, payload []byte) (string, string, error) {
 	_ = ctx
 
 	if err := s.validateKey(); err != nil {
@@ -58,7 +62,7 @@ func (s *HMACSigner) Sign(ctx context.Context, payload []byte) (string, string,
 		return "", "", err
 	}
 
-	token, err := signToken(claims, s.key, s.keyID)
+	token, err := signToken(claims, jwt.SigningMethodHS256, s.key, s.keyID, typ)
 	if err != nil {
 		return "", "", fmt.Errorf("sign jwt: %w", err)
 	}
@@ -84,4 +84,17 @@ func TestHMACSigner_Sign(t *testing.T) {
 		require.Equal(t, "JWT", parsed.Header["typ"])
 		require.Equal(t, testKidXYZ, parsed.Header["kid"])
 	})
+
+	t.Run("explicit-typ", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewHMACSigner([]byte(testKey), testKidXYZ)
+
+		tok, _, err := s.SignWithType(context.Background(), TypeAccessToken, []byte(`{"sub":"u1"}`))
+		require.NoError(t, err)
+
+		parsed, err := jwt.Parse(tok, func(tk *jwt.Token) (any, error) { return []byte(testKey), nil })
+		require.NoError(t, err)
+		require.Equal(t, TypeAccessToken, parsed.Header["typ"])
+	})
 }
@@ -6,11 +6,26 @@ import (
 
 type Signer interface {
 	Sign(ctx context.Context, payload []byte) (token string, kid string, err error)
-	Verify(ctx context.Context, token string) (payload []byte, kid string, err error)
+	Verify(ctx context.Context, token string, opt *VerifyOptions) (*VerifyResult, error)
 	Alg() string
 	KeyID() string
 }
 
+const (
+	TypeJWT         = "JWT"
+	TypeAccessToken = "at+jwt"
+	TypeLogoutToken = "logout+jwt"
+)
+
+// TypedSigner signs with an explicit JOSE "typ" header instead of the default "JWT".
+type TypedSigner interface {
+	SignWithType(ctx context.Context, typ string, payload []byte) (token string, kid string, err error)
+}
+
+type PublicKeySource interface {
+	PublicJWKs(ctx context.Context) ([]JWK, error)
+}
+
 type Claims struct {
 	Iss string `json:"iss"`
 	Sub string `json:"sub"`
@@ -19,3 +34,25 @@ type Claims struct {
 	Exp int64  `json:"exp"`
 	Gen int    `json:"gen"`
 }
+
+var (
+	_ Signer = (*HMACSigner)(nil)
+	_ Signer = (*RSASigner)(nil)
+	_ Signer = (*ECDSASigner)(nil)
+	_ Signer = (*Ed25519Signer)(nil)
+	_ Signer = (*KMSSigner)(nil)
+	_ Signer = (*KeyRing)(nil)
+
+	_ TypedSigner = (*HMACSigner)(nil)
+	_ TypedSigner = (*RSASigner)(nil)
+	_ TypedSigner = (*ECDSASigner)(nil)
+	_ TypedSigner = (*Ed25519Signer)(nil)
+	_ TypedSigner = (*KMSSigner)(nil)
+	_ TypedSigner = (*KeyRing)(nil)
+
+	_ PublicKeySource = (*RSASigner)(nil)
+	_ PublicKeySource = (*ECDSASigner)(nil)
+	_ PublicKeySource = (*Ed25519Signer)(nil)
+	_ PublicKeySource = (*KMSSigner)(nil)
+	_ PublicKeySource = (*KeyRing)(nil)
+)
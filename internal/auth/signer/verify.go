@@ -14,6 +14,9 @@ type VerifyOptions struct {
 	ExpectKID  string
 	RequireTyp bool
 	ExpectTyp  string
+
+	// skips exp/nbf/iat checks; the signature is still verified
+	AllowExpired bool
 }
 
 type VerifyResult struct {
@@ -23,20 +26,24 @@ type VerifyResult struct {
 	Claims jwt.MapClaims
 }
 
-func (s *HMACSigner) parseAndVerifyHS256(token string, now func() time.Time, leeway time.Duration) (*jwt.Token, jwt.MapClaims, error) {
-	parser := jwt.NewParser(
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
-		jwt.WithLeeway(leeway),
+func parseAndVerify(token string, method jwt.SigningMethod, key any, now func() time.Time, o VerifyOptions) (*jwt.Token, jwt.MapClaims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{method.Alg()}),
+		jwt.WithLeeway(o.Leeway),
 		jwt.WithTimeFunc(now),
-	)
+	}
+	if o.AllowExpired {
+		opts = append(opts, jwt.WithoutClaimsValidation())
+	}
+	parser := jwt.NewParser(opts...)
 
 	var claims jwt.MapClaims
 	t, err := parser.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
-		if t.Method != jwt.SigningMethodHS256 {
+		if t.Method != method {
 			return nil, ErrInvalidAlg
 		}
 
-		return s.key, nil
+		return key, nil
 	})
 	if err != nil {
 		return nil, nil, err
@@ -48,6 +55,10 @@ func (s *HMACSigner) parseAndVerifyHS256(token string, now func() time.Time, lee
 	return t, claims, nil
 }
 
+func (s *HMACSigner) parseAndVerifyHS256(token string, now func() time.Time, o VerifyOptions) (*jwt.Token, jwt.MapClaims, error) {
+	return parseAndVerify(token, jwt.SigningMethodHS256, s.key, now, o)
+}
+
 func checkHeaderPolicy(t *jwt.Token, opt VerifyOptions) error {
 	if opt.RequireTyp {
 		typ, _ := t.Header["typ"].(string)
@@ -92,11 +103,15 @@ func (s *HMACSigner) Verify(ctx context.Context, token string, opt *VerifyOption
 		nowFn = o.Now
 	}
 
-	tok, claims, err := s.parseAndVerifyHS256(token, nowFn, o.Leeway)
+	tok, claims, err := s.parseAndVerifyHS256(token, nowFn, o)
 	if err != nil {
 		return nil, err
 	}
 
+	return buildVerifyResult(tok, claims, o)
+}
+
+func buildVerifyResult(tok *jwt.Token, claims jwt.MapClaims, o VerifyOptions) (*VerifyResult, error) {
 	if err := checkHeaderPolicy(tok, o); err != nil {
 		return nil, err
 	}
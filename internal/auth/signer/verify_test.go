@@ -110,6 +110,18 @@ func TestHMACSigner_Verify(t *testing.T) {
 			key:       secret,
 			wantErr:   jwt.ErrTokenExpired,
 		},
+		{
+			name: "expired allowed",
+			claims: jwt.MapClaims{
+				"exp": base.Add(-time.Hour).Unix(),
+			},
+			typ:       "JWT",
+			kidHeader: kid,
+			opt:       &VerifyOptions{AllowExpired: true},
+			now:       base,
+			key:       secret,
+			wantErr:   nil,
+		},
 		{
 			name: "expired with leeway ok",
 			claims: jwt.MapClaims{
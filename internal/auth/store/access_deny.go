@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+)
+
+func (r *Repo) Deny(ctx context.Context, rec *AccessDenylistRecord) error {
+	if rec == nil {
+		return ErrInvalidArgument
+	}
+	if err := validateRefreshID(rec.JTI); err != nil {
+		return err
+	}
+
+	if rec.RevokedAt.IsZero() {
+		rec.RevokedAt = r.now().UTC()
+	}
+	if rec.DeleteAt.IsZero() {
+		rec.DeleteAt = rec.ExpiresAt
+	}
+
+	_, err := r.docAD(rec.JTI).Set(ctx, rec)
+
+	return err
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_Deny(t *testing.T) {
+	requireEmulator(t)
+	t.Parallel()
+
+	t.Run("nil record -> ErrInvalidArgument", func(t *testing.T) {
+		t.Parallel()
+		r := newTestRepo(t)
+
+		err := r.Deny(context.Background(), nil)
+		require.ErrorIs(t, err, ErrInvalidArgument)
+	})
+
+	t.Run("empty JTI -> ErrInvalidID", func(t *testing.T) {
+		t.Parallel()
+		r := newTestRepo(t)
+
+		err := r.Deny(context.Background(), &AccessDenylistRecord{})
+		require.ErrorIs(t, err, ErrInvalidID)
+	})
+
+	t.Run("stores record and defaults DeleteAt to ExpiresAt", func(t *testing.T) {
+		t.Parallel()
+
+		fixed := time.Unix(1_900_000_000, 0).UTC()
+		r := newTestRepoWithNow(t, fixed)
+
+		jti := safeUserID(t, "jti-deny-")
+		ctx := context.Background()
+		t.Cleanup(func() { _, _ = r.docAD(jti).Delete(ctx) })
+
+		exp := fixed.Add(15 * time.Minute)
+		require.NoError(t, r.Deny(ctx, &AccessDenylistRecord{JTI: jti, UserID: "github:1", ExpiresAt: exp}))
+
+		snap, err := r.docAD(jti).Get(ctx)
+		require.NoError(t, err)
+
+		var got AccessDenylistRecord
+		require.NoError(t, snap.DataTo(&got))
+		require.Equal(t, jti, got.JTI)
+		require.True(t, got.RevokedAt.Equal(fixed))
+		require.True(t, got.DeleteAt.Equal(exp))
+	})
+}
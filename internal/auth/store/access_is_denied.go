@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (r *Repo) IsDenied(ctx context.Context, jti string) (bool, error) {
+	if err := validateRefreshID(jti); err != nil {
+		return false, err
+	}
+
+	_, err := r.docAD(jti).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
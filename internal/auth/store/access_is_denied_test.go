@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_IsDenied(t *testing.T) {
+	requireEmulator(t)
+	t.Parallel()
+
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	t.Run("empty JTI -> ErrInvalidID", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := r.IsDenied(ctx, "")
+		require.ErrorIs(t, err, ErrInvalidID)
+	})
+
+	t.Run("unknown JTI -> false", func(t *testing.T) {
+		t.Parallel()
+
+		denied, err := r.IsDenied(ctx, safeUserID(t, "jti-unknown-"))
+		require.NoError(t, err)
+		require.False(t, denied)
+	})
+
+	t.Run("denied JTI -> true", func(t *testing.T) {
+		t.Parallel()
+
+		jti := safeUserID(t, "jti-denied-")
+		t.Cleanup(func() { _, _ = r.docAD(jti).Delete(ctx) })
+
+		require.NoError(t, r.Deny(ctx, &AccessDenylistRecord{JTI: jti, ExpiresAt: time.Now().Add(time.Minute)}))
+
+		denied, err := r.IsDenied(ctx, jti)
+		require.NoError(t, err)
+		require.True(t, denied)
+	})
+}
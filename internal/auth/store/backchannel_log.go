@@ -0,0 +1,22 @@
+package store
+
+import (
+	"context"
+)
+
+func (r *Repo) LogDelivery(ctx context.Context, rec *BackchannelDeliveryRecord) error {
+	if rec == nil {
+		return ErrInvalidArgument
+	}
+	if err := validateRefreshID(rec.JTI); err != nil {
+		return err
+	}
+
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = r.now().UTC()
+	}
+
+	_, err := r.docBL(rec.JTI).Set(ctx, rec)
+
+	return err
+}
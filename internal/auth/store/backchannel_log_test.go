@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_LogDelivery(t *testing.T) {
+	requireEmulator(t)
+	t.Parallel()
+
+	t.Run("nil record -> ErrInvalidArgument", func(t *testing.T) {
+		t.Parallel()
+		r := newTestRepo(t)
+
+		err := r.LogDelivery(context.Background(), nil)
+		require.ErrorIs(t, err, ErrInvalidArgument)
+	})
+
+	t.Run("empty JTI -> ErrInvalidID", func(t *testing.T) {
+		t.Parallel()
+		r := newTestRepo(t)
+
+		err := r.LogDelivery(context.Background(), &BackchannelDeliveryRecord{})
+		require.ErrorIs(t, err, ErrInvalidID)
+	})
+
+	t.Run("stores record and defaults CreatedAt", func(t *testing.T) {
+		t.Parallel()
+
+		fixed := time.Unix(1_900_000_000, 0).UTC()
+		r := newTestRepoWithNow(t, fixed)
+
+		jti := safeUserID(t, "jti-bcl-")
+		ctx := context.Background()
+		t.Cleanup(func() { _, _ = r.docBL(jti).Delete(ctx) })
+
+		require.NoError(t, r.LogDelivery(ctx, &BackchannelDeliveryRecord{
+			JTI:        jti,
+			SessionID:  "sid-1",
+			ClientID:   "client-1",
+			URI:        "https://rp.example.com/logout",
+			Status:     "delivered",
+			Attempts:   2,
+			StatusCode: http.StatusOK,
+		}))
+
+		snap, err := r.docBL(jti).Get(ctx)
+		require.NoError(t, err)
+
+		var got BackchannelDeliveryRecord
+		require.NoError(t, snap.DataTo(&got))
+		require.Equal(t, "client-1", got.ClientID)
+		require.Equal(t, 2, got.Attempts)
+		require.True(t, got.CreatedAt.Equal(fixed))
+	})
+}
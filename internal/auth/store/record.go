@@ -7,12 +7,17 @@ type RefreshTokenRecord struct {
 	UserID    string `firestore:"user_id"`
 	DigestB64 string `firestore:"digest_b64"`
 	KeyID     string `firestore:"key_id"`
+	ClientID  string `firestore:"client_id"`
+	Scope     string `firestore:"scope"`
+	SessionID string `firestore:"session_id"`
 
-	FamilyID     string    `firestore:"family_id"`
-	ReplacedBy   string    `firestore:"replaced_by"`
-	RevokedAt    time.Time `firestore:"revoked_at"`
-	RevokeReason string    `firestore:"revoke_reason"`
+	FamilyID        string    `firestore:"family_id"`
+	FamilyCreatedAt time.Time `firestore:"family_created_at"`
+	ReplacedBy      string    `firestore:"replaced_by"`
+	RevokedAt       time.Time `firestore:"revoked_at"`
+	RevokeReason    string    `firestore:"revoke_reason"`
 
+	AuthTime   time.Time `firestore:"auth_time"`
 	CreatedAt  time.Time `firestore:"created_at"`
 	LastUsedAt time.Time `firestore:"last_used_at"`
 	ExpiresAt  time.Time `firestore:"expires_at"`
@@ -24,3 +29,26 @@ type AccessGenerationRecord struct {
 	Gen       int       `firestore:"gen"`
 	UpdatedAt time.Time `firestore:"updated_at"`
 }
+
+type AccessDenylistRecord struct {
+	JTI       string    `firestore:"jti"`
+	UserID    string    `firestore:"user_id"`
+	ClientID  string    `firestore:"client_id"`
+	RevokedAt time.Time `firestore:"revoked_at"`
+	ExpiresAt time.Time `firestore:"expires_at"`
+	DeleteAt  time.Time `firestore:"delete_at"`
+}
+
+type BackchannelDeliveryRecord struct {
+	JTI         string    `firestore:"jti"`
+	SessionID   string    `firestore:"session_id"`
+	UserID      string    `firestore:"user_id"`
+	ClientID    string    `firestore:"client_id"`
+	URI         string    `firestore:"uri"`
+	Status      string    `firestore:"status"`
+	Attempts    int       `firestore:"attempts"`
+	StatusCode  int       `firestore:"status_code"`
+	LastError   string    `firestore:"last_error"`
+	CreatedAt   time.Time `firestore:"created_at"`
+	CompletedAt time.Time `firestore:"completed_at"`
+}
@@ -43,7 +43,7 @@ func validateReplaceArgs(oldID string, newRec *RefreshTokenRecord) error {
 }
 
 func checkReplaceAllowed(old *RefreshTokenRecord, newRec *RefreshTokenRecord, t time.Time) error {
-	if !isActive(old, t) {
+	if !IsActive(old, t) {
 		return ErrConflict
 	}
 	if old.UserID != newRec.UserID {
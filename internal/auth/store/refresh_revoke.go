@@ -19,7 +19,7 @@ func (r *Repo) Revoke(ctx context.Context, id, reason string, t time.Time) error
 		if err := snap.DataTo(&rec); err != nil {
 			return err
 		}
-		if !isActive(&rec, t) {
+		if !IsActive(&rec, t) {
 			return ErrAlreadyRevoked
 		}
 
@@ -17,7 +17,11 @@ func (r *Repo) RevokeFamily(ctx context.Context, familyID, reason string, t time
 		t = r.now()
 	}
 
-	iter := r.fs.Collection(colRefreshTokens).Where("family_id", "==", familyID).Documents(ctx)
+	return r.revokeWhere(ctx, "family_id", familyID, reason, t)
+}
+
+func (r *Repo) revokeWhere(ctx context.Context, field, value, reason string, t time.Time) (int, error) {
+	iter := r.fs.Collection(colRefreshTokens).Where(field, "==", value).Documents(ctx)
 	defer iter.Stop()
 
 	bw := r.fs.BulkWriter(ctx)
@@ -0,0 +1,18 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (r *Repo) RevokeUser(ctx context.Context, userID, reason string, t time.Time) (int, error) {
+	if userID == "" || containsSlash(userID) {
+		return 0, fmt.Errorf("invalid userID: %w", ErrInvalidUserID)
+	}
+	if t.IsZero() {
+		t = r.now()
+	}
+
+	return r.revokeWhere(ctx, "user_id", userID, reason, t)
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_RevokeUser(t *testing.T) {
+	requireEmulator(t)
+	t.Parallel()
+
+	fixed := time.Unix(1_800_000_000, 0).UTC()
+	repo := newTestRepoWithNow(t, fixed)
+	ctx := context.Background()
+
+	t.Run("revokes every non-revoked token of the user", func(t *testing.T) {
+		t.Parallel()
+
+		const user = "github:55555555-5555-5555-5555-555555555555"
+
+		u1 := makeActiveRec("rt-u1", user, fixed)
+		u1.FamilyID = "fam-u1"
+
+		u2 := makeActiveRec("rt-u2", user, fixed)
+		u2.FamilyID = "fam-u2"
+
+		other := makeActiveRec("rt-u-other", "github:66666666-6666-6666-6666-666666666666", fixed)
+
+		for _, r := range []*RefreshTokenRecord{u1, u2, other} {
+			seedRefreshDoc(t, repo, r)
+			id := r.RefreshID
+			t.Cleanup(func() { _, _ = repo.docRT(id).Delete(ctx) })
+		}
+
+		n, err := repo.RevokeUser(ctx, user, "logout", fixed)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+
+		require.Equal(t, "logout", getRefreshDoc(t, repo, "rt-u1").RevokeReason)
+		require.Equal(t, "logout", getRefreshDoc(t, repo, "rt-u2").RevokeReason)
+		require.True(t, getRefreshDoc(t, repo, "rt-u-other").RevokedAt.IsZero())
+	})
+
+	t.Run("invalid userID", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := repo.RevokeUser(ctx, "", "logout", fixed)
+		require.ErrorIs(t, err, ErrInvalidUserID)
+	})
+}
@@ -2,7 +2,7 @@ package store
 
 import "time"
 
-func isActive(rec *RefreshTokenRecord, now time.Time) bool {
+func IsActive(rec *RefreshTokenRecord, now time.Time) bool {
 	if rec == nil {
 		return false
 	}
@@ -59,7 +59,7 @@ func TestIsActive(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			got := isActive(tt.rec, now)
+			got := IsActive(tt.rec, now)
 			require.Equal(t, tt.want, got)
 		})
 	}
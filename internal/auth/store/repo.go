@@ -10,15 +10,18 @@ import (
 const (
 	colRefreshTokens     = "refresh_tokens"
 	colAccessGenerations = "access_generations"
+	colAccessDenylist    = "access_denylist"
+	colBackchannelLog    = "backchannel_deliveries"
 )
 
 type RefreshRepo interface {
 	Create(ctx context.Context, rec *RefreshTokenRecord) error
 	GetByID(ctx context.Context, refreshID string) (*RefreshTokenRecord, error)
-	MarkUsed(ctx context.Context, refreshID string, t time.Time) error
+	MarkUsed(ctx context.Context, refreshID string) error
 	Revoke(ctx context.Context, refreshID, reason string, t time.Time) error
 	Replace(ctx context.Context, oldID string, newRec *RefreshTokenRecord, t time.Time) error
 	RevokeFamily(ctx context.Context, familyID, reason string, t time.Time) (int, error)
+	RevokeUser(ctx context.Context, userID, reason string, t time.Time) (int, error)
 	DeleteExpired(ctx context.Context, until time.Time) (int, error)
 }
 
@@ -28,6 +31,22 @@ type AccessGenRepo interface {
 	Bump(ctx context.Context, userID string, t time.Time) (newGen int, err error)
 }
 
+type AccessDenylist interface {
+	Deny(ctx context.Context, rec *AccessDenylistRecord) error
+	IsDenied(ctx context.Context, jti string) (bool, error)
+}
+
+type BackchannelDeliveryLog interface {
+	LogDelivery(ctx context.Context, rec *BackchannelDeliveryRecord) error
+}
+
+var (
+	_ RefreshRepo            = (*Repo)(nil)
+	_ AccessGenRepo          = (*Repo)(nil)
+	_ AccessDenylist         = (*Repo)(nil)
+	_ BackchannelDeliveryLog = (*Repo)(nil)
+)
+
 type Repo struct {
 	fs  *firestore.Client
 	now func() time.Time
@@ -44,3 +63,11 @@ func (r *Repo) docRT(id string) *firestore.DocumentRef {
 func (r *Repo) docAG(userID string) *firestore.DocumentRef {
 	return r.fs.Collection(colAccessGenerations).Doc(userID)
 }
+
+func (r *Repo) docAD(jti string) *firestore.DocumentRef {
+	return r.fs.Collection(colAccessDenylist).Doc(jti)
+}
+
+func (r *Repo) docBL(jti string) *firestore.DocumentRef {
+	return r.fs.Collection(colBackchannelLog).Doc(jti)
+}
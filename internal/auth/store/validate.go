@@ -19,29 +19,33 @@ func validateRefreshID(id string) error {
 	return nil
 }
 
-var allowedProviders = map[string]struct{}{
-	"github": {},
-	"google": {},
-}
-
+// validateUserID accepts the subjects the users service issues: an internal
+// uuid, or the '<provider>:<subject>' id kept by users created before linked
+// identities. Upstream subjects are opaque (GitHub numeric ids, Firebase uids),
+// so only their shape is checked.
 func validateUserID(userID string) error {
 	userID = strings.TrimSpace(userID)
 	if userID == "" {
 		return fmt.Errorf("%w: empty", ErrInvalidUserID)
 	}
+	if containsSlash(userID) {
+		return fmt.Errorf("%w: must not contain '/'", ErrInvalidUserID)
+	}
 
-	provider, raw, ok := strings.Cut(userID, ":")
+	provider, subject, ok := strings.Cut(userID, ":")
 	if !ok {
-		return fmt.Errorf("%w: want '<provider>:<uuid>'", ErrInvalidUserID)
+		// internal user subject
+		if _, err := uuid.Parse(userID); err != nil {
+			return fmt.Errorf("%w: want '<provider>:<subject>' or '<uuid>'", ErrInvalidUserID)
+		}
+		return nil
 	}
-	provider = strings.ToLower(strings.TrimSpace(provider))
 
-	if _, ok := allowedProviders[provider]; !ok {
-		return fmt.Errorf("%w: unknown provider %q", ErrInvalidUserID, provider)
+	if strings.TrimSpace(provider) == "" {
+		return fmt.Errorf("%w: missing provider", ErrInvalidUserID)
 	}
-
-	if _, err := uuid.Parse(strings.TrimSpace(raw)); err != nil {
-		return fmt.Errorf("%w: %v", ErrInvalidUserID, err)
+	if strings.TrimSpace(subject) == "" {
+		return fmt.Errorf("%w: missing subject", ErrInvalidUserID)
 	}
 
 	return nil
@@ -4,8 +4,8 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -41,23 +41,25 @@ func TestValidateRefreshID(t *testing.T) {
 func TestValidateUserID(t *testing.T) {
 	t.Parallel()
 
-	okUUID := uuid.New().String()
+	okUUID := "0b6f3f52-3d8c-4a8e-9b0e-6f1d2c3b4a59"
 
 	cases := []struct {
 		name string
 		in   string
 		ok   bool
 	}{
-		{"ok-github", "github:" + okUUID, true},
-		{"ok-google", "google:" + okUUID, true},
-		{"ok-trim-and-case", "  GitHub :  " + okUUID + "  ", true},
+		{"ok-internal", okUUID, true},
+		{"ok-internal-trim", "  " + okUUID + "  ", true},
+		{"ok-github", "github:12345", true},
+		{"ok-google", "google:kD3pQ9xYz0aBcDeFgHiJkLmNoP12", true},
+		{"ok-trim", "  github:12345  ", true},
 
 		{"ng-empty", "   ", false},
-		{"ng-no-colon", "github" + okUUID, false},
-		{"ng-unknown-provider", "x:" + okUUID, false},
-		{"ng-bad-uuid", "github:not-a-uuid", false},
-		{"ng-missing-uuid", "github:", false},
-		{"ng-missing-provider", ":" + okUUID, false},
+		{"ng-no-colon", "github12345", false},
+		{"ng-bare-not-uuid", "octocat", false},
+		{"ng-slash", "github:123/45", false},
+		{"ng-missing-subject", "github:", false},
+		{"ng-missing-provider", ":12345", false},
 	}
 
 	for _, tc := range cases {
@@ -71,9 +73,23 @@ func TestValidateUserID(t *testing.T) {
 				require.Error(t, err)
 				require.ErrorIs(t, err, ErrInvalidUserID)
 				if strings.Contains(tc.name, "no-colon") {
-					require.Contains(t, err.Error(), "want '<provider>:<uuid>'")
+					require.Contains(t, err.Error(), "want '<provider>:<subject>'")
 				}
 			}
 		})
 	}
 }
+
+// Ids issued at login must pass the same checks Repo.Create applies.
+func TestValidateForCreate_IssuedUserIDs(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	for _, userID := range []string{
+		"0b6f3f52-3d8c-4a8e-9b0e-6f1d2c3b4a59",
+		"github:12345",
+		"google:kD3pQ9xYz0aBcDeFgHiJkLmNoP12",
+	} {
+		require.NoError(t, validateForCreate(makeRec("rt-1", userID, "fam-1", now)), userID)
+	}
+}
@@ -2,9 +2,21 @@ package authcode
 
 import "time"
 
+type PKCE struct {
+	Challenge string
+	Method    string
+}
+
 type ProxyCode struct {
-	Code      string
-	UserID    string
-	ClientID  string
-	ExpiresAt time.Time
+	Code                string
+	UserID              string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	SessionID           string
+	AuthTime            time.Time
+	ExpiresAt           time.Time
 }
@@ -10,27 +10,41 @@ import (
 	"github.com/vinylhousegarage/idpproxy/internal/authcode/store"
 )
 
+const DefaultTTL = 60 * time.Second
+
 type Service struct {
 	store store.Store
 }
 
+func NewService(s store.Store) *Service {
+	return &Service{store: s}
+}
+
 func (s *Service) Issue(
 	ctx context.Context,
 	userID string,
 	clientID string,
+	pkce authcode.PKCE,
 ) (string, error) {
+	return s.IssueCode(ctx, authcode.ProxyCode{
+		UserID:              userID,
+		ClientID:            clientID,
+		CodeChallenge:       pkce.Challenge,
+		CodeChallengeMethod: pkce.Method,
+	})
+}
 
+func (s *Service) IssueCode(
+	ctx context.Context,
+	pc authcode.ProxyCode,
+) (string, error) {
 	proxyCode, err := generateProxyCode()
 	if err != nil {
 		return "", err
 	}
 
-	pc := authcode.ProxyCode{
-		Code:      proxyCode,
-		UserID:    userID,
-		ClientID:  clientID,
-		ExpiresAt: time.Now().Add(60 * time.Second),
-	}
+	pc.Code = proxyCode
+	pc.ExpiresAt = time.Now().Add(DefaultTTL)
 
 	if err := s.store.Save(ctx, pc); err != nil {
 		return "", err
@@ -43,7 +57,7 @@ func (s *Service) Consume(
 	ctx context.Context,
 	proxyCode string,
 	clientID string,
-) (string, error) {
+) (*authcode.ProxyCode, error) {
 	return s.store.Consume(ctx, proxyCode, clientID)
 }
 
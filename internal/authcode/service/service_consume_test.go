@@ -27,11 +27,22 @@ func (f *fakeConsumeStore) Consume(
 	ctx context.Context,
 	proxyCode string,
 	clientID string,
-) (string, error) {
+) (*authcode.ProxyCode, error) {
 	f.called = true
 	f.gotCode = proxyCode
 	f.gotCID = clientID
-	return f.retUID, f.retError
+	if f.retError != nil {
+		return nil, f.retError
+	}
+	return &authcode.ProxyCode{Code: proxyCode, UserID: f.retUID, ClientID: clientID}, nil
+}
+
+func (f *fakeConsumeStore) BindFamily(
+	ctx context.Context,
+	proxyCode string,
+	familyID string,
+) error {
+	panic("not used")
 }
 
 func TestService_Consume(t *testing.T) {
@@ -48,12 +59,12 @@ func TestService_Consume(t *testing.T) {
 		}
 		svc := &Service{store: store}
 
-		uid, err := svc.Consume(ctx, "code-abc", "client-xyz")
+		pc, err := svc.Consume(ctx, "code-abc", "client-xyz")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		if uid != "user-123" {
-			t.Fatalf("unexpected uid: got=%s", uid)
+		if pc.UserID != "user-123" {
+			t.Fatalf("unexpected uid: got=%s", pc.UserID)
 		}
 
 		if !store.called {
@@ -76,15 +87,15 @@ func TestService_Consume(t *testing.T) {
 		}
 		svc := &Service{store: store}
 
-		uid, err := svc.Consume(ctx, "bad-code", "client-xyz")
+		pc, err := svc.Consume(ctx, "bad-code", "client-xyz")
 		if err == nil {
 			t.Fatalf("expected error, got nil")
 		}
 		if err != wantErr {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		if uid != "" {
-			t.Fatalf("unexpected uid: got=%s", uid)
+		if pc != nil {
+			t.Fatalf("unexpected proxy code: got=%+v", pc)
 		}
 	})
 }
@@ -25,7 +25,15 @@ func (f *fakeIssueStore) Consume(
 	ctx context.Context,
 	proxyCode string,
 	clientID string,
-) (string, error) {
+) (*authcode.ProxyCode, error) {
+	panic("not used")
+}
+
+func (f *fakeIssueStore) BindFamily(
+	ctx context.Context,
+	proxyCode string,
+	familyID string,
+) error {
 	panic("not used")
 }
 
@@ -40,7 +48,7 @@ func TestService_Issue(t *testing.T) {
 			store: fs,
 		}
 
-		proxyCode, err := svc.Issue(context.Background(), "user-1", "client-1")
+		proxyCode, err := svc.Issue(context.Background(), "user-1", "client-1", authcode.PKCE{Challenge: "challenge", Method: "S256"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -61,6 +69,10 @@ func TestService_Issue(t *testing.T) {
 			t.Errorf("ClientID mismatch: got=%s", fs.saved.ClientID)
 		}
 
+		if fs.saved.CodeChallenge != "challenge" || fs.saved.CodeChallengeMethod != "S256" {
+			t.Errorf("PKCE mismatch: got=%s/%s", fs.saved.CodeChallenge, fs.saved.CodeChallengeMethod)
+		}
+
 		if time.Until(fs.saved.ExpiresAt) <= 0 {
 			t.Errorf("ExpiresAt should be in the future: got=%v", fs.saved.ExpiresAt)
 		}
@@ -78,7 +90,7 @@ func TestService_Issue(t *testing.T) {
 			store: fs,
 		}
 
-		proxyCode, err := svc.Issue(context.Background(), "user-1", "client-1")
+		proxyCode, err := svc.Issue(context.Background(), "user-1", "client-1", authcode.PKCE{})
 
 		if err != expectedErr {
 			t.Fatalf("expected error %v, got %v", expectedErr, err)
@@ -89,3 +101,37 @@ func TestService_Issue(t *testing.T) {
 		}
 	})
 }
+
+func TestService_IssueCode(t *testing.T) {
+	t.Parallel()
+
+	fs := &fakeIssueStore{}
+	svc := NewService(fs)
+
+	proxyCode, err := svc.IssueCode(context.Background(), authcode.ProxyCode{
+		Code:     "ignored",
+		UserID:   "user-1",
+		ClientID: "client-1",
+		Scope:    "openid email",
+		Nonce:    "n-1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if proxyCode == "" || proxyCode == "ignored" {
+		t.Fatalf("expected generated proxycode, got=%q", proxyCode)
+	}
+
+	if fs.saved.Code != proxyCode {
+		t.Errorf("saved proxycode mismatch: got=%s want=%s", fs.saved.Code, proxyCode)
+	}
+
+	if fs.saved.Scope != "openid email" || fs.saved.Nonce != "n-1" {
+		t.Errorf("scope/nonce mismatch: got=%s/%s", fs.saved.Scope, fs.saved.Nonce)
+	}
+
+	if time.Until(fs.saved.ExpiresAt) <= 0 {
+		t.Errorf("ExpiresAt should be in the future: got=%v", fs.saved.ExpiresAt)
+	}
+}
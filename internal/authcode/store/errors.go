@@ -1,9 +1,26 @@
 package store
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
-	ErrClientMismatch = errors.New("proxycode client mismatch")
-	ErrExpired        = errors.New("proxycode expired")
-	ErrNotFound       = errors.New("proxycode not found")
+	ErrAlreadyConsumed = errors.New("proxycode already consumed")
+	ErrClientMismatch  = errors.New("proxycode client mismatch")
+	ErrExpired         = errors.New("proxycode expired")
+	ErrNotFound        = errors.New("proxycode not found")
+	ErrReplayed        = errors.New("proxycode replayed before family was bound")
 )
+
+// ReplayError is returned by Consume when the code has already been redeemed.
+type ReplayError struct {
+	UserID     string
+	ClientID   string
+	FamilyID   string
+	ConsumedAt time.Time
+}
+
+func (e *ReplayError) Error() string { return ErrAlreadyConsumed.Error() }
+
+func (e *ReplayError) Unwrap() error { return ErrAlreadyConsumed }
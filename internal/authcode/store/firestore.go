@@ -0,0 +1,192 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vinylhousegarage/idpproxy/internal/authcode"
+)
+
+const colProxyCodes = "proxy_codes"
+
+type proxyCodeRecord struct {
+	UserID              string    `firestore:"user_id"`
+	ClientID            string    `firestore:"client_id"`
+	RedirectURI         string    `firestore:"redirect_uri"`
+	Scope               string    `firestore:"scope"`
+	Nonce               string    `firestore:"nonce"`
+	CodeChallenge       string    `firestore:"code_challenge"`
+	CodeChallengeMethod string    `firestore:"code_challenge_method"`
+	SessionID           string    `firestore:"session_id"`
+	AuthTime            time.Time `firestore:"auth_time"`
+	FamilyID            string    `firestore:"family_id"`
+	CreatedAt           time.Time `firestore:"created_at"`
+	ExpiresAt           time.Time `firestore:"expires_at"`
+	ConsumedAt          time.Time `firestore:"consumed_at"`
+	ReplayedAt          time.Time `firestore:"replayed_at"`
+	DeleteAt            time.Time `firestore:"delete_at"`
+}
+
+type FirestoreStore struct {
+	fs  *firestore.Client
+	now func() time.Time
+}
+
+var _ Store = (*FirestoreStore)(nil)
+
+func NewFirestoreStore(fs *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{fs: fs, now: time.Now}
+}
+
+// Documents are keyed by a digest so the raw code is never persisted.
+func (s *FirestoreStore) doc(code string) *firestore.DocumentRef {
+	sum := sha256.Sum256([]byte(code))
+	return s.fs.Collection(colProxyCodes).Doc(base64.RawURLEncoding.EncodeToString(sum[:]))
+}
+
+func (s *FirestoreStore) Save(ctx context.Context, proxyCode authcode.ProxyCode) error {
+	rec := proxyCodeRecord{
+		UserID:              proxyCode.UserID,
+		ClientID:            proxyCode.ClientID,
+		RedirectURI:         proxyCode.RedirectURI,
+		Scope:               proxyCode.Scope,
+		Nonce:               proxyCode.Nonce,
+		CodeChallenge:       proxyCode.CodeChallenge,
+		CodeChallengeMethod: proxyCode.CodeChallengeMethod,
+		SessionID:           proxyCode.SessionID,
+		AuthTime:            proxyCode.AuthTime,
+		CreatedAt:           s.now(),
+		ExpiresAt:           proxyCode.ExpiresAt,
+		DeleteAt:            proxyCode.ExpiresAt,
+	}
+
+	_, err := s.doc(proxyCode.Code).Create(ctx, rec)
+	return err
+}
+
+func (s *FirestoreStore) Consume(ctx context.Context, proxyCodeValue, clientID string) (*authcode.ProxyCode, error) {
+	if proxyCodeValue == "" {
+		return nil, ErrNotFound
+	}
+
+	ref := s.doc(proxyCodeValue)
+
+	var (
+		consumed *authcode.ProxyCode
+		replay   *ReplayError
+	)
+	err := s.fs.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		consumed, replay = nil, nil
+
+		snap, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var rec proxyCodeRecord
+		if err := snap.DataTo(&rec); err != nil {
+			return err
+		}
+
+		if !rec.ConsumedAt.IsZero() {
+			replay = &ReplayError{
+				UserID:     rec.UserID,
+				ClientID:   rec.ClientID,
+				FamilyID:   rec.FamilyID,
+				ConsumedAt: rec.ConsumedAt,
+			}
+			// committed so a redemption still binding its family sees the replay
+			return tx.Update(ref, []firestore.Update{
+				{Path: "replayed_at", Value: s.now()},
+			})
+		}
+
+		if rec.ClientID != clientID {
+			return ErrClientMismatch
+		}
+
+		now := s.now()
+		if !now.Before(rec.ExpiresAt) {
+			return tx.Delete(ref)
+		}
+
+		if err := tx.Update(ref, []firestore.Update{
+			{Path: "consumed_at", Value: now},
+			{Path: "delete_at", Value: now.Add(TombstoneRetention)},
+		}); err != nil {
+			return err
+		}
+
+		consumed = &authcode.ProxyCode{
+			Code:                proxyCodeValue,
+			UserID:              rec.UserID,
+			ClientID:            rec.ClientID,
+			RedirectURI:         rec.RedirectURI,
+			Scope:               rec.Scope,
+			Nonce:               rec.Nonce,
+			CodeChallenge:       rec.CodeChallenge,
+			CodeChallengeMethod: rec.CodeChallengeMethod,
+			SessionID:           rec.SessionID,
+			AuthTime:            rec.AuthTime,
+			ExpiresAt:           rec.ExpiresAt,
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if replay != nil {
+		return nil, replay
+	}
+
+	if consumed == nil {
+		return nil, ErrExpired
+	}
+
+	return consumed, nil
+}
+
+func (s *FirestoreStore) BindFamily(ctx context.Context, proxyCodeValue, familyID string) error {
+	ref := s.doc(proxyCodeValue)
+
+	var replayed bool
+	err := s.fs.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var rec proxyCodeRecord
+		if err := snap.DataTo(&rec); err != nil {
+			return err
+		}
+		replayed = !rec.ReplayedAt.IsZero()
+
+		return tx.Update(ref, []firestore.Update{
+			{Path: "family_id", Value: familyID},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if replayed {
+		return ErrReplayed
+	}
+
+	return nil
+}
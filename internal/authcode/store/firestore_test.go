@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+
+	"github.com/vinylhousegarage/idpproxy/internal/authcode"
+)
+
+func newTestFirestoreStore(t *testing.T) *FirestoreStore {
+	t.Helper()
+
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("FIRESTORE_EMULATOR_HOST is not set; skipping Firestore emulator tests")
+	}
+
+	projectID := os.Getenv("TEST_FIRESTORE_PROJECT")
+	require.NotEmpty(t, projectID, "TEST_FIRESTORE_PROJECT is not set")
+
+	fs, err := firestore.NewClient(context.Background(), projectID, option.WithoutAuthentication())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fs.Close() })
+
+	return NewFirestoreStore(fs)
+}
+
+func uniqueCode(t *testing.T) string {
+	return t.Name() + "-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+func TestFirestoreStore_Consume(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("round trips and is single use", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestFirestoreStore(t)
+		code := uniqueCode(t)
+
+		require.NoError(t, s.Save(ctx, authcode.ProxyCode{
+			Code:                code,
+			UserID:              "user-1",
+			ClientID:            "client-1",
+			Scope:               "openid email",
+			Nonce:               "n-1",
+			CodeChallenge:       "challenge",
+			CodeChallengeMethod: "S256",
+			ExpiresAt:           time.Now().Add(time.Minute),
+		}))
+
+		_, err := s.Consume(ctx, code, "client-2")
+		require.ErrorIs(t, err, ErrClientMismatch)
+
+		got, err := s.Consume(ctx, code, "client-1")
+		require.NoError(t, err)
+		require.Equal(t, "user-1", got.UserID)
+		require.Equal(t, "openid email", got.Scope)
+		require.Equal(t, "n-1", got.Nonce)
+		require.Equal(t, "challenge", got.CodeChallenge)
+		require.Equal(t, "S256", got.CodeChallengeMethod)
+
+		require.NoError(t, s.BindFamily(ctx, code, "fam-1"))
+
+		_, err = s.Consume(ctx, code, "client-1")
+		var replay *ReplayError
+		require.ErrorAs(t, err, &replay)
+		require.Equal(t, "fam-1", replay.FamilyID)
+		require.Equal(t, "user-1", replay.UserID)
+	})
+
+	t.Run("expired code is deleted", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestFirestoreStore(t)
+		code := uniqueCode(t)
+
+		require.NoError(t, s.Save(ctx, authcode.ProxyCode{
+			Code:      code,
+			UserID:    "user-1",
+			ClientID:  "client-1",
+			ExpiresAt: time.Now().Add(-time.Minute),
+		}))
+
+		_, err := s.Consume(ctx, code, "client-1")
+		require.ErrorIs(t, err, ErrExpired)
+
+		_, err = s.Consume(ctx, code, "client-1")
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("concurrent consumes succeed at most once", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestFirestoreStore(t)
+		code := uniqueCode(t)
+
+		require.NoError(t, s.Save(ctx, authcode.ProxyCode{
+			Code:      code,
+			UserID:    "user-1",
+			ClientID:  "client-1",
+			ExpiresAt: time.Now().Add(time.Minute),
+		}))
+
+		const n = 8
+		var (
+			wg        sync.WaitGroup
+			mu        sync.Mutex
+			successes int
+		)
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				_, err := s.Consume(ctx, code, "client-1")
+				if err == nil {
+					mu.Lock()
+					successes++
+					mu.Unlock()
+				} else if !errors.Is(err, ErrAlreadyConsumed) {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, 1, successes)
+	})
+
+	t.Run("save rejects duplicate code", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestFirestoreStore(t)
+		pc := authcode.ProxyCode{Code: uniqueCode(t), ClientID: "client-1", ExpiresAt: time.Now().Add(time.Minute)}
+
+		require.NoError(t, s.Save(ctx, pc))
+		require.Error(t, s.Save(ctx, pc))
+	})
+}
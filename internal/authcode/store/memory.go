@@ -8,14 +8,27 @@ import (
 	"github.com/vinylhousegarage/idpproxy/internal/authcode"
 )
 
+type tombstone struct {
+	userID     string
+	clientID   string
+	familyID   string
+	replayed   bool
+	consumedAt time.Time
+	deleteAt   time.Time
+}
+
 type MemoryStore struct {
 	mu         sync.Mutex
 	proxyCodes map[string]authcode.ProxyCode
+	tombstones map[string]tombstone
+	now        func() time.Time
 }
 
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
 		proxyCodes: make(map[string]authcode.ProxyCode),
+		tombstones: make(map[string]tombstone),
+		now:        time.Now,
 	}
 }
 
@@ -27,26 +40,74 @@ func (s *MemoryStore) Save(ctx context.Context, proxyCode authcode.ProxyCode) er
 	return nil
 }
 
-func (s *MemoryStore) Consume(ctx context.Context, proxyCodeValue, clientID string) (string, error) {
+func (s *MemoryStore) Consume(ctx context.Context, proxyCodeValue, clientID string) (*authcode.ProxyCode, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	now := s.now()
+	s.purgeTombstones(now)
+
+	if ts, ok := s.tombstones[proxyCodeValue]; ok {
+		ts.replayed = true
+		s.tombstones[proxyCodeValue] = ts
+
+		return nil, &ReplayError{
+			UserID:     ts.userID,
+			ClientID:   ts.clientID,
+			FamilyID:   ts.familyID,
+			ConsumedAt: ts.consumedAt,
+		}
+	}
+
 	pc, ok := s.proxyCodes[proxyCodeValue]
 	if !ok {
-		return "", ErrNotFound
+		return nil, ErrNotFound
 	}
 
 	if pc.ClientID != clientID {
-		return "", ErrClientMismatch
+		return nil, ErrClientMismatch
 	}
 
-	if time.Now().After(pc.ExpiresAt) {
+	if now.After(pc.ExpiresAt) {
 		delete(s.proxyCodes, proxyCodeValue)
 
-		return "", ErrExpired
+		return nil, ErrExpired
 	}
 
 	delete(s.proxyCodes, proxyCodeValue)
+	s.tombstones[proxyCodeValue] = tombstone{
+		userID:     pc.UserID,
+		clientID:   pc.ClientID,
+		consumedAt: now,
+		deleteAt:   now.Add(TombstoneRetention),
+	}
+
+	return &pc, nil
+}
+
+func (s *MemoryStore) BindFamily(ctx context.Context, proxyCodeValue, familyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts, ok := s.tombstones[proxyCodeValue]
+	if !ok {
+		return ErrNotFound
+	}
+
+	ts.familyID = familyID
+	s.tombstones[proxyCodeValue] = ts
 
-	return pc.UserID, nil
+	if ts.replayed {
+		return ErrReplayed
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) purgeTombstones(now time.Time) {
+	for code, ts := range s.tombstones {
+		if !now.Before(ts.deleteAt) {
+			delete(s.tombstones, code)
+		}
+	}
 }
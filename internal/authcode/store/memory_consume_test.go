@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -66,12 +67,12 @@ func TestMemoryStore_Consume(t *testing.T) {
 			t.Fatalf("expected ErrClientMismatch, got %v", err)
 		}
 
-		uid, err := s.Consume(ctx, "code-client", "client-1")
+		got, err := s.Consume(ctx, "code-client", "client-1")
 		if err != nil {
 			t.Fatalf("expected success after mismatch, got %v", err)
 		}
-		if uid != "user-1" {
-			t.Fatalf("unexpected user id: %s", uid)
+		if got.UserID != "user-1" {
+			t.Fatalf("unexpected user id: %s", got.UserID)
 		}
 	})
 
@@ -88,17 +89,111 @@ func TestMemoryStore_Consume(t *testing.T) {
 
 		_ = s.Save(ctx, pc)
 
-		uid, err := s.Consume(ctx, "code-ok", "client-1")
+		got, err := s.Consume(ctx, "code-ok", "client-1")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		if uid != "user-1" {
-			t.Fatalf("unexpected user id: %s", uid)
+		if got.UserID != "user-1" {
+			t.Fatalf("unexpected user id: %s", got.UserID)
 		}
 
 		_, err = s.Consume(ctx, "code-ok", "client-1")
+		if !errors.Is(err, ErrAlreadyConsumed) {
+			t.Fatalf("expected ErrAlreadyConsumed after consume, got %v", err)
+		}
+	})
+
+	t.Run("returns ReplayError with bound family on second consume", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewMemoryStore()
+		pc := authcode.ProxyCode{
+			Code:      "code-replay",
+			UserID:    "user-1",
+			ClientID:  "client-1",
+			ExpiresAt: time.Now().Add(5 * time.Minute),
+		}
+
+		_ = s.Save(ctx, pc)
+
+		if _, err := s.Consume(ctx, "code-replay", "client-1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := s.BindFamily(ctx, "code-replay", "fam-1"); err != nil {
+			t.Fatalf("unexpected bind error: %v", err)
+		}
+
+		_, err := s.Consume(ctx, "code-replay", "client-2")
+
+		var replay *ReplayError
+		if !errors.As(err, &replay) {
+			t.Fatalf("expected ReplayError, got %v", err)
+		}
+		if replay.FamilyID != "fam-1" || replay.UserID != "user-1" || replay.ClientID != "client-1" {
+			t.Fatalf("unexpected replay details: %+v", replay)
+		}
+	})
+
+	t.Run("forgets tombstones after retention", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewMemoryStore()
+		now := time.Now()
+		s.now = func() time.Time { return now }
+
+		_ = s.Save(ctx, authcode.ProxyCode{
+			Code:      "code-old",
+			ClientID:  "client-1",
+			ExpiresAt: now.Add(time.Minute),
+		})
+
+		if _, err := s.Consume(ctx, "code-old", "client-1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s.now = func() time.Time { return now.Add(TombstoneRetention) }
+
+		_, err := s.Consume(ctx, "code-old", "client-1")
 		if err != ErrNotFound {
-			t.Fatalf("expected ErrNotFound after consume, got %v", err)
+			t.Fatalf("expected ErrNotFound after retention, got %v", err)
+		}
+	})
+
+	t.Run("BindFamily reports a replay that happened before binding", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewMemoryStore()
+		_ = s.Save(ctx, authcode.ProxyCode{
+			Code:      "code-race",
+			UserID:    "user-1",
+			ClientID:  "client-1",
+			ExpiresAt: time.Now().Add(time.Minute),
+		})
+
+		if _, err := s.Consume(ctx, "code-race", "client-1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var replay *ReplayError
+		if _, err := s.Consume(ctx, "code-race", "client-1"); !errors.As(err, &replay) || replay.FamilyID != "" {
+			t.Fatalf("expected unbound ReplayError, got %v", err)
+		}
+
+		if err := s.BindFamily(ctx, "code-race", "fam-1"); err != ErrReplayed {
+			t.Fatalf("expected ErrReplayed, got %v", err)
+		}
+
+		_, err := s.Consume(ctx, "code-race", "client-1")
+		if !errors.As(err, &replay) || replay.FamilyID != "fam-1" {
+			t.Fatalf("expected family to stay bound, got %v", err)
+		}
+	})
+
+	t.Run("BindFamily returns ErrNotFound for unconsumed code", func(t *testing.T) {
+		t.Parallel()
+
+		if err := NewMemoryStore().BindFamily(ctx, "missing", "fam-1"); err != ErrNotFound {
+			t.Fatalf("expected ErrNotFound, got %v", err)
 		}
 	})
 }
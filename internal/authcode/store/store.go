@@ -2,11 +2,19 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/vinylhousegarage/idpproxy/internal/authcode"
 )
 
+// TombstoneRetention is how long a consumed code is remembered for replay detection.
+const TombstoneRetention = 24 * time.Hour
+
 type Store interface {
 	Save(ctx context.Context, proxyCode authcode.ProxyCode) error
-	Consume(ctx context.Context, proxyCodeValue, clientID string) (string, error)
+	Consume(ctx context.Context, proxyCodeValue, clientID string) (*authcode.ProxyCode, error)
+	// BindFamily records the refresh family minted from a consumed code. It
+	// returns ErrReplayed if the code was presented again before the family
+	// was bound, since that replay had nothing to revoke.
+	BindFamily(ctx context.Context, proxyCodeValue, familyID string) error
 }
@@ -0,0 +1,18 @@
+package authorize
+
+import "time"
+
+type Request struct {
+	ID                  string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	Nonce               string
+	Prompt              string
+	MaxAge              *time.Duration
+	IDPHint             string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	ExpiresAt           time.Time
+}
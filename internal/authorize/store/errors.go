@@ -0,0 +1,8 @@
+package store
+
+import "errors"
+
+var (
+	ErrExpired  = errors.New("authorization request expired")
+	ErrNotFound = errors.New("authorization request not found")
+)
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vinylhousegarage/idpproxy/internal/authorize"
+)
+
+const colAuthorizeRequests = "authorize_requests"
+
+type requestRecord struct {
+	ClientID            string         `firestore:"client_id"`
+	RedirectURI         string         `firestore:"redirect_uri"`
+	Scope               string         `firestore:"scope"`
+	State               string         `firestore:"state"`
+	Nonce               string         `firestore:"nonce"`
+	Prompt              string         `firestore:"prompt"`
+	MaxAge              *time.Duration `firestore:"max_age"`
+	IDPHint             string         `firestore:"idp_hint"`
+	CodeChallenge       string         `firestore:"code_challenge"`
+	CodeChallengeMethod string         `firestore:"code_challenge_method"`
+	CreatedAt           time.Time      `firestore:"created_at"`
+	ExpiresAt           time.Time      `firestore:"expires_at"`
+	DeleteAt            time.Time      `firestore:"delete_at"`
+}
+
+// FirestoreStore keeps pending authorization requests in Firestore so the
+// upstream callback can land on any instance.
+type FirestoreStore struct {
+	fs  *firestore.Client
+	now func() time.Time
+}
+
+var _ Store = (*FirestoreStore)(nil)
+
+func NewFirestoreStore(fs *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{fs: fs, now: time.Now}
+}
+
+// Documents are keyed by a digest so the request handle is never persisted.
+func (s *FirestoreStore) doc(id string) *firestore.DocumentRef {
+	sum := sha256.Sum256([]byte(id))
+	return s.fs.Collection(colAuthorizeRequests).Doc(base64.RawURLEncoding.EncodeToString(sum[:]))
+}
+
+func (s *FirestoreStore) Save(ctx context.Context, req authorize.Request) error {
+	rec := requestRecord{
+		ClientID:            req.ClientID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		State:               req.State,
+		Nonce:               req.Nonce,
+		Prompt:              req.Prompt,
+		MaxAge:              req.MaxAge,
+		IDPHint:             req.IDPHint,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		CreatedAt:           s.now(),
+		ExpiresAt:           req.ExpiresAt,
+		DeleteAt:            req.ExpiresAt,
+	}
+
+	_, err := s.doc(req.ID).Create(ctx, rec)
+	return err
+}
+
+func (s *FirestoreStore) Take(ctx context.Context, id string) (*authorize.Request, error) {
+	if id == "" {
+		return nil, ErrNotFound
+	}
+
+	ref := s.doc(id)
+
+	var taken *authorize.Request
+	err := s.fs.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		taken = nil
+
+		snap, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var rec requestRecord
+		if err := snap.DataTo(&rec); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(ref); err != nil {
+			return err
+		}
+
+		if s.now().After(rec.ExpiresAt) {
+			return nil
+		}
+
+		taken = &authorize.Request{
+			ID:                  id,
+			ClientID:            rec.ClientID,
+			RedirectURI:         rec.RedirectURI,
+			Scope:               rec.Scope,
+			State:               rec.State,
+			Nonce:               rec.Nonce,
+			Prompt:              rec.Prompt,
+			MaxAge:              rec.MaxAge,
+			IDPHint:             rec.IDPHint,
+			CodeChallenge:       rec.CodeChallenge,
+			CodeChallengeMethod: rec.CodeChallengeMethod,
+			ExpiresAt:           rec.ExpiresAt,
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if taken == nil {
+		return nil, ErrExpired
+	}
+
+	return taken, nil
+}
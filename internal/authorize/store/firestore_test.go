@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+
+	"github.com/vinylhousegarage/idpproxy/internal/authorize"
+)
+
+func newTestFirestoreStore(t *testing.T) *FirestoreStore {
+	t.Helper()
+
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("FIRESTORE_EMULATOR_HOST is not set; skipping Firestore emulator tests")
+	}
+
+	projectID := os.Getenv("TEST_FIRESTORE_PROJECT")
+	require.NotEmpty(t, projectID, "TEST_FIRESTORE_PROJECT is not set")
+
+	fs, err := firestore.NewClient(context.Background(), projectID, option.WithoutAuthentication())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fs.Close() })
+
+	return NewFirestoreStore(fs)
+}
+
+func uniqueID(t *testing.T) string {
+	return t.Name() + "-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+func TestFirestoreStore_Take(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("round trips and is single use", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestFirestoreStore(t)
+		id := uniqueID(t)
+		maxAge := 5 * time.Minute
+
+		require.NoError(t, s.Save(ctx, authorize.Request{
+			ID:                  id,
+			ClientID:            "client-1",
+			RedirectURI:         "https://app.example.com/cb",
+			Scope:               "openid email",
+			State:               "st-1",
+			Nonce:               "n-1",
+			MaxAge:              &maxAge,
+			CodeChallenge:       "challenge",
+			CodeChallengeMethod: "S256",
+			ExpiresAt:           time.Now().Add(time.Minute),
+		}))
+
+		got, err := s.Take(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, id, got.ID)
+		require.Equal(t, "client-1", got.ClientID)
+		require.Equal(t, "https://app.example.com/cb", got.RedirectURI)
+		require.Equal(t, "st-1", got.State)
+		require.Equal(t, "challenge", got.CodeChallenge)
+		require.NotNil(t, got.MaxAge)
+		require.Equal(t, maxAge, *got.MaxAge)
+
+		_, err = s.Take(ctx, id)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("expired request is deleted", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestFirestoreStore(t)
+		id := uniqueID(t)
+
+		require.NoError(t, s.Save(ctx, authorize.Request{
+			ID:        id,
+			ClientID:  "client-1",
+			ExpiresAt: time.Now().Add(-time.Minute),
+		}))
+
+		_, err := s.Take(ctx, id)
+		require.ErrorIs(t, err, ErrExpired)
+
+		_, err = s.Take(ctx, id)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vinylhousegarage/idpproxy/internal/authorize"
+)
+
+type MemoryStore struct {
+	mu       sync.Mutex
+	requests map[string]authorize.Request
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		requests: make(map[string]authorize.Request),
+	}
+}
+
+func (s *MemoryStore) Save(ctx context.Context, req authorize.Request) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, r := range s.requests {
+		if now.After(r.ExpiresAt) {
+			delete(s.requests, id)
+		}
+	}
+
+	s.requests[req.ID] = req
+	return nil
+}
+
+func (s *MemoryStore) Take(ctx context.Context, id string) (*authorize.Request, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req, ok := s.requests[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	delete(s.requests, id)
+
+	if time.Now().After(req.ExpiresAt) {
+		return nil, ErrExpired
+	}
+
+	return &req, nil
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vinylhousegarage/idpproxy/internal/authorize"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("returns ErrNotFound when request does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewMemoryStore()
+
+		_, err := s.Take(ctx, "no-such-id")
+		if err != ErrNotFound {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("returns ErrExpired and deletes request when expired", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewMemoryStore()
+		_ = s.Save(ctx, authorize.Request{
+			ID:        "req-expired",
+			ClientID:  "client-1",
+			ExpiresAt: time.Now().Add(-time.Minute),
+		})
+
+		_, err := s.Take(ctx, "req-expired")
+		if err != ErrExpired {
+			t.Fatalf("expected ErrExpired, got %v", err)
+		}
+
+		_, err = s.Take(ctx, "req-expired")
+		if err != ErrNotFound {
+			t.Fatalf("expected ErrNotFound after expiration delete, got %v", err)
+		}
+	})
+
+	t.Run("returns request once", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewMemoryStore()
+		_ = s.Save(ctx, authorize.Request{
+			ID:          "req-1",
+			ClientID:    "client-1",
+			RedirectURI: "https://app.example.com/cb",
+			ExpiresAt:   time.Now().Add(time.Minute),
+		})
+
+		got, err := s.Take(ctx, "req-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.ClientID != "client-1" || got.RedirectURI != "https://app.example.com/cb" {
+			t.Fatalf("unexpected request: %+v", got)
+		}
+
+		_, err = s.Take(ctx, "req-1")
+		if err != ErrNotFound {
+			t.Fatalf("expected ErrNotFound on second take, got %v", err)
+		}
+	})
+}
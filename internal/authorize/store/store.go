@@ -0,0 +1,12 @@
+package store
+
+import (
+	"context"
+
+	"github.com/vinylhousegarage/idpproxy/internal/authorize"
+)
+
+type Store interface {
+	Save(ctx context.Context, req authorize.Request) error
+	Take(ctx context.Context, id string) (*authorize.Request, error)
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	TypeConfidential = "confidential"
+	TypePublic       = "public"
+)
+
+var (
+	DefaultGrantTypes = []string{"authorization_code", "refresh_token"}
+	DefaultScopes     = []string{"openid"}
+)
+
+type Client struct {
+	ID           string   `firestore:"client_id"`
+	Type         string   `firestore:"client_type"`
+	SecretHash   string   `firestore:"secret_hash"`
+	RedirectURIs []string `firestore:"redirect_uris"`
+	GrantTypes   []string `firestore:"grant_types"`
+	Scopes       []string `firestore:"scopes"`
+
+	PostLogoutRedirectURIs []string `firestore:"post_logout_redirect_uris"`
+	BackchannelLogoutURI   string   `firestore:"backchannel_logout_uri"`
+
+	AccessTokenTTL  time.Duration `firestore:"access_token_ttl"`
+	IDTokenTTL      time.Duration `firestore:"id_token_ttl"`
+	RefreshTokenTTL time.Duration `firestore:"refresh_token_ttl"`
+
+	LoginPolicy LoginPolicy `firestore:"login_policy"`
+
+	// ResourceServer lets the client introspect tokens issued to other clients.
+	ResourceServer bool `firestore:"resource_server"`
+
+	CreatedAt time.Time `firestore:"created_at"`
+	UpdatedAt time.Time `firestore:"updated_at"`
+}
+
+func (c *Client) IsPublic() bool {
+	return c.Type == TypePublic
+}
+
+func (c *Client) AllowsRedirectURI(redirectURI string) bool {
+	return slices.Contains(c.RedirectURIs, redirectURI)
+}
+
+func (c *Client) AllowsPostLogoutRedirectURI(redirectURI string) bool {
+	return slices.Contains(c.PostLogoutRedirectURIs, redirectURI)
+}
+
+func (c *Client) AllowsGrantType(grantType string) bool {
+	grantTypes := c.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = DefaultGrantTypes
+	}
+
+	return slices.Contains(grantTypes, grantType)
+}
+
+// CanIntrospect reports whether the client may learn the state of a token
+// issued to tokenClientID.
+func (c *Client) CanIntrospect(tokenClientID string) bool {
+	return c.ResourceServer || c.ID == tokenClientID
+}
+
+func (c *Client) AllowsScope(scope string) bool {
+	scopes := c.Scopes
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(scopes, s) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *Client) Validate() error {
+	if c.ID == "" || strings.Contains(c.ID, "/") {
+		return ErrInvalidClientID
+	}
+
+	switch c.Type {
+	case TypeConfidential:
+		if c.SecretHash == "" {
+			return ErrMissingSecret
+		}
+	case TypePublic:
+		if c.SecretHash != "" {
+			return ErrUnexpectedSecret
+		}
+	default:
+		return ErrInvalidClientType
+	}
+
+	if len(c.RedirectURIs) == 0 {
+		return ErrMissingRedirectURI
+	}
+
+	return c.LoginPolicy.Validate()
+}
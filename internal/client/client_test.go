@@ -0,0 +1,106 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Allows(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{
+		ID:           "client-1",
+		Type:         TypeConfidential,
+		RedirectURIs: []string{"https://app.example.com/cb"},
+		GrantTypes:   []string{"authorization_code"},
+		Scopes:       []string{"openid", "email"},
+
+		PostLogoutRedirectURIs: []string{"https://app.example.com/logged-out"},
+	}
+
+	require.True(t, c.AllowsRedirectURI("https://app.example.com/cb"))
+	require.False(t, c.AllowsRedirectURI("https://app.example.com/cb/other"))
+
+	require.True(t, c.AllowsPostLogoutRedirectURI("https://app.example.com/logged-out"))
+	require.False(t, c.AllowsPostLogoutRedirectURI("https://app.example.com/cb"))
+
+	require.True(t, c.AllowsGrantType("authorization_code"))
+	require.False(t, c.AllowsGrantType("refresh_token"))
+
+	require.True(t, c.AllowsScope("openid email"))
+	require.True(t, c.AllowsScope(""))
+	require.False(t, c.AllowsScope("openid profile"))
+
+	require.False(t, c.IsPublic())
+
+	require.True(t, c.CanIntrospect("client-1"))
+	require.False(t, c.CanIntrospect("client-2"))
+
+	c.ResourceServer = true
+	require.True(t, c.CanIntrospect("client-2"))
+}
+
+func TestClient_AllowsDefaults(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{ID: "client-1", Type: TypePublic}
+
+	require.True(t, c.AllowsGrantType("authorization_code"))
+	require.True(t, c.AllowsGrantType("refresh_token"))
+	require.False(t, c.AllowsGrantType("client_credentials"))
+
+	require.True(t, c.AllowsScope("openid"))
+	require.False(t, c.AllowsScope("openid email"))
+
+	require.True(t, c.IsPublic())
+}
+
+func TestClient_Validate(t *testing.T) {
+	t.Parallel()
+
+	valid := func() *Client {
+		return &Client{
+			ID:           "client-1",
+			Type:         TypeConfidential,
+			SecretHash:   "hash",
+			RedirectURIs: []string{"https://app.example.com/cb"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*Client)
+		wantErr error
+	}{
+		{"valid", func(*Client) {}, nil},
+		{"empty id", func(c *Client) { c.ID = "" }, ErrInvalidClientID},
+		{"slash in id", func(c *Client) { c.ID = "a/b" }, ErrInvalidClientID},
+		{"unknown type", func(c *Client) { c.Type = "other" }, ErrInvalidClientType},
+		{"confidential without secret", func(c *Client) { c.SecretHash = "" }, ErrMissingSecret},
+		{"public with secret", func(c *Client) { c.Type = TypePublic }, ErrUnexpectedSecret},
+		{"no redirect uri", func(c *Client) { c.RedirectURIs = nil }, ErrMissingRedirectURI},
+		{"login policy", func(c *Client) {
+			c.LoginPolicy = LoginPolicy{GitHubOrgs: []string{"acme"}, GitHubTeams: []string{"acme/admins"}, GoogleDomains: []string{"acme.com"}}
+		}, nil},
+		{"team without org", func(c *Client) { c.LoginPolicy.GitHubTeams = []string{"admins"} }, ErrInvalidLoginPolicy},
+		{"email as domain", func(c *Client) { c.LoginPolicy.GoogleDomains = []string{"jane@acme.com"} }, ErrInvalidLoginPolicy},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := valid()
+			tt.mutate(c)
+
+			err := c.Validate()
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package client
+
+import "errors"
+
+// Registry
+var (
+	ErrNotFound = errors.New("client not found")
+)
+
+// Validation
+var (
+	ErrInvalidClientID    = errors.New("client id invalid")
+	ErrInvalidClientType  = errors.New("client type invalid")
+	ErrMissingRedirectURI = errors.New("client redirect uri missing")
+	ErrMissingSecret      = errors.New("client secret missing")
+	ErrWeakSecret         = errors.New("client secret must be at least 32 random bytes, base64url encoded")
+	ErrUnexpectedSecret   = errors.New("public client must not have a secret")
+	ErrInvalidLoginPolicy = errors.New("client login policy invalid")
+)
+
+// Authentication
+var (
+	ErrSecretMismatch = errors.New("client secret mismatch")
+)
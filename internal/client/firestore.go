@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const colClients = "clients"
+
+type FirestoreRegistry struct {
+	fs  *firestore.Client
+	now func() time.Time
+}
+
+func NewFirestoreRegistry(fs *firestore.Client) *FirestoreRegistry {
+	return &FirestoreRegistry{fs: fs, now: time.Now}
+}
+
+func (r *FirestoreRegistry) doc(clientID string) *firestore.DocumentRef {
+	return r.fs.Collection(colClients).Doc(clientID)
+}
+
+func (r *FirestoreRegistry) Get(ctx context.Context, clientID string) (*Client, error) {
+	if clientID == "" || strings.Contains(clientID, "/") {
+		return nil, ErrNotFound
+	}
+
+	snap, err := r.doc(clientID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var c Client
+	if err := snap.DataTo(&c); err != nil {
+		return nil, err
+	}
+
+	c.ID = snap.Ref.ID
+	return &c, nil
+}
+
+func (r *FirestoreRegistry) Save(ctx context.Context, c *Client) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	now := r.now()
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = now
+	}
+	c.UpdatedAt = now
+
+	_, err := r.doc(c.ID).Set(ctx, c)
+	return err
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+func newTestFirestoreRegistry(t *testing.T) *FirestoreRegistry {
+	t.Helper()
+
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("FIRESTORE_EMULATOR_HOST is not set; skipping Firestore emulator tests")
+	}
+
+	projectID := os.Getenv("TEST_FIRESTORE_PROJECT")
+	require.NotEmpty(t, projectID, "TEST_FIRESTORE_PROJECT is not set")
+
+	fs, err := firestore.NewClient(context.Background(), projectID, option.WithoutAuthentication())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fs.Close() })
+
+	fixed := time.Unix(1_725_000_000, 0).UTC()
+
+	return &FirestoreRegistry{fs: fs, now: func() time.Time { return fixed }}
+}
+
+func TestFirestoreRegistry(t *testing.T) {
+	t.Parallel()
+
+	r := newTestFirestoreRegistry(t)
+	ctx := context.Background()
+
+	c := &Client{
+		ID:              "client-fs-1",
+		Type:            TypeConfidential,
+		SecretHash:      "hash",
+		RedirectURIs:    []string{"https://app.example.com/cb"},
+		GrantTypes:      []string{"authorization_code"},
+		Scopes:          []string{"openid", "email"},
+		AccessTokenTTL:  5 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+	}
+	require.NoError(t, r.Save(ctx, c))
+
+	got, err := r.Get(ctx, "client-fs-1")
+	require.NoError(t, err)
+	require.Equal(t, c.RedirectURIs, got.RedirectURIs)
+	require.Equal(t, c.GrantTypes, got.GrantTypes)
+	require.Equal(t, c.Scopes, got.Scopes)
+	require.Equal(t, 5*time.Minute, got.AccessTokenTTL)
+	require.Equal(t, r.now(), got.CreatedAt.UTC())
+
+	_, err = r.Get(ctx, "no-such-client")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = r.Get(ctx, "bad/id")
+	require.ErrorIs(t, err, ErrNotFound)
+}
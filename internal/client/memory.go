@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+type MemoryRegistry struct {
+	mu      sync.RWMutex
+	clients map[string]Client
+}
+
+func NewMemoryRegistry(clients ...*Client) *MemoryRegistry {
+	r := &MemoryRegistry{
+		clients: make(map[string]Client, len(clients)),
+	}
+	for _, c := range clients {
+		r.clients[c.ID] = clone(c)
+	}
+
+	return r
+}
+
+func (r *MemoryRegistry) Get(ctx context.Context, clientID string) (*Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.clients[clientID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	out := clone(&c)
+	return &out, nil
+}
+
+func (r *MemoryRegistry) Save(ctx context.Context, c *Client) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.clients[c.ID] = clone(c)
+	return nil
+}
+
+func clone(c *Client) Client {
+	out := *c
+	out.RedirectURIs = slices.Clone(c.RedirectURIs)
+	out.PostLogoutRedirectURIs = slices.Clone(c.PostLogoutRedirectURIs)
+	out.GrantTypes = slices.Clone(c.GrantTypes)
+	out.Scopes = slices.Clone(c.Scopes)
+
+	return out
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRegistry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	r := NewMemoryRegistry(&Client{
+		ID:           "client-1",
+		Type:         TypePublic,
+		RedirectURIs: []string{"https://app.example.com/cb"},
+	})
+
+	c, err := r.Get(ctx, "client-1")
+	require.NoError(t, err)
+	require.Equal(t, "client-1", c.ID)
+
+	c.RedirectURIs[0] = "https://mutated.example.com/cb"
+	again, err := r.Get(ctx, "client-1")
+	require.NoError(t, err)
+	require.Equal(t, "https://app.example.com/cb", again.RedirectURIs[0])
+
+	_, err = r.Get(ctx, "client-2")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.ErrorIs(t, r.Save(ctx, &Client{ID: "client-2", Type: TypePublic}), ErrMissingRedirectURI)
+
+	require.NoError(t, r.Save(ctx, &Client{
+		ID:           "client-2",
+		Type:         TypePublic,
+		RedirectURIs: []string{"https://two.example.com/cb"},
+	}))
+	_, err = r.Get(ctx, "client-2")
+	require.NoError(t, err)
+}
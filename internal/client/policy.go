@@ -0,0 +1,41 @@
+package client
+
+import "strings"
+
+// LoginPolicy restricts which upstream identities may sign in to a client.
+// An empty policy admits everyone; otherwise matching any one rule admits.
+type LoginPolicy struct {
+	GitHubOrgs []string `firestore:"github_orgs"`
+	// "<org>/<team-slug>"
+	GitHubTeams []string `firestore:"github_teams"`
+	// Google Workspace domains, matched against the hd claim of a Google OIDC
+	// upstream; Firebase Google sign-ins carry no hd and never match
+	GoogleDomains []string `firestore:"google_domains"`
+}
+
+func (p LoginPolicy) IsZero() bool {
+	return len(p.GitHubOrgs) == 0 && len(p.GitHubTeams) == 0 && len(p.GoogleDomains) == 0
+}
+
+func (p LoginPolicy) Validate() error {
+	for _, org := range p.GitHubOrgs {
+		if org == "" || strings.Contains(org, "/") {
+			return ErrInvalidLoginPolicy
+		}
+	}
+
+	for _, team := range p.GitHubTeams {
+		org, slug, ok := strings.Cut(team, "/")
+		if !ok || org == "" || slug == "" || strings.Contains(slug, "/") {
+			return ErrInvalidLoginPolicy
+		}
+	}
+
+	for _, domain := range p.GoogleDomains {
+		if domain == "" || strings.ContainsAny(domain, "/@") {
+			return ErrInvalidLoginPolicy
+		}
+	}
+
+	return nil
+}
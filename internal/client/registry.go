@@ -0,0 +1,13 @@
+package client
+
+import "context"
+
+type Registry interface {
+	Get(ctx context.Context, clientID string) (*Client, error)
+	Save(ctx context.Context, c *Client) error
+}
+
+var (
+	_ Registry = (*MemoryRegistry)(nil)
+	_ Registry = (*FirestoreRegistry)(nil)
+)
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+const secretBytes = 32
+
+func GenerateSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashSecret returns a SHA-256 digest of the secret. Only secrets shaped like
+// GenerateSecret output, at least 256 bits encoded as base64url, are accepted:
+// at that entropy an unsalted fast digest cannot be brute-forced, and a slow
+// password hash would only add CPU cost to every client authentication.
+func HashSecret(secret string) (string, error) {
+	if secret == "" {
+		return "", ErrMissingSecret
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(secret)
+	if err != nil || len(raw) < secretBytes {
+		return "", ErrWeakSecret
+	}
+
+	return digestSecret(secret), nil
+}
+
+func (c *Client) VerifySecret(secret string) error {
+	if c.SecretHash == "" || secret == "" {
+		return ErrSecretMismatch
+	}
+
+	if subtle.ConstantTimeCompare([]byte(c.SecretHash), []byte(digestSecret(secret))) != 1 {
+		return ErrSecretMismatch
+	}
+
+	return nil
+}
+
+func digestSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
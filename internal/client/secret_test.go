@@ -0,0 +1,34 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecret(t *testing.T) {
+	t.Parallel()
+
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, 43)
+
+	hash, err := HashSecret(secret)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, hash)
+
+	c := &Client{SecretHash: hash}
+	require.NoError(t, c.VerifySecret(secret))
+	require.ErrorIs(t, c.VerifySecret("wrong"), ErrSecretMismatch)
+	require.ErrorIs(t, c.VerifySecret(""), ErrSecretMismatch)
+
+	require.ErrorIs(t, (&Client{}).VerifySecret(secret), ErrSecretMismatch)
+
+	_, err = HashSecret("")
+	require.ErrorIs(t, err, ErrMissingSecret)
+
+	for _, weak := range []string{"password", secret[:42], secret + "!"} {
+		_, err = HashSecret(weak)
+		require.ErrorIs(t, err, ErrWeakSecret, weak)
+	}
+}
@@ -2,10 +2,14 @@ package config
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func GetPort() string {
@@ -40,6 +44,11 @@ type GitHubOAuthConfig struct {
 	RedirectURI  string
 	Scope        string
 	AllowSignup  string
+
+	// fetch org and team membership at login
+	ReadOrg bool
+	// fetch /user/emails at login to find the primary verified email
+	ReadEmail bool
 }
 
 func LoadGitHubOAuthConfig() (*GitHubOAuthConfig, error) {
@@ -75,12 +84,33 @@ func loadGitHubOAuthConfigWithPrefix(prefix string) (*GitHubOAuthConfig, error)
 		return nil, fmt.Errorf("%sREDIRECT_URI is invalid: %w", prefix, err)
 	}
 
+	// org and team membership for login policies needs read:org
+	readOrg, err := loadBool(prefix + "READ_ORG")
+	if err != nil {
+		return nil, err
+	}
+
+	readEmail, err := loadBool(prefix + "READ_EMAIL")
+	if err != nil {
+		return nil, err
+	}
+
+	scope := GitHubScope
+	if readOrg {
+		scope += " " + GitHubOrgScope
+	}
+	if readEmail {
+		scope += " " + GitHubEmailScope
+	}
+
 	return &GitHubOAuthConfig{
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
 		RedirectURI:  redirectURI,
-		Scope:        GitHubScope,
+		Scope:        scope,
 		AllowSignup:  GitHubAllowSignup,
+		ReadOrg:      readOrg,
+		ReadEmail:    readEmail,
 	}, nil
 }
 
@@ -98,6 +128,386 @@ func LoadGitHubAPIConfig() *GitHubAPIConfig {
 	}
 }
 
+type OIDCConfig struct {
+	Issuer         string
+	GoogleLoginURL string
+
+	AccessTokenRevocation string
+	LogoutRevokesTokens   bool
+
+	// zero disables the limit
+	SessionIdleTimeout time.Duration
+	SessionMaxLifetime time.Duration
+
+	// upstream providers whose verified emails are trusted to link a
+	// first-time identity to the user with the same email; empty disables
+	AutoLinkProviders []string
+	// zero uses the account link handler's default
+	AccountLinkMaxAuthAge time.Duration
+
+	// GitHub membership claims (ClaimGroups, ClaimGitHubOrgs) added to ID
+	// tokens and userinfo for the groups scope
+	GitHubClaims []string
+
+	Upstreams []UpstreamOIDCConfig
+}
+
+func LoadOIDCConfig() (*OIDCConfig, error) {
+	issuer := strings.TrimSpace(os.Getenv("IDPPROXY_ISSUER"))
+	if issuer == "" {
+		return nil, fmt.Errorf("IDPPROXY_ISSUER is not set")
+	}
+
+	u, err := url.Parse(issuer)
+	if err != nil {
+		return nil, fmt.Errorf("IDPPROXY_ISSUER is invalid: %w", err)
+	}
+
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("IDPPROXY_ISSUER must be an absolute http(s) URL")
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("IDPPROXY_ISSUER must not contain query or fragment")
+	}
+
+	revocation := strings.TrimSpace(os.Getenv("IDPPROXY_ACCESS_TOKEN_REVOCATION"))
+	switch revocation {
+	case "":
+		revocation = AccessTokenRevocationGeneration
+	case AccessTokenRevocationGeneration, AccessTokenRevocationDenylist:
+	default:
+		return nil, fmt.Errorf("IDPPROXY_ACCESS_TOKEN_REVOCATION must be %q or %q", AccessTokenRevocationGeneration, AccessTokenRevocationDenylist)
+	}
+
+	logoutRevokesTokens, err := loadBool("IDPPROXY_LOGOUT_REVOKE_TOKENS")
+	if err != nil {
+		return nil, err
+	}
+
+	idleTimeout, err := loadDuration("IDPPROXY_SESSION_IDLE_TIMEOUT")
+	if err != nil {
+		return nil, err
+	}
+
+	maxLifetime, err := loadDuration("IDPPROXY_SESSION_MAX_LIFETIME")
+	if err != nil {
+		return nil, err
+	}
+
+	linkMaxAuthAge, err := loadDuration("IDPPROXY_ACCOUNT_LINK_MAX_AUTH_AGE")
+	if err != nil {
+		return nil, err
+	}
+
+	githubClaims, err := loadGitHubClaims()
+	if err != nil {
+		return nil, err
+	}
+
+	upstreams, err := LoadUpstreamOIDCConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	autoLink, err := loadAutoLinkProviders(upstreams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCConfig{
+		Issuer:                strings.TrimSuffix(issuer, "/"),
+		GoogleLoginURL:        strings.TrimSpace(os.Getenv("IDPPROXY_GOOGLE_LOGIN_URL")),
+		AccessTokenRevocation: revocation,
+		LogoutRevokesTokens:   logoutRevokesTokens,
+		SessionIdleTimeout:    idleTimeout,
+		SessionMaxLifetime:    maxLifetime,
+		AutoLinkProviders:     autoLink,
+		AccountLinkMaxAuthAge: linkMaxAuthAge,
+		GitHubClaims:          githubClaims,
+		Upstreams:             upstreams,
+	}, nil
+}
+
+func loadGitHubClaims() ([]string, error) {
+	var claims []string
+	for _, c := range strings.Split(os.Getenv("IDPPROXY_GITHUB_CLAIMS"), ",") {
+		c = strings.TrimSpace(c)
+		switch c {
+		case "":
+			continue
+		case ClaimGroups, ClaimGitHubOrgs:
+		default:
+			return nil, fmt.Errorf("IDPPROXY_GITHUB_CLAIMS must list %q or %q, got %q", ClaimGroups, ClaimGitHubOrgs, c)
+		}
+		if !slices.Contains(claims, c) {
+			claims = append(claims, c)
+		}
+	}
+
+	return claims, nil
+}
+
+// loadAutoLinkProviders reads the providers trusted to assert verified
+// emails; each must be a built-in provider or a configured upstream.
+func loadAutoLinkProviders(upstreams []UpstreamOIDCConfig) ([]string, error) {
+	known := slices.Clone(reservedUpstreamIDs)
+	for _, u := range upstreams {
+		known = append(known, u.ID)
+	}
+
+	var providers []string
+	for _, p := range strings.Split(os.Getenv("IDPPROXY_AUTO_LINK_PROVIDERS"), ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !slices.Contains(known, p) {
+			return nil, fmt.Errorf("IDPPROXY_AUTO_LINK_PROVIDERS lists unknown provider %q", p)
+		}
+		if !slices.Contains(providers, p) {
+			providers = append(providers, p)
+		}
+	}
+
+	return providers, nil
+}
+
+func loadBool(name string) (bool, error) {
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s is invalid: %w", name, err)
+	}
+
+	return b, nil
+}
+
+func loadDuration(name string) (time.Duration, error) {
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s is invalid: %w", name, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s must not be negative", name)
+	}
+
+	return d, nil
+}
+
+type SigningKeyConfig struct {
+	KeyID         string
+	PrivateKeyPEM []byte
+	KMSKeyVersion string
+
+	State      string
+	ActivateAt time.Time
+	RetireAt   time.Time
+	RemoveAt   time.Time
+}
+
+func LoadSigningKeyConfig() (*SigningKeyConfig, error) {
+	if kmsKeyVersion := strings.TrimSpace(os.Getenv("IDPPROXY_SIGNING_KMS_KEY_VERSION")); kmsKeyVersion != "" {
+		return &SigningKeyConfig{KMSKeyVersion: kmsKeyVersion}, nil
+	}
+
+	keyID := strings.TrimSpace(os.Getenv("IDPPROXY_SIGNING_KEY_ID"))
+	b64 := strings.TrimSpace(os.Getenv("IDPPROXY_SIGNING_KEY_PEM_BASE64"))
+
+	if keyID == "" && b64 == "" {
+		return nil, fmt.Errorf("IDPPROXY_SIGNING_KMS_KEY_VERSION or IDPPROXY_SIGNING_KEY_ID and IDPPROXY_SIGNING_KEY_PEM_BASE64 are not set")
+	}
+
+	if keyID == "" {
+		return nil, fmt.Errorf("IDPPROXY_SIGNING_KEY_ID is not set")
+	}
+
+	if b64 == "" {
+		return nil, fmt.Errorf("IDPPROXY_SIGNING_KEY_PEM_BASE64 is not set")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode IDPPROXY_SIGNING_KEY_PEM_BASE64: %w", err)
+	}
+
+	return &SigningKeyConfig{
+		KeyID:         keyID,
+		PrivateKeyPEM: decoded,
+	}, nil
+}
+
+type signingKeyJSON struct {
+	KeyID         string    `json:"kid"`
+	PEMBase64     string    `json:"pem_base64"`
+	KMSKeyVersion string    `json:"kms_key_version"`
+	State         string    `json:"state"`
+	ActivateAt    time.Time `json:"activate_at"`
+	RetireAt      time.Time `json:"retire_at"`
+	RemoveAt      time.Time `json:"remove_at"`
+}
+
+var signingKeyStates = []string{"", "pending", "active", "retiring", "retired"}
+
+func LoadSigningKeyRingConfig() ([]SigningKeyConfig, error) {
+	raw := strings.TrimSpace(os.Getenv("IDPPROXY_SIGNING_KEYS_JSON"))
+	if raw == "" {
+		cfg, err := LoadSigningKeyConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		return []SigningKeyConfig{*cfg}, nil
+	}
+
+	var entries []signingKeyJSON
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil, fmt.Errorf("IDPPROXY_SIGNING_KEYS_JSON is invalid: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("IDPPROXY_SIGNING_KEYS_JSON has no keys")
+	}
+
+	out := make([]SigningKeyConfig, 0, len(entries))
+	for i, e := range entries {
+		cfg := SigningKeyConfig{
+			KeyID:         strings.TrimSpace(e.KeyID),
+			KMSKeyVersion: strings.TrimSpace(e.KMSKeyVersion),
+			State:         strings.TrimSpace(e.State),
+			ActivateAt:    e.ActivateAt,
+			RetireAt:      e.RetireAt,
+			RemoveAt:      e.RemoveAt,
+		}
+
+		if !slices.Contains(signingKeyStates, cfg.State) {
+			return nil, fmt.Errorf("IDPPROXY_SIGNING_KEYS_JSON[%d]: unknown state %q", i, cfg.State)
+		}
+
+		if cfg.KMSKeyVersion == "" {
+			if cfg.KeyID == "" || e.PEMBase64 == "" {
+				return nil, fmt.Errorf("IDPPROXY_SIGNING_KEYS_JSON[%d]: kms_key_version or kid and pem_base64 are required", i)
+			}
+
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(e.PEMBase64))
+			if err != nil {
+				return nil, fmt.Errorf("IDPPROXY_SIGNING_KEYS_JSON[%d]: failed to decode pem_base64: %w", i, err)
+			}
+			cfg.PrivateKeyPEM = decoded
+		}
+
+		out = append(out, cfg)
+	}
+
+	return out, nil
+}
+
+type UpstreamOIDCConfig struct {
+	ID           string
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	Scopes       []string
+}
+
+type upstreamOIDCJSON struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURI  string   `json:"redirect_uri"`
+	Scopes       []string `json:"scopes"`
+}
+
+var reservedUpstreamIDs = []string{"github", "google"}
+
+func LoadUpstreamOIDCConfig() ([]UpstreamOIDCConfig, error) {
+	raw := strings.TrimSpace(os.Getenv("IDPPROXY_UPSTREAM_OIDC_JSON"))
+	if raw == "" {
+		return nil, nil
+	}
+
+	var entries []upstreamOIDCJSON
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil, fmt.Errorf("IDPPROXY_UPSTREAM_OIDC_JSON is invalid: %w", err)
+	}
+
+	seen := make(map[string]bool, len(entries))
+	out := make([]UpstreamOIDCConfig, 0, len(entries))
+	for i, e := range entries {
+		cfg := UpstreamOIDCConfig{
+			ID:           strings.TrimSpace(e.ID),
+			Name:         strings.TrimSpace(e.Name),
+			Issuer:       strings.TrimSpace(e.Issuer),
+			ClientID:     strings.TrimSpace(e.ClientID),
+			ClientSecret: strings.TrimSpace(e.ClientSecret),
+			RedirectURI:  strings.TrimSpace(e.RedirectURI),
+			Scopes:       e.Scopes,
+		}
+
+		if !validUpstreamID(cfg.ID) {
+			return nil, fmt.Errorf("IDPPROXY_UPSTREAM_OIDC_JSON[%d]: id must be lowercase letters, digits or '-'", i)
+		}
+		if slices.Contains(reservedUpstreamIDs, cfg.ID) || seen[cfg.ID] {
+			return nil, fmt.Errorf("IDPPROXY_UPSTREAM_OIDC_JSON[%d]: id %q is already in use", i, cfg.ID)
+		}
+		seen[cfg.ID] = true
+
+		if cfg.Name == "" {
+			cfg.Name = cfg.ID
+		}
+
+		if u, err := url.Parse(cfg.Issuer); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("IDPPROXY_UPSTREAM_OIDC_JSON[%d]: issuer must be an absolute http(s) URL", i)
+		}
+
+		if cfg.ClientID == "" {
+			return nil, fmt.Errorf("IDPPROXY_UPSTREAM_OIDC_JSON[%d]: client_id is required", i)
+		}
+
+		if _, err := url.ParseRequestURI(cfg.RedirectURI); err != nil {
+			return nil, fmt.Errorf("IDPPROXY_UPSTREAM_OIDC_JSON[%d]: redirect_uri is invalid", i)
+		}
+
+		if len(cfg.Scopes) == 0 {
+			cfg.Scopes = slices.Clone(UpstreamOIDCDefaultScopes)
+		}
+		if !slices.Contains(cfg.Scopes, "openid") {
+			cfg.Scopes = slices.Concat([]string{"openid"}, cfg.Scopes)
+		}
+
+		out = append(out, cfg)
+	}
+
+	return out, nil
+}
+
+func validUpstreamID(id string) bool {
+	if id == "" {
+		return false
+	}
+
+	for _, c := range id {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
 type ServiceAccountConfig struct {
 	ImpersonateSA string
 }
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/base64"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -41,6 +42,7 @@ func TestLoadFirebaseConfig(t *testing.T) {
 	})
 
 	t.Run("invalid base64", func(t *testing.T) {
+		t.Setenv("IDPPROXY_SIGNING_KMS_KEY_VERSION", "")
 		t.Setenv("GOOGLE_APPLICATION_CREDENTIALS_BASE64", "not-base64!!!")
 
 		cfg, err := LoadFirebaseConfig()
@@ -65,6 +67,32 @@ func TestLoadGitHubOAuthConfig(t *testing.T) {
 		require.Equal(t, "true", cfg.AllowSignup)
 	})
 
+	t.Run("read org scope", func(t *testing.T) {
+		t.Setenv("GITHUB_CLIENT_ID", "test-github-client-id")
+		t.Setenv("GITHUB_CLIENT_SECRET", "test-github-client-secret")
+		t.Setenv("GITHUB_REDIRECT_URI", "https://idpproxy.com/github/callback")
+		t.Setenv("GITHUB_READ_ORG", "true")
+
+		cfg, err := LoadGitHubOAuthConfig()
+		require.NoError(t, err)
+		require.Equal(t, "read:user read:org", cfg.Scope)
+		require.True(t, cfg.ReadOrg)
+		require.False(t, cfg.ReadEmail)
+	})
+
+	t.Run("read email scope", func(t *testing.T) {
+		t.Setenv("GITHUB_CLIENT_ID", "test-github-client-id")
+		t.Setenv("GITHUB_CLIENT_SECRET", "test-github-client-secret")
+		t.Setenv("GITHUB_REDIRECT_URI", "https://idpproxy.com/github/callback")
+		t.Setenv("GITHUB_READ_ORG", "true")
+		t.Setenv("GITHUB_READ_EMAIL", "true")
+
+		cfg, err := LoadGitHubOAuthConfig()
+		require.NoError(t, err)
+		require.Equal(t, "read:user read:org user:email", cfg.Scope)
+		require.True(t, cfg.ReadEmail)
+	})
+
 	t.Run("missing both variables", func(t *testing.T) {
 		t.Setenv("GITHUB_CLIENT_ID", "")
 		t.Setenv("GITHUB_CLIENT_SECRET", "")
@@ -167,3 +195,326 @@ func TestLoadServiceAccountConfig(t *testing.T) {
 		require.Equal(t, "sa@example.iam.gserviceaccount.com", cfg.ImpersonateSA)
 	})
 }
+
+func TestLoadOIDCConfig(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com")
+
+		cfg, err := LoadOIDCConfig()
+		require.NoError(t, err)
+		require.Equal(t, "https://idpproxy.com", cfg.Issuer)
+		require.Empty(t, cfg.GoogleLoginURL)
+		require.Equal(t, AccessTokenRevocationGeneration, cfg.AccessTokenRevocation)
+		require.False(t, cfg.LogoutRevokesTokens)
+		require.Zero(t, cfg.SessionIdleTimeout)
+		require.Zero(t, cfg.SessionMaxLifetime)
+	})
+
+	t.Run("access token revocation mode", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com")
+		t.Setenv("IDPPROXY_ACCESS_TOKEN_REVOCATION", "denylist")
+
+		cfg, err := LoadOIDCConfig()
+		require.NoError(t, err)
+		require.Equal(t, AccessTokenRevocationDenylist, cfg.AccessTokenRevocation)
+	})
+
+	t.Run("logout revokes tokens", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com")
+		t.Setenv("IDPPROXY_LOGOUT_REVOKE_TOKENS", "true")
+
+		cfg, err := LoadOIDCConfig()
+		require.NoError(t, err)
+		require.True(t, cfg.LogoutRevokesTokens)
+	})
+
+	t.Run("session lifetimes", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com")
+		t.Setenv("IDPPROXY_SESSION_IDLE_TIMEOUT", "30m")
+		t.Setenv("IDPPROXY_SESSION_MAX_LIFETIME", "12h")
+
+		cfg, err := LoadOIDCConfig()
+		require.NoError(t, err)
+		require.Equal(t, 30*time.Minute, cfg.SessionIdleTimeout)
+		require.Equal(t, 12*time.Hour, cfg.SessionMaxLifetime)
+	})
+
+	t.Run("account linking", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com")
+		t.Setenv("IDPPROXY_UPSTREAM_OIDC_JSON", `[{"id": "okta", "issuer": "https://example.okta.com", "client_id": "cid", "redirect_uri": "https://idp.example.com/oidc/okta/callback"}]`)
+		t.Setenv("IDPPROXY_AUTO_LINK_PROVIDERS", " google, okta,google")
+		t.Setenv("IDPPROXY_ACCOUNT_LINK_MAX_AUTH_AGE", "2m")
+
+		cfg, err := LoadOIDCConfig()
+		require.NoError(t, err)
+		require.Equal(t, []string{"google", "okta"}, cfg.AutoLinkProviders)
+		require.Equal(t, 2*time.Minute, cfg.AccountLinkMaxAuthAge)
+	})
+
+	t.Run("auto link rejects unknown providers", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com")
+		t.Setenv("IDPPROXY_AUTO_LINK_PROVIDERS", "corp")
+
+		_, err := LoadOIDCConfig()
+		require.EqualError(t, err, `IDPPROXY_AUTO_LINK_PROVIDERS lists unknown provider "corp"`)
+	})
+
+	t.Run("github claims", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com")
+		t.Setenv("IDPPROXY_GITHUB_CLAIMS", " groups, github_orgs,groups")
+
+		cfg, err := LoadOIDCConfig()
+		require.NoError(t, err)
+		require.Equal(t, []string{ClaimGroups, ClaimGitHubOrgs}, cfg.GitHubClaims)
+	})
+
+	t.Run("unknown github claim", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com")
+		t.Setenv("IDPPROXY_GITHUB_CLAIMS", "groups,roles")
+
+		cfg, err := LoadOIDCConfig()
+		require.Nil(t, cfg)
+		require.ErrorContains(t, err, "IDPPROXY_GITHUB_CLAIMS")
+	})
+
+	t.Run("invalid session idle timeout", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com")
+		t.Setenv("IDPPROXY_SESSION_IDLE_TIMEOUT", "-5m")
+
+		cfg, err := LoadOIDCConfig()
+		require.Nil(t, cfg)
+		require.ErrorContains(t, err, "IDPPROXY_SESSION_IDLE_TIMEOUT must not be negative")
+	})
+
+	t.Run("invalid logout revoke tokens flag", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com")
+		t.Setenv("IDPPROXY_LOGOUT_REVOKE_TOKENS", "sometimes")
+
+		cfg, err := LoadOIDCConfig()
+		require.Nil(t, cfg)
+		require.ErrorContains(t, err, "IDPPROXY_LOGOUT_REVOKE_TOKENS is invalid")
+	})
+
+	t.Run("unknown access token revocation mode", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com")
+		t.Setenv("IDPPROXY_ACCESS_TOKEN_REVOCATION", "never")
+
+		cfg, err := LoadOIDCConfig()
+		require.Nil(t, cfg)
+		require.EqualError(t, err, `IDPPROXY_ACCESS_TOKEN_REVOCATION must be "generation" or "denylist"`)
+	})
+
+	t.Run("google login url", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com")
+		t.Setenv("IDPPROXY_GOOGLE_LOGIN_URL", "https://idpproxy.com/google/login")
+
+		cfg, err := LoadOIDCConfig()
+		require.NoError(t, err)
+		require.Equal(t, "https://idpproxy.com/google/login", cfg.GoogleLoginURL)
+	})
+
+	t.Run("trailing slash is trimmed", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com/")
+
+		cfg, err := LoadOIDCConfig()
+		require.NoError(t, err)
+		require.Equal(t, "https://idpproxy.com", cfg.Issuer)
+	})
+
+	t.Run("missing issuer", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "")
+
+		cfg, err := LoadOIDCConfig()
+		require.Nil(t, cfg)
+		require.EqualError(t, err, "IDPPROXY_ISSUER is not set")
+	})
+
+	t.Run("relative issuer", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "idpproxy.com")
+
+		cfg, err := LoadOIDCConfig()
+		require.Nil(t, cfg)
+		require.EqualError(t, err, "IDPPROXY_ISSUER must be an absolute http(s) URL")
+	})
+
+	t.Run("issuer with query", func(t *testing.T) {
+		t.Setenv("IDPPROXY_ISSUER", "https://idpproxy.com?x=1")
+
+		cfg, err := LoadOIDCConfig()
+		require.Nil(t, cfg)
+		require.EqualError(t, err, "IDPPROXY_ISSUER must not contain query or fragment")
+	})
+}
+
+func TestLoadSigningKeyConfig(t *testing.T) {
+	t.Run("kms key version takes precedence", func(t *testing.T) {
+		t.Setenv("IDPPROXY_SIGNING_KMS_KEY_VERSION", "projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1")
+		t.Setenv("IDPPROXY_SIGNING_KEY_ID", "key-1")
+		t.Setenv("IDPPROXY_SIGNING_KEY_PEM_BASE64", "cGVt")
+
+		cfg, err := LoadSigningKeyConfig()
+		require.NoError(t, err)
+		require.Equal(t, "projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1", cfg.KMSKeyVersion)
+		require.Empty(t, cfg.KeyID)
+		require.Nil(t, cfg.PrivateKeyPEM)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		t.Setenv("IDPPROXY_SIGNING_KMS_KEY_VERSION", "")
+		t.Setenv("IDPPROXY_SIGNING_KEY_ID", "key-1")
+		t.Setenv("IDPPROXY_SIGNING_KEY_PEM_BASE64", base64.StdEncoding.EncodeToString([]byte("pem")))
+
+		cfg, err := LoadSigningKeyConfig()
+		require.NoError(t, err)
+		require.Equal(t, "key-1", cfg.KeyID)
+		require.Equal(t, []byte("pem"), cfg.PrivateKeyPEM)
+	})
+
+	t.Run("nothing set", func(t *testing.T) {
+		t.Setenv("IDPPROXY_SIGNING_KMS_KEY_VERSION", "")
+		t.Setenv("IDPPROXY_SIGNING_KEY_ID", "")
+		t.Setenv("IDPPROXY_SIGNING_KEY_PEM_BASE64", "")
+
+		cfg, err := LoadSigningKeyConfig()
+		require.Nil(t, cfg)
+		require.EqualError(t, err, "IDPPROXY_SIGNING_KMS_KEY_VERSION or IDPPROXY_SIGNING_KEY_ID and IDPPROXY_SIGNING_KEY_PEM_BASE64 are not set")
+	})
+
+	t.Run("missing key id", func(t *testing.T) {
+		t.Setenv("IDPPROXY_SIGNING_KMS_KEY_VERSION", "")
+		t.Setenv("IDPPROXY_SIGNING_KEY_ID", "")
+		t.Setenv("IDPPROXY_SIGNING_KEY_PEM_BASE64", "cGVt")
+
+		cfg, err := LoadSigningKeyConfig()
+		require.Nil(t, cfg)
+		require.EqualError(t, err, "IDPPROXY_SIGNING_KEY_ID is not set")
+	})
+
+	t.Run("invalid base64", func(t *testing.T) {
+		t.Setenv("IDPPROXY_SIGNING_KMS_KEY_VERSION", "")
+		t.Setenv("IDPPROXY_SIGNING_KEY_ID", "key-1")
+		t.Setenv("IDPPROXY_SIGNING_KEY_PEM_BASE64", "%%%")
+
+		cfg, err := LoadSigningKeyConfig()
+		require.Nil(t, cfg)
+		require.ErrorContains(t, err, "failed to decode IDPPROXY_SIGNING_KEY_PEM_BASE64")
+	})
+}
+
+func TestLoadSigningKeyRingConfig(t *testing.T) {
+	t.Run("falls back to single key", func(t *testing.T) {
+		t.Setenv("IDPPROXY_SIGNING_KEYS_JSON", "")
+		t.Setenv("IDPPROXY_SIGNING_KMS_KEY_VERSION", "projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1")
+
+		keys, err := LoadSigningKeyRingConfig()
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.Equal(t, "projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1", keys[0].KMSKeyVersion)
+	})
+
+	t.Run("parses schedule", func(t *testing.T) {
+		t.Setenv("IDPPROXY_SIGNING_KEYS_JSON", `[
+			{"kms_key_version": "projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/2", "activate_at": "2025-02-01T00:00:00Z"},
+			{"kid": "old", "pem_base64": "cGVt", "retire_at": "2025-02-01T00:00:00Z", "remove_at": "2025-02-08T00:00:00Z"},
+			{"kid": "pinned", "pem_base64": "cGVt", "state": "retiring"}
+		]`)
+
+		keys, err := LoadSigningKeyRingConfig()
+		require.NoError(t, err)
+		require.Len(t, keys, 3)
+		require.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), keys[0].ActivateAt)
+		require.Equal(t, "old", keys[1].KeyID)
+		require.Equal(t, []byte("pem"), keys[1].PrivateKeyPEM)
+		require.Equal(t, time.Date(2025, 2, 8, 0, 0, 0, 0, time.UTC), keys[1].RemoveAt)
+		require.Equal(t, "retiring", keys[2].State)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		t.Setenv("IDPPROXY_SIGNING_KEYS_JSON", "{")
+
+		_, err := LoadSigningKeyRingConfig()
+		require.ErrorContains(t, err, "IDPPROXY_SIGNING_KEYS_JSON is invalid")
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		t.Setenv("IDPPROXY_SIGNING_KEYS_JSON", "[]")
+
+		_, err := LoadSigningKeyRingConfig()
+		require.EqualError(t, err, "IDPPROXY_SIGNING_KEYS_JSON has no keys")
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		t.Setenv("IDPPROXY_SIGNING_KEYS_JSON", `[{"kid": "k", "pem_base64": "cGVt", "state": "paused"}]`)
+
+		_, err := LoadSigningKeyRingConfig()
+		require.EqualError(t, err, `IDPPROXY_SIGNING_KEYS_JSON[0]: unknown state "paused"`)
+	})
+
+	t.Run("missing key material", func(t *testing.T) {
+		t.Setenv("IDPPROXY_SIGNING_KEYS_JSON", `[{"kid": "k"}]`)
+
+		_, err := LoadSigningKeyRingConfig()
+		require.EqualError(t, err, "IDPPROXY_SIGNING_KEYS_JSON[0]: kms_key_version or kid and pem_base64 are required")
+	})
+}
+
+func TestLoadUpstreamOIDCConfig(t *testing.T) {
+	t.Run("unset", func(t *testing.T) {
+		t.Setenv("IDPPROXY_UPSTREAM_OIDC_JSON", "")
+
+		cfgs, err := LoadUpstreamOIDCConfig()
+		require.NoError(t, err)
+		require.Empty(t, cfgs)
+	})
+
+	t.Run("parses providers", func(t *testing.T) {
+		t.Setenv("IDPPROXY_UPSTREAM_OIDC_JSON", `[
+			{"id": "okta", "name": "Okta", "issuer": "https://example.okta.com", "client_id": "cid", "client_secret": "sec", "redirect_uri": "https://idp.example.com/oidc/okta/callback"},
+			{"id": "keycloak", "issuer": "https://kc.example.com/realms/main", "client_id": "kc", "redirect_uri": "https://idp.example.com/oidc/keycloak/callback", "scopes": ["email"]}
+		]`)
+
+		cfgs, err := LoadUpstreamOIDCConfig()
+		require.NoError(t, err)
+		require.Len(t, cfgs, 2)
+		require.Equal(t, "Okta", cfgs[0].Name)
+		require.Equal(t, "sec", cfgs[0].ClientSecret)
+		require.Equal(t, UpstreamOIDCDefaultScopes, cfgs[0].Scopes)
+		require.Equal(t, "keycloak", cfgs[1].Name)
+		require.Equal(t, []string{"openid", "email"}, cfgs[1].Scopes)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		t.Setenv("IDPPROXY_UPSTREAM_OIDC_JSON", "{")
+
+		_, err := LoadUpstreamOIDCConfig()
+		require.ErrorContains(t, err, "IDPPROXY_UPSTREAM_OIDC_JSON is invalid")
+	})
+
+	t.Run("rejects invalid id", func(t *testing.T) {
+		t.Setenv("IDPPROXY_UPSTREAM_OIDC_JSON", `[{"id": "Okta/1", "issuer": "https://a.example.com", "client_id": "c", "redirect_uri": "https://b.example.com/cb"}]`)
+
+		_, err := LoadUpstreamOIDCConfig()
+		require.EqualError(t, err, "IDPPROXY_UPSTREAM_OIDC_JSON[0]: id must be lowercase letters, digits or '-'")
+	})
+
+	t.Run("rejects reserved id", func(t *testing.T) {
+		t.Setenv("IDPPROXY_UPSTREAM_OIDC_JSON", `[{"id": "github", "issuer": "https://a.example.com", "client_id": "c", "redirect_uri": "https://b.example.com/cb"}]`)
+
+		_, err := LoadUpstreamOIDCConfig()
+		require.EqualError(t, err, `IDPPROXY_UPSTREAM_OIDC_JSON[0]: id "github" is already in use`)
+	})
+
+	t.Run("rejects relative issuer", func(t *testing.T) {
+		t.Setenv("IDPPROXY_UPSTREAM_OIDC_JSON", `[{"id": "okta", "issuer": "okta", "client_id": "c", "redirect_uri": "https://b.example.com/cb"}]`)
+
+		_, err := LoadUpstreamOIDCConfig()
+		require.EqualError(t, err, "IDPPROXY_UPSTREAM_OIDC_JSON[0]: issuer must be an absolute http(s) URL")
+	})
+
+	t.Run("requires client id", func(t *testing.T) {
+		t.Setenv("IDPPROXY_UPSTREAM_OIDC_JSON", `[{"id": "okta", "issuer": "https://a.example.com", "redirect_uri": "https://b.example.com/cb"}]`)
+
+		_, err := LoadUpstreamOIDCConfig()
+		require.EqualError(t, err, "IDPPROXY_UPSTREAM_OIDC_JSON[0]: client_id is required")
+	})
+}
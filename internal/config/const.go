@@ -7,11 +7,16 @@ const (
 	// for GitHub OAuth
 	GitHubAllowSignup = "true"
 	GitHubScope       = "read:user"
+	GitHubOrgScope    = "read:org"
+	GitHubEmailScope  = "user:email"
 
 	// for GitHub API
 	GitHubAPIBaseURL = "https://api.github.com"
 	GitHubAPIVersion = "2022-11-28"
 	GitHubUserURL    = GitHubAPIBaseURL + "/user"
+	GitHubOrgsURL    = GitHubUserURL + "/orgs"
+	GitHubTeamsURL   = GitHubUserURL + "/teams"
+	GitHubEmailsURL  = GitHubUserURL + "/emails"
 
 	// for BuildGitHubLoginURL
 	GitHubAuthorizeURL = "https://github.com/login/oauth/authorize"
@@ -25,6 +30,17 @@ const (
 	// for OAuth state (bytes of entropy)
 	OAuthStateBytes = 16
 
+	// for /revoke of access tokens
+	AccessTokenRevocationGeneration = "generation"
+	AccessTokenRevocationDenylist   = "denylist"
+
+	// for IDPPROXY_GITHUB_CLAIMS
+	ClaimGroups     = "groups"
+	ClaimGitHubOrgs = "github_orgs"
+
 	// for UserAgent
 	UserAgentProduct = "idpproxy"
 )
+
+// for upstream OIDC providers without explicit scopes
+var UpstreamOIDCDefaultScopes = []string{"openid", "email", "profile"}
@@ -0,0 +1,44 @@
+package deps
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/backchannel"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	authorizestore "github.com/vinylhousegarage/idpproxy/internal/authorize/store"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/httpclient"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+type OIDCDependencies struct {
+	Config *config.OIDCConfig
+	Logger *zap.Logger
+
+	// optional
+	Signer                signer.Signer
+	ProxyCodes            authcodestore.Store
+	RefreshTokens         store.RefreshRepo
+	AccessGenerations     store.AccessGenRepo
+	AccessDenylist        store.AccessDenylist
+	Authorizations        authorizestore.Store
+	Clients               client.Registry
+	Users                 users.Repository
+	Sessions              session.Repository
+	BackchannelDeliveries store.BackchannelDeliveryLog
+	// delivers back-channel logouts in the background; the owner closes it on
+	// shutdown. Without it, logouts are delivered inline.
+	Backchannel *backchannel.Queue
+	HTTPClient  httpclient.HTTPClient
+}
+
+func NewOIDCDeps(cfg *config.OIDCConfig, logger *zap.Logger) *OIDCDependencies {
+	return &OIDCDependencies{
+		Config: cfg,
+		Logger: logger,
+	}
+}
@@ -0,0 +1,121 @@
+package loginpolicy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+const googleIssuer = "https://accounts.google.com"
+
+type GitHubMembership interface {
+	Orgs(ctx context.Context, accessToken string) ([]string, error)
+	Teams(ctx context.Context, accessToken string) ([]string, error)
+}
+
+// Engine evaluates a client's LoginPolicy against a freshly authenticated
+// upstream identity.
+type Engine struct {
+	// optional; without it GitHub org and team rules never match
+	GitHub GitHubMembership
+}
+
+func NewEngine(github GitHubMembership) *Engine {
+	return &Engine{GitHub: github}
+}
+
+// Allow returns ErrDenied unless the identity matches one of the client's
+// rules. Lookup failures are reported separately so they are not mistaken
+// for a denial.
+func (e *Engine) Allow(ctx context.Context, cl *client.Client, id *upstream.Identity) error {
+	p := cl.LoginPolicy
+	if p.IsZero() {
+		return nil
+	}
+	if id == nil {
+		return ErrDenied
+	}
+
+	if hd := googleHostedDomain(id); hd != "" && containsFold(p.GoogleDomains, hd) {
+		return nil
+	}
+
+	if id.Provider != users.ProviderGitHub {
+		return ErrDenied
+	}
+
+	if len(p.GitHubOrgs) > 0 {
+		orgs, err := e.membership(ctx, id, id.GitHubOrgs, GitHubMembership.Orgs)
+		if err != nil {
+			return err
+		}
+		if anyFold(p.GitHubOrgs, orgs) {
+			return nil
+		}
+	}
+
+	if len(p.GitHubTeams) > 0 {
+		teams, err := e.membership(ctx, id, id.GitHubTeams, GitHubMembership.Teams)
+		if err != nil {
+			return err
+		}
+		if anyFold(p.GitHubTeams, teams) {
+			return nil
+		}
+	}
+
+	return ErrDenied
+}
+
+// membership prefers what the connector already fetched at login.
+func (e *Engine) membership(
+	ctx context.Context,
+	id *upstream.Identity,
+	fetched []string,
+	lookup func(GitHubMembership, context.Context, string) ([]string, error),
+) ([]string, error) {
+	if fetched != nil {
+		return fetched, nil
+	}
+	if e.GitHub == nil || id.AccessToken == "" {
+		return nil, nil
+	}
+
+	names, err := lookup(e.GitHub, ctx, id.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMembershipLookup, err)
+	}
+
+	return names, nil
+}
+
+// googleHostedDomain returns the hd claim only when Google itself issued the
+// token; any other upstream could put an arbitrary hd in it. Firebase ID
+// tokens carry no hd, and an email domain does not prove Workspace
+// membership, so Firebase sign-ins never match a domain rule.
+func googleHostedDomain(id *upstream.Identity) string {
+	if id.Provider == users.ProviderGoogle {
+		return ""
+	}
+
+	hd, _ := id.Claims["hd"].(string)
+	if iss, _ := id.Claims["iss"].(string); iss == googleIssuer || iss == "accounts.google.com" {
+		return hd
+	}
+
+	return ""
+}
+
+// GitHub logins and slugs are case-insensitive, as are domain names.
+func containsFold(list []string, v string) bool {
+	return slices.ContainsFunc(list, func(s string) bool { return strings.EqualFold(s, v) })
+}
+
+func anyFold(allowed, have []string) bool {
+	return slices.ContainsFunc(have, func(v string) bool { return containsFold(allowed, v) })
+}
@@ -0,0 +1,117 @@
+package loginpolicy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+)
+
+type fakeMembership struct {
+	orgs  []string
+	teams []string
+	err   error
+	calls int
+}
+
+func (f *fakeMembership) Orgs(context.Context, string) ([]string, error) {
+	f.calls++
+	return f.orgs, f.err
+}
+
+func (f *fakeMembership) Teams(context.Context, string) ([]string, error) {
+	f.calls++
+	return f.teams, f.err
+}
+
+// firebaseIdentity mirrors what the Firebase provider builds from a verified
+// ID token: the SDK strips iss/aud/sub from Claims and no hd is present.
+func firebaseIdentity(uid, email string) *upstream.Identity {
+	return &upstream.Identity{
+		Provider:      "google",
+		Subject:       uid,
+		Email:         email,
+		EmailVerified: true,
+		Claims: map[string]any{
+			"name":           "Jane Doe",
+			"picture":        "https://lh3.googleusercontent.com/a/photo",
+			"auth_time":      float64(1_725_000_000),
+			"user_id":        uid,
+			"email":          email,
+			"email_verified": true,
+			"firebase": map[string]any{
+				"identities": map[string]any{
+					"google.com": []any{"1234567890"},
+					"email":      []any{email},
+				},
+				"sign_in_provider": "google.com",
+			},
+		},
+	}
+}
+
+func TestEngine_Allow(t *testing.T) {
+	t.Parallel()
+
+	github := &upstream.Identity{Provider: "github", Subject: "42", AccessToken: "gh-token"}
+	firebaseWorkspace := firebaseIdentity("uid-1", "jane@acme.com")
+	gmail := firebaseIdentity("uid-2", "jane@gmail.com")
+	googleOIDC := &upstream.Identity{Provider: "corp", Subject: "s-1", Claims: map[string]any{"iss": "https://accounts.google.com", "hd": "Acme.com"}}
+	spoofedHD := &upstream.Identity{Provider: "corp", Subject: "s-2", Claims: map[string]any{"iss": "https://sso.example.com", "hd": "acme.com"}}
+
+	for _, tc := range []struct {
+		name       string
+		policy     client.LoginPolicy
+		membership *fakeMembership
+		id         *upstream.Identity
+		want       error
+	}{
+		{"empty policy admits everyone", client.LoginPolicy{}, &fakeMembership{}, gmail, nil},
+		{"org member", client.LoginPolicy{GitHubOrgs: []string{"acme"}}, &fakeMembership{orgs: []string{"ACME"}}, github, nil},
+		{"not an org member", client.LoginPolicy{GitHubOrgs: []string{"acme"}}, &fakeMembership{orgs: []string{"globex"}}, github, ErrDenied},
+		{"team member", client.LoginPolicy{GitHubTeams: []string{"acme/admins"}}, &fakeMembership{teams: []string{"acme/admins"}}, github, nil},
+		{"other team", client.LoginPolicy{GitHubTeams: []string{"acme/admins"}}, &fakeMembership{teams: []string{"acme/interns"}}, github, ErrDenied},
+		{"allowlisted domain via google oidc upstream", client.LoginPolicy{GoogleDomains: []string{"acme.com"}}, &fakeMembership{}, googleOIDC, nil},
+		{"firebase google sign-in has no workspace domain", client.LoginPolicy{GoogleDomains: []string{"acme.com"}}, &fakeMembership{}, firebaseWorkspace, ErrDenied},
+		{"hd from another issuer", client.LoginPolicy{GoogleDomains: []string{"acme.com"}}, &fakeMembership{}, spoofedHD, ErrDenied},
+		{"no identity", client.LoginPolicy{GoogleDomains: []string{"acme.com"}}, &fakeMembership{}, nil, ErrDenied},
+		{"consumer account", client.LoginPolicy{GoogleDomains: []string{"acme.com"}}, &fakeMembership{}, gmail, ErrDenied},
+		{"github rules do not admit google", client.LoginPolicy{GitHubOrgs: []string{"acme"}}, &fakeMembership{orgs: []string{"acme"}}, gmail, ErrDenied},
+		{"lookup failure", client.LoginPolicy{GitHubOrgs: []string{"acme"}}, &fakeMembership{err: errors.New("403")}, github, ErrMembershipLookup},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cl := &client.Client{ID: "client-1", LoginPolicy: tc.policy}
+			err := NewEngine(tc.membership).Allow(context.Background(), cl, tc.id)
+			if tc.want == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.want)
+		})
+	}
+
+	t.Run("uses membership fetched at login", func(t *testing.T) {
+		t.Parallel()
+
+		m := &fakeMembership{}
+		id := &upstream.Identity{Provider: "github", Subject: "42", AccessToken: "gh-token", GitHubOrgs: []string{"acme"}, GitHubTeams: []string{}}
+		cl := &client.Client{LoginPolicy: client.LoginPolicy{GitHubOrgs: []string{"globex"}, GitHubTeams: []string{"acme/admins"}}}
+		require.ErrorIs(t, NewEngine(m).Allow(context.Background(), cl, id), ErrDenied)
+		require.Zero(t, m.calls)
+	})
+
+	t.Run("domain match skips github lookups", func(t *testing.T) {
+		t.Parallel()
+
+		m := &fakeMembership{}
+		cl := &client.Client{LoginPolicy: client.LoginPolicy{GitHubOrgs: []string{"acme"}, GoogleDomains: []string{"acme.com"}}}
+		require.NoError(t, NewEngine(m).Allow(context.Background(), cl, googleOIDC))
+		require.Zero(t, m.calls)
+	})
+}
@@ -0,0 +1,8 @@
+package loginpolicy
+
+import "errors"
+
+var (
+	ErrDenied           = errors.New("loginpolicy: access denied")
+	ErrMembershipLookup = errors.New("loginpolicy: github membership lookup failed")
+)
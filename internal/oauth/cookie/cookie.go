@@ -5,9 +5,14 @@ import (
 	"time"
 )
 
+const (
+	IDTokenCookieName = "id_token"
+	SessionCookieName = "idpproxy_session"
+)
+
 func SetIDTokenCookie(w http.ResponseWriter, idToken string) {
 	http.SetCookie(w, &http.Cookie{
-		Name:     "id_token",
+		Name:     IDTokenCookieName,
 		Value:    idToken,
 		HttpOnly: true,
 		Secure:   true,
@@ -16,3 +21,35 @@ func SetIDTokenCookie(w http.ResponseWriter, idToken string) {
 		Expires:  time.Now().Add(15 * time.Minute),
 	})
 }
+
+func SetSessionCookie(w http.ResponseWriter, sessionID string, expiresAt time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    sessionID,
+		HttpOnly: true,
+		Secure:   true,
+		Path:     "/",
+		SameSite: http.SameSiteLaxMode,
+		Expires:  expiresAt,
+	})
+}
+
+func ClearIDTokenCookie(w http.ResponseWriter) {
+	http.SetCookie(w, expired(IDTokenCookieName))
+}
+
+func ClearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, expired(SessionCookieName))
+}
+
+func expired(name string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    "",
+		HttpOnly: true,
+		Secure:   true,
+		Path:     "/",
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+	}
+}
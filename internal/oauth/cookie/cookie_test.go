@@ -31,3 +31,24 @@ func TestSetIDTokenCookie(t *testing.T) {
 	now := time.Now()
 	require.WithinDuration(t, now.Add(15*time.Minute), cookie.Expires, time.Minute)
 }
+
+func TestClearCookies(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+
+	ClearIDTokenCookie(rr)
+	ClearSessionCookie(rr)
+
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 2)
+
+	require.Equal(t, IDTokenCookieName, cookies[0].Name)
+	require.Equal(t, SessionCookieName, cookies[1].Name)
+	for _, c := range cookies {
+		require.Empty(t, c.Value)
+		require.Equal(t, -1, c.MaxAge)
+		require.Equal(t, "/", c.Path)
+		require.True(t, c.Secure)
+	}
+}
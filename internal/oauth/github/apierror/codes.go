@@ -1,6 +1,9 @@
 package apierror
 
 const (
+	// login
+	ErrorCodeInvalidCodeChallenge ErrorCode = "invalid_code_challenge"
+
 	// callback
 	ErrorCodeMissingGitHubCode  ErrorCode = "missing_github_code"
 	ErrorCodeMissingState       ErrorCode = "missing_state"
@@ -8,6 +11,13 @@ const (
 	ErrorCodeInvalidQueryState  ErrorCode = "invalid_query_state"
 	ErrorCodeInvalidState       ErrorCode = "invalid_state"
 
+	// authorize
+	ErrorCodeInvalidAuthorizationRequest ErrorCode = "invalid_authorization_request"
+
+	// account link
+	ErrorCodeReauthenticationRequired ErrorCode = "reauthentication_required"
+	ErrorCodeIdentityLinked           ErrorCode = "identity_already_linked"
+
 	// token
 	ErrorCodeBuildAccessTokenRequest  ErrorCode = "build_access_token_request_failed"
 	ErrorCodeGitHubAccessTokenRequest ErrorCode = "github_access_token_request_failed"
@@ -18,6 +28,7 @@ const (
 	ErrorCodeGitHubUserRequestBuild ErrorCode = "github_user_request_build_failed"
 	ErrorCodeGitHubUserRequest      ErrorCode = "github_user_request_failed"
 	ErrorCodeGitHubUserDecode       ErrorCode = "github_user_decode_failed"
+	ErrorCodeGitHubUserDetails      ErrorCode = "github_user_details_request_failed"
 
 	// internal
 	ErrorCodeInternalServerError ErrorCode = "internal_server_error"
@@ -3,6 +3,9 @@ package apierror
 import "errors"
 
 var (
+	// login
+	ErrInvalidCodeChallenge = errors.New(string(ErrorCodeInvalidCodeChallenge))
+
 	// callback
 	ErrMissingGitHubCode  = errors.New(string(ErrorCodeMissingGitHubCode))
 	ErrMissingState       = errors.New(string(ErrorCodeMissingState))
@@ -10,6 +13,13 @@ var (
 	ErrInvalidQueryState  = errors.New(string(ErrorCodeInvalidQueryState))
 	ErrInvalidState       = errors.New(string(ErrorCodeInvalidState))
 
+	// authorize
+	ErrInvalidAuthorizationRequest = errors.New(string(ErrorCodeInvalidAuthorizationRequest))
+
+	// account link
+	ErrReauthenticationRequired = errors.New(string(ErrorCodeReauthenticationRequired))
+	ErrIdentityLinked           = errors.New(string(ErrorCodeIdentityLinked))
+
 	// token
 	ErrBuildAccessTokenRequest  = errors.New(string(ErrorCodeBuildAccessTokenRequest))
 	ErrGitHubAccessTokenRequest = errors.New(string(ErrorCodeGitHubAccessTokenRequest))
@@ -20,6 +30,7 @@ var (
 	ErrGitHubUserRequestBuild = errors.New(string(ErrorCodeGitHubUserRequestBuild))
 	ErrGitHubUserRequest      = errors.New(string(ErrorCodeGitHubUserRequest))
 	ErrGitHubUserDecode       = errors.New(string(ErrorCodeGitHubUserDecode))
+	ErrGitHubUserDetails      = errors.New(string(ErrorCodeGitHubUserDetails))
 
 	// internal
 	ErrInternalServerError = errors.New(string(ErrorCodeInternalServerError))
@@ -2,6 +2,11 @@ package apierror
 
 import "net/http"
 
+// login
+func InvalidCodeChallenge(err error, internals ...APIInternal) *APIError {
+	return New(ErrorCodeInvalidCodeChallenge, http.StatusBadRequest, err, internals...)
+}
+
 // callback
 func MissingGitHubCode(err error, internals ...APIInternal) *APIError {
 	return New(ErrorCodeMissingGitHubCode, http.StatusBadRequest, err, internals...)
@@ -15,6 +20,20 @@ func InvalidState(err error, internals ...APIInternal) *APIError {
 	return New(ErrorCodeInvalidState, http.StatusBadRequest, err, internals...)
 }
 
+// authorize
+func InvalidAuthorizationRequest(err error, internals ...APIInternal) *APIError {
+	return New(ErrorCodeInvalidAuthorizationRequest, http.StatusBadRequest, err, internals...)
+}
+
+// account link
+func ReauthenticationRequired(err error, internals ...APIInternal) *APIError {
+	return New(ErrorCodeReauthenticationRequired, http.StatusUnauthorized, err, internals...)
+}
+
+func IdentityLinked(err error, internals ...APIInternal) *APIError {
+	return New(ErrorCodeIdentityLinked, http.StatusConflict, err, internals...)
+}
+
 // token
 func GitHubAccessTokenRequestError(err error, internals ...APIInternal) *APIError {
 	return New(ErrorCodeGitHubAccessTokenRequest, http.StatusBadGateway, err, internals...)
@@ -41,6 +60,10 @@ func GitHubUserDecodeError(err error, internals ...APIInternal) *APIError {
 	return New(ErrorCodeGitHubUserDecode, http.StatusBadGateway, err, internals...)
 }
 
+func GitHubUserDetailsError(err error, internals ...APIInternal) *APIError {
+	return New(ErrorCodeGitHubUserDetails, http.StatusBadGateway, err, internals...)
+}
+
 // internal
 func InternalServerError(err error, internals ...APIInternal) *APIError {
 	return New(ErrorCodeInternalServerError, http.StatusInternalServerError, err, internals...)
@@ -19,6 +19,18 @@ func TestAPIErrors(t *testing.T) {
 		expectedStatus int
 		internalsInfo  []APIInternal
 	}{
+		{
+			name:           "InvalidCodeChallenge",
+			fn:             InvalidCodeChallenge,
+			expectedCode:   ErrorCodeInvalidCodeChallenge,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidAuthorizationRequest",
+			fn:             InvalidAuthorizationRequest,
+			expectedCode:   ErrorCodeInvalidAuthorizationRequest,
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:           "MissingGitHubCode",
 			fn:             MissingGitHubCode,
@@ -77,6 +89,24 @@ func TestAPIErrors(t *testing.T) {
 			expectedCode:   ErrorCodeGitHubUserDecode,
 			expectedStatus: http.StatusBadGateway,
 		},
+		{
+			name:           "GitHubUserDetailsError",
+			fn:             GitHubUserDetailsError,
+			expectedCode:   ErrorCodeGitHubUserDetails,
+			expectedStatus: http.StatusBadGateway,
+		},
+		{
+			name:           "ReauthenticationRequired",
+			fn:             ReauthenticationRequired,
+			expectedCode:   ErrorCodeReauthenticationRequired,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "IdentityLinked",
+			fn:             IdentityLinked,
+			expectedCode:   ErrorCodeIdentityLinked,
+			expectedStatus: http.StatusConflict,
+		},
 		{
 			name:           "InternalServerError",
 			fn:             InternalServerError,
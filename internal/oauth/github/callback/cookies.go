@@ -1,8 +1,13 @@
 package callback
 
-import "net/http"
+import (
+	"net/http"
+)
 
-const stateCookieName = "oauth_state"
+const (
+	pkceCookieName  = "oauth_pkce"
+	stateCookieName = "oauth_state"
+)
 
 func deleteStateCookie() *http.Cookie {
 	return &http.Cookie{
@@ -16,6 +21,18 @@ func deleteStateCookie() *http.Cookie {
 	}
 }
 
+func deletePKCECookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     pkceCookieName,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+	}
+}
+
 func safeCookieVal(c *http.Cookie) string {
 	if c == nil || c.Value == "" {
 		return ""
@@ -8,6 +8,8 @@ import (
 	"net/http"
 
 	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
 )
 
 type fakeHTTPClient struct {
@@ -53,7 +55,7 @@ type fakeUserService struct {
 	err      error
 }
 
-func (s *fakeUserService) UpsertFromGitHub(_ context.Context, _ int64, _ string, _ string) (string, error) {
+func (s *fakeUserService) UpsertProfile(_ context.Context, _ string, _ users.Profile) (string, error) {
 	if s.err != nil {
 		return "", s.err
 	}
@@ -61,22 +63,23 @@ func (s *fakeUserService) UpsertFromGitHub(_ context.Context, _ int64, _ string,
 	return s.returnID, nil
 }
 
-type fakeProxyCodeService struct {
-	proxyCode string
-	err       error
-	called    bool
+func (s *fakeUserService) LinkProfile(_ context.Context, _, _ string, _ users.Profile) error {
+	return s.err
 }
 
-func (f *fakeProxyCodeService) Issue(
-	_ context.Context,
-	_ string,
-	_ string,
-) (string, error) {
-	f.called = true
+type fakeAuthorizationCompleter struct {
+	location string
+	ok       bool
+	err      error
+	userID   string
+}
 
-	if f.err != nil {
-		return "", f.err
-	}
+func (f *fakeAuthorizationCompleter) Complete(
+	_ http.ResponseWriter,
+	_ *http.Request,
+	res *upstream.Result,
+) (string, bool, error) {
+	f.userID = res.UserID
 
-	return f.proxyCode, nil
+	return f.location, f.ok, f.err
 }
@@ -1,22 +1,16 @@
 package callback
 
 import (
-	"context"
+	"errors"
 	"net/http"
-	"net/url"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/vinylhousegarage/idpproxy/internal/oauth/github/apierror"
-	githubtoken "github.com/vinylhousegarage/idpproxy/internal/oauth/github/token"
-	githubuser "github.com/vinylhousegarage/idpproxy/internal/oauth/github/user"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/github/connector"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
 )
 
-func callbackSuccessLocation(proxyCode, qState string) string {
-	return "/oauth/github/callback/success?code=" + url.QueryEscape(proxyCode) +
-		"&state=" + url.QueryEscape(qState)
-}
-
 func (h *GitHubCallbackHandler) Serve(c *gin.Context) {
 	if !h.ready() {
 		h.notReady(c.Writer)
@@ -48,94 +42,72 @@ func (h *GitHubCallbackHandler) Serve(c *gin.Context) {
 		_ = c.Error(apiErr)
 
 		http.SetCookie(c.Writer, deleteStateCookie())
+		http.SetCookie(c.Writer, deletePKCECookie())
 
 		return
 	}
 
 	http.SetCookie(c.Writer, deleteStateCookie())
+	http.SetCookie(c.Writer, deletePKCECookie())
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
-	defer cancel()
-
-	req, err := githubtoken.BuildAccessTokenRequest(ctx, h.OAuth.Config, githubCode, qState)
-	if err != nil {
-		apiErr := apierror.GitHubAccessTokenRequestError(apierror.ErrGitHubAccessTokenRequest)
-		_ = c.Error(apiErr)
-
-		return
-	}
-
-	resp, err := h.API.HTTPClient.Do(req)
-	if err != nil {
-		apiErr := apierror.GitHubTokenRequestError(apierror.ErrGitHubTokenRequest)
-		_ = c.Error(apiErr)
-
-		return
-	}
-
-	defer resp.Body.Close()
-
-	githubAccessToken, err := githubtoken.ExtractAccessTokenFromResponse(resp)
-	if err != nil {
-		apiErr := apierror.GitHubTokenExchangeError(apierror.ErrGitHubTokenExchange)
-		_ = c.Error(apiErr)
-
-		return
-	}
+	auth := h.authenticator()
 
-	githubUserReq, err := githubuser.NewGitHubUserRequest(ctx, githubAccessToken)
+	res, err := auth.Authenticate(c.Writer, c.Request, upstream.Transaction{State: qState})
 	if err != nil {
-		apiErr := apierror.GitHubUserRequestBuildError(apierror.ErrGitHubUserRequestBuild)
-		_ = c.Error(apiErr)
+		_ = c.Error(authenticateError(err))
 
 		return
 	}
 
-	githubUserResp, err := h.API.HTTPClient.Do(githubUserReq)
-	if err != nil {
-		apiErr := apierror.GitHubUserRequestError(apierror.ErrGitHubUserRequest)
-		_ = c.Error(apiErr)
+	// the GitHub leg only finishes a pending /authorize request or an
+	// account link; there is no client to hand a code to otherwise
+	location, ok, err := auth.Resume(c.Writer, c.Request, res)
+	if err == nil && !ok && res.Linked {
+		c.JSON(http.StatusOK, upstream.LinkedBody(res.Identity.Provider))
 
 		return
 	}
-
-	defer githubUserResp.Body.Close()
-
-	githubUser, err := githubuser.DecodeGitHubUserResponse(githubUserResp)
-	if err != nil {
-		apiErr := apierror.GitHubUserDecodeError(apierror.ErrGitHubUserDecode)
-		_ = c.Error(apiErr)
+	if err != nil || !ok {
+		_ = c.Error(apierror.InvalidAuthorizationRequest(apierror.ErrInvalidAuthorizationRequest))
 
 		return
 	}
 
-	internalUserID, err := h.UserService.UpsertFromGitHub(
-		ctx,
-		githubUser.ID,
-		githubUser.Login,
-		githubUser.Email,
-	)
-	if err != nil {
-		apiErr := apierror.UserUpsertError(apierror.ErrUserUpsert)
-		_ = c.Error(apiErr)
+	c.Redirect(http.StatusFound, location)
+}
 
-		return
+func (h *GitHubCallbackHandler) authenticator() *upstream.Authenticator {
+	return &upstream.Authenticator{
+		Provider:       connector.NewProvider(h.OAuth.Config, h.API.HTTPClient),
+		Users:          h.UserService,
+		Authorizations: h.Authorizations,
+		Links:          h.Links,
 	}
+}
 
-	proxyCode, err := h.ProxyCodeService.Issue(
-		ctx,
-		internalUserID,
-		h.ClientID,
-	)
-	if err != nil {
-		apiErr := apierror.ProxyCodeIssueError(apierror.ErrProxyCodeIssue)
-		_ = c.Error(apiErr)
-
-		return
+func authenticateError(err error) error {
+	switch {
+	case errors.Is(err, connector.ErrMissingCode):
+		return apierror.MissingGitHubCode(apierror.ErrMissingGitHubCode)
+	case errors.Is(err, connector.ErrTokenRequestBuild):
+		return apierror.GitHubAccessTokenRequestError(apierror.ErrGitHubAccessTokenRequest)
+	case errors.Is(err, connector.ErrTokenRequest):
+		return apierror.GitHubTokenRequestError(apierror.ErrGitHubTokenRequest)
+	case errors.Is(err, connector.ErrTokenExchange):
+		return apierror.GitHubTokenExchangeError(apierror.ErrGitHubTokenExchange)
+	case errors.Is(err, connector.ErrUserRequestBuild):
+		return apierror.GitHubUserRequestBuildError(apierror.ErrGitHubUserRequestBuild)
+	case errors.Is(err, connector.ErrUserRequest):
+		return apierror.GitHubUserRequestError(apierror.ErrGitHubUserRequest)
+	case errors.Is(err, connector.ErrUserDecode):
+		return apierror.GitHubUserDecodeError(apierror.ErrGitHubUserDecode)
+	case errors.Is(err, connector.ErrEmailsRequest), errors.Is(err, connector.ErrMembershipRequest):
+		return apierror.GitHubUserDetailsError(apierror.ErrGitHubUserDetails)
+	case errors.Is(err, upstream.ErrReauthenticationRequired):
+		return apierror.ReauthenticationRequired(apierror.ErrReauthenticationRequired)
+	case errors.Is(err, users.ErrIdentityLinked):
+		return apierror.IdentityLinked(apierror.ErrIdentityLinked)
+	default:
+		return apierror.UserUpsertError(apierror.ErrUserUpsert)
 	}
-
-	c.Redirect(
-		http.StatusFound,
-		callbackSuccessLocation(proxyCode, qState),
-	)
 }
@@ -1,8 +1,8 @@
 package callback
 
 import (
+	"errors"
 	"net/http"
-	"net/url"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -16,14 +16,14 @@ func TestGitHubCallbackHandler_Serve(t *testing.T) {
 	tokenJSON := loadTestDataJSON(t, "testdata/token_success.json")
 	userJSON := loadTestDataJSON(t, "testdata/user_success.json")
 
-	t.Run("successfully_exchanges_code_and_redirects_and_deletes_state_cookie", func(t *testing.T) {
+	t.Run("resumes_pending_authorization_and_deletes_state_cookie", func(t *testing.T) {
 		t.Parallel()
 
 		httpc := &fakeHTTPClient{tokenJSON: tokenJSON, userJSON: userJSON}
 		us := &fakeUserService{returnID: "user-internal-123"}
-		pcs := &fakeProxyCodeService{proxyCode: "proxycode-123"}
+		authz := &fakeAuthorizationCompleter{location: "https://app.example.com/cb?code=c1&state=client-st", ok: true}
 
-		h := newHandlerForTest(t, httpc, us, pcs)
+		h := newHandlerForTest(t, httpc, us, authz)
 
 		rr, req := newCallbackRequest(t, "/oauth/github/callback", "code123", "st-abc")
 		setStateCookie(req, "st-abc")
@@ -37,26 +37,12 @@ func TestGitHubCallbackHandler_Serve(t *testing.T) {
 			t.Fatalf("expected 302, got=%d body=%s", rr.Code, rr.Body.String())
 		}
 
-		loc := rr.Header().Get("Location")
-		if loc == "" {
-			t.Fatalf("missing Location header")
+		if loc := rr.Header().Get("Location"); loc != authz.location {
+			t.Fatalf("expected Location=%s, got=%s", authz.location, loc)
 		}
 
-		u, err := url.Parse(loc)
-		if err != nil {
-			t.Fatalf("invalid Location: %v (%s)", err, loc)
-		}
-
-		if got := u.Query().Get("code"); got != "proxycode-123" {
-			t.Fatalf("expected proxycode-123, got=%s (loc=%s)", got, loc)
-		}
-
-		if got := u.Query().Get("state"); got != "st-abc" {
-			t.Fatalf("expected state=st-abc, got=%s (loc=%s)", got, loc)
-		}
-
-		if !pcs.called {
-			t.Fatalf("ProxyCodeService.Issue was not called")
+		if authz.userID != "user-internal-123" {
+			t.Fatalf("expected completer to receive internal user id, got=%s", authz.userID)
 		}
 
 		assertStateCookieDeleted(t, rr)
@@ -67,9 +53,9 @@ func TestGitHubCallbackHandler_Serve(t *testing.T) {
 
 		httpc := &fakeHTTPClient{tokenJSON: tokenJSON, userJSON: userJSON}
 		us := &fakeUserService{returnID: "user-internal-123"}
-		pcs := &fakeProxyCodeService{proxyCode: "proxycode-123"}
+		authz := &fakeAuthorizationCompleter{ok: true}
 
-		h := newHandlerForTest(t, httpc, us, pcs)
+		h := newHandlerForTest(t, httpc, us, authz)
 
 		rr, req := newCallbackRequest(t, "/oauth/github/callback", "code123", "st-abc")
 		setStateCookie(req, "st-wrong")
@@ -90,8 +76,8 @@ func TestGitHubCallbackHandler_Serve(t *testing.T) {
 			t.Fatalf("expected error=%s, got=%s", apierror.ErrorCodeInvalidState, resp.Error)
 		}
 
-		if pcs.called {
-			t.Fatalf("ProxyCodeService.Issue must not be called on invalid state")
+		if authz.userID != "" {
+			t.Fatalf("completer must not be called on invalid state")
 		}
 
 		assertStateCookieDeleted(t, rr)
@@ -106,9 +92,9 @@ func TestGitHubCallbackHandler_Serve(t *testing.T) {
 			forceTokenErr: true,
 		}
 		us := &fakeUserService{returnID: "user-internal-123"}
-		pcs := &fakeProxyCodeService{proxyCode: "proxycode-123"}
+		authz := &fakeAuthorizationCompleter{ok: true}
 
-		h := newHandlerForTest(t, httpc, us, pcs)
+		h := newHandlerForTest(t, httpc, us, authz)
 
 		rr, req := newCallbackRequest(t, "/oauth/github/callback", "code123", "st-abc")
 		setStateCookie(req, "st-abc")
@@ -130,8 +116,65 @@ func TestGitHubCallbackHandler_Serve(t *testing.T) {
 			t.Fatalf("expected error=%s, got=%s", apierror.ErrorCodeGitHubTokenRequest, resp.Error)
 		}
 
-		if pcs.called {
-			t.Fatalf("ProxyCodeService.Issue must not be called when token exchange fails")
+		if authz.userID != "" {
+			t.Fatalf("completer must not be called when token exchange fails")
+		}
+	})
+
+	t.Run("returns_400_when_pending_authorization_cannot_be_completed", func(t *testing.T) {
+		t.Parallel()
+
+		httpc := &fakeHTTPClient{tokenJSON: tokenJSON, userJSON: userJSON}
+		us := &fakeUserService{returnID: "user-internal-123"}
+		authz := &fakeAuthorizationCompleter{ok: true, err: errors.New("expired")}
+
+		h := newHandlerForTest(t, httpc, us, authz)
+
+		rr, req := newCallbackRequest(t, "/oauth/github/callback", "code123", "st-abc")
+		setStateCookie(req, "st-abc")
+
+		_, r := gin.CreateTestContext(rr)
+		r.Use(apierror.ErrorLogger(h.OAuth.Logger))
+
+		r.GET("/oauth/github/callback", h.Serve)
+
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got=%d body=%s", rr.Code, rr.Body.String())
+		}
+
+		resp := decodeErrorResponse(t, rr)
+
+		if resp.Error != apierror.ErrorCodeInvalidAuthorizationRequest {
+			t.Fatalf("expected error=%s, got=%s", apierror.ErrorCodeInvalidAuthorizationRequest, resp.Error)
+		}
+	})
+
+	t.Run("returns_400_when_no_authorization_is_pending", func(t *testing.T) {
+		t.Parallel()
+
+		httpc := &fakeHTTPClient{tokenJSON: tokenJSON, userJSON: userJSON}
+		us := &fakeUserService{returnID: "user-internal-123"}
+
+		h := newHandlerForTest(t, httpc, us, &fakeAuthorizationCompleter{})
+
+		rr, req := newCallbackRequest(t, "/oauth/github/callback", "code123", "st-abc")
+		setStateCookie(req, "st-abc")
+
+		_, r := gin.CreateTestContext(rr)
+		r.Use(apierror.ErrorLogger(h.OAuth.Logger))
+
+		r.GET("/oauth/github/callback", h.Serve)
+
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got=%d body=%s", rr.Code, rr.Body.String())
+		}
+
+		if loc := rr.Header().Get("Location"); loc != "" {
+			t.Fatalf("expected no redirect, got Location=%s", loc)
 		}
 	})
 }
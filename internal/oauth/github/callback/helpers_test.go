@@ -21,7 +21,7 @@ func newHandlerForTest(
 	t *testing.T,
 	httpc *fakeHTTPClient,
 	us *fakeUserService,
-	pcs *fakeProxyCodeService,
+	authz *fakeAuthorizationCompleter,
 ) *GitHubCallbackHandler {
 	t.Helper()
 
@@ -35,7 +35,7 @@ func newHandlerForTest(
 		httpc,
 		logger,
 	)
-	return NewGitHubCallbackHandler(oauth, api, us, pcs, "test-client")
+	return NewGitHubCallbackHandler(oauth, api, us, authz)
 }
 
 func newCallbackRequest(t *testing.T, path, githubCode, state string) (*httptest.ResponseRecorder, *http.Request) {
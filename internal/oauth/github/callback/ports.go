@@ -1,13 +1,9 @@
 package callback
 
 import (
-	"context"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
 )
 
-type UserService interface {
-	UpsertFromGitHub(ctx context.Context, githubID int64, login, email string) (string, error)
-}
+type UserService = upstream.UserService
 
-type ProxyCodeService interface {
-	Issue(ctx context.Context, userID string, clientID string) (string, error)
-}
+type AuthorizationCompleter = upstream.AuthorizationCompleter
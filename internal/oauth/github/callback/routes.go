@@ -0,0 +1,11 @@
+package callback
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const Path = "/github/callback"
+
+func RegisterRoutes(r *gin.Engine, h *GitHubCallbackHandler) {
+	r.GET(Path, h.Serve)
+}
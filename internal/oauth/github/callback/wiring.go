@@ -4,29 +4,30 @@ import (
 	"net/http"
 
 	"github.com/vinylhousegarage/idpproxy/internal/deps"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
 )
 
 type GitHubCallbackHandler struct {
-	OAuth            *deps.GitHubOAuthDependencies
-	API              *deps.GitHubAPIDependencies
-	UserService      UserService
-	ProxyCodeService ProxyCodeService
-	ClientID         string
+	OAuth          *deps.GitHubOAuthDependencies
+	API            *deps.GitHubAPIDependencies
+	UserService    UserService
+	Authorizations AuthorizationCompleter
+
+	// optional
+	Links upstream.LinkRequests
 }
 
 func NewGitHubCallbackHandler(
 	oauth *deps.GitHubOAuthDependencies,
 	api *deps.GitHubAPIDependencies,
 	userSvc UserService,
-	proxyCodeSvc ProxyCodeService,
-	clientID string,
+	authorizations AuthorizationCompleter,
 ) *GitHubCallbackHandler {
 	return &GitHubCallbackHandler{
-		OAuth:            oauth,
-		API:              api,
-		UserService:      userSvc,
-		ProxyCodeService: proxyCodeSvc,
-		ClientID:         clientID,
+		OAuth:          oauth,
+		API:            api,
+		UserService:    userSvc,
+		Authorizations: authorizations,
 	}
 }
 
@@ -35,8 +36,7 @@ func (h *GitHubCallbackHandler) ready() bool {
 		h.OAuth != nil && h.OAuth.Config != nil && h.OAuth.Logger != nil &&
 		h.API != nil && h.API.HTTPClient != nil &&
 		h.UserService != nil &&
-		h.ProxyCodeService != nil &&
-		h.ClientID != ""
+		h.Authorizations != nil
 }
 
 func (h *GitHubCallbackHandler) notReady(w http.ResponseWriter) {
@@ -0,0 +1,17 @@
+package connector
+
+import "errors"
+
+var (
+	ErrMissingCode        = errors.New("github: callback has no code")
+	ErrTokenRequestBuild  = errors.New("github: failed to build token request")
+	ErrTokenRequest       = errors.New("github: token request failed")
+	ErrTokenExchange      = errors.New("github: token exchange failed")
+	ErrUserRequestBuild   = errors.New("github: failed to build user request")
+	ErrUserRequest        = errors.New("github: user request failed")
+	ErrUserDecode         = errors.New("github: failed to decode user")
+	ErrInvalidGitHubID    = errors.New("github: invalid user id")
+	ErrEmailsRequest      = errors.New("github: emails request failed")
+	ErrMembershipRequest  = errors.New("github: membership request failed")
+	ErrMissingOAuthConfig = errors.New("github: oauth config is not set")
+)
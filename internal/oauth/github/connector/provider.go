@@ -0,0 +1,154 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/httpclient"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/github/login"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/github/membership"
+	githubtoken "github.com/vinylhousegarage/idpproxy/internal/oauth/github/token"
+	githubuser "github.com/vinylhousegarage/idpproxy/internal/oauth/github/user"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+const upstreamTimeout = 10 * time.Second
+
+// Provider is GitHub as an upstream.Provider. GitHub is plain OAuth 2.0,
+// so the identity comes from GET /user instead of an ID token and the
+// transaction nonce is unused.
+type Provider struct {
+	OAuth      *config.GitHubOAuthConfig
+	HTTPClient httpclient.HTTPClient
+
+	// used when OAuth.ReadOrg or OAuth.ReadEmail is set
+	Membership *membership.Client
+}
+
+var _ upstream.Provider = (*Provider)(nil)
+
+func NewProvider(cfg *config.GitHubOAuthConfig, client httpclient.HTTPClient) *Provider {
+	return &Provider{
+		OAuth:      cfg,
+		HTTPClient: client,
+		Membership: membership.NewClient(client),
+	}
+}
+
+func (p *Provider) ID() string {
+	return users.ProviderGitHub
+}
+
+func (p *Provider) Name() string {
+	return "GitHub"
+}
+
+func (p *Provider) BeginAuth(_ context.Context, t upstream.Transaction) (string, error) {
+	if p.OAuth == nil {
+		return "", fmt.Errorf("%w: %w", upstream.ErrUnavailable, ErrMissingOAuthConfig)
+	}
+
+	return login.BuildGitHubLoginURL(p.OAuth, t.State), nil
+}
+
+func (p *Provider) CompleteAuth(_ http.ResponseWriter, r *http.Request, t upstream.Transaction) (*upstream.Identity, error) {
+	if e := r.URL.Query().Get("error"); e != "" {
+		return nil, fmt.Errorf("%w: %s", upstream.ErrDenied, e)
+	}
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		return nil, fmt.Errorf("%w: %w", upstream.ErrInvalidResponse, ErrMissingCode)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), upstreamTimeout)
+	defer cancel()
+
+	req, err := githubtoken.BuildAccessTokenRequest(ctx, p.OAuth, code, t.State)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w: %v", upstream.ErrUnavailable, ErrTokenRequestBuild, err)
+	}
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w: %v", upstream.ErrUnavailable, ErrTokenRequest, err)
+	}
+	defer resp.Body.Close()
+
+	accessToken, err := githubtoken.ExtractAccessTokenFromResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w: %v", upstream.ErrInvalidCredential, ErrTokenExchange, err)
+	}
+
+	userReq, err := githubuser.NewGitHubUserRequest(ctx, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w: %v", upstream.ErrUnavailable, ErrUserRequestBuild, err)
+	}
+
+	userResp, err := p.HTTPClient.Do(userReq)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w: %v", upstream.ErrUnavailable, ErrUserRequest, err)
+	}
+	defer userResp.Body.Close()
+
+	u, err := githubuser.DecodeGitHubUserResponse(userResp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w: %v", upstream.ErrUnavailable, ErrUserDecode, err)
+	}
+
+	if u.ID <= 0 {
+		return nil, fmt.Errorf("%w: %w", upstream.ErrInvalidCredential, ErrInvalidGitHubID)
+	}
+
+	id := &upstream.Identity{
+		Subject:  strconv.FormatInt(u.ID, 10),
+		Username: u.Login,
+		Email:    u.Email,
+		Name:     u.Name,
+		Picture:  u.AvatarURL,
+		Claims: map[string]any{
+			"id":    u.ID,
+			"login": u.Login,
+		},
+		AccessToken: accessToken,
+	}
+
+	if err := p.enrich(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return id, nil
+}
+
+// enrich adds what GET /user leaves out: the primary verified email, which
+// /user hides when it is private, and org and team membership.
+func (p *Provider) enrich(ctx context.Context, id *upstream.Identity) error {
+	if p.OAuth.ReadEmail {
+		email, err := p.Membership.PrimaryVerifiedEmail(ctx, id.AccessToken)
+		if err != nil {
+			return fmt.Errorf("%w: %w: %v", upstream.ErrUnavailable, ErrEmailsRequest, err)
+		}
+		if email != "" {
+			id.Email, id.EmailVerified = email, true
+		}
+	}
+
+	if p.OAuth.ReadOrg {
+		orgs, err := p.Membership.Orgs(ctx, id.AccessToken)
+		if err != nil {
+			return fmt.Errorf("%w: %w: %v", upstream.ErrUnavailable, ErrMembershipRequest, err)
+		}
+		teams, err := p.Membership.Teams(ctx, id.AccessToken)
+		if err != nil {
+			return fmt.Errorf("%w: %w: %v", upstream.ErrUnavailable, ErrMembershipRequest, err)
+		}
+		id.GitHubOrgs, id.GitHubTeams = orgs, teams
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+)
+
+type fakeHTTPClient struct {
+	userJSON string
+	tokenErr error
+
+	emailsJSON string
+	orgsJSON   string
+	teamsJSON  string
+}
+
+func (f *fakeHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	body := `not found`
+	status := http.StatusNotFound
+
+	u := *req.URL
+	u.RawQuery = ""
+
+	switch u.String() {
+	case config.GitHubTokenURL:
+		if f.tokenErr != nil {
+			return nil, f.tokenErr
+		}
+		body, status = `{"access_token":"ACCESS-TOKEN-XYZ","token_type":"bearer"}`, http.StatusOK
+	case config.GitHubUserURL:
+		body, status = f.userJSON, http.StatusOK
+	case config.GitHubEmailsURL:
+		body, status = f.emailsJSON, http.StatusOK
+	case config.GitHubOrgsURL:
+		body, status = f.orgsJSON, http.StatusOK
+	case config.GitHubTeamsURL:
+		body, status = f.teamsJSON, http.StatusOK
+	}
+
+	h := make(http.Header)
+	h.Set("Content-Type", "application/json")
+
+	return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(body)), Header: h}, nil
+}
+
+func newTestProvider(httpc *fakeHTTPClient) *Provider {
+	return NewProvider(&config.GitHubOAuthConfig{
+		ClientID:     "cid",
+		ClientSecret: "sec",
+		RedirectURI:  "https://idpproxy.example.com/github/callback",
+		Scope:        config.GitHubScope,
+		AllowSignup:  config.GitHubAllowSignup,
+	}, httpc)
+}
+
+func TestProvider_BeginAuth(t *testing.T) {
+	t.Parallel()
+
+	loginURL, err := newTestProvider(&fakeHTTPClient{}).BeginAuth(context.Background(), upstream.Transaction{State: "st"})
+	require.NoError(t, err)
+
+	u, err := url.Parse(loginURL)
+	require.NoError(t, err)
+	require.Equal(t, config.GitHubAuthorizeURL, u.Scheme+"://"+u.Host+u.Path)
+	require.Equal(t, "st", u.Query().Get("state"))
+	require.Equal(t, "cid", u.Query().Get("client_id"))
+}
+
+func TestProvider_CompleteAuth(t *testing.T) {
+	t.Parallel()
+
+	t.Run("normalizes the github user", func(t *testing.T) {
+		t.Parallel()
+
+		p := newTestProvider(&fakeHTTPClient{
+			userJSON: `{"id":12345,"login":"octocat","email":"octo@example.com","name":"The Octocat","avatar_url":"https://avatars.example.com/u/12345"}`,
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/github/callback?code=c&state=st", nil)
+		id, err := p.CompleteAuth(httptest.NewRecorder(), req, upstream.Transaction{State: "st"})
+		require.NoError(t, err)
+		require.Equal(t, "12345", id.Subject)
+		require.Equal(t, "octocat", id.Username)
+		require.Equal(t, "octo@example.com", id.Email)
+		require.False(t, id.EmailVerified)
+		require.Equal(t, "The Octocat", id.Name)
+		require.Equal(t, "https://avatars.example.com/u/12345", id.Picture)
+		require.Equal(t, "ACCESS-TOKEN-XYZ", id.AccessToken)
+	})
+
+	t.Run("fetches emails and membership when configured", func(t *testing.T) {
+		t.Parallel()
+
+		p := newTestProvider(&fakeHTTPClient{
+			userJSON:   `{"id":12345,"login":"octocat"}`,
+			emailsJSON: `[{"email":"octo@users.noreply.github.com","primary":false,"verified":true},{"email":"octo@example.com","primary":true,"verified":true}]`,
+			orgsJSON:   `[{"login":"acme"}]`,
+			teamsJSON:  `[{"slug":"admins","organization":{"login":"acme"}}]`,
+		})
+		p.OAuth.ReadEmail = true
+		p.OAuth.ReadOrg = true
+
+		req := httptest.NewRequest(http.MethodGet, "/github/callback?code=c&state=st", nil)
+		id, err := p.CompleteAuth(httptest.NewRecorder(), req, upstream.Transaction{State: "st"})
+		require.NoError(t, err)
+		require.Equal(t, "octo@example.com", id.Email)
+		require.True(t, id.EmailVerified)
+		require.Equal(t, []string{"acme"}, id.GitHubOrgs)
+		require.Equal(t, []string{"acme/admins"}, id.GitHubTeams)
+	})
+
+	t.Run("membership failure fails the login", func(t *testing.T) {
+		t.Parallel()
+
+		p := newTestProvider(&fakeHTTPClient{userJSON: `{"id":12345,"login":"octocat"}`})
+		p.OAuth.ReadOrg = true
+
+		req := httptest.NewRequest(http.MethodGet, "/github/callback?code=c&state=st", nil)
+		_, err := p.CompleteAuth(httptest.NewRecorder(), req, upstream.Transaction{State: "st"})
+		require.ErrorIs(t, err, ErrMembershipRequest)
+		require.ErrorIs(t, err, upstream.ErrUnavailable)
+	})
+
+	for _, tc := range []struct {
+		name     string
+		query    string
+		httpc    *fakeHTTPClient
+		want     error
+		category error
+	}{
+		{"upstream error", "?error=access_denied", &fakeHTTPClient{}, upstream.ErrDenied, upstream.ErrDenied},
+		{"missing code", "", &fakeHTTPClient{}, ErrMissingCode, upstream.ErrInvalidResponse},
+		{"token request failure", "?code=c", &fakeHTTPClient{tokenErr: errors.New("down")}, ErrTokenRequest, upstream.ErrUnavailable},
+		{"invalid user response", "?code=c", &fakeHTTPClient{userJSON: `{`}, ErrUserDecode, upstream.ErrUnavailable},
+		{"zero github id", "?code=c", &fakeHTTPClient{userJSON: `{"id":0}`}, ErrInvalidGitHubID, upstream.ErrInvalidCredential},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/github/callback"+tc.query, nil)
+			_, err := newTestProvider(tc.httpc).CompleteAuth(httptest.NewRecorder(), req, upstream.Transaction{State: "st"})
+			require.ErrorIs(t, err, tc.want)
+			require.ErrorIs(t, err, tc.category)
+		})
+	}
+}
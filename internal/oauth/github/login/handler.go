@@ -6,7 +6,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
 	"github.com/vinylhousegarage/idpproxy/internal/deps"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/github/apierror"
 )
 
 type GitHubLoginHandler struct {
@@ -20,8 +22,20 @@ func NewGitHubLoginHandler(githubOAuthDeps *deps.GitHubOAuthDependencies) *GitHu
 }
 
 func (h *GitHubLoginHandler) Serve(c *gin.Context) {
+	challenge, method, err := pkce.NormalizeChallenge(
+		c.Query("code_challenge"),
+		c.Query("code_challenge_method"),
+	)
+	if err != nil {
+		_ = c.Error(apierror.InvalidCodeChallenge(apierror.ErrInvalidCodeChallenge).
+			AddInternal(apierror.ErrorCodeInvalidCodeChallenge, "code_challenge", err.Error()))
+
+		return
+	}
+
 	state := GenerateState()
 	http.SetCookie(c.Writer, BuildStateCookie(state))
+	http.SetCookie(c.Writer, BuildPKCECookie(challenge, method))
 
 	loginURL := BuildGitHubLoginURL(h.Deps.Config, state)
 
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/github/apierror"
 	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
 )
 
@@ -49,3 +50,55 @@ func TestGitHubLoginHandler_Serve(t *testing.T) {
 	}
 	require.True(t, foundStateCookie, "oauth_state cookie should be set and secure")
 }
+
+func TestGitHubLoginHandler_Serve_PKCE(t *testing.T) {
+	t.Parallel()
+
+	const challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	t.Run("stores challenge in cookie", func(t *testing.T) {
+		t.Parallel()
+
+		handler := NewGitHubLoginHandler(testhelpers.NewMockGitHubOAuthDeps(zap.NewNop()))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet,
+			"/github/login?code_challenge="+challenge+"&code_challenge_method=S256", nil)
+
+		handler.Serve(c)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		require.Equal(t, http.StatusFound, res.StatusCode)
+
+		var pkceCookie *http.Cookie
+		for _, ck := range res.Cookies() {
+			if ck.Name == PKCECookieName {
+				pkceCookie = ck
+			}
+		}
+		require.NotNil(t, pkceCookie)
+		require.Equal(t, "S256:"+challenge, pkceCookie.Value)
+		require.True(t, pkceCookie.HttpOnly)
+		require.True(t, pkceCookie.Secure)
+	})
+
+	t.Run("rejects invalid challenge", func(t *testing.T) {
+		t.Parallel()
+
+		handler := NewGitHubLoginHandler(testhelpers.NewMockGitHubOAuthDeps(zap.NewNop()))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet,
+			"/github/login?code_challenge=short&code_challenge_method=S256", nil)
+
+		handler.Serve(c)
+
+		require.Len(t, c.Errors, 1)
+		require.ErrorIs(t, c.Errors[0].Err, apierror.ErrInvalidCodeChallenge)
+		require.Empty(t, w.Header().Get("Location"))
+	})
+}
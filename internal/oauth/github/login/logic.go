@@ -9,6 +9,8 @@ import (
 	"github.com/vinylhousegarage/idpproxy/internal/config"
 )
 
+const PKCECookieName = "oauth_pkce"
+
 func GenerateState() string {
 	b := make([]byte, config.OAuthStateBytes)
 	if _, err := rand.Read(b); err != nil {
@@ -28,6 +30,29 @@ func BuildStateCookie(state string) *http.Cookie {
 	}
 }
 
+func BuildPKCECookie(challenge, method string) *http.Cookie {
+	if challenge == "" {
+		return &http.Cookie{
+			Name:     PKCECookieName,
+			Value:    "",
+			HttpOnly: true,
+			Path:     "/",
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+			MaxAge:   -1,
+		}
+	}
+
+	return &http.Cookie{
+		Name:     PKCECookieName,
+		Value:    method + ":" + challenge,
+		HttpOnly: true,
+		Path:     "/",
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
 func BuildGitHubLoginURL(cfg *config.GitHubOAuthConfig, state string) string {
 	v := url.Values{}
 	v.Set("client_id", cfg.ClientID)
@@ -60,3 +60,18 @@ func TestBuildGitHubLoginURL(t *testing.T) {
 	require.Equal(t, state, q.Get("state"))
 	require.Equal(t, cfg.AllowSignup, q.Get("allow_signup"))
 }
+
+func TestBuildPKCECookie(t *testing.T) {
+	t.Parallel()
+
+	cookie := BuildPKCECookie("challenge", "S256")
+	require.Equal(t, PKCECookieName, cookie.Name)
+	require.Equal(t, "S256:challenge", cookie.Value)
+	require.True(t, cookie.HttpOnly)
+	require.True(t, cookie.Secure)
+
+	cleared := BuildPKCECookie("", "")
+	require.Equal(t, PKCECookieName, cleared.Name)
+	require.Empty(t, cleared.Value)
+	require.Equal(t, -1, cleared.MaxAge)
+}
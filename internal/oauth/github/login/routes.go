@@ -6,7 +6,9 @@ import (
 	"github.com/vinylhousegarage/idpproxy/internal/deps"
 )
 
+const Path = "/github/login"
+
 func RegisterRoutes(r *gin.Engine, githubOAuthDeps *deps.GitHubOAuthDependencies) {
 	h := NewGitHubLoginHandler(githubOAuthDeps)
-	r.GET("/github/login", h.Serve)
+	r.GET(Path, h.Serve)
 }
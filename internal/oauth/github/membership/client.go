@@ -0,0 +1,152 @@
+package membership
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/httpclient"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/github/response"
+)
+
+const (
+	perPage  = 100
+	maxPages = 10
+)
+
+// Client lists the signed-in user's GitHub organizations, teams and emails
+// with the user's own access token. Private memberships need the read:org
+// scope and emails need user:email.
+type Client struct {
+	HTTPClient httpclient.HTTPClient
+	OrgsURL    string
+	TeamsURL   string
+	EmailsURL  string
+}
+
+func NewClient(client httpclient.HTTPClient) *Client {
+	return &Client{
+		HTTPClient: client,
+		OrgsURL:    config.GitHubOrgsURL,
+		TeamsURL:   config.GitHubTeamsURL,
+		EmailsURL:  config.GitHubEmailsURL,
+	}
+}
+
+// Orgs returns the organization logins.
+func (c *Client) Orgs(ctx context.Context, accessToken string) ([]string, error) {
+	orgs, err := getAll[response.GitHubOrgAPIResponse](ctx, c.HTTPClient, c.OrgsURL, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	logins := make([]string, 0, len(orgs))
+	for _, o := range orgs {
+		logins = append(logins, o.Login)
+	}
+
+	return logins, nil
+}
+
+// Teams returns teams as "<org>/<team-slug>".
+func (c *Client) Teams(ctx context.Context, accessToken string) ([]string, error) {
+	teams, err := getAll[response.GitHubTeamAPIResponse](ctx, c.HTTPClient, c.TeamsURL, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(teams))
+	for _, t := range teams {
+		names = append(names, t.Organization.Login+"/"+t.Slug)
+	}
+
+	return names, nil
+}
+
+// PrimaryVerifiedEmail returns the primary email when GitHub has verified
+// it, or "" when there is none.
+func (c *Client) PrimaryVerifiedEmail(ctx context.Context, accessToken string) (string, error) {
+	emails, err := getAll[response.GitHubEmailAPIResponse](ctx, c.HTTPClient, c.EmailsURL, accessToken)
+	if err != nil {
+		return "", err
+	}
+
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			return e.Email, nil
+		}
+	}
+
+	return "", nil
+}
+
+func getAll[T any](ctx context.Context, client httpclient.HTTPClient, rawURL, accessToken string) ([]T, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRequest, err)
+	}
+	q := u.Query()
+	q.Set("per_page", fmt.Sprint(perPage))
+	u.RawQuery = q.Encode()
+
+	var all []T
+	next := u.String()
+	for page := 0; next != "" && page < maxPages; page++ {
+		var items []T
+		next, err = get(ctx, client, next, accessToken, &items)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+	}
+
+	return all, nil
+}
+
+// get decodes one page into v and returns the next page URL, if any.
+func get(ctx context.Context, client httpclient.HTTPClient, rawURL, accessToken string, v any) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRequest, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", config.GitHubAPIVersion)
+	req.Header.Set("User-Agent", config.UserAgent())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRequest, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return "", fmt.Errorf("%w: %d body=%q", ErrStatus, resp.StatusCode, snippet)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDecode, err)
+	}
+
+	return nextLink(resp.Header.Get("Link")), nil
+}
+
+// nextLink extracts rel="next" from a GitHub Link header.
+func nextLink(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+
+		return strings.Trim(strings.TrimSpace(target), "<>")
+	}
+
+	return ""
+}
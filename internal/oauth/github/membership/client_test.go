@@ -0,0 +1,86 @@
+package membership
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/user/orgs", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer gh-token", r.Header.Get("Authorization"))
+		require.Equal(t, "100", r.URL.Query().Get("per_page"))
+
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = w.Write([]byte(`[{"login":"globex"}]`))
+			return
+		}
+		w.Header().Set("Link", `<`+srv.URL+`/user/orgs?per_page=100&page=2>; rel="next", <`+srv.URL+`/user/orgs?per_page=100&page=2>; rel="last"`)
+		_, _ = w.Write([]byte(`[{"login":"acme"}]`))
+	})
+	mux.HandleFunc("/user/teams", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"slug":"admins","organization":{"login":"acme"}}]`))
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"email":"old@example.com","primary":false,"verified":true},{"email":"octo@example.com","primary":true,"verified":true}]`))
+	})
+	mux.HandleFunc("/user/emails/unverified", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"email":"octo@example.com","primary":true,"verified":false}]`))
+	})
+	mux.HandleFunc("/forbidden", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	c := &Client{HTTPClient: srv.Client(), OrgsURL: srv.URL + "/user/orgs", TeamsURL: srv.URL + "/user/teams"}
+	ctx := context.Background()
+
+	orgs, err := c.Orgs(ctx, "gh-token")
+	require.NoError(t, err)
+	require.Equal(t, []string{"acme", "globex"}, orgs, "follows pagination")
+
+	teams, err := c.Teams(ctx, "gh-token")
+	require.NoError(t, err)
+	require.Equal(t, []string{"acme/admins"}, teams)
+
+	c.TeamsURL = srv.URL + "/forbidden"
+	_, err = c.Teams(ctx, "gh-token")
+	require.ErrorIs(t, err, ErrStatus)
+}
+
+func TestClient_PrimaryVerifiedEmail(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	c := &Client{HTTPClient: srv.Client(), EmailsURL: srv.URL + "/user/emails"}
+	ctx := context.Background()
+
+	email, err := c.PrimaryVerifiedEmail(ctx, "gh-token")
+	require.NoError(t, err)
+	require.Equal(t, "octo@example.com", email)
+
+	c.EmailsURL = srv.URL + "/user/emails/unverified"
+	email, err = c.PrimaryVerifiedEmail(ctx, "gh-token")
+	require.NoError(t, err)
+	require.Empty(t, email)
+
+	c.EmailsURL = srv.URL + "/forbidden"
+	_, err = c.PrimaryVerifiedEmail(ctx, "gh-token")
+	require.ErrorIs(t, err, ErrStatus)
+}
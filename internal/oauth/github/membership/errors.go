@@ -0,0 +1,9 @@
+package membership
+
+import "errors"
+
+var (
+	ErrRequest = errors.New("github membership: request failed")
+	ErrStatus  = errors.New("github membership: unexpected status")
+	ErrDecode  = errors.New("github membership: failed to decode response")
+)
@@ -16,8 +16,24 @@ type UserProfileResponse struct {
 }
 
 type GitHubUserAPIResponse struct {
-	ID    int64  `json:"id"`
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Email     string `json:"email,omitempty"`
+	Name      string `json:"name,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+type GitHubOrgAPIResponse struct {
 	Login string `json:"login"`
-	Email string `json:"email,omitempty"`
-	Name  string `json:"name,omitempty"`
+}
+
+type GitHubTeamAPIResponse struct {
+	Slug         string               `json:"slug"`
+	Organization GitHubOrgAPIResponse `json:"organization"`
+}
+
+type GitHubEmailAPIResponse struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
 }
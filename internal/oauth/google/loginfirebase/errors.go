@@ -7,6 +7,10 @@ import (
 )
 
 var (
-	ErrInvalidIDToken = apperror.New(http.StatusUnauthorized, "invalid id_token") // 401 Unauthorized
-	ErrInvalidRequest = apperror.New(http.StatusBadRequest, "invalid request")    // 400 Bad Request
+	ErrInvalidAuthorizationRequest = apperror.New(http.StatusBadRequest, "invalid authorization request") // 400 Bad Request
+	ErrInvalidIDToken              = apperror.New(http.StatusUnauthorized, "invalid id_token")            // 401 Unauthorized
+	ErrInvalidRequest              = apperror.New(http.StatusBadRequest, "invalid request")               // 400 Bad Request
+	ErrReauthenticationRequired    = apperror.New(http.StatusUnauthorized, "reauthentication required")   // 401 Unauthorized
+	ErrIdentityLinked              = apperror.New(http.StatusConflict, "identity already linked")         // 409 Conflict
+	ErrUserUpsert                  = apperror.New(http.StatusInternalServerError, "user upsert failed")   // 500 Internal Server Error
 )
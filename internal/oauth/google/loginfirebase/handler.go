@@ -1,6 +1,8 @@
 package loginfirebase
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
 	"go.uber.org/zap"
@@ -8,11 +10,18 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
 	"github.com/vinylhousegarage/idpproxy/internal/oauth/google/verify"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
 )
 
 type LoginFirebaseHandler struct {
 	Verifier verify.Verifier
 	Logger   *zap.Logger
+
+	// optional
+	Authorizations AuthorizationCompleter
+	Users          UserService
+	Links          upstream.LinkRequests
 }
 
 func NewLoginFirebaseHandler(
@@ -29,21 +38,60 @@ func (h *LoginFirebaseHandler) LoginFirebaseHandler(
 	w http.ResponseWriter,
 	r *http.Request,
 ) error {
-	req, err := ParseGoogleLoginRequest(r)
-	if err != nil {
+	auth := &upstream.Authenticator{
+		Provider:       NewProvider(h.Verifier, ""),
+		Users:          h.Users,
+		Authorizations: h.Authorizations,
+		Links:          h.Links,
+	}
+
+	res, err := auth.Authenticate(w, r, upstream.Transaction{})
+	switch {
+	case errors.Is(err, upstream.ErrInvalidResponse):
 		h.Logger.Error("invalid request", zap.Error(err))
 
 		return ErrInvalidRequest
-	}
+	case errors.Is(err, upstream.ErrReauthenticationRequired):
+		h.Logger.Warn("account link needs reauthentication", zap.Error(err))
 
-	_, err = verify.VerifyIDToken(r.Context(), h.Verifier, req.IDToken)
-	if err != nil {
+		return ErrReauthenticationRequired
+	case errors.Is(err, users.ErrIdentityLinked):
+		h.Logger.Warn("identity already linked", zap.Error(err))
+
+		return ErrIdentityLinked
+	case errors.Is(err, upstream.ErrLink):
+		h.Logger.Error("user upsert failed", zap.Error(err))
+
+		return ErrUserUpsert
+	case err != nil:
 		h.Logger.Error("unauthorized id_token", zap.Error(err))
 
 		return ErrInvalidIDToken
 	}
 
-	cookie.SetIDTokenCookie(w, req.IDToken)
+	cookie.SetIDTokenCookie(w, res.Identity.IDToken)
+
+	location, ok, err := auth.Resume(w, r, res)
+	if err != nil {
+		h.Logger.Warn("authorization completion failed", zap.Error(err))
+
+		return ErrInvalidAuthorizationRequest
+	}
+
+	if ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		return json.NewEncoder(w).Encode(map[string]string{"redirect_to": location})
+	}
+
+	if res.Linked {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		return json.NewEncoder(w).Encode(upstream.LinkedBody(res.Identity.Provider))
+	}
+
 	w.WriteHeader(http.StatusOK)
 
 	return nil
@@ -54,6 +102,10 @@ func (h *LoginFirebaseHandler) Serve(c *gin.Context) {
 		h.Logger.Warn("loginfirebase failed", zap.Error(err))
 
 		switch err {
+		case ErrInvalidAuthorizationRequest:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "invalid authorization request",
+			})
 		case ErrInvalidRequest:
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
 				"error": "invalid request",
@@ -62,6 +114,14 @@ func (h *LoginFirebaseHandler) Serve(c *gin.Context) {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "unauthorized id_token",
 			})
+		case ErrReauthenticationRequired:
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "reauthentication required",
+			})
+		case ErrIdentityLinked:
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+				"error": "identity already linked",
+			})
 		default:
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
 				"error": "internal server error",
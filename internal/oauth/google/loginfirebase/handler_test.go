@@ -12,6 +12,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
 	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
 )
 
@@ -82,4 +84,152 @@ func TestLoginFirebaseHandler(t *testing.T) {
 
 		require.ErrorIs(t, err, ErrInvalidIDToken)
 	})
+
+	t.Run("completes pending authorization", func(t *testing.T) {
+		t.Parallel()
+
+		mockVerifier := &testhelpers.MockVerifier{
+			VerifyFunc: func(ctx context.Context, idToken string) (*firebaseauth.Token, error) {
+				return &firebaseauth.Token{UID: "test-uid"}, nil
+			},
+		}
+		completer := &fakeCompleter{location: "https://app.example.com/cb?code=c&state=s", ok: true}
+
+		body := []byte(`{"id_token":"dummy.token.value"}`)
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler := &LoginFirebaseHandler{
+			Logger:         zap.NewNop(),
+			Verifier:       mockVerifier,
+			Authorizations: completer,
+		}
+		err := handler.LoginFirebaseHandler(rr, req)
+
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "google:test-uid", completer.userID)
+		require.JSONEq(t, `{"redirect_to":"https://app.example.com/cb?code=c\u0026state=s"}`, rr.Body.String())
+	})
+
+	t.Run("authorization completion failure", func(t *testing.T) {
+		t.Parallel()
+
+		mockVerifier := &testhelpers.MockVerifier{
+			VerifyFunc: func(ctx context.Context, idToken string) (*firebaseauth.Token, error) {
+				return &firebaseauth.Token{UID: "test-uid"}, nil
+			},
+		}
+
+		body := []byte(`{"id_token":"dummy.token.value"}`)
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler := &LoginFirebaseHandler{
+			Logger:         zap.NewNop(),
+			Verifier:       mockVerifier,
+			Authorizations: &fakeCompleter{ok: true, err: errors.New("expired")},
+		}
+		err := handler.LoginFirebaseHandler(rr, req)
+
+		require.ErrorIs(t, err, ErrInvalidAuthorizationRequest)
+	})
+
+	t.Run("upserts the user profile", func(t *testing.T) {
+		t.Parallel()
+
+		mockVerifier := &testhelpers.MockVerifier{
+			VerifyFunc: func(ctx context.Context, idToken string) (*firebaseauth.Token, error) {
+				return &firebaseauth.Token{UID: "test-uid", Claims: map[string]any{
+					"name":           "Jane Doe",
+					"email":          "jane@example.com",
+					"email_verified": true,
+					"picture":        "https://example.com/jane.png",
+				}}, nil
+			},
+		}
+		userSvc := &fakeUserService{}
+
+		body := []byte(`{"id_token":"dummy.token.value"}`)
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler := &LoginFirebaseHandler{
+			Logger:   zap.NewNop(),
+			Verifier: mockVerifier,
+			Users:    userSvc,
+		}
+		err := handler.LoginFirebaseHandler(rr, req)
+
+		require.NoError(t, err)
+		require.Equal(t, users.ProviderGoogle, userSvc.provider)
+		require.Equal(t, users.Profile{
+			Subject:       "test-uid",
+			Name:          "Jane Doe",
+			Email:         "jane@example.com",
+			EmailVerified: true,
+			Picture:       "https://example.com/jane.png",
+		}, userSvc.got)
+	})
+
+	t.Run("user upsert failure", func(t *testing.T) {
+		t.Parallel()
+
+		mockVerifier := &testhelpers.MockVerifier{
+			VerifyFunc: func(ctx context.Context, idToken string) (*firebaseauth.Token, error) {
+				return &firebaseauth.Token{UID: "test-uid"}, nil
+			},
+		}
+
+		body := []byte(`{"id_token":"dummy.token.value"}`)
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler := &LoginFirebaseHandler{
+			Logger:   zap.NewNop(),
+			Verifier: mockVerifier,
+			Users:    &fakeUserService{err: errors.New("firestore down")},
+		}
+		err := handler.LoginFirebaseHandler(rr, req)
+
+		require.ErrorIs(t, err, ErrUserUpsert)
+		require.Empty(t, rr.Result().Cookies())
+	})
+}
+
+type fakeUserService struct {
+	provider string
+	got      users.Profile
+	err      error
+}
+
+func (f *fakeUserService) UpsertProfile(_ context.Context, provider string, p users.Profile) (string, error) {
+	f.provider = provider
+	f.got = p
+	if f.err != nil {
+		return "", f.err
+	}
+	return users.UserID(provider, p.Subject), nil
+}
+
+func (f *fakeUserService) LinkProfile(_ context.Context, _, provider string, p users.Profile) error {
+	f.provider = provider
+	f.got = p
+	return f.err
+}
+
+type fakeCompleter struct {
+	location string
+	ok       bool
+	err      error
+	userID   string
+}
+
+func (f *fakeCompleter) Complete(_ http.ResponseWriter, _ *http.Request, res *upstream.Result) (string, bool, error) {
+	f.userID = res.UserID
+	return f.location, f.ok, f.err
 }
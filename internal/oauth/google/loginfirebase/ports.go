@@ -0,0 +1,9 @@
+package loginfirebase
+
+import (
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+)
+
+type AuthorizationCompleter = upstream.AuthorizationCompleter
+
+type UserService = upstream.UserService
@@ -0,0 +1,76 @@
+package loginfirebase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"firebase.google.com/go/v4/auth"
+
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/google/verify"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+var ErrMissingLoginURL = errors.New("google login url is not configured")
+
+// Provider is Google (via Firebase Auth) as an upstream.Provider. The
+// browser signs in on LoginURL and posts the resulting Firebase ID token,
+// so the transaction is unused.
+type Provider struct {
+	Verifier verify.Verifier
+	LoginURL string
+}
+
+var _ upstream.Provider = (*Provider)(nil)
+
+func NewProvider(verifier verify.Verifier, loginURL string) *Provider {
+	return &Provider{
+		Verifier: verifier,
+		LoginURL: loginURL,
+	}
+}
+
+func (p *Provider) ID() string {
+	return users.ProviderGoogle
+}
+
+func (p *Provider) Name() string {
+	return "Google"
+}
+
+func (p *Provider) BeginAuth(_ context.Context, _ upstream.Transaction) (string, error) {
+	if p.LoginURL == "" {
+		return "", fmt.Errorf("%w: %w", upstream.ErrUnavailable, ErrMissingLoginURL)
+	}
+
+	return p.LoginURL, nil
+}
+
+func (p *Provider) CompleteAuth(_ http.ResponseWriter, r *http.Request, _ upstream.Transaction) (*upstream.Identity, error) {
+	req, err := ParseGoogleLoginRequest(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", upstream.ErrInvalidResponse, err)
+	}
+
+	token, err := verify.VerifyIDToken(r.Context(), p.Verifier, req.IDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", upstream.ErrInvalidCredential, err)
+	}
+
+	id := googleIdentity(token)
+	id.IDToken = req.IDToken
+
+	return id, nil
+}
+
+func googleIdentity(token *auth.Token) *upstream.Identity {
+	id := &upstream.Identity{Subject: token.UID, Claims: token.Claims}
+	id.Name, _ = token.Claims["name"].(string)
+	id.Email, _ = token.Claims["email"].(string)
+	id.EmailVerified, _ = token.Claims["email_verified"].(bool)
+	id.Picture, _ = token.Claims["picture"].(string)
+
+	return id
+}
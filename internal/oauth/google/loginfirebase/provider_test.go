@@ -0,0 +1,61 @@
+package loginfirebase
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	firebaseauth "firebase.google.com/go/v4/auth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+func TestProvider(t *testing.T) {
+	t.Parallel()
+
+	verifier := &testhelpers.MockVerifier{
+		VerifyFunc: func(ctx context.Context, idToken string) (*firebaseauth.Token, error) {
+			return &firebaseauth.Token{UID: "test-uid", Claims: map[string]any{
+				"email":          "jane@example.com",
+				"email_verified": true,
+				"hd":             "example.com",
+			}}, nil
+		},
+	}
+
+	t.Run("begin redirects to the configured login page", func(t *testing.T) {
+		t.Parallel()
+
+		loginURL, err := NewProvider(verifier, "https://idpproxy.example.com/google/login").BeginAuth(context.Background(), upstream.Transaction{})
+		require.NoError(t, err)
+		require.Equal(t, "https://idpproxy.example.com/google/login", loginURL)
+
+		_, err = NewProvider(verifier, "").BeginAuth(context.Background(), upstream.Transaction{})
+		require.ErrorIs(t, err, upstream.ErrUnavailable)
+	})
+
+	t.Run("complete normalizes the firebase token", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPost, "/google/login/firebase", bytes.NewReader([]byte(`{"id_token":"tok"}`)))
+		id, err := NewProvider(verifier, "").CompleteAuth(httptest.NewRecorder(), req, upstream.Transaction{})
+		require.NoError(t, err)
+		require.Equal(t, "test-uid", id.Subject)
+		require.Equal(t, "jane@example.com", id.Email)
+		require.True(t, id.EmailVerified)
+		require.Equal(t, "example.com", id.Claims["hd"])
+		require.Equal(t, "tok", id.IDToken)
+	})
+
+	t.Run("complete rejects a malformed body", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPost, "/google/login/firebase", bytes.NewReader([]byte(`{}`)))
+		_, err := NewProvider(verifier, "").CompleteAuth(httptest.NewRecorder(), req, upstream.Transaction{})
+		require.ErrorIs(t, err, upstream.ErrInvalidResponse)
+	})
+}
@@ -4,9 +4,19 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/vinylhousegarage/idpproxy/internal/deps"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
 )
 
-func RegisterRoutes(r gin.IRoutes, googleDeps *deps.GoogleDependencies) {
+func RegisterRoutes(
+	r gin.IRoutes,
+	googleDeps *deps.GoogleDependencies,
+	authorizations AuthorizationCompleter,
+	userSvc UserService,
+	links upstream.LinkRequests,
+) {
 	h := NewLoginFirebaseHandler(googleDeps.Verifier, googleDeps.Logger)
+	h.Authorizations = authorizations
+	h.Users = userSvc
+	h.Links = links
 	r.POST("/google/login/firebase", h.Serve)
 }
@@ -0,0 +1,35 @@
+package accountlink
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	linkCookieName = "idpproxy_link"
+	linkTTL        = 10 * time.Minute
+)
+
+func buildLinkCookie(provider string) *http.Cookie {
+	return &http.Cookie{
+		Name:     linkCookieName,
+		Value:    provider,
+		Path:     "/",
+		MaxAge:   int(linkTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func deleteLinkCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     linkCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
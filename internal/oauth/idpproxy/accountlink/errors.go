@@ -0,0 +1,20 @@
+package accountlink
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/vinylhousegarage/idpproxy/internal/apperror"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+)
+
+var (
+	ErrNoSession    = fmt.Errorf("accountlink: no active session: %w", upstream.ErrReauthenticationRequired)
+	ErrStaleSession = fmt.Errorf("accountlink: session too old to link: %w", upstream.ErrReauthenticationRequired)
+)
+
+// Handler
+var (
+	ErrUnknownProvider = apperror.New(http.StatusNotFound, "unknown provider")                 // 404 Not Found
+	ErrSessionLookup   = apperror.New(http.StatusInternalServerError, "session lookup failed") // 500 Internal Server Error
+)
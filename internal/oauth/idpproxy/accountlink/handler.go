@@ -0,0 +1,143 @@
+package accountlink
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/apperror"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/idpproxy/authorize"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+)
+
+const DefaultMaxAuthAge = 5 * time.Minute
+
+// Handler lets a signed-in user attach another upstream account. Start
+// requires a recent login and marks the browser; the provider's callback
+// then asks PendingLink whether to link instead of logging in.
+type Handler struct {
+	Sessions  SessionToucher
+	Providers []authorize.Provider
+	Logger    *zap.Logger
+
+	// zero uses DefaultMaxAuthAge
+	MaxAuthAge time.Duration
+	// defaults to time.Now
+	Now func() time.Time
+}
+
+var _ upstream.LinkRequests = (*Handler)(nil)
+
+func NewHandler(sessions SessionToucher, providers []authorize.Provider, logger *zap.Logger) *Handler {
+	return &Handler{
+		Sessions:  sessions,
+		Providers: providers,
+		Logger:    logger,
+	}
+}
+
+func (h *Handler) Start(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	p, ok := h.provider(c.Param("provider"))
+	if !ok {
+		h.fail(c, ErrUnknownProvider, nil)
+
+		return
+	}
+
+	if _, err := h.session(c.Request, h.maxAuthAge()); err != nil {
+		if errors.Is(err, upstream.ErrReauthenticationRequired) {
+			h.fail(c, upstream.ErrReauthentication, err)
+		} else {
+			h.fail(c, ErrSessionLookup, err)
+		}
+
+		return
+	}
+
+	http.SetCookie(c.Writer, buildLinkCookie(p.ID))
+	c.Redirect(http.StatusSeeOther, p.LoginPath)
+}
+
+// PendingLink consumes the browser's link request for provider. The session
+// may have aged by the upstream round trip since Start checked it.
+func (h *Handler) PendingLink(w http.ResponseWriter, r *http.Request, provider string) (string, bool, error) {
+	ck, err := r.Cookie(linkCookieName)
+	if err != nil || ck.Value != provider {
+		return "", false, nil
+	}
+	http.SetCookie(w, deleteLinkCookie())
+
+	s, err := h.session(r, h.maxAuthAge()+linkTTL)
+	if err != nil {
+		return "", false, err
+	}
+
+	return s.UserID, true, nil
+}
+
+func (h *Handler) session(r *http.Request, maxAge time.Duration) (*session.Session, error) {
+	ck, err := r.Cookie(cookie.SessionCookieName)
+	if err != nil || ck.Value == "" {
+		return nil, ErrNoSession
+	}
+
+	s, err := h.Sessions.Touch(r.Context(), ck.Value)
+	switch {
+	case errors.Is(err, session.ErrNotFound),
+		errors.Is(err, session.ErrExpiredSession),
+		errors.Is(err, session.ErrIdleSession),
+		errors.Is(err, session.ErrInactiveSession):
+		return nil, ErrNoSession
+	case err != nil:
+		return nil, err
+	}
+
+	if h.now().Sub(s.CreatedAt) > maxAge {
+		return nil, ErrStaleSession
+	}
+
+	return s, nil
+}
+
+func (h *Handler) provider(id string) (authorize.Provider, bool) {
+	for _, p := range h.Providers {
+		if p.ID == id {
+			return p, true
+		}
+	}
+
+	return authorize.Provider{}, false
+}
+
+func (h *Handler) maxAuthAge() time.Duration {
+	if h.MaxAuthAge > 0 {
+		return h.MaxAuthAge
+	}
+
+	return DefaultMaxAuthAge
+}
+
+func (h *Handler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+
+	return time.Now()
+}
+
+func (h *Handler) fail(c *gin.Context, appErr *apperror.AppError, cause error) {
+	h.Logger.Info("accountlink: request rejected",
+		zap.String("provider", c.Param("provider")),
+		zap.String("reason", appErr.Message),
+		zap.Error(cause),
+	)
+
+	c.AbortWithStatusJSON(appErr.StatusCode(), gin.H{"error": appErr.Message})
+}
@@ -0,0 +1,181 @@
+package accountlink
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/idpproxy/authorize"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+)
+
+var testProviders = []authorize.Provider{
+	{ID: "github", Name: "GitHub", LoginPath: "/github/login"},
+	{ID: "corp", Name: "Corp SSO", LoginPath: "/oidc/corp/login"},
+}
+
+type testEnv struct {
+	h   *Handler
+	r   *gin.Engine
+	now time.Time
+	sid string
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	env := &testEnv{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	sessions := &session.Usecase{
+		Repo:        session.NewMemoryRepository(),
+		Now:         func() time.Time { return env.now },
+		TTL:         session.DefaultTTL,
+		IDGenerator: session.NewID,
+	}
+
+	s, err := sessions.Start(context.Background(), "user-1")
+	require.NoError(t, err)
+	env.sid = s.SessionID
+
+	env.h = NewHandler(sessions, testProviders, zap.NewNop())
+	env.h.Now = func() time.Time { return env.now }
+
+	gin.SetMode(gin.TestMode)
+	env.r = gin.New()
+	RegisterRoutes(env.r, env.h)
+
+	return env
+}
+
+func (env *testEnv) start(provider string, withSession bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/account/link/"+provider, nil)
+	if withSession {
+		req.AddCookie(&http.Cookie{Name: cookie.SessionCookieName, Value: env.sid})
+	}
+
+	w := httptest.NewRecorder()
+	env.r.ServeHTTP(w, req)
+
+	return w
+}
+
+func linkCookie(t *testing.T, w *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name == linkCookieName {
+			return ck
+		}
+	}
+	t.Fatal("link cookie not set")
+
+	return nil
+}
+
+func TestHandler_Start(t *testing.T) {
+	t.Parallel()
+
+	t.Run("redirects to the provider login", func(t *testing.T) {
+		t.Parallel()
+
+		env := newTestEnv(t)
+		env.now = env.now.Add(time.Minute)
+
+		w := env.start("corp", true)
+		require.Equal(t, http.StatusSeeOther, w.Code, w.Body.String())
+		require.Equal(t, "/oidc/corp/login", w.Header().Get("Location"))
+
+		ck := linkCookie(t, w)
+		require.Equal(t, "corp", ck.Value)
+		require.True(t, ck.HttpOnly)
+		require.True(t, ck.Secure)
+	})
+
+	t.Run("requires a session", func(t *testing.T) {
+		t.Parallel()
+
+		w := newTestEnv(t).start("corp", false)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.JSONEq(t, `{"error":"reauthentication required"}`, w.Body.String())
+	})
+
+	t.Run("requires a recent login", func(t *testing.T) {
+		t.Parallel()
+
+		env := newTestEnv(t)
+		env.now = env.now.Add(DefaultMaxAuthAge + time.Second)
+
+		w := env.start("corp", true)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Empty(t, w.Result().Cookies())
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		t.Parallel()
+
+		w := newTestEnv(t).start("nope", true)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestHandler_PendingLink(t *testing.T) {
+	t.Parallel()
+
+	callback := func(env *testEnv, link *http.Cookie) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/oidc/corp/callback", nil)
+		req.AddCookie(&http.Cookie{Name: cookie.SessionCookieName, Value: env.sid})
+		if link != nil {
+			req.AddCookie(link)
+		}
+		return req
+	}
+
+	t.Run("returns the signed-in user and clears the request", func(t *testing.T) {
+		t.Parallel()
+
+		env := newTestEnv(t)
+		link := linkCookie(t, env.start("corp", true))
+		env.now = env.now.Add(DefaultMaxAuthAge)
+
+		w := httptest.NewRecorder()
+		userID, ok, err := env.h.PendingLink(w, callback(env, link), "corp")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "user-1", userID)
+		require.Equal(t, -1, linkCookie(t, w).MaxAge)
+	})
+
+	t.Run("ignores other providers", func(t *testing.T) {
+		t.Parallel()
+
+		env := newTestEnv(t)
+		link := linkCookie(t, env.start("corp", true))
+
+		_, ok, err := env.h.PendingLink(httptest.NewRecorder(), callback(env, link), "github")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		_, ok, err = env.h.PendingLink(httptest.NewRecorder(), callback(env, nil), "corp")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("rejects a session that aged out", func(t *testing.T) {
+		t.Parallel()
+
+		env := newTestEnv(t)
+		link := linkCookie(t, env.start("corp", true))
+		env.now = env.now.Add(DefaultMaxAuthAge + linkTTL + time.Second)
+
+		_, _, err := env.h.PendingLink(httptest.NewRecorder(), callback(env, link), "corp")
+		require.ErrorIs(t, err, ErrStaleSession)
+		require.ErrorIs(t, err, upstream.ErrReauthenticationRequired)
+	})
+}
@@ -0,0 +1,11 @@
+package accountlink
+
+import (
+	"context"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+)
+
+type SessionToucher interface {
+	Touch(ctx context.Context, sessionID string) (*session.Session, error)
+}
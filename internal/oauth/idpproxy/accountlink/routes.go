@@ -0,0 +1,34 @@
+package accountlink
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/deps"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/idpproxy/authorize"
+)
+
+const Path = "/account/link/:provider"
+
+func NewHandlerFromDeps(oidcDeps *deps.OIDCDependencies, providers []authorize.Provider) *Handler {
+	sessions := &session.Usecase{
+		Repo: oidcDeps.Sessions,
+		Now:  time.Now,
+		TTL:  session.DefaultTTL,
+		Policy: session.Policy{
+			IdleTimeout: oidcDeps.Config.SessionIdleTimeout,
+			MaxLifetime: oidcDeps.Config.SessionMaxLifetime,
+		},
+	}
+
+	h := NewHandler(sessions, providers, oidcDeps.Logger)
+	h.MaxAuthAge = oidcDeps.Config.AccountLinkMaxAuthAge
+
+	return h
+}
+
+func RegisterRoutes(r gin.IRoutes, h *Handler) {
+	r.POST(Path, h.Start)
+}
@@ -0,0 +1,136 @@
+package authorize
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/authcode"
+	"github.com/vinylhousegarage/idpproxy/internal/authorize"
+	authorizestore "github.com/vinylhousegarage/idpproxy/internal/authorize/store"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/loginpolicy"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+)
+
+type ProxyCodeIssuer interface {
+	IssueCode(ctx context.Context, pc authcode.ProxyCode) (string, error)
+}
+
+type SessionStarter interface {
+	Start(ctx context.Context, userID string) (*session.Session, error)
+}
+
+type SessionToucher interface {
+	Touch(ctx context.Context, sessionID string) (*session.Session, error)
+}
+
+type LoginPolicy interface {
+	Allow(ctx context.Context, cl *client.Client, id *upstream.Identity) error
+}
+
+type Completer struct {
+	Store      authorizestore.Store
+	ProxyCodes ProxyCodeIssuer
+
+	// optional
+	Sessions SessionStarter
+	Logger   *zap.Logger
+
+	// optional; set both to enforce per-client login policies
+	Clients client.Registry
+	Policy  LoginPolicy
+}
+
+func NewCompleter(store authorizestore.Store, proxyCodes ProxyCodeIssuer) *Completer {
+	return &Completer{
+		Store:      store,
+		ProxyCodes: proxyCodes,
+	}
+}
+
+// Complete finishes the pending /authorize request bound to the browser, if
+// any, and returns the client redirect carrying the proxy code.
+func (c *Completer) Complete(w http.ResponseWriter, r *http.Request, res *upstream.Result) (string, bool, error) {
+	pending, err := r.Cookie(CookieName)
+	if err != nil || pending.Value == "" {
+		return "", false, nil
+	}
+
+	http.SetCookie(w, deleteCookie())
+
+	req, err := c.Store.Take(r.Context(), pending.Value)
+	if err != nil {
+		return "", true, err
+	}
+
+	if err := c.allow(r.Context(), req, res); err != nil {
+		return errorRedirect(req.RedirectURI, req.State, err), true, nil
+	}
+
+	s := &session.Session{UserID: res.UserID, CreatedAt: time.Now()}
+	if c.Sessions != nil {
+		s, err = c.Sessions.Start(r.Context(), res.UserID)
+		if err != nil {
+			return "", true, err
+		}
+		cookie.SetSessionCookie(w, s.SessionID, s.ExpiresAt)
+	}
+
+	code, err := issueCode(r.Context(), c.ProxyCodes, req, s)
+	if err != nil {
+		return "", true, err
+	}
+
+	return successRedirect(req.RedirectURI, code, req.State), true, nil
+}
+
+// allow applies the client's login policy before any session or code exists.
+func (c *Completer) allow(ctx context.Context, req *authorize.Request, res *upstream.Result) error {
+	if c.Clients == nil || c.Policy == nil {
+		return nil
+	}
+
+	cl, err := c.Clients.Get(ctx, req.ClientID)
+	if err == nil {
+		err = c.Policy.Allow(ctx, cl, res.Identity)
+	}
+	if err == nil {
+		return nil
+	}
+
+	if c.Logger != nil {
+		c.Logger.Info("authorize: login policy rejected user",
+			zap.String("client_id", req.ClientID),
+			zap.String("user_id", res.UserID),
+			zap.Error(err),
+		)
+	}
+
+	if errors.Is(err, loginpolicy.ErrDenied) {
+		return ErrAccessDenied
+	}
+
+	return err
+}
+
+// issueCode binds a proxy code for the pending request to the session the
+// user authenticated in.
+func issueCode(ctx context.Context, proxyCodes ProxyCodeIssuer, req *authorize.Request, s *session.Session) (string, error) {
+	return proxyCodes.IssueCode(ctx, authcode.ProxyCode{
+		UserID:              s.UserID,
+		ClientID:            req.ClientID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		Nonce:               req.Nonce,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		SessionID:           s.SessionID,
+		AuthTime:            s.CreatedAt,
+	})
+}
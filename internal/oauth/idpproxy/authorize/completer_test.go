@@ -0,0 +1,236 @@
+package authorize
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/authcode"
+	"github.com/vinylhousegarage/idpproxy/internal/authorize"
+	authorizestore "github.com/vinylhousegarage/idpproxy/internal/authorize/store"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/loginpolicy"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+)
+
+type fakeProxyCodeIssuer struct {
+	issued authcode.ProxyCode
+	err    error
+}
+
+func (f *fakeProxyCodeIssuer) IssueCode(_ context.Context, pc authcode.ProxyCode) (string, error) {
+	f.issued = pc
+	if f.err != nil {
+		return "", f.err
+	}
+
+	return "proxy-code-1", nil
+}
+
+type fakeLoginPolicy struct {
+	err error
+	got *upstream.Identity
+}
+
+func (f *fakeLoginPolicy) Allow(_ context.Context, _ *client.Client, id *upstream.Identity) error {
+	f.got = id
+	return f.err
+}
+
+func TestCompleter_Complete(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	user1 := &upstream.Result{
+		UserID:   "user-1",
+		Identity: &upstream.Identity{Provider: "github", Subject: "1"},
+	}
+
+	newPending := func(t *testing.T) *authorizestore.MemoryStore {
+		t.Helper()
+
+		store := authorizestore.NewMemoryStore()
+		require.NoError(t, store.Save(ctx, authorize.Request{
+			ID:                  "req-1",
+			ClientID:            "client-1",
+			RedirectURI:         "https://app.example.com/cb?tenant=a",
+			Scope:               "openid email",
+			State:               "st-1",
+			Nonce:               "n-1",
+			CodeChallenge:       "challenge",
+			CodeChallengeMethod: "S256",
+			ExpiresAt:           time.Now().Add(time.Minute),
+		}))
+
+		return store
+	}
+
+	t.Run("without pending authorization", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewCompleter(authorizestore.NewMemoryStore(), &fakeProxyCodeIssuer{})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/cb", nil)
+
+		loc, ok, err := c.Complete(w, r, user1)
+		require.NoError(t, err)
+		require.False(t, ok)
+		require.Empty(t, loc)
+	})
+
+	t.Run("issues proxy code and redirects to client", func(t *testing.T) {
+		t.Parallel()
+
+		issuer := &fakeProxyCodeIssuer{}
+		c := NewCompleter(newPending(t), issuer)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/cb", nil)
+		r.AddCookie(&http.Cookie{Name: CookieName, Value: "req-1"})
+
+		loc, ok, err := c.Complete(w, r, user1)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		u, err := url.Parse(loc)
+		require.NoError(t, err)
+		require.Equal(t, "app.example.com", u.Host)
+		require.Equal(t, "proxy-code-1", u.Query().Get("code"))
+		require.Equal(t, "st-1", u.Query().Get("state"))
+		require.Equal(t, "a", u.Query().Get("tenant"))
+
+		require.False(t, issuer.issued.AuthTime.IsZero())
+		issuer.issued.AuthTime = time.Time{}
+		require.Equal(t, authcode.ProxyCode{
+			UserID:              "user-1",
+			ClientID:            "client-1",
+			RedirectURI:         "https://app.example.com/cb?tenant=a",
+			Scope:               "openid email",
+			Nonce:               "n-1",
+			CodeChallenge:       "challenge",
+			CodeChallengeMethod: "S256",
+		}, issuer.issued)
+
+		require.Contains(t, w.Header().Get("Set-Cookie"), CookieName+"=;")
+	})
+
+	t.Run("starts a session bound to the proxy code", func(t *testing.T) {
+		t.Parallel()
+
+		sessions := session.NewMemoryRepository()
+		issuer := &fakeProxyCodeIssuer{}
+		c := NewCompleter(newPending(t), issuer)
+		c.Sessions = &session.Usecase{
+			Repo:        sessions,
+			Now:         time.Now,
+			TTL:         time.Hour,
+			IDGenerator: func() (string, error) { return "sid-1", nil },
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/cb", nil)
+		r.AddCookie(&http.Cookie{Name: CookieName, Value: "req-1"})
+
+		_, ok, err := c.Complete(w, r, user1)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "sid-1", issuer.issued.SessionID)
+
+		s, err := sessions.FindByID(ctx, "sid-1")
+		require.NoError(t, err)
+		require.Equal(t, "user-1", s.UserID)
+
+		var sessionCookie *http.Cookie
+		for _, ck := range w.Result().Cookies() {
+			if ck.Name == cookie.SessionCookieName {
+				sessionCookie = ck
+			}
+		}
+		require.NotNil(t, sessionCookie)
+		require.Equal(t, "sid-1", sessionCookie.Value)
+		require.True(t, sessionCookie.HttpOnly)
+	})
+
+	t.Run("pending authorization is single use", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewCompleter(newPending(t), &fakeProxyCodeIssuer{})
+
+		complete := func() error {
+			r := httptest.NewRequest(http.MethodGet, "/cb", nil)
+			r.AddCookie(&http.Cookie{Name: CookieName, Value: "req-1"})
+			_, _, err := c.Complete(httptest.NewRecorder(), r, user1)
+			return err
+		}
+
+		require.NoError(t, complete())
+		require.ErrorIs(t, complete(), authorizestore.ErrNotFound)
+	})
+
+	t.Run("issuer failure is returned", func(t *testing.T) {
+		t.Parallel()
+
+		boom := errors.New("boom")
+		c := NewCompleter(newPending(t), &fakeProxyCodeIssuer{err: boom})
+
+		r := httptest.NewRequest(http.MethodGet, "/cb", nil)
+		r.AddCookie(&http.Cookie{Name: CookieName, Value: "req-1"})
+
+		_, ok, err := c.Complete(httptest.NewRecorder(), r, user1)
+		require.True(t, ok)
+		require.ErrorIs(t, err, boom)
+	})
+
+	t.Run("login policy denial redirects with access_denied", func(t *testing.T) {
+		t.Parallel()
+
+		issuer := &fakeProxyCodeIssuer{}
+		policy := &fakeLoginPolicy{err: loginpolicy.ErrDenied}
+		c := NewCompleter(newPending(t), issuer)
+		c.Clients = client.NewMemoryRegistry(&client.Client{ID: "client-1"})
+		c.Policy = policy
+
+		r := httptest.NewRequest(http.MethodGet, "/cb", nil)
+		r.AddCookie(&http.Cookie{Name: CookieName, Value: "req-1"})
+
+		loc, ok, err := c.Complete(httptest.NewRecorder(), r, user1)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Same(t, user1.Identity, policy.got)
+		require.Empty(t, issuer.issued.UserID, "no code is issued")
+
+		u, err := url.Parse(loc)
+		require.NoError(t, err)
+		require.Equal(t, "access_denied", u.Query().Get("error"))
+		require.Equal(t, "st-1", u.Query().Get("state"))
+		require.Empty(t, u.Query().Get("code"))
+	})
+
+	t.Run("login policy lookup failure is a server error", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewCompleter(newPending(t), &fakeProxyCodeIssuer{})
+		c.Clients = client.NewMemoryRegistry(&client.Client{ID: "client-1"})
+		c.Policy = &fakeLoginPolicy{err: loginpolicy.ErrMembershipLookup}
+
+		r := httptest.NewRequest(http.MethodGet, "/cb", nil)
+		r.AddCookie(&http.Cookie{Name: CookieName, Value: "req-1"})
+
+		loc, ok, err := c.Complete(httptest.NewRecorder(), r, user1)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		u, err := url.Parse(loc)
+		require.NoError(t, err)
+		require.Equal(t, "server_error", u.Query().Get("error"))
+	})
+}
@@ -0,0 +1,32 @@
+package authorize
+
+import (
+	"net/http"
+	"time"
+)
+
+const CookieName = "idpproxy_authorize"
+
+func buildCookie(id string, ttl time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     CookieName,
+		Value:    id,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(ttl / time.Second),
+	}
+}
+
+func deleteCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     CookieName,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+	}
+}
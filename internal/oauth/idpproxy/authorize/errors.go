@@ -0,0 +1,12 @@
+package authorize
+
+import "errors"
+
+var (
+	ErrAccessDenied            = errors.New("authorize: access_denied")
+	ErrInvalidRequest          = errors.New("authorize: invalid_request")
+	ErrInvalidScope            = errors.New("authorize: invalid_scope")
+	ErrLoginRequired           = errors.New("authorize: login_required")
+	ErrUnauthorizedClient      = errors.New("authorize: unauthorized_client")
+	ErrUnsupportedResponseType = errors.New("authorize: unsupported_response_type")
+)
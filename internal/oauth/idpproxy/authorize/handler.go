@@ -0,0 +1,230 @@
+package authorize
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/authorize"
+	authorizestore "github.com/vinylhousegarage/idpproxy/internal/authorize/store"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
+)
+
+const DefaultRequestTTL = 10 * time.Minute
+
+type Handler struct {
+	Store     authorizestore.Store
+	Clients   client.Registry
+	Providers []Provider
+	Logger    *zap.Logger
+
+	// optional; set both to let an existing session cookie skip upstream login
+	Sessions   SessionToucher
+	ProxyCodes ProxyCodeIssuer
+
+	// defaults to time.Now
+	Now func() time.Time
+}
+
+func NewHandler(
+	store authorizestore.Store,
+	clients client.Registry,
+	providers []Provider,
+	logger *zap.Logger,
+) *Handler {
+	return &Handler{
+		Store:     store,
+		Clients:   clients,
+		Providers: providers,
+		Logger:    logger,
+	}
+}
+
+func (h *Handler) Serve(c *gin.Context) {
+	w := c.Writer
+	w.Header().Set("Cache-Control", "no-store")
+
+	req, err := ParseRequest(c.Request.URL.Query())
+	if req.ClientID == "" || req.RedirectURI == "" {
+		writeError(w, ErrInvalidRequest)
+		return
+	}
+
+	cl, cerr := h.Clients.Get(c.Request.Context(), req.ClientID)
+	if cerr != nil || !cl.AllowsRedirectURI(req.RedirectURI) {
+		h.Logger.Warn("authorize: rejected client",
+			zap.String("client_id", req.ClientID),
+			zap.String("redirect_uri", req.RedirectURI),
+			zap.Error(cerr),
+		)
+
+		writeError(w, ErrInvalidRequest)
+		return
+	}
+
+	if err == nil {
+		err = checkClient(cl, req)
+	}
+	if err == nil {
+		if s := h.reusableSession(c.Request, cl, req); s != nil {
+			h.completeFromSession(c, req, s)
+			return
+		}
+		if hasPrompt(req, PromptNone) {
+			err = ErrLoginRequired
+		}
+	}
+	if err != nil {
+		h.Logger.Info("authorize: request rejected",
+			zap.String("client_id", req.ClientID),
+			zap.Error(err),
+		)
+
+		c.Redirect(http.StatusFound, errorRedirect(req.RedirectURI, req.State, err))
+		return
+	}
+
+	req.ID, err = generateRequestID()
+	if err != nil {
+		h.Logger.Error("authorize: generate request id failed", zap.Error(err))
+
+		c.Redirect(http.StatusFound, errorRedirect(req.RedirectURI, req.State, err))
+		return
+	}
+	req.ExpiresAt = h.now().Add(DefaultRequestTTL)
+
+	if err := h.Store.Save(c.Request.Context(), *req); err != nil {
+		h.Logger.Error("authorize: save request failed", zap.Error(err))
+
+		c.Redirect(http.StatusFound, errorRedirect(req.RedirectURI, req.State, err))
+		return
+	}
+
+	http.SetCookie(w, buildCookie(req.ID, DefaultRequestTTL))
+
+	if p, ok := findProvider(h.Providers, req.IDPHint); ok && !hasPrompt(req, PromptSelectAccount) {
+		c.Redirect(http.StatusFound, p.LoginPath)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := pickerTemplate.Execute(w, h.Providers); err != nil {
+		h.Logger.Error("authorize: render picker failed", zap.Error(err))
+	}
+}
+
+// reusableSession returns the browser's active session when it satisfies the
+// request's prompt and max_age, or nil when the user must log in upstream.
+// Clients with a login policy always need a fresh upstream identity.
+func (h *Handler) reusableSession(r *http.Request, cl *client.Client, req *authorize.Request) *session.Session {
+	if h.Sessions == nil || h.ProxyCodes == nil || !cl.LoginPolicy.IsZero() {
+		return nil
+	}
+	if hasPrompt(req, PromptLogin) || hasPrompt(req, PromptSelectAccount) {
+		return nil
+	}
+
+	ck, err := r.Cookie(cookie.SessionCookieName)
+	if err != nil || ck.Value == "" {
+		return nil
+	}
+
+	s, err := h.Sessions.Touch(r.Context(), ck.Value)
+	if err != nil {
+		if !errors.Is(err, session.ErrNotFound) &&
+			!errors.Is(err, session.ErrExpiredSession) &&
+			!errors.Is(err, session.ErrIdleSession) &&
+			!errors.Is(err, session.ErrInactiveSession) {
+			h.Logger.Warn("authorize: touch session failed", zap.Error(err))
+		}
+		return nil
+	}
+
+	if req.MaxAge != nil && h.now().Sub(s.CreatedAt) > *req.MaxAge {
+		return nil
+	}
+
+	return s
+}
+
+func (h *Handler) completeFromSession(c *gin.Context, req *authorize.Request, s *session.Session) {
+	code, err := issueCode(c.Request.Context(), h.ProxyCodes, req, s)
+	if err != nil {
+		h.Logger.Error("authorize: issue code from session failed", zap.Error(err))
+
+		c.Redirect(http.StatusFound, errorRedirect(req.RedirectURI, req.State, err))
+		return
+	}
+
+	cookie.SetSessionCookie(c.Writer, s.SessionID, s.ExpiresAt)
+	c.Redirect(http.StatusFound, successRedirect(req.RedirectURI, code, req.State))
+}
+
+func (h *Handler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+
+	return time.Now()
+}
+
+func checkClient(cl *client.Client, req *authorize.Request) error {
+	if !cl.AllowsGrantType("authorization_code") {
+		return ErrUnauthorizedClient
+	}
+	if !cl.AllowsScope(req.Scope) {
+		return ErrInvalidScope
+	}
+	if cl.IsPublic() && req.CodeChallenge == "" {
+		return ErrInvalidRequest
+	}
+
+	return nil
+}
+
+func generateRequestID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrAccessDenied):
+		return "access_denied"
+	case errors.Is(err, ErrInvalidRequest):
+		return "invalid_request"
+	case errors.Is(err, ErrInvalidScope):
+		return "invalid_scope"
+	case errors.Is(err, ErrLoginRequired):
+		return "login_required"
+	case errors.Is(err, ErrUnauthorizedClient):
+		return "unauthorized_client"
+	case errors.Is(err, ErrUnsupportedResponseType):
+		return "unsupported_response_type"
+	default:
+		return "server_error"
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: errorCode(err)})
+}
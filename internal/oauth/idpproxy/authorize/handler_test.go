@@ -0,0 +1,447 @@
+package authorize
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	authorizestore "github.com/vinylhousegarage/idpproxy/internal/authorize/store"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
+)
+
+var testProviders = []Provider{
+	{ID: "github", Name: "GitHub", LoginPath: "/github/login"},
+	{ID: "google", Name: "Google", LoginPath: "https://idpproxy.example.com/google/login"},
+}
+
+func newTestRouter(store authorizestore.Store) *gin.Engine {
+	return newHandlerRouter(newTestHandler(store))
+}
+
+func newHandlerRouter(h *Handler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET(Path, h.Serve)
+
+	return r
+}
+
+func newTestHandler(store authorizestore.Store) *Handler {
+	return NewHandler(store, client.NewMemoryRegistry(
+		&client.Client{
+			ID:           "client-1",
+			Type:         client.TypeConfidential,
+			SecretHash:   "hash",
+			RedirectURIs: []string{"https://app.example.com/cb"},
+			Scopes:       []string{"openid", "email"},
+		},
+		&client.Client{
+			ID:           "spa",
+			Type:         client.TypePublic,
+			RedirectURIs: []string{"https://app.example.com/cb"},
+		},
+		&client.Client{
+			ID:           "refresh-only",
+			Type:         client.TypeConfidential,
+			SecretHash:   "hash",
+			RedirectURIs: []string{"https://app.example.com/cb"},
+			GrantTypes:   []string{"refresh_token"},
+		},
+	), testProviders, zap.NewNop())
+}
+
+func authorizeQuery(extra url.Values) string {
+	q := url.Values{
+		"client_id":     {"client-1"},
+		"redirect_uri":  {"https://app.example.com/cb"},
+		"response_type": {"code"},
+		"scope":         {"openid"},
+		"state":         {"st-1"},
+		"nonce":         {"n-1"},
+	}
+	for k, v := range extra {
+		q[k] = v
+	}
+
+	return Path + "?" + q.Encode()
+}
+
+func serve(r *gin.Engine, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func authorizeCookie(t *testing.T, w *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+
+	for _, c := range w.Result().Cookies() {
+		if c.Name == CookieName {
+			return c
+		}
+	}
+
+	t.Fatalf("cookie %s not set", CookieName)
+	return nil
+}
+
+func TestHandler_Serve(t *testing.T) {
+	t.Parallel()
+
+	t.Run("renders provider picker and stores request", func(t *testing.T) {
+		t.Parallel()
+
+		store := authorizestore.NewMemoryStore()
+		w := serve(newTestRouter(store), authorizeQuery(nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Header().Get("Content-Type"), "text/html")
+		require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		require.Contains(t, w.Body.String(), `href="/github/login"`)
+		require.Contains(t, w.Body.String(), `href="https://idpproxy.example.com/google/login"`)
+
+		cookie := authorizeCookie(t, w)
+		require.True(t, cookie.HttpOnly)
+		require.True(t, cookie.Secure)
+
+		req, err := store.Take(context.Background(), cookie.Value)
+		require.NoError(t, err)
+		require.Equal(t, "client-1", req.ClientID)
+		require.Equal(t, "st-1", req.State)
+		require.Equal(t, "n-1", req.Nonce)
+	})
+
+	t.Run("idp_hint redirects to upstream login", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(newTestRouter(authorizestore.NewMemoryStore()), authorizeQuery(url.Values{"idp_hint": {"github"}}))
+
+		require.Equal(t, http.StatusFound, w.Code)
+		require.Equal(t, "/github/login", w.Header().Get("Location"))
+		authorizeCookie(t, w)
+	})
+
+	t.Run("prompt=select_account shows picker despite idp_hint", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(newTestRouter(authorizestore.NewMemoryStore()), authorizeQuery(url.Values{
+			"idp_hint": {"github"},
+			"prompt":   {"select_account"},
+		}))
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("unknown idp_hint shows picker", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(newTestRouter(authorizestore.NewMemoryStore()), authorizeQuery(url.Values{"idp_hint": {"okta"}}))
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("unregistered redirect_uri is not redirected to", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(newTestRouter(authorizestore.NewMemoryStore()), authorizeQuery(url.Values{
+			"redirect_uri": {"https://evil.example.com/cb"},
+		}))
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Empty(t, w.Header().Get("Location"))
+		require.JSONEq(t, `{"error":"invalid_request"}`, w.Body.String())
+	})
+
+	t.Run("unknown client is rejected", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(newTestRouter(authorizestore.NewMemoryStore()), authorizeQuery(url.Values{
+			"client_id": {"client-2"},
+		}))
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Empty(t, w.Header().Get("Location"))
+	})
+
+	t.Run("invalid scope redirects error to client", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(newTestRouter(authorizestore.NewMemoryStore()), authorizeQuery(url.Values{"scope": {"email"}}))
+
+		require.Equal(t, http.StatusFound, w.Code)
+
+		loc, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "app.example.com", loc.Host)
+		require.Equal(t, "invalid_scope", loc.Query().Get("error"))
+		require.Equal(t, "st-1", loc.Query().Get("state"))
+	})
+
+	t.Run("prompt=none returns login_required", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(newTestRouter(authorizestore.NewMemoryStore()), authorizeQuery(url.Values{"prompt": {"none"}}))
+
+		require.Equal(t, http.StatusFound, w.Code)
+
+		loc, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "login_required", loc.Query().Get("error"))
+	})
+
+	t.Run("scope not allowed for client redirects invalid_scope", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(newTestRouter(authorizestore.NewMemoryStore()), authorizeQuery(url.Values{"scope": {"openid profile"}}))
+
+		require.Equal(t, http.StatusFound, w.Code)
+
+		loc, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "invalid_scope", loc.Query().Get("error"))
+	})
+
+	t.Run("client without authorization_code grant redirects unauthorized_client", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(newTestRouter(authorizestore.NewMemoryStore()), authorizeQuery(url.Values{"client_id": {"refresh-only"}}))
+
+		require.Equal(t, http.StatusFound, w.Code)
+
+		loc, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "unauthorized_client", loc.Query().Get("error"))
+	})
+
+	t.Run("public client must send code_challenge", func(t *testing.T) {
+		t.Parallel()
+
+		r := newTestRouter(authorizestore.NewMemoryStore())
+
+		w := serve(r, authorizeQuery(url.Values{"client_id": {"spa"}}))
+		require.Equal(t, http.StatusFound, w.Code)
+
+		loc, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "invalid_request", loc.Query().Get("error"))
+
+		w = serve(r, authorizeQuery(url.Values{
+			"client_id":             {"spa"},
+			"code_challenge":        {"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+			"code_challenge_method": {"S256"},
+		}))
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestHandler_ServeWithSession(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	newRouter := func(t *testing.T, s *session.Session) (*gin.Engine, *fakeProxyCodeIssuer) {
+		t.Helper()
+
+		sessions := session.NewMemoryRepository()
+		require.NoError(t, sessions.Create(ctx, s))
+
+		issuer := &fakeProxyCodeIssuer{}
+		h := newTestHandler(authorizestore.NewMemoryStore())
+		h.Sessions = &session.Usecase{Repo: sessions, Now: func() time.Time { return now }}
+		h.ProxyCodes = issuer
+		h.Now = func() time.Time { return now }
+
+		return newHandlerRouter(h), issuer
+	}
+
+	active := func() *session.Session {
+		return &session.Session{
+			SessionID: "sid-1",
+			UserID:    "user-1",
+			Status:    "active",
+			CreatedAt: now.Add(-10 * time.Minute),
+			ExpiresAt: now.Add(time.Hour),
+		}
+	}
+
+	serveWithSession := func(r *gin.Engine, extra url.Values) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, authorizeQuery(extra), nil)
+		req.AddCookie(&http.Cookie{Name: cookie.SessionCookieName, Value: "sid-1"})
+		r.ServeHTTP(w, req)
+
+		return w
+	}
+
+	location := func(t *testing.T, w *httptest.ResponseRecorder) *url.URL {
+		t.Helper()
+
+		require.Equal(t, http.StatusFound, w.Code)
+		loc, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+
+		return loc
+	}
+
+	t.Run("active session issues a code without upstream login", func(t *testing.T) {
+		t.Parallel()
+
+		r, issuer := newRouter(t, active())
+		loc := location(t, serveWithSession(r, nil))
+
+		require.Equal(t, "app.example.com", loc.Host)
+		require.Equal(t, "proxy-code-1", loc.Query().Get("code"))
+		require.Equal(t, "st-1", loc.Query().Get("state"))
+		require.Equal(t, "user-1", issuer.issued.UserID)
+		require.Equal(t, "sid-1", issuer.issued.SessionID)
+		require.True(t, issuer.issued.AuthTime.Equal(now.Add(-10*time.Minute)))
+	})
+
+	t.Run("prompt=none succeeds with an active session", func(t *testing.T) {
+		t.Parallel()
+
+		r, _ := newRouter(t, active())
+		loc := location(t, serveWithSession(r, url.Values{"prompt": {"none"}}))
+
+		require.Equal(t, "proxy-code-1", loc.Query().Get("code"))
+	})
+
+	t.Run("prompt=login forces upstream login", func(t *testing.T) {
+		t.Parallel()
+
+		r, issuer := newRouter(t, active())
+		w := serveWithSession(r, url.Values{"prompt": {"login"}})
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Header().Get("Content-Type"), "text/html")
+		require.Empty(t, issuer.issued.UserID)
+	})
+
+	t.Run("max_age older than the session forces upstream login", func(t *testing.T) {
+		t.Parallel()
+
+		r, issuer := newRouter(t, active())
+		w := serveWithSession(r, url.Values{"max_age": {"60"}})
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, issuer.issued.UserID)
+	})
+
+	t.Run("max_age within the session is honored", func(t *testing.T) {
+		t.Parallel()
+
+		r, _ := newRouter(t, active())
+		loc := location(t, serveWithSession(r, url.Values{"max_age": {"3600"}}))
+
+		require.Equal(t, "proxy-code-1", loc.Query().Get("code"))
+	})
+
+	t.Run("prompt=none with stale max_age returns login_required", func(t *testing.T) {
+		t.Parallel()
+
+		r, _ := newRouter(t, active())
+		loc := location(t, serveWithSession(r, url.Values{"prompt": {"none"}, "max_age": {"0"}}))
+
+		require.Equal(t, "login_required", loc.Query().Get("error"))
+	})
+
+	t.Run("client with a login policy skips session reuse", func(t *testing.T) {
+		t.Parallel()
+
+		sessions := session.NewMemoryRepository()
+		require.NoError(t, sessions.Create(ctx, active()))
+
+		issuer := &fakeProxyCodeIssuer{}
+		h := newTestHandler(authorizestore.NewMemoryStore())
+		h.Clients = client.NewMemoryRegistry(&client.Client{
+			ID:           "client-1",
+			Type:         client.TypeConfidential,
+			SecretHash:   "hash",
+			RedirectURIs: []string{"https://app.example.com/cb"},
+			Scopes:       []string{"openid", "email"},
+			LoginPolicy:  client.LoginPolicy{GitHubOrgs: []string{"acme"}},
+		})
+		h.Sessions = &session.Usecase{Repo: sessions, Now: func() time.Time { return now }}
+		h.ProxyCodes = issuer
+
+		w := serveWithSession(newHandlerRouter(h), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, issuer.issued.UserID)
+	})
+
+	t.Run("idle session falls back to upstream login", func(t *testing.T) {
+		t.Parallel()
+
+		sessions := session.NewMemoryRepository()
+		require.NoError(t, sessions.Create(ctx, active()))
+
+		h := newTestHandler(authorizestore.NewMemoryStore())
+		h.Sessions = &session.Usecase{
+			Repo:   sessions,
+			Now:    func() time.Time { return now },
+			Policy: session.Policy{IdleTimeout: 5 * time.Minute},
+		}
+		h.ProxyCodes = &fakeProxyCodeIssuer{}
+
+		loc := location(t, serveWithSession(newHandlerRouter(h), url.Values{"prompt": {"none"}}))
+
+		require.Equal(t, "login_required", loc.Query().Get("error"))
+	})
+
+	t.Run("reuse slides the session expiry", func(t *testing.T) {
+		t.Parallel()
+
+		sessions := session.NewMemoryRepository()
+		require.NoError(t, sessions.Create(ctx, active()))
+
+		h := newTestHandler(authorizestore.NewMemoryStore())
+		h.Sessions = &session.Usecase{
+			Repo:   sessions,
+			Now:    func() time.Time { return now },
+			TTL:    24 * time.Hour,
+			Policy: session.Policy{IdleTimeout: 30 * time.Minute, MaxLifetime: 8 * time.Hour},
+		}
+		h.ProxyCodes = &fakeProxyCodeIssuer{}
+
+		w := serveWithSession(newHandlerRouter(h), nil)
+		require.Equal(t, http.StatusFound, w.Code)
+
+		s, err := sessions.FindByID(ctx, "sid-1")
+		require.NoError(t, err)
+		require.True(t, s.ExpiresAt.Equal(now.Add(30*time.Minute)))
+		require.NotNil(t, s.LastUsed)
+
+		var sessionCookie *http.Cookie
+		for _, ck := range w.Result().Cookies() {
+			if ck.Name == cookie.SessionCookieName {
+				sessionCookie = ck
+			}
+		}
+		require.NotNil(t, sessionCookie)
+		require.Equal(t, "sid-1", sessionCookie.Value)
+	})
+
+	t.Run("ended session falls back to upstream login", func(t *testing.T) {
+		t.Parallel()
+
+		s := active()
+		s.Status = "inactive"
+		r, _ := newRouter(t, s)
+		loc := location(t, serveWithSession(r, url.Values{"prompt": {"none"}}))
+
+		require.Equal(t, "login_required", loc.Query().Get("error"))
+	})
+}
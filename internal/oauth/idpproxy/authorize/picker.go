@@ -0,0 +1,22 @@
+package authorize
+
+import "html/template"
+
+var pickerTemplate = template.Must(template.New("picker").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+  <meta charset="utf-8">
+  <title>idpproxy</title>
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+  <h1>idpproxy</h1>
+
+  <ul>
+  {{- range .}}
+    <li><a href="{{.LoginPath}}">{{.Name}}</a></li>
+  {{- end}}
+  </ul>
+</body>
+</html>
+`))
@@ -0,0 +1,17 @@
+package authorize
+
+type Provider struct {
+	ID        string
+	Name      string
+	LoginPath string
+}
+
+func findProvider(providers []Provider, id string) (Provider, bool) {
+	for _, p := range providers {
+		if p.ID == id {
+			return p, true
+		}
+	}
+
+	return Provider{}, false
+}
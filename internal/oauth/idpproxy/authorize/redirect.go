@@ -0,0 +1,38 @@
+package authorize
+
+import (
+	"net/url"
+)
+
+func redirectWithParams(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	q := u.Query()
+	for k, vs := range params {
+		for _, v := range vs {
+			if v != "" {
+				q.Set(k, v)
+			}
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+func errorRedirect(redirectURI, state string, err error) string {
+	return redirectWithParams(redirectURI, url.Values{
+		"error": {errorCode(err)},
+		"state": {state},
+	})
+}
+
+func successRedirect(redirectURI, code, state string) string {
+	return redirectWithParams(redirectURI, url.Values{
+		"code":  {code},
+		"state": {state},
+	})
+}
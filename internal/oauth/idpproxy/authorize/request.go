@@ -0,0 +1,80 @@
+package authorize
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/authorize"
+)
+
+const (
+	PromptConsent       = "consent"
+	PromptLogin         = "login"
+	PromptNone          = "none"
+	PromptSelectAccount = "select_account"
+)
+
+var supportedPrompts = []string{PromptConsent, PromptLogin, PromptNone, PromptSelectAccount}
+
+func ParseRequest(q url.Values) (*authorize.Request, error) {
+	req := &authorize.Request{
+		ClientID:    q.Get("client_id"),
+		RedirectURI: q.Get("redirect_uri"),
+		Scope:       strings.Join(strings.Fields(q.Get("scope")), " "),
+		State:       q.Get("state"),
+		Nonce:       q.Get("nonce"),
+		Prompt:      strings.Join(strings.Fields(q.Get("prompt")), " "),
+		IDPHint:     q.Get("idp_hint"),
+	}
+
+	if req.ClientID == "" || req.RedirectURI == "" {
+		return req, ErrInvalidRequest
+	}
+
+	if responseType := q.Get("response_type"); responseType != "code" {
+		if responseType == "" {
+			return req, ErrInvalidRequest
+		}
+		return req, ErrUnsupportedResponseType
+	}
+
+	if !slices.Contains(strings.Fields(req.Scope), "openid") {
+		return req, ErrInvalidScope
+	}
+
+	prompts := strings.Fields(req.Prompt)
+	for _, p := range prompts {
+		if !slices.Contains(supportedPrompts, p) {
+			return req, ErrInvalidRequest
+		}
+	}
+	if slices.Contains(prompts, PromptNone) && len(prompts) > 1 {
+		return req, ErrInvalidRequest
+	}
+
+	if raw := q.Get("max_age"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 0 {
+			return req, ErrInvalidRequest
+		}
+		maxAge := time.Duration(seconds) * time.Second
+		req.MaxAge = &maxAge
+	}
+
+	challenge, method, err := pkce.NormalizeChallenge(q.Get("code_challenge"), q.Get("code_challenge_method"))
+	if err != nil {
+		return req, ErrInvalidRequest
+	}
+	req.CodeChallenge = challenge
+	req.CodeChallengeMethod = method
+
+	return req, nil
+}
+
+func hasPrompt(req *authorize.Request, prompt string) bool {
+	return slices.Contains(strings.Fields(req.Prompt), prompt)
+}
@@ -0,0 +1,86 @@
+package authorize
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequest(t *testing.T) {
+	t.Parallel()
+
+	base := func() url.Values {
+		return url.Values{
+			"client_id":     {"client-1"},
+			"redirect_uri":  {"https://app.example.com/cb"},
+			"response_type": {"code"},
+			"scope":         {"openid  email"},
+			"state":         {"st-1"},
+			"nonce":         {"n-1"},
+		}
+	}
+
+	t.Run("valid request", func(t *testing.T) {
+		t.Parallel()
+
+		q := base()
+		q.Set("prompt", "login")
+		q.Set("idp_hint", "github")
+		q.Set("code_challenge", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM")
+		q.Set("code_challenge_method", "S256")
+		q.Set("max_age", "300")
+
+		req, err := ParseRequest(q)
+		require.NoError(t, err)
+		require.Equal(t, "client-1", req.ClientID)
+		require.Equal(t, "https://app.example.com/cb", req.RedirectURI)
+		require.Equal(t, "openid email", req.Scope)
+		require.Equal(t, "st-1", req.State)
+		require.Equal(t, "n-1", req.Nonce)
+		require.Equal(t, "login", req.Prompt)
+		require.Equal(t, "github", req.IDPHint)
+		require.Equal(t, "S256", req.CodeChallengeMethod)
+		require.NotNil(t, req.MaxAge)
+		require.Equal(t, 5*time.Minute, *req.MaxAge)
+	})
+
+	t.Run("max_age is optional", func(t *testing.T) {
+		t.Parallel()
+
+		req, err := ParseRequest(base())
+		require.NoError(t, err)
+		require.Nil(t, req.MaxAge)
+	})
+
+	tests := []struct {
+		name    string
+		mutate  func(url.Values)
+		wantErr error
+	}{
+		{"missing client_id", func(q url.Values) { q.Del("client_id") }, ErrInvalidRequest},
+		{"missing redirect_uri", func(q url.Values) { q.Del("redirect_uri") }, ErrInvalidRequest},
+		{"missing response_type", func(q url.Values) { q.Del("response_type") }, ErrInvalidRequest},
+		{"token response_type", func(q url.Values) { q.Set("response_type", "token") }, ErrUnsupportedResponseType},
+		{"scope without openid", func(q url.Values) { q.Set("scope", "email") }, ErrInvalidScope},
+		{"unknown prompt", func(q url.Values) { q.Set("prompt", "always") }, ErrInvalidRequest},
+		{"prompt none with others", func(q url.Values) { q.Set("prompt", "none login") }, ErrInvalidRequest},
+		{"negative max_age", func(q url.Values) { q.Set("max_age", "-1") }, ErrInvalidRequest},
+		{"non-numeric max_age", func(q url.Values) { q.Set("max_age", "soon") }, ErrInvalidRequest},
+		{"invalid code_challenge", func(q url.Values) { q.Set("code_challenge", "short") }, ErrInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			q := base()
+			tt.mutate(q)
+
+			_, err := ParseRequest(q)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package authorize
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	authcodeservice "github.com/vinylhousegarage/idpproxy/internal/authcode/service"
+	"github.com/vinylhousegarage/idpproxy/internal/deps"
+)
+
+const Path = "/authorize"
+
+func newSessionUsecase(oidcDeps *deps.OIDCDependencies) *session.Usecase {
+	return &session.Usecase{
+		Repo:        oidcDeps.Sessions,
+		Now:         time.Now,
+		TTL:         session.DefaultTTL,
+		IDGenerator: session.NewID,
+		Policy: session.Policy{
+			IdleTimeout: oidcDeps.Config.SessionIdleTimeout,
+			MaxLifetime: oidcDeps.Config.SessionMaxLifetime,
+		},
+	}
+}
+
+func NewHandlerFromDeps(oidcDeps *deps.OIDCDependencies, providers []Provider) *Handler {
+	h := NewHandler(oidcDeps.Authorizations, oidcDeps.Clients, providers, oidcDeps.Logger)
+
+	if oidcDeps.Sessions != nil {
+		h.Sessions = newSessionUsecase(oidcDeps)
+		h.ProxyCodes = authcodeservice.NewService(oidcDeps.ProxyCodes)
+	}
+
+	return h
+}
+
+func NewCompleterFromDeps(oidcDeps *deps.OIDCDependencies) *Completer {
+	c := NewCompleter(oidcDeps.Authorizations, authcodeservice.NewService(oidcDeps.ProxyCodes))
+	c.Logger = oidcDeps.Logger
+	c.Clients = oidcDeps.Clients
+
+	if oidcDeps.Sessions != nil {
+		c.Sessions = newSessionUsecase(oidcDeps)
+	}
+
+	return c
+}
+
+func RegisterRoutes(r gin.IRoutes, oidcDeps *deps.OIDCDependencies, providers []Provider) {
+	h := NewHandlerFromDeps(oidcDeps, providers)
+	r.GET(Path, h.Serve)
+}
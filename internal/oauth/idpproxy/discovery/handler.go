@@ -0,0 +1,34 @@
+package discovery
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type DiscoveryHandler struct {
+	Issuer   string
+	Metadata Metadata
+	Logger   *zap.Logger
+}
+
+func NewDiscoveryHandler(issuer string, meta Metadata, logger *zap.Logger) *DiscoveryHandler {
+	return &DiscoveryHandler{
+		Issuer:   issuer,
+		Metadata: meta,
+		Logger:   logger,
+	}
+}
+
+func (h *DiscoveryHandler) Serve(c *gin.Context) {
+	if h.Issuer == "" {
+		h.Logger.Error("discovery issuer is not configured")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server not ready"})
+
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, BuildDiscoveryResponse(h.Issuer, h.Metadata))
+}
@@ -0,0 +1,56 @@
+package discovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDiscoveryHandler_Serve(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns discovery document", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewDiscoveryHandler("https://idpproxy.com", Metadata{
+			TokenPath:  "/token",
+			GrantTypes: []string{"authorization_code"},
+		}, zap.NewNop())
+
+		r := gin.New()
+		r.GET(Path, h.Serve)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, Path, nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+		require.JSONEq(t, `{
+			"issuer": "https://idpproxy.com",
+			"token_endpoint": "https://idpproxy.com/token",
+			"response_types_supported": [],
+			"grant_types_supported": ["authorization_code"],
+			"subject_types_supported": ["public"],
+			"id_token_signing_alg_values_supported": []
+		}`, w.Body.String())
+	})
+
+	t.Run("returns 500 when issuer is empty", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewDiscoveryHandler("", Metadata{}, zap.NewNop())
+
+		r := gin.New()
+		r.GET(Path, h.Serve)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, Path, nil))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
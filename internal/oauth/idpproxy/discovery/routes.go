@@ -0,0 +1,14 @@
+package discovery
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/vinylhousegarage/idpproxy/internal/deps"
+)
+
+const Path = "/.well-known/openid-configuration"
+
+func RegisterRoutes(r gin.IRoutes, oidcDeps *deps.OIDCDependencies, meta Metadata) {
+	h := NewDiscoveryHandler(oidcDeps.Config.Issuer, meta, oidcDeps.Logger)
+	r.GET(Path, h.Serve)
+}
@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"slices"
+	"strings"
+)
+
+var (
+	DefaultScopes = []string{"openid"}
+
+	DefaultClaims = []string{
+		"iss", "sub", "aud", "exp", "iat",
+		"auth_time", "nonce", "amr", "azp", "at_hash", "sid",
+	}
+
+	DefaultSubjectTypes = []string{"public"}
+)
+
+type Metadata struct {
+	AuthorizationPath string
+	TokenPath         string
+	UserInfoPath      string
+	JWKSPath          string
+	RevocationPath    string
+	IntrospectionPath string
+	EndSessionPath    string
+
+	GrantTypes           []string
+	ResponseTypes        []string
+	Scopes               []string
+	Claims               []string
+	SigningAlgs          []string
+	CodeChallengeMethods []string
+	TokenAuthMethods     []string
+
+	BackchannelLogout bool
+}
+
+func endpointURL(issuer, path string) string {
+	if path == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(issuer, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+func nonNil(v []string) []string {
+	if v == nil {
+		return []string{}
+	}
+
+	return slices.Clone(v)
+}
+
+func BuildDiscoveryResponse(issuer string, m Metadata) *DiscoveryResponse {
+	return &DiscoveryResponse{
+		Issuer:                            strings.TrimSuffix(issuer, "/"),
+		AuthorizationEndpoint:             endpointURL(issuer, m.AuthorizationPath),
+		TokenEndpoint:                     endpointURL(issuer, m.TokenPath),
+		UserInfoEndpoint:                  endpointURL(issuer, m.UserInfoPath),
+		JWKSURI:                           endpointURL(issuer, m.JWKSPath),
+		RevocationEndpoint:                endpointURL(issuer, m.RevocationPath),
+		IntrospectionEndpoint:             endpointURL(issuer, m.IntrospectionPath),
+		EndSessionEndpoint:                endpointURL(issuer, m.EndSessionPath),
+		ScopesSupported:                   slices.Clone(m.Scopes),
+		ResponseTypesSupported:            nonNil(m.ResponseTypes),
+		GrantTypesSupported:               slices.Clone(m.GrantTypes),
+		SubjectTypesSupported:             slices.Clone(DefaultSubjectTypes),
+		IDTokenSigningAlgValuesSupported:  nonNil(m.SigningAlgs),
+		ClaimsSupported:                   slices.Clone(m.Claims),
+		CodeChallengeMethodsSupported:     slices.Clone(m.CodeChallengeMethods),
+		TokenEndpointAuthMethodsSupported: slices.Clone(m.TokenAuthMethods),
+		BackchannelLogoutSupported:        m.BackchannelLogout,
+		BackchannelLogoutSessionSupported: m.BackchannelLogout,
+	}
+}
@@ -0,0 +1,70 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDiscoveryResponse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("mounted endpoints are resolved against issuer", func(t *testing.T) {
+		t.Parallel()
+
+		meta := Metadata{
+			AuthorizationPath:    "/authorize",
+			TokenPath:            "/token",
+			JWKSPath:             "/.well-known/jwks.json",
+			GrantTypes:           []string{"authorization_code"},
+			ResponseTypes:        []string{"code"},
+			Scopes:               DefaultScopes,
+			Claims:               DefaultClaims,
+			SigningAlgs:          []string{"RS256"},
+			CodeChallengeMethods: []string{"S256", "plain"},
+			TokenAuthMethods:     []string{"client_secret_basic"},
+		}
+
+		got := BuildDiscoveryResponse("https://idpproxy.com/", meta)
+
+		require.Equal(t, "https://idpproxy.com", got.Issuer)
+		require.Equal(t, "https://idpproxy.com/authorize", got.AuthorizationEndpoint)
+		require.Equal(t, "https://idpproxy.com/token", got.TokenEndpoint)
+		require.Equal(t, "https://idpproxy.com/.well-known/jwks.json", got.JWKSURI)
+		require.Equal(t, []string{"authorization_code"}, got.GrantTypesSupported)
+		require.Equal(t, []string{"code"}, got.ResponseTypesSupported)
+		require.Equal(t, []string{"openid"}, got.ScopesSupported)
+		require.Equal(t, []string{"public"}, got.SubjectTypesSupported)
+		require.Equal(t, []string{"RS256"}, got.IDTokenSigningAlgValuesSupported)
+		require.Equal(t, []string{"S256", "plain"}, got.CodeChallengeMethodsSupported)
+		require.Equal(t, []string{"client_secret_basic"}, got.TokenEndpointAuthMethodsSupported)
+		require.Contains(t, got.ClaimsSupported, "sub")
+	})
+
+	t.Run("unmounted endpoints are omitted", func(t *testing.T) {
+		t.Parallel()
+
+		got := BuildDiscoveryResponse("https://idpproxy.com", Metadata{})
+
+		require.Empty(t, got.AuthorizationEndpoint)
+		require.Empty(t, got.TokenEndpoint)
+		require.Empty(t, got.UserInfoEndpoint)
+		require.Empty(t, got.JWKSURI)
+		require.Empty(t, got.RevocationEndpoint)
+		require.Empty(t, got.IntrospectionEndpoint)
+		require.Empty(t, got.EndSessionEndpoint)
+		require.False(t, got.BackchannelLogoutSupported)
+		require.NotNil(t, got.ResponseTypesSupported)
+		require.NotNil(t, got.IDTokenSigningAlgValuesSupported)
+	})
+
+	t.Run("metadata slices are not shared", func(t *testing.T) {
+		t.Parallel()
+
+		scopes := []string{"openid"}
+		got := BuildDiscoveryResponse("https://idpproxy.com", Metadata{Scopes: scopes})
+
+		got.ScopesSupported[0] = "changed"
+		require.Equal(t, "openid", scopes[0])
+	})
+}
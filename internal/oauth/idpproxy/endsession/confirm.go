@@ -0,0 +1,58 @@
+package endsession
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"time"
+)
+
+const (
+	ConfirmCookieName = "idpproxy_logout"
+	confirmTTL        = 10 * time.Minute
+)
+
+type confirmPage struct {
+	Action                string
+	ConfirmToken          string
+	ClientID              string
+	PostLogoutRedirectURI string
+	State                 string
+}
+
+// The confirmation form is double-submitted: a cross-site page cannot read the
+// strict cookie, so it cannot forge a matching confirm_token.
+func isConfirmed(r *http.Request, req Request) bool {
+	if r.Method != http.MethodPost || req.ConfirmToken == "" {
+		return false
+	}
+
+	ck, err := r.Cookie(ConfirmCookieName)
+	if err != nil || ck.Value == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(ck.Value), []byte(req.ConfirmToken)) == 1
+}
+
+func newConfirmToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func confirmCookie(token string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     ConfirmCookieName,
+		Value:    token,
+		Path:     Path,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   maxAge,
+	}
+}
@@ -0,0 +1,9 @@
+package endsession
+
+import "errors"
+
+var (
+	ErrInvalidIDTokenHint   = errors.New("endsession: invalid id_token_hint")
+	ErrInvalidRequest       = errors.New("endsession: invalid request")
+	ErrUnregisteredRedirect = errors.New("endsession: post_logout_redirect_uri is not registered")
+)
@@ -0,0 +1,299 @@
+package endsession
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
+)
+
+type Handler struct {
+	Issuer   string
+	IDTokens IDTokenVerifier
+	Clients  ClientRegistry
+	Logger   *zap.Logger
+
+	// optional
+	Sessions    SessionInvalidator
+	Backchannel BackchannelNotifier
+
+	// optional; set both to cut off the user's tokens on logout
+	RefreshTokens RefreshTokenRevoker
+	Generations   AccessGenerationBumper
+
+	// defaults to time.Now
+	Now func() time.Time
+}
+
+func NewHandler(issuer string, idTokens IDTokenVerifier, clients ClientRegistry, logger *zap.Logger) *Handler {
+	return &Handler{
+		Issuer:   issuer,
+		IDTokens: idTokens,
+		Clients:  clients,
+		Logger:   logger,
+	}
+}
+
+func (h *Handler) Serve(c *gin.Context) {
+	w := c.Writer
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := c.Request.ParseForm(); err != nil {
+		writeError(w, ErrInvalidRequest)
+		return
+	}
+	req := ParseRequest(c.Request.Form)
+	ctx := c.Request.Context()
+
+	var hint *hintClaims
+	if req.IDTokenHint != "" {
+		var err error
+		hint, err = parseIDTokenHint(ctx, h.IDTokens, h.Issuer, req.IDTokenHint)
+		if err != nil {
+			h.Logger.Warn("end_session: rejected id_token_hint", zap.Error(err))
+			writeError(w, err)
+			return
+		}
+	}
+
+	redirectURI, err := h.resolveRedirect(ctx, req, hint)
+	if err != nil {
+		h.Logger.Warn("end_session: rejected request",
+			zap.String("client_id", req.ClientID),
+			zap.String("post_logout_redirect_uri", req.PostLogoutRedirectURI),
+			zap.Error(err),
+		)
+		writeError(w, err)
+		return
+	}
+
+	sessionID := ""
+	if ck, err := c.Request.Cookie(cookie.SessionCookieName); err == nil {
+		sessionID = ck.Value
+	}
+
+	confirmed, err := h.confirmed(ctx, c.Request, req, hint, sessionID)
+	if err != nil {
+		h.Logger.Error("end_session: load session failed", zap.Error(err))
+		writeServerError(w)
+		return
+	}
+	if !confirmed {
+		h.renderConfirm(w, req, hint)
+		return
+	}
+
+	if err := h.logout(ctx, hint, sessionID); err != nil {
+		h.Logger.Error("end_session: logout failed", zap.Error(err))
+		writeServerError(w)
+		return
+	}
+
+	cookie.ClearSessionCookie(w)
+	cookie.ClearIDTokenCookie(w)
+	if req.ConfirmToken != "" {
+		http.SetCookie(w, confirmCookie("", -1))
+	}
+
+	if redirectURI != "" {
+		c.Redirect(http.StatusFound, redirectWithState(redirectURI, req.State))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := loggedOutTemplate.Execute(w, nil); err != nil {
+		h.Logger.Error("end_session: render page failed", zap.Error(err))
+	}
+}
+
+func (h *Handler) resolveRedirect(ctx context.Context, req Request, hint *hintClaims) (string, error) {
+	if hint != nil && req.ClientID != "" && !hint.issuedTo(req.ClientID) {
+		return "", ErrInvalidRequest
+	}
+	clientID := requestClientID(req, hint)
+
+	if req.PostLogoutRedirectURI == "" {
+		return "", nil
+	}
+	if clientID == "" {
+		return "", ErrInvalidRequest
+	}
+
+	cl, err := h.Clients.Get(ctx, clientID)
+	if err != nil {
+		return "", errors.Join(ErrInvalidRequest, err)
+	}
+	if !cl.AllowsPostLogoutRedirectURI(req.PostLogoutRedirectURI) {
+		return "", ErrUnregisteredRedirect
+	}
+
+	return req.PostLogoutRedirectURI, nil
+}
+
+// requestClientID is the client the request names, falling back to the
+// single audience of the hint.
+func requestClientID(req Request, hint *hintClaims) string {
+	if req.ClientID == "" && hint != nil && len(hint.Audience) == 1 {
+		return hint.Audience[0]
+	}
+
+	return req.ClientID
+}
+
+// confirmed reports whether the logout may proceed without asking the user:
+// either the confirmation form was submitted, or the hint was issued to the
+// user of the live session in the cookie. Hints are accepted after expiry,
+// so a hint alone never skips the confirmation.
+func (h *Handler) confirmed(ctx context.Context, r *http.Request, req Request, hint *hintClaims, sessionID string) (bool, error) {
+	if isConfirmed(r, req) {
+		return true, nil
+	}
+	if hint == nil || h.Sessions == nil || sessionID == "" {
+		return false, nil
+	}
+
+	s, err := h.Sessions.Get(ctx, sessionID)
+	switch {
+	case err == nil:
+		live := s.Status == "active" && s.ExpiresAt.After(h.now())
+		return live && s.UserID == hint.Subject, nil
+	case errors.Is(err, session.ErrNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (h *Handler) renderConfirm(w http.ResponseWriter, req Request, hint *hintClaims) {
+	token, err := newConfirmToken()
+	if err != nil {
+		h.Logger.Error("end_session: generate confirm token failed", zap.Error(err))
+		writeServerError(w)
+		return
+	}
+
+	http.SetCookie(w, confirmCookie(token, int(confirmTTL/time.Second)))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	page := confirmPage{
+		Action:                Path,
+		ConfirmToken:          token,
+		ClientID:              requestClientID(req, hint),
+		PostLogoutRedirectURI: req.PostLogoutRedirectURI,
+		State:                 req.State,
+	}
+	if err := confirmTemplate.Execute(w, page); err != nil {
+		h.Logger.Error("end_session: render confirm page failed", zap.Error(err))
+	}
+}
+
+func (h *Handler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+
+	return time.Now()
+}
+
+func (h *Handler) logout(ctx context.Context, hint *hintClaims, sessionID string) error {
+	var userID string
+	if hint != nil {
+		userID = hint.Subject
+		if sessionID == "" {
+			sessionID = hint.SessionID
+		}
+	}
+
+	if h.Sessions != nil && sessionID != "" {
+		s, err := h.Sessions.Invalidate(ctx, sessionID)
+		switch {
+		case err == nil:
+			userID = s.UserID
+			h.notifyBackchannel(ctx, s)
+		case errors.Is(err, session.ErrNotFound),
+			errors.Is(err, session.ErrExpiredSession),
+			errors.Is(err, session.ErrInactiveSession):
+		default:
+			return err
+		}
+	}
+
+	if userID == "" {
+		return nil
+	}
+
+	if h.RefreshTokens != nil {
+		if _, err := h.RefreshTokens.RevokeUser(ctx, userID, refresh.RevokeReasonLogout, h.now()); err != nil {
+			return err
+		}
+	}
+	if h.Generations != nil {
+		if _, err := h.Generations.Bump(ctx, userID, h.now()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) notifyBackchannel(ctx context.Context, s *session.Session) {
+	if h.Backchannel == nil {
+		return
+	}
+
+	// deliveries are recorded in the delivery log, and run in the background
+	// when a queue is configured; logout proceeds even if they fail
+	if err := h.Backchannel.Notify(ctx, s); err != nil {
+		h.Logger.Warn("end_session: back-channel logout failed",
+			zap.String("session_id", s.SessionID),
+			zap.Error(err),
+		)
+	}
+}
+
+func redirectWithState(redirectURI, state string) string {
+	if state == "" {
+		return redirectURI
+	}
+
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	q := u.Query()
+	q.Set("state", state)
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: "invalid_request"})
+}
+
+func writeServerError(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: "server_error"})
+}
@@ -0,0 +1,379 @@
+package endsession
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
+)
+
+const testIssuer = "https://idpproxy.example.com"
+
+var testSigner = signer.NewHMACSigner([]byte("secret"), "kid-1")
+
+type fakeSessions struct {
+	sessions    map[string]*session.Session
+	invalidated []string
+	err         error
+}
+
+func (f *fakeSessions) Get(_ context.Context, sessionID string) (*session.Session, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	s, ok := f.sessions[sessionID]
+	if !ok {
+		return nil, session.ErrNotFound
+	}
+	return s, nil
+}
+
+func (f *fakeSessions) Invalidate(_ context.Context, sessionID string) (*session.Session, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	s, ok := f.sessions[sessionID]
+	if !ok {
+		return nil, session.ErrNotFound
+	}
+	f.invalidated = append(f.invalidated, sessionID)
+	return s, nil
+}
+
+type fakeRevoker struct {
+	users []string
+}
+
+func (f *fakeRevoker) RevokeUser(_ context.Context, userID, reason string, _ time.Time) (int, error) {
+	f.users = append(f.users, userID+":"+reason)
+	return 1, nil
+}
+
+func (f *fakeRevoker) Bump(_ context.Context, userID string, _ time.Time) (int, error) {
+	f.users = append(f.users, userID+":bump")
+	return 1, nil
+}
+
+type fakeNotifier struct {
+	notified []string
+	err      error
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, s *session.Session) error {
+	f.notified = append(f.notified, s.SessionID)
+	return f.err
+}
+
+func idToken(t *testing.T, s signer.Signer, claims map[string]any) string {
+	t.Helper()
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	tok, _, err := s.Sign(context.Background(), payload)
+	require.NoError(t, err)
+
+	return tok
+}
+
+func TestHandler_Serve(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	clients := client.NewMemoryRegistry(
+		&client.Client{
+			ID:                     "client-1",
+			Type:                   client.TypePublic,
+			RedirectURIs:           []string{"https://app.example.com/cb"},
+			PostLogoutRedirectURIs: []string{"https://app.example.com/bye"},
+		},
+		&client.Client{ID: "client-2", Type: client.TypePublic, RedirectURIs: []string{"https://two.example.com/cb"}},
+	)
+
+	hint := func(t *testing.T, aud string) string {
+		return idToken(t, testSigner, map[string]any{
+			"iss": testIssuer,
+			"sub": "github:1",
+			"aud": aud,
+			"sid": "sid-from-hint",
+			"iat": time.Now().Add(-2 * time.Hour).Unix(),
+			"exp": time.Now().Add(-time.Hour).Unix(),
+		})
+	}
+
+	serve := func(h *Handler, method string, params url.Values, sessionCookie string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET(Path, h.Serve)
+		r.POST(Path, h.Serve)
+
+		var req *http.Request
+		if method == http.MethodGet {
+			req = httptest.NewRequest(method, Path+"?"+params.Encode(), nil)
+		} else {
+			req = httptest.NewRequest(method, Path, strings.NewReader(params.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if sessionCookie != "" {
+			req.AddCookie(&http.Cookie{Name: cookie.SessionCookieName, Value: sessionCookie})
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// confirm renders the confirmation page for params and submits its form.
+	confirm := func(t *testing.T, h *Handler, params url.Values, sessionCookie string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		page := serve(h, http.MethodGet, params, sessionCookie)
+		require.Equal(t, http.StatusOK, page.Code)
+
+		var token *http.Cookie
+		for _, c := range page.Result().Cookies() {
+			if c.Name == ConfirmCookieName {
+				token = c
+			}
+		}
+		require.NotNil(t, token)
+		require.Equal(t, http.SameSiteStrictMode, token.SameSite)
+		require.Contains(t, page.Body.String(), token.Value)
+
+		form := url.Values{"confirm_token": {token.Value}}
+		for k, v := range params {
+			form[k] = v
+		}
+
+		return serve(h, http.MethodPost, form, sessionCookie, token)
+	}
+
+	newHandler := func() (*Handler, *fakeSessions, *fakeRevoker) {
+		sessions := &fakeSessions{sessions: map[string]*session.Session{
+			"sid-cookie":    {SessionID: "sid-cookie", UserID: "github:1", Status: "active", ExpiresAt: time.Now().Add(time.Hour)},
+			"sid-from-hint": {SessionID: "sid-from-hint", UserID: "github:1", Status: "active", ExpiresAt: time.Now().Add(time.Hour)},
+		}}
+		revoker := &fakeRevoker{}
+
+		h := NewHandler(testIssuer, testSigner, clients, zap.NewNop())
+		h.Sessions = sessions
+		h.RefreshTokens = revoker
+		h.Generations = revoker
+
+		return h, sessions, revoker
+	}
+
+	t.Run("redirects to registered uri with state and clears cookies", func(t *testing.T) {
+		t.Parallel()
+
+		h, sessions, revoker := newHandler()
+		w := serve(h, http.MethodGet, url.Values{
+			"id_token_hint":            {hint(t, "client-1")},
+			"post_logout_redirect_uri": {"https://app.example.com/bye"},
+			"state":                    {"xyz"},
+		}, "sid-cookie")
+
+		require.Equal(t, http.StatusFound, w.Code)
+		require.Equal(t, "https://app.example.com/bye?state=xyz", w.Header().Get("Location"))
+		require.Equal(t, []string{"sid-cookie"}, sessions.invalidated)
+		require.Equal(t, []string{"github:1:" + refresh.RevokeReasonLogout, "github:1:bump"}, revoker.users)
+
+		names := map[string]int{}
+		for _, c := range w.Result().Cookies() {
+			names[c.Name] = c.MaxAge
+		}
+		require.Equal(t, map[string]int{cookie.SessionCookieName: -1, cookie.IDTokenCookieName: -1}, names)
+	})
+
+	t.Run("falls back to the sid in the hint", func(t *testing.T) {
+		t.Parallel()
+
+		h, sessions, _ := newHandler()
+		w := confirm(t, h, url.Values{"id_token_hint": {hint(t, "client-1")}}, "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Header().Get("Content-Type"), "text/html")
+		require.Equal(t, []string{"sid-from-hint"}, sessions.invalidated)
+	})
+
+	t.Run("hint without a session cookie asks for confirmation", func(t *testing.T) {
+		t.Parallel()
+
+		h, sessions, revoker := newHandler()
+		w := serve(h, http.MethodGet, url.Values{"id_token_hint": {hint(t, "client-1")}}, "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), `method="post"`)
+		require.Empty(t, sessions.invalidated)
+		require.Empty(t, revoker.users)
+	})
+
+	t.Run("hint for an ended session asks for confirmation", func(t *testing.T) {
+		t.Parallel()
+
+		h, sessions, revoker := newHandler()
+		sessions.sessions["sid-ended"] = &session.Session{SessionID: "sid-ended", UserID: "github:1", Status: "inactive", ExpiresAt: time.Now().Add(time.Hour)}
+
+		for _, sid := range []string{"sid-ended", "sid-unknown"} {
+			w := serve(h, http.MethodGet, url.Values{"id_token_hint": {hint(t, "client-1")}}, sid)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Contains(t, w.Body.String(), `method="post"`)
+		}
+		require.Empty(t, sessions.invalidated)
+		require.Empty(t, revoker.users)
+	})
+
+	t.Run("notifies back-channel clients of the ended session", func(t *testing.T) {
+		t.Parallel()
+
+		h, _, revoker := newHandler()
+		notifier := &fakeNotifier{err: errors.New("rp unreachable")}
+		h.Backchannel = notifier
+
+		w := confirm(t, h, url.Values{}, "sid-cookie")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, []string{"sid-cookie"}, notifier.notified)
+		require.Len(t, revoker.users, 2)
+	})
+
+	t.Run("without hint asks for confirmation before logging out", func(t *testing.T) {
+		t.Parallel()
+
+		h, sessions, revoker := newHandler()
+		w := serve(h, http.MethodGet, url.Values{}, "sid-cookie")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), `method="post"`)
+		require.Empty(t, sessions.invalidated)
+		require.Empty(t, revoker.users)
+		require.Len(t, w.Result().Cookies(), 1, "only the confirm cookie is set")
+	})
+
+	t.Run("confirmation without the confirm cookie is asked again", func(t *testing.T) {
+		t.Parallel()
+
+		h, sessions, _ := newHandler()
+		w := serve(h, http.MethodPost, url.Values{"confirm_token": {"forged"}}, "sid-cookie")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, sessions.invalidated)
+
+		w = serve(h, http.MethodPost, url.Values{"confirm_token": {"forged"}}, "sid-cookie",
+			&http.Cookie{Name: ConfirmCookieName, Value: "other"})
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, sessions.invalidated)
+	})
+
+	t.Run("hint for another user asks for confirmation", func(t *testing.T) {
+		t.Parallel()
+
+		h, sessions, revoker := newHandler()
+		sessions.sessions["sid-other"] = &session.Session{SessionID: "sid-other", UserID: "github:2"}
+
+		w := serve(h, http.MethodGet, url.Values{"id_token_hint": {hint(t, "client-1")}}, "sid-other")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), `name="client_id" value="client-1"`)
+		require.Empty(t, sessions.invalidated)
+		require.Empty(t, revoker.users)
+	})
+
+	t.Run("confirmed without session only clears cookies", func(t *testing.T) {
+		t.Parallel()
+
+		h, sessions, revoker := newHandler()
+		w := confirm(t, h, url.Values{}, "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, sessions.invalidated)
+		require.Empty(t, revoker.users)
+		require.Len(t, w.Result().Cookies(), 3)
+	})
+
+	t.Run("client_id selects the redirect registration", func(t *testing.T) {
+		t.Parallel()
+
+		h, _, _ := newHandler()
+		w := confirm(t, h, url.Values{
+			"client_id":                {"client-1"},
+			"post_logout_redirect_uri": {"https://app.example.com/bye"},
+		}, "sid-cookie")
+
+		require.Equal(t, http.StatusFound, w.Code)
+		require.Equal(t, "https://app.example.com/bye", w.Header().Get("Location"))
+	})
+
+	t.Run("rejects unregistered redirect uri", func(t *testing.T) {
+		t.Parallel()
+
+		h, sessions, _ := newHandler()
+		w := serve(h, http.MethodGet, url.Values{
+			"client_id":                {"client-1"},
+			"post_logout_redirect_uri": {"https://evil.example.com/"},
+		}, "sid-cookie")
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Empty(t, sessions.invalidated)
+		require.Empty(t, w.Header().Get("Location"))
+	})
+
+	t.Run("rejects redirect uri without a client", func(t *testing.T) {
+		t.Parallel()
+
+		h, _, _ := newHandler()
+		w := serve(h, http.MethodGet, url.Values{"post_logout_redirect_uri": {"https://app.example.com/bye"}}, "")
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("rejects client_id that does not match the hint", func(t *testing.T) {
+		t.Parallel()
+
+		h, _, _ := newHandler()
+		w := serve(h, http.MethodGet, url.Values{"id_token_hint": {hint(t, "client-1")}, "client_id": {"client-2"}}, "")
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("rejects hint signed by another key", func(t *testing.T) {
+		t.Parallel()
+
+		h, _, _ := newHandler()
+		forged := idToken(t, signer.NewHMACSigner([]byte("other"), "kid-1"), map[string]any{
+			"iss": testIssuer, "sub": "github:1", "aud": "client-1", "exp": time.Now().Add(time.Hour).Unix(),
+		})
+		w := serve(h, http.MethodGet, url.Values{"id_token_hint": {forged}}, "")
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("session store failure is a server error", func(t *testing.T) {
+		t.Parallel()
+
+		h, sessions, _ := newHandler()
+		sessions.err = errors.New("firestore down")
+		w := serve(h, http.MethodGet, url.Values{"id_token_hint": {hint(t, "client-1")}}, "sid-cookie")
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,47 @@
+package endsession
+
+import "html/template"
+
+var loggedOutTemplate = template.Must(template.New("logged_out").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+  <meta charset="utf-8">
+  <title>idpproxy</title>
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+  <h1>idpproxy</h1>
+
+  <p>ログアウトしました。</p>
+</body>
+</html>
+`))
+
+var confirmTemplate = template.Must(template.New("confirm_logout").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+  <meta charset="utf-8">
+  <title>idpproxy</title>
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+  <h1>idpproxy</h1>
+
+  <p>ログアウトしますか？</p>
+
+  <form method="post" action="{{.Action}}">
+    <input type="hidden" name="confirm_token" value="{{.ConfirmToken}}">
+  {{- with .ClientID}}
+    <input type="hidden" name="client_id" value="{{.}}">
+  {{- end}}
+  {{- with .PostLogoutRedirectURI}}
+    <input type="hidden" name="post_logout_redirect_uri" value="{{.}}">
+  {{- end}}
+  {{- with .State}}
+    <input type="hidden" name="state" value="{{.}}">
+  {{- end}}
+    <button type="submit">ログアウト</button>
+  </form>
+</body>
+</html>
+`))
@@ -0,0 +1,35 @@
+package endsession
+
+import (
+	"context"
+	"time"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+)
+
+type IDTokenVerifier interface {
+	Verify(ctx context.Context, token string, opt *signer.VerifyOptions) (*signer.VerifyResult, error)
+}
+
+type ClientRegistry interface {
+	Get(ctx context.Context, clientID string) (*client.Client, error)
+}
+
+type SessionInvalidator interface {
+	Get(ctx context.Context, sessionID string) (*session.Session, error)
+	Invalidate(ctx context.Context, sessionID string) (*session.Session, error)
+}
+
+type BackchannelNotifier interface {
+	Notify(ctx context.Context, s *session.Session) error
+}
+
+type RefreshTokenRevoker interface {
+	RevokeUser(ctx context.Context, userID, reason string, t time.Time) (int, error)
+}
+
+type AccessGenerationBumper interface {
+	Bump(ctx context.Context, userID string, t time.Time) (int, error)
+}
@@ -0,0 +1,62 @@
+package endsession
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+)
+
+type Request struct {
+	IDTokenHint           string
+	ClientID              string
+	PostLogoutRedirectURI string
+	State                 string
+	ConfirmToken          string
+}
+
+func ParseRequest(v url.Values) Request {
+	return Request{
+		IDTokenHint:           v.Get("id_token_hint"),
+		ClientID:              v.Get("client_id"),
+		PostLogoutRedirectURI: v.Get("post_logout_redirect_uri"),
+		State:                 v.Get("state"),
+		ConfirmToken:          v.Get("confirm_token"),
+	}
+}
+
+type hintClaims struct {
+	Subject   string
+	Audience  jwt.ClaimStrings
+	SessionID string
+}
+
+// parseIDTokenHint accepts expired ID tokens, as RPs typically send whatever they last received.
+func parseIDTokenHint(ctx context.Context, v IDTokenVerifier, issuer, token string) (*hintClaims, error) {
+	res, err := v.Verify(ctx, token, &signer.VerifyOptions{AllowExpired: true, RequireTyp: true, ExpectTyp: signer.TypeJWT})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDTokenHint, err)
+	}
+
+	if iss, _ := res.Claims.GetIssuer(); iss != issuer {
+		return nil, ErrInvalidIDTokenHint
+	}
+
+	sub, _ := res.Claims.GetSubject()
+	aud, _ := res.Claims.GetAudience()
+	if sub == "" || len(aud) == 0 {
+		return nil, ErrInvalidIDTokenHint
+	}
+
+	sid, _ := res.Claims["sid"].(string)
+
+	return &hintClaims{Subject: sub, Audience: aud, SessionID: sid}, nil
+}
+
+func (h *hintClaims) issuedTo(clientID string) bool {
+	return slices.Contains(h.Audience, clientID)
+}
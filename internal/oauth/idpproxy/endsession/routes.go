@@ -0,0 +1,49 @@
+package endsession
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/backchannel"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/deps"
+)
+
+const Path = "/end_session"
+
+func NewHandlerFromDeps(oidcDeps *deps.OIDCDependencies) *Handler {
+	h := NewHandler(oidcDeps.Config.Issuer, oidcDeps.Signer, oidcDeps.Clients, oidcDeps.Logger)
+
+	if oidcDeps.Sessions != nil {
+		h.Sessions = &session.Usecase{Repo: oidcDeps.Sessions, Now: time.Now}
+
+		if oidcDeps.Backchannel != nil {
+			h.Backchannel = oidcDeps.Backchannel
+		} else {
+			h.Backchannel = NewBackchannelNotifier(oidcDeps)
+		}
+	}
+
+	if oidcDeps.Config.LogoutRevokesTokens {
+		h.RefreshTokens = oidcDeps.RefreshTokens
+		h.Generations = oidcDeps.AccessGenerations
+	}
+
+	return h
+}
+
+// NewBackchannelNotifier builds the notifier that signs and posts logout
+// tokens for the configured issuer and clients.
+func NewBackchannelNotifier(oidcDeps *deps.OIDCDependencies) *backchannel.Notifier {
+	notifier := backchannel.NewNotifier(oidcDeps.Config.Issuer, backchannel.NewSignerAdapter(oidcDeps.Signer), oidcDeps.Clients)
+	notifier.Log = oidcDeps.BackchannelDeliveries
+
+	return notifier
+}
+
+func RegisterRoutes(r gin.IRoutes, oidcDeps *deps.OIDCDependencies) {
+	h := NewHandlerFromDeps(oidcDeps)
+	r.GET(Path, h.Serve)
+	r.POST(Path, h.Serve)
+}
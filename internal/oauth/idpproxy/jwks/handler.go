@@ -0,0 +1,39 @@
+package jwks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+)
+
+type JWKSHandler struct {
+	Source signer.PublicKeySource
+	Logger *zap.Logger
+}
+
+func NewJWKSHandler(src signer.PublicKeySource, logger *zap.Logger) *JWKSHandler {
+	return &JWKSHandler{
+		Source: src,
+		Logger: logger,
+	}
+}
+
+func (h *JWKSHandler) Serve(c *gin.Context) {
+	keys, err := h.Source.PublicJWKs(c.Request.Context())
+	if err != nil {
+		h.Logger.Error("failed to load public keys", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server not ready"})
+
+		return
+	}
+
+	if keys == nil {
+		keys = []signer.JWK{}
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, signer.JWKS{Keys: keys})
+}
@@ -0,0 +1,84 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+)
+
+type fakeSource struct {
+	keys []signer.JWK
+	err  error
+}
+
+func (f fakeSource) PublicJWKs(context.Context) ([]signer.JWK, error) {
+	return f.keys, f.err
+}
+
+func serve(t *testing.T, src signer.PublicKeySource) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := gin.New()
+	RegisterRoutes(r, src, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, Path, nil))
+
+	return w
+}
+
+func TestJWKSHandler_Serve(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns public keys of the signer", func(t *testing.T) {
+		t.Parallel()
+
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		s, err := signer.NewEd25519Signer(priv, "ed-1")
+		require.NoError(t, err)
+
+		w := serve(t, s)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+
+		var got signer.JWKS
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		require.Len(t, got.Keys, 1)
+		require.Equal(t, "OKP", got.Keys[0].Kty)
+		require.Equal(t, "ed-1", got.Keys[0].Kid)
+		require.Equal(t, signer.AlgEdDSA, got.Keys[0].Alg)
+		require.NotContains(t, w.Body.String(), `"d"`)
+	})
+
+	t.Run("returns empty key set", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(t, fakeSource{})
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"keys":[]}`, w.Body.String())
+	})
+
+	t.Run("returns 500 when keys cannot be loaded", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(t, fakeSource{err: errors.New("boom")})
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,15 @@
+package jwks
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+)
+
+const Path = "/.well-known/jwks.json"
+
+func RegisterRoutes(r gin.IRoutes, src signer.PublicKeySource, logger *zap.Logger) {
+	h := NewJWKSHandler(src, logger)
+	r.GET(Path, h.Serve)
+}
@@ -0,0 +1,53 @@
+package token
+
+import (
+	"context"
+	"errors"
+
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+)
+
+type ProxyCodeStore struct {
+	Store authcodestore.Store
+}
+
+func NewProxyCodeStore(s authcodestore.Store) *ProxyCodeStore {
+	return &ProxyCodeStore{Store: s}
+}
+
+func (s *ProxyCodeStore) Consume(ctx context.Context, code string, clientID string) (*AuthCode, error) {
+	pc, err := s.Store.Consume(ctx, code, clientID)
+	var replay *authcodestore.ReplayError
+	if errors.As(err, &replay) {
+		return nil, &AuthCodeReplayError{
+			UserID:   replay.UserID,
+			ClientID: replay.ClientID,
+			FamilyID: replay.FamilyID,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthCode{
+		UserID:              pc.UserID,
+		ClientID:            pc.ClientID,
+		RedirectURI:         pc.RedirectURI,
+		Scope:               pc.Scope,
+		Nonce:               pc.Nonce,
+		CodeChallenge:       pc.CodeChallenge,
+		CodeChallengeMethod: pc.CodeChallengeMethod,
+		SessionID:           pc.SessionID,
+		AuthTime:            pc.AuthTime,
+		ExpiresAt:           pc.ExpiresAt,
+	}, nil
+}
+
+func (s *ProxyCodeStore) BindFamily(ctx context.Context, code string, familyID string) error {
+	err := s.Store.BindFamily(ctx, code, familyID)
+	if errors.Is(err, authcodestore.ErrReplayed) {
+		return &AuthCodeReplayError{FamilyID: familyID}
+	}
+
+	return err
+}
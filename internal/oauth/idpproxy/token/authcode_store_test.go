@@ -0,0 +1,55 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/authcode"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+)
+
+func TestProxyCodeStore_Consume(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	exp := time.Now().Add(time.Minute)
+
+	mem := authcodestore.NewMemoryStore()
+	require.NoError(t, mem.Save(ctx, authcode.ProxyCode{
+		Code:                "code-1",
+		UserID:              "user-1",
+		ClientID:            "client-1",
+		RedirectURI:         "https://app.example.com/cb",
+		Scope:               "openid email",
+		CodeChallenge:       "challenge",
+		CodeChallengeMethod: "S256",
+		ExpiresAt:           exp,
+	}))
+
+	s := NewProxyCodeStore(mem)
+
+	_, err := s.Consume(ctx, "code-1", "client-2")
+	require.ErrorIs(t, err, authcodestore.ErrClientMismatch)
+
+	ac, err := s.Consume(ctx, "code-1", "client-1")
+	require.NoError(t, err)
+	require.Equal(t, &AuthCode{
+		UserID:              "user-1",
+		ClientID:            "client-1",
+		RedirectURI:         "https://app.example.com/cb",
+		Scope:               "openid email",
+		CodeChallenge:       "challenge",
+		CodeChallengeMethod: "S256",
+		ExpiresAt:           exp,
+	}, ac)
+
+	require.NoError(t, s.BindFamily(ctx, "code-1", "fam-1"))
+
+	_, err = s.Consume(ctx, "code-1", "client-1")
+	var replay *AuthCodeReplayError
+	require.ErrorAs(t, err, &replay)
+	require.Equal(t, &AuthCodeReplayError{UserID: "user-1", ClientID: "client-1", FamilyID: "fam-1"}, replay)
+}
@@ -2,8 +2,26 @@ package token
 
 import "errors"
 
+var errMultipleClientAuth = errors.New("token: multiple client authentication methods")
+
 var (
 	ErrInvalidClient        = errors.New("token: invalid client")
 	ErrInvalidGrant         = errors.New("token: invalid grant")
+	ErrInvalidRequest       = errors.New("token: invalid request")
+	ErrInvalidScope         = errors.New("token: invalid scope")
+	ErrServerError          = errors.New("token: server error")
+	ErrUnauthorizedClient   = errors.New("token: unauthorized client")
 	ErrUnsupportedGrantType = errors.New("token: unsupported grant_type")
+	ErrUnsupportedTokenType = errors.New("token: unsupported token_type")
 )
+
+// AuthCodeReplayError reports that an already redeemed authorization code was presented again.
+type AuthCodeReplayError struct {
+	UserID   string
+	ClientID string
+	FamilyID string
+}
+
+func (e *AuthCodeReplayError) Error() string {
+	return "token: authorization code replayed"
+}
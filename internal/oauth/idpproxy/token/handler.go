@@ -2,7 +2,10 @@ package token
 
 import (
 	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
+	"net/url"
 
 	"go.uber.org/zap"
 )
@@ -20,14 +23,13 @@ func NewHandler(svc *Service, logger *zap.Logger) *Handler {
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	var req TokenRequest
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	req, err := decodeTokenRequest(r)
+	if err != nil {
 		h.Logger.Warn("invalid token request",
 			zap.Error(err),
 		)
 
-		http.Error(w, "invalid request", http.StatusBadRequest)
+		writeOAuthError(w, ErrInvalidRequest)
 		return
 	}
 
@@ -44,6 +46,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
 	w.WriteHeader(http.StatusOK)
 
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
@@ -54,25 +58,90 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func decodeTokenRequest(r *http.Request) (TokenRequest, error) {
+	var req TokenRequest
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		if err := r.ParseForm(); err != nil {
+			return req, err
+		}
+
+		req.GrantType = r.PostForm.Get("grant_type")
+		req.Code = r.PostForm.Get("code")
+		req.CodeVerifier = r.PostForm.Get("code_verifier")
+		req.RedirectURI = r.PostForm.Get("redirect_uri")
+		req.RefreshToken = r.PostForm.Get("refresh_token")
+		req.Scope = r.PostForm.Get("scope")
+		req.ClientID = r.PostForm.Get("client_id")
+		req.ClientSecret = r.PostForm.Get("client_secret")
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, err
+	}
+
+	return req, applyBasicAuth(r, &req.ClientID, &req.ClientSecret)
+}
+
+func applyBasicAuth(r *http.Request, clientID, clientSecret *string) error {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return nil
+	}
+
+	id, err := url.QueryUnescape(user)
+	if err != nil {
+		return err
+	}
+	secret, err := url.QueryUnescape(pass)
+	if err != nil {
+		return err
+	}
+
+	if *clientSecret != "" {
+		return errMultipleClientAuth
+	}
+	if *clientID != "" && *clientID != id {
+		return errMultipleClientAuth
+	}
+
+	*clientID = id
+	*clientSecret = secret
+
+	return nil
+}
+
 func writeOAuthError(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
 
 	type oauthErr struct {
 		Error string `json:"error"`
 	}
 
 	code := "invalid_request"
+	status := http.StatusBadRequest
 
-	switch err {
-	case ErrUnsupportedGrantType:
+	switch {
+	case errors.Is(err, ErrUnsupportedGrantType):
 		code = "unsupported_grant_type"
-	case ErrInvalidClient:
+	case errors.Is(err, ErrInvalidClient):
 		code = "invalid_client"
-	case ErrInvalidGrant:
+		status = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", `Basic realm="idpproxy"`)
+	case errors.Is(err, ErrUnauthorizedClient):
+		code = "unauthorized_client"
+	case errors.Is(err, ErrInvalidGrant):
 		code = "invalid_grant"
+	case errors.Is(err, ErrInvalidScope):
+		code = "invalid_scope"
+	case errors.Is(err, ErrUnsupportedTokenType):
+		code = "unsupported_token_type"
+	case errors.Is(err, ErrServerError):
+		code = "server_error"
+		status = http.StatusInternalServerError
 	}
 
-	w.WriteHeader(http.StatusBadRequest)
+	w.WriteHeader(status)
 
 	_ = json.NewEncoder(w).Encode(oauthErr{Error: code})
 }
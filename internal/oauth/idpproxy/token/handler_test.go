@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,24 +17,16 @@ func TestTokenHandler(t *testing.T) {
 	t.Parallel()
 
 	validReq := TokenRequest{
-		GrantType: "authorization_code",
-		Code:      "valid",
-		ClientID:  "client-1",
+		GrantType:    "authorization_code",
+		Code:         "valid",
+		ClientID:     "client-1",
+		CodeVerifier: testVerifier,
 	}
 
 	t.Run("success returns 200 and id_token response", func(t *testing.T) {
 		t.Parallel()
 
-		svc := &Service{
-			Store: &mockStore{
-				code: &AuthCode{
-					UserID:    "user1",
-					ClientID:  "client-1",
-					ExpiresAt: time.Now().Add(time.Hour),
-				},
-			},
-			Clock: fixedClock{t: time.Now()},
-		}
+		svc := newTestServiceWithValidCode()
 
 		handler := NewHandler(svc, zap.NewNop())
 
@@ -54,6 +48,60 @@ func TestTokenHandler(t *testing.T) {
 		if resp.IDToken == "" {
 			t.Fatal("id_token should not be empty")
 		}
+
+		if rec.Header().Get("Cache-Control") != "no-store" {
+			t.Fatalf("unexpected Cache-Control: %q", rec.Header().Get("Cache-Control"))
+		}
+	})
+
+	t.Run("form encoded request is accepted", func(t *testing.T) {
+		t.Parallel()
+
+		svc := newTestServiceWithValidCode()
+		handler := NewHandler(svc, zap.NewNop())
+
+		form := url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {"valid"},
+			"client_id":     {"client-1"},
+			"code_verifier": {testVerifier},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+
+		var resp TokenResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+
+		if resp.AccessToken == "" || resp.RefreshToken == "" || resp.TokenType != "Bearer" {
+			t.Fatalf("unexpected response: %+v", resp)
+		}
+	})
+
+	t.Run("server error returns 500", func(t *testing.T) {
+		t.Parallel()
+
+		svc := newTestServiceWithValidCode()
+		svc.AccessTokens = nil
+		handler := NewHandler(svc, zap.NewNop())
+
+		body, _ := json.Marshal(validReq)
+		req := httptest.NewRequest(http.MethodPost, "/token", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("expected 500, got %d", rec.Code)
+		}
 	})
 
 	t.Run("invalid grant returns oauth error", func(t *testing.T) {
@@ -97,4 +145,69 @@ func TestTokenHandler(t *testing.T) {
 			t.Fatalf("expected 400, got %d", rec.Code)
 		}
 	})
+
+	t.Run("client_secret_basic is accepted", func(t *testing.T) {
+		t.Parallel()
+
+		svc := newTestServiceWithValidCode()
+		svc.Store = &mockStore{code: &AuthCode{UserID: "user1", ClientID: "confidential-1", ExpiresAt: time.Now().Add(time.Hour)}}
+		handler := NewHandler(svc, zap.NewNop())
+
+		form := url.Values{"grant_type": {"authorization_code"}, "code": {"valid"}}
+		req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("confidential-1", url.QueryEscape(testClientSecret))
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("wrong client secret returns 401 with WWW-Authenticate", func(t *testing.T) {
+		t.Parallel()
+
+		svc := newTestServiceWithValidCode()
+		handler := NewHandler(svc, zap.NewNop())
+
+		form := url.Values{"grant_type": {"authorization_code"}, "code": {"valid"}}
+		req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("confidential-1", "wrong")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected 401, got %d", rec.Code)
+		}
+		if rec.Header().Get("WWW-Authenticate") == "" {
+			t.Fatal("WWW-Authenticate header should be set")
+		}
+	})
+
+	t.Run("multiple client authentication methods are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		svc := newTestServiceWithValidCode()
+		handler := NewHandler(svc, zap.NewNop())
+
+		form := url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {"valid"},
+			"client_secret": {testClientSecret},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("confidential-1", testClientSecret)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d", rec.Code)
+		}
+	})
 }
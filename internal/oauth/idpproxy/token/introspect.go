@@ -0,0 +1,135 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/accesstoken"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+)
+
+type IntrospectionService struct {
+	Clients       ClientRegistry
+	Clock         Clock
+	RefreshTokens RefreshTokenStore
+	AccessTokens  AccessTokenVerifier
+}
+
+func (s *IntrospectionService) Introspect(ctx context.Context, req IntrospectionRequest) (*IntrospectionResponse, error) {
+	if s.Clock == nil {
+		return nil, ErrServerError
+	}
+	if req.Token == "" {
+		return nil, ErrInvalidRequest
+	}
+
+	cl, err := authenticateClient(ctx, s.Clients, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if cl.IsPublic() {
+		return nil, ErrInvalidClient
+	}
+
+	lookups := []func(context.Context, string) (*IntrospectionResponse, error){
+		s.introspectRefreshToken,
+		s.introspectAccessToken,
+	}
+	if req.TokenTypeHint == TokenTypeHintAccessToken {
+		lookups[0], lookups[1] = lookups[1], lookups[0]
+	}
+
+	for _, lookup := range lookups {
+		resp, err := lookup(ctx, req.Token)
+		if err != nil {
+			return nil, err
+		}
+		if resp == nil {
+			continue
+		}
+
+		// other clients' tokens look inactive unless the caller is a resource server
+		if resp.Active && !cl.CanIntrospect(resp.ClientID) {
+			return &IntrospectionResponse{Active: false}, nil
+		}
+
+		return resp, nil
+	}
+
+	return &IntrospectionResponse{Active: false}, nil
+}
+
+func (s *IntrospectionService) introspectRefreshToken(ctx context.Context, token string) (*IntrospectionResponse, error) {
+	if s.RefreshTokens == nil {
+		return nil, nil
+	}
+
+	refreshID, secret, err := refresh.ParseRefreshToken(token)
+	if err != nil {
+		return nil, nil
+	}
+
+	rec, err := s.RefreshTokens.GetByID(ctx, refreshID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: load refresh token: %w", ErrServerError, err)
+	}
+
+	if err := refresh.VerifyRefreshSecret(rec, secret); err != nil {
+		if errors.Is(err, refresh.ErrDigestMismatch) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: verify refresh token: %w", ErrServerError, err)
+	}
+
+	if !store.IsActive(rec, s.Clock.Now()) {
+		return &IntrospectionResponse{Active: false}, nil
+	}
+
+	resp := &IntrospectionResponse{
+		Active:    true,
+		Subject:   rec.UserID,
+		ClientID:  rec.ClientID,
+		Scope:     rec.Scope,
+		TokenType: TokenTypeHintRefreshToken,
+		FamilyID:  rec.FamilyID,
+	}
+	if !rec.ExpiresAt.IsZero() {
+		resp.ExpiresAt = rec.ExpiresAt.Unix()
+	}
+	if !rec.CreatedAt.IsZero() {
+		resp.IssuedAt = rec.CreatedAt.Unix()
+	}
+
+	return resp, nil
+}
+
+func (s *IntrospectionService) introspectAccessToken(ctx context.Context, token string) (*IntrospectionResponse, error) {
+	if s.AccessTokens == nil {
+		return nil, nil
+	}
+
+	claims, err := s.AccessTokens.Verify(ctx, token)
+	switch {
+	case errors.Is(err, accesstoken.ErrRevoked):
+		return &IntrospectionResponse{Active: false}, nil
+	case isInvalidAccessToken(err):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("%w: verify access token: %w", ErrServerError, err)
+	}
+
+	return &IntrospectionResponse{
+		Active:    true,
+		Subject:   claims.Subject,
+		ClientID:  claims.ClientID,
+		Scope:     claims.Scope,
+		ExpiresAt: claims.ExpiresAt.Unix(),
+		IssuedAt:  claims.IssuedAt.Unix(),
+		TokenType: TokenTypeHintAccessToken,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package token
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type IntrospectionHandler struct {
+	Service *IntrospectionService
+	Logger  *zap.Logger
+}
+
+func NewIntrospectionHandler(svc *IntrospectionService, logger *zap.Logger) *IntrospectionHandler {
+	return &IntrospectionHandler{
+		Service: svc,
+		Logger:  logger,
+	}
+}
+
+func (h *IntrospectionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeIntrospectionRequest(r)
+	if err != nil {
+		h.Logger.Warn("invalid introspection request",
+			zap.Error(err),
+		)
+
+		writeOAuthError(w, ErrInvalidRequest)
+		return
+	}
+
+	resp, err := h.Service.Introspect(r.Context(), req)
+	if err != nil {
+		h.Logger.Warn("token introspection failed",
+			zap.String("client_id", req.ClientID),
+			zap.String("token_type_hint", req.TokenTypeHint),
+			zap.Error(err),
+		)
+
+		writeOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.Logger.Error("encode introspection response failed",
+			zap.Error(err),
+		)
+		return
+	}
+}
+
+func decodeIntrospectionRequest(r *http.Request) (IntrospectionRequest, error) {
+	var req IntrospectionRequest
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" {
+		return req, errors.New("token: introspection request must be form encoded")
+	}
+	if err := r.ParseForm(); err != nil {
+		return req, err
+	}
+
+	req.Token = r.PostForm.Get("token")
+	req.TokenTypeHint = r.PostForm.Get("token_type_hint")
+	req.ClientID = r.PostForm.Get("client_id")
+	req.ClientSecret = r.PostForm.Get("client_secret")
+
+	return req, applyBasicAuth(r, &req.ClientID, &req.ClientSecret)
+}
@@ -0,0 +1,153 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/accesstoken"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+)
+
+func TestIntrospectionService_Introspect(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	issueRefresh := func(t *testing.T) (*fakeRefreshStore, string) {
+		t.Helper()
+
+		svc := withIssuers(&Service{
+			Store: &mockStore{code: &AuthCode{UserID: "user1", ClientID: "client-1", CodeChallenge: testChallenge, CodeChallengeMethod: "S256", Scope: "openid email", ExpiresAt: now.Add(time.Hour)}},
+			Clock: fixedClock{t: now},
+		})
+		svc.NewRefreshToken = nil
+
+		resp, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "client-1", CodeVerifier: testVerifier})
+		require.NoError(t, err)
+
+		return svc.RefreshTokens.(*fakeRefreshStore), resp.RefreshToken
+	}
+
+	gens := &fakeGenerations{gens: map[string]int{}}
+	uc := &accesstoken.IssueAccessTokenUsecase{Issuer: testIssuer, Signer: accesstoken.NewSignerAdapter(testSigner), Generations: gens}
+	at, _, err := uc.Issue(ctx, &accesstoken.AccessTokenInput{UserID: "user1", ClientID: "client-1", Scope: "openid", Now: now, TTL: time.Minute})
+	require.NoError(t, err)
+
+	newService := func(rs *fakeRefreshStore) *IntrospectionService {
+		verifier := accesstoken.NewVerifier(testIssuer, testSigner)
+		verifier.Generations = gens
+
+		return &IntrospectionService{
+			Clients:       testClients,
+			Clock:         fixedClock{t: now},
+			RefreshTokens: rs,
+			AccessTokens:  verifier,
+		}
+	}
+
+	introspect := func(svc *IntrospectionService, token string) (*IntrospectionResponse, error) {
+		return svc.Introspect(ctx, IntrospectionRequest{Token: token, ClientID: "resource-1", ClientSecret: testClientSecret})
+	}
+
+	t.Run("active refresh token", func(t *testing.T) {
+		rs, rt := issueRefresh(t)
+		id, _, err := refresh.ParseRefreshToken(rt)
+		require.NoError(t, err)
+
+		resp, err := introspect(newService(rs), rt)
+		require.NoError(t, err)
+		require.Equal(t, &IntrospectionResponse{
+			Active:    true,
+			Subject:   "user1",
+			ClientID:  "client-1",
+			Scope:     "openid email",
+			ExpiresAt: rs.records[id].ExpiresAt.Unix(),
+			IssuedAt:  rs.records[id].CreatedAt.Unix(),
+			TokenType: TokenTypeHintRefreshToken,
+			FamilyID:  rs.records[id].FamilyID,
+		}, resp)
+	})
+
+	t.Run("revoked refresh token is inactive", func(t *testing.T) {
+		rs, rt := issueRefresh(t)
+		id, _, err := refresh.ParseRefreshToken(rt)
+		require.NoError(t, err)
+		_, err = rs.RevokeFamily(ctx, rs.records[id].FamilyID, refresh.RevokeReasonClient, now)
+		require.NoError(t, err)
+
+		resp, err := introspect(newService(rs), rt)
+		require.NoError(t, err)
+		require.Equal(t, &IntrospectionResponse{Active: false}, resp)
+	})
+
+	t.Run("active access token", func(t *testing.T) {
+		resp, err := introspect(newService(&fakeRefreshStore{}), at)
+		require.NoError(t, err)
+		require.Equal(t, &IntrospectionResponse{
+			Active:    true,
+			Subject:   "user1",
+			ClientID:  "client-1",
+			Scope:     "openid",
+			ExpiresAt: now.Add(time.Minute).Unix(),
+			IssuedAt:  now.Unix(),
+			TokenType: TokenTypeHintAccessToken,
+		}, resp)
+	})
+
+	t.Run("access token from an older generation is inactive", func(t *testing.T) {
+		svc := newService(&fakeRefreshStore{})
+		local := &fakeGenerations{gens: map[string]int{"user1": 1}}
+		svc.AccessTokens.(*accesstoken.Verifier).Generations = local
+
+		resp, err := introspect(svc, at)
+		require.NoError(t, err)
+		require.False(t, resp.Active)
+	})
+
+	t.Run("unknown token is inactive", func(t *testing.T) {
+		resp, err := introspect(newService(&fakeRefreshStore{}), "garbage")
+		require.NoError(t, err)
+		require.Equal(t, &IntrospectionResponse{Active: false}, resp)
+	})
+
+	t.Run("other clients only see their own tokens", func(t *testing.T) {
+		svc := newService(&fakeRefreshStore{})
+		asConfidential := func(token string) *IntrospectionResponse {
+			resp, err := svc.Introspect(ctx, IntrospectionRequest{Token: token, ClientID: "confidential-1", ClientSecret: testClientSecret})
+			require.NoError(t, err)
+			return resp
+		}
+
+		require.Equal(t, &IntrospectionResponse{Active: false}, asConfidential(at))
+
+		own, _, err := uc.Issue(ctx, &accesstoken.AccessTokenInput{UserID: "user1", ClientID: "confidential-1", Scope: "openid", Now: now, TTL: time.Minute})
+		require.NoError(t, err)
+		require.True(t, asConfidential(own).Active)
+	})
+
+	t.Run("public clients are rejected", func(t *testing.T) {
+		_, err := newService(&fakeRefreshStore{}).Introspect(ctx, IntrospectionRequest{Token: at, ClientID: "client-1"})
+		require.ErrorIs(t, err, ErrInvalidClient)
+	})
+
+	t.Run("generation lookup failure is a server error", func(t *testing.T) {
+		svc := newService(&fakeRefreshStore{})
+		svc.AccessTokens.(*accesstoken.Verifier).Generations = &failingGenerations{}
+
+		_, err := introspect(svc, at)
+		require.ErrorIs(t, err, ErrServerError)
+	})
+}
+
+type failingGenerations struct{}
+
+func (failingGenerations) Get(ctx context.Context, userID string) (*store.AccessGenerationRecord, error) {
+	return nil, errors.New("firestore down")
+}
@@ -3,6 +3,24 @@ package token
 type TokenRequest struct {
 	GrantType    string `json:"grant_type"`
 	Code         string `json:"code"`
+	CodeVerifier string `json:"code_verifier"`
+	RedirectURI  string `json:"redirect_uri"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
 	ClientID     string `json:"client_id"`
 	ClientSecret string `json:"client_secret"`
 }
+
+type RevocationRequest struct {
+	Token         string
+	TokenTypeHint string
+	ClientID      string
+	ClientSecret  string
+}
+
+type IntrospectionRequest struct {
+	Token         string
+	TokenTypeHint string
+	ClientID      string
+	ClientSecret  string
+}
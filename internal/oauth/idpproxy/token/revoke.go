@@ -0,0 +1,166 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/accesstoken"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+)
+
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+type AccessTokenVerifier interface {
+	Verify(ctx context.Context, token string) (*accesstoken.Claims, error)
+}
+
+type AccessGenerationBumper interface {
+	Bump(ctx context.Context, userID string, t time.Time) (int, error)
+}
+
+type AccessTokenDenylist interface {
+	Deny(ctx context.Context, rec *store.AccessDenylistRecord) error
+}
+
+type RevocationService struct {
+	Clients       ClientRegistry
+	Clock         Clock
+	RefreshTokens RefreshTokenStore
+	AccessTokens  AccessTokenVerifier
+
+	// access tokens are denylisted by jti when Denylist is set,
+	// otherwise every token of the user is cut off through Generations
+	Generations AccessGenerationBumper
+	Denylist    AccessTokenDenylist
+}
+
+func (s *RevocationService) Revoke(ctx context.Context, req RevocationRequest) error {
+	if s.Clock == nil {
+		return ErrServerError
+	}
+	if req.Token == "" {
+		return ErrInvalidRequest
+	}
+
+	cl, err := authenticateClient(ctx, s.Clients, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return err
+	}
+
+	revokers := []func(context.Context, *client.Client, string) (bool, error){
+		s.revokeRefreshToken,
+		s.revokeAccessToken,
+	}
+	if req.TokenTypeHint == TokenTypeHintAccessToken {
+		revokers[0], revokers[1] = revokers[1], revokers[0]
+	}
+
+	for _, revoke := range revokers {
+		found, err := revoke(ctx, cl, req.Token)
+		if err != nil || found {
+			return err
+		}
+	}
+
+	// RFC 7009 2.2: invalid or unknown tokens are not an error
+	return nil
+}
+
+func (s *RevocationService) revokeRefreshToken(ctx context.Context, cl *client.Client, token string) (bool, error) {
+	if s.RefreshTokens == nil {
+		return false, nil
+	}
+
+	refreshID, secret, err := refresh.ParseRefreshToken(token)
+	if err != nil {
+		return false, nil
+	}
+
+	rec, err := s.RefreshTokens.GetByID(ctx, refreshID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: load refresh token: %w", ErrServerError, err)
+	}
+
+	if err := refresh.VerifyRefreshSecret(rec, secret); err != nil {
+		if errors.Is(err, refresh.ErrDigestMismatch) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%w: verify refresh token: %w", ErrServerError, err)
+	}
+
+	if rec.ClientID != "" && rec.ClientID != cl.ID {
+		return true, ErrUnauthorizedClient
+	}
+
+	if _, err := s.RefreshTokens.RevokeFamily(ctx, rec.FamilyID, refresh.RevokeReasonClient, s.Clock.Now()); err != nil {
+		return true, fmt.Errorf("%w: revoke refresh family: %w", ErrServerError, err)
+	}
+
+	return true, nil
+}
+
+func (s *RevocationService) revokeAccessToken(ctx context.Context, cl *client.Client, token string) (bool, error) {
+	if s.AccessTokens == nil {
+		return false, nil
+	}
+
+	claims, err := s.AccessTokens.Verify(ctx, token)
+	switch {
+	case errors.Is(err, accesstoken.ErrRevoked):
+		return true, nil
+	case isInvalidAccessToken(err):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("%w: verify access token: %w", ErrServerError, err)
+	}
+
+	if claims.ClientID != cl.ID {
+		return true, ErrUnauthorizedClient
+	}
+
+	now := s.Clock.Now()
+
+	if s.Denylist != nil {
+		if claims.JTI == "" {
+			return true, ErrUnsupportedTokenType
+		}
+		err := s.Denylist.Deny(ctx, &store.AccessDenylistRecord{
+			JTI:       claims.JTI,
+			UserID:    claims.Subject,
+			ClientID:  claims.ClientID,
+			RevokedAt: now,
+			ExpiresAt: claims.ExpiresAt,
+		})
+		if err != nil {
+			return true, fmt.Errorf("%w: denylist access token: %w", ErrServerError, err)
+		}
+		return true, nil
+	}
+
+	if s.Generations == nil {
+		return true, ErrUnsupportedTokenType
+	}
+	if _, err := s.Generations.Bump(ctx, claims.Subject, now); err != nil {
+		return true, fmt.Errorf("%w: bump access generation: %w", ErrServerError, err)
+	}
+
+	return true, nil
+}
+
+func isInvalidAccessToken(err error) bool {
+	return errors.Is(err, accesstoken.ErrInvalidToken) ||
+		errors.Is(err, accesstoken.ErrInvalidIssuer) ||
+		errors.Is(err, accesstoken.ErrInvalidAudience) ||
+		errors.Is(err, accesstoken.ErrInvalidSubject) ||
+		errors.Is(err, accesstoken.ErrInvalidClient)
+}
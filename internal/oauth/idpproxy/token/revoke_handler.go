@@ -0,0 +1,66 @@
+package token
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type RevocationHandler struct {
+	Service *RevocationService
+	Logger  *zap.Logger
+}
+
+func NewRevocationHandler(svc *RevocationService, logger *zap.Logger) *RevocationHandler {
+	return &RevocationHandler{
+		Service: svc,
+		Logger:  logger,
+	}
+}
+
+func (h *RevocationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeRevocationRequest(r)
+	if err != nil {
+		h.Logger.Warn("invalid revocation request",
+			zap.Error(err),
+		)
+
+		writeOAuthError(w, ErrInvalidRequest)
+		return
+	}
+
+	if err := h.Service.Revoke(r.Context(), req); err != nil {
+		h.Logger.Warn("token revocation failed",
+			zap.String("client_id", req.ClientID),
+			zap.String("token_type_hint", req.TokenTypeHint),
+			zap.Error(err),
+		)
+
+		writeOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+func decodeRevocationRequest(r *http.Request) (RevocationRequest, error) {
+	var req RevocationRequest
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" {
+		return req, errors.New("token: revocation request must be form encoded")
+	}
+	if err := r.ParseForm(); err != nil {
+		return req, err
+	}
+
+	req.Token = r.PostForm.Get("token")
+	req.TokenTypeHint = r.PostForm.Get("token_type_hint")
+	req.ClientID = r.PostForm.Get("client_id")
+	req.ClientSecret = r.PostForm.Get("client_secret")
+
+	return req, applyBasicAuth(r, &req.ClientID, &req.ClientSecret)
+}
@@ -0,0 +1,78 @@
+package token
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRevocationHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := NewRevocationHandler(&RevocationService{
+		Clients:       testClients,
+		Clock:         fixedClock{t: time.Now()},
+		RefreshTokens: &fakeRefreshStore{},
+	}, zap.NewNop())
+
+	post := func(form url.Values, contentType string, basic ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, RevokePath, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", contentType)
+		if len(basic) == 2 {
+			req.SetBasicAuth(basic[0], basic[1])
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("unknown token returns 200", func(t *testing.T) {
+		t.Parallel()
+
+		rec := post(url.Values{"token": {"unknown"}, "client_id": {"client-1"}}, "application/x-www-form-urlencoded")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Empty(t, rec.Body.String())
+		require.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("client_secret_basic is accepted", func(t *testing.T) {
+		t.Parallel()
+
+		rec := post(url.Values{"token": {"unknown"}}, "application/x-www-form-urlencoded", "confidential-1", testClientSecret)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("unauthenticated client returns 401", func(t *testing.T) {
+		t.Parallel()
+
+		rec := post(url.Values{"token": {"unknown"}}, "application/x-www-form-urlencoded")
+
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+		require.JSONEq(t, `{"error":"invalid_client"}`, rec.Body.String())
+	})
+
+	t.Run("missing token returns 400", func(t *testing.T) {
+		t.Parallel()
+
+		rec := post(url.Values{"client_id": {"client-1"}}, "application/x-www-form-urlencoded")
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.JSONEq(t, `{"error":"invalid_request"}`, rec.Body.String())
+	})
+
+	t.Run("non-form body returns 400", func(t *testing.T) {
+		t.Parallel()
+
+		rec := post(url.Values{"token": {"unknown"}, "client_id": {"client-1"}}, "application/json")
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
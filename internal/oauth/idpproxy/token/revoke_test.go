@@ -0,0 +1,208 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/accesstoken"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+)
+
+type fakeGenerations struct {
+	gens map[string]int
+	err  error
+}
+
+func (f *fakeGenerations) Get(ctx context.Context, userID string) (*store.AccessGenerationRecord, error) {
+	gen, ok := f.gens[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &store.AccessGenerationRecord{UserID: userID, Gen: gen}, nil
+}
+
+func (f *fakeGenerations) Bump(ctx context.Context, userID string, t time.Time) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.gens[userID]++
+	return f.gens[userID], nil
+}
+
+type fakeDenylist map[string]*store.AccessDenylistRecord
+
+func (f fakeDenylist) Deny(ctx context.Context, rec *store.AccessDenylistRecord) error {
+	f[rec.JTI] = rec
+	return nil
+}
+
+func (f fakeDenylist) IsDenied(ctx context.Context, jti string) (bool, error) {
+	_, ok := f[jti]
+	return ok, nil
+}
+
+func TestRevocationService_Revoke(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	ctx := context.Background()
+	now := time.Now()
+
+	issueRefresh := func(t *testing.T) (*fakeRefreshStore, string) {
+		t.Helper()
+
+		svc := withIssuers(&Service{
+			Store: &mockStore{code: &AuthCode{UserID: "user1", ClientID: "client-1", CodeChallenge: testChallenge, CodeChallengeMethod: "S256", ExpiresAt: now.Add(time.Hour)}},
+			Clock: fixedClock{t: now},
+		})
+		svc.NewRefreshToken = nil
+
+		resp, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "client-1", CodeVerifier: testVerifier})
+		require.NoError(t, err)
+
+		return svc.RefreshTokens.(*fakeRefreshStore), resp.RefreshToken
+	}
+
+	issueAccess := func(t *testing.T, gens *fakeGenerations, clientID string) string {
+		t.Helper()
+
+		uc := &accesstoken.IssueAccessTokenUsecase{Issuer: testIssuer, Signer: accesstoken.NewSignerAdapter(testSigner), Generations: gens}
+		tok, _, err := uc.Issue(ctx, &accesstoken.AccessTokenInput{UserID: "user1", ClientID: clientID, Now: now, TTL: time.Minute})
+		require.NoError(t, err)
+
+		return tok
+	}
+
+	newService := func(rs *fakeRefreshStore, gens *fakeGenerations, denylist fakeDenylist) (*RevocationService, *accesstoken.Verifier) {
+		verifier := accesstoken.NewVerifier(testIssuer, testSigner)
+		verifier.Generations = gens
+		if denylist != nil {
+			verifier.Denylist = denylist
+		}
+
+		svc := &RevocationService{
+			Clients:       testClients,
+			Clock:         fixedClock{t: now},
+			RefreshTokens: rs,
+			AccessTokens:  verifier,
+			Generations:   gens,
+		}
+		if denylist != nil {
+			svc.Denylist = denylist
+		}
+
+		return svc, verifier
+	}
+
+	t.Run("refresh token revokes its family", func(t *testing.T) {
+		rs, rt := issueRefresh(t)
+		svc, _ := newService(rs, &fakeGenerations{gens: map[string]int{}}, nil)
+
+		err := svc.Revoke(ctx, RevocationRequest{Token: rt, TokenTypeHint: TokenTypeHintRefreshToken, ClientID: "client-1"})
+		require.NoError(t, err)
+
+		id, _, err := refresh.ParseRefreshToken(rt)
+		require.NoError(t, err)
+		require.Equal(t, []string{rs.records[id].FamilyID + ":" + refresh.RevokeReasonClient}, rs.revoked)
+		require.False(t, rs.records[id].RevokedAt.IsZero())
+	})
+
+	t.Run("refresh token with a wrong hint is still found", func(t *testing.T) {
+		rs, rt := issueRefresh(t)
+		svc, _ := newService(rs, &fakeGenerations{gens: map[string]int{}}, nil)
+
+		require.NoError(t, svc.Revoke(ctx, RevocationRequest{Token: rt, TokenTypeHint: TokenTypeHintAccessToken, ClientID: "client-1"}))
+		require.Len(t, rs.revoked, 1)
+	})
+
+	t.Run("refresh token of another client is refused", func(t *testing.T) {
+		rs, rt := issueRefresh(t)
+		svc, _ := newService(rs, &fakeGenerations{gens: map[string]int{}}, nil)
+
+		err := svc.Revoke(ctx, RevocationRequest{Token: rt, ClientID: "client-2"})
+		require.ErrorIs(t, err, ErrUnauthorizedClient)
+		require.Empty(t, rs.revoked)
+	})
+
+	t.Run("access token bumps the generation", func(t *testing.T) {
+		gens := &fakeGenerations{gens: map[string]int{}}
+		svc, verifier := newService(&fakeRefreshStore{}, gens, nil)
+		at := issueAccess(t, gens, "client-1")
+
+		require.NoError(t, svc.Revoke(ctx, RevocationRequest{Token: at, TokenTypeHint: TokenTypeHintAccessToken, ClientID: "client-1"}))
+		require.Equal(t, 1, gens.gens["user1"])
+
+		_, err := verifier.Verify(ctx, at)
+		require.ErrorIs(t, err, accesstoken.ErrRevoked)
+
+		require.NoError(t, svc.Revoke(ctx, RevocationRequest{Token: at, ClientID: "client-1"}))
+		require.Equal(t, 1, gens.gens["user1"])
+	})
+
+	t.Run("access token is denylisted", func(t *testing.T) {
+		gens := &fakeGenerations{gens: map[string]int{}}
+		denylist := fakeDenylist{}
+		svc, verifier := newService(&fakeRefreshStore{}, gens, denylist)
+		at := issueAccess(t, gens, "client-1")
+		other := issueAccess(t, gens, "client-1")
+
+		require.NoError(t, svc.Revoke(ctx, RevocationRequest{Token: at, ClientID: "client-1"}))
+		require.Len(t, denylist, 1)
+		require.Zero(t, gens.gens["user1"])
+
+		_, err := verifier.Verify(ctx, at)
+		require.ErrorIs(t, err, accesstoken.ErrRevoked)
+
+		_, err = verifier.Verify(ctx, other)
+		require.NoError(t, err)
+	})
+
+	t.Run("access token of another client is refused", func(t *testing.T) {
+		gens := &fakeGenerations{gens: map[string]int{}}
+		svc, _ := newService(&fakeRefreshStore{}, gens, nil)
+
+		err := svc.Revoke(ctx, RevocationRequest{Token: issueAccess(t, gens, "client-2"), ClientID: "client-1"})
+		require.ErrorIs(t, err, ErrUnauthorizedClient)
+		require.Zero(t, gens.gens["user1"])
+	})
+
+	t.Run("access token without a revocation store", func(t *testing.T) {
+		gens := &fakeGenerations{gens: map[string]int{}}
+		svc, _ := newService(&fakeRefreshStore{}, gens, nil)
+		svc.Generations = nil
+
+		err := svc.Revoke(ctx, RevocationRequest{Token: issueAccess(t, gens, "client-1"), ClientID: "client-1"})
+		require.ErrorIs(t, err, ErrUnsupportedTokenType)
+	})
+
+	t.Run("unknown token is not an error", func(t *testing.T) {
+		svc, _ := newService(&fakeRefreshStore{}, &fakeGenerations{gens: map[string]int{}}, nil)
+
+		for _, tok := range []string{"garbage", "rt1.AAAAAAAAAAAAAAAAAAAAAA.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", "a.b.c"} {
+			require.NoError(t, svc.Revoke(ctx, RevocationRequest{Token: tok, ClientID: "client-1"}), tok)
+		}
+	})
+
+	t.Run("store failure is a server error", func(t *testing.T) {
+		gens := &fakeGenerations{gens: map[string]int{}}
+		svc, _ := newService(&fakeRefreshStore{}, gens, nil)
+		at := issueAccess(t, gens, "client-1")
+		gens.err = errors.New("firestore down")
+
+		err := svc.Revoke(ctx, RevocationRequest{Token: at, ClientID: "client-1"})
+		require.ErrorIs(t, err, ErrServerError)
+	})
+
+	t.Run("client authentication is required", func(t *testing.T) {
+		svc, _ := newService(&fakeRefreshStore{}, &fakeGenerations{gens: map[string]int{}}, nil)
+
+		require.ErrorIs(t, svc.Revoke(ctx, RevocationRequest{Token: "garbage"}), ErrInvalidClient)
+		require.ErrorIs(t, svc.Revoke(ctx, RevocationRequest{Token: "garbage", ClientID: "confidential-1", ClientSecret: "wrong"}), ErrInvalidClient)
+		require.ErrorIs(t, svc.Revoke(ctx, RevocationRequest{ClientID: "client-1"}), ErrInvalidRequest)
+	})
+}
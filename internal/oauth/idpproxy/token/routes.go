@@ -0,0 +1,106 @@
+package token
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/accesstoken"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/idtoken"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/securityevent"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/deps"
+)
+
+const (
+	Path           = "/token"
+	RevokePath     = "/revoke"
+	IntrospectPath = "/introspect"
+)
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func NewServiceFromDeps(oidcDeps *deps.OIDCDependencies) *Service {
+	jwtSigner := idtoken.NewSignerAdapter(oidcDeps.Signer)
+
+	svc := &Service{
+		Store:   NewProxyCodeStore(oidcDeps.ProxyCodes),
+		Clock:   systemClock{},
+		Clients: oidcDeps.Clients,
+		IDTokens: &idtoken.IssueIDTokenUsecase{
+			Issuer: oidcDeps.Config.Issuer,
+			Signer: jwtSigner,
+		},
+		AccessTokens: &accesstoken.IssueAccessTokenUsecase{
+			Issuer:      oidcDeps.Config.Issuer,
+			Signer:      accesstoken.NewSignerAdapter(oidcDeps.Signer),
+			Generations: oidcDeps.AccessGenerations,
+		},
+		RefreshTokens: oidcDeps.RefreshTokens,
+		Generations:   oidcDeps.AccessGenerations,
+		Events:        securityevent.NewLogRecorder(oidcDeps.Logger),
+		SessionPolicy: session.Policy{
+			IdleTimeout: oidcDeps.Config.SessionIdleTimeout,
+			MaxLifetime: oidcDeps.Config.SessionMaxLifetime,
+		},
+	}
+
+	if oidcDeps.Users != nil && len(oidcDeps.Config.GitHubClaims) > 0 {
+		svc.Users = oidcDeps.Users
+		svc.GitHubClaims = oidcDeps.Config.GitHubClaims
+	}
+
+	if oidcDeps.Sessions != nil {
+		svc.Sessions = &session.Usecase{Repo: oidcDeps.Sessions, Now: time.Now, Policy: svc.SessionPolicy}
+	}
+
+	return svc
+}
+
+func newAccessTokenVerifier(oidcDeps *deps.OIDCDependencies) *accesstoken.Verifier {
+	verifier := accesstoken.NewVerifier(oidcDeps.Config.Issuer, oidcDeps.Signer)
+	verifier.Generations = oidcDeps.AccessGenerations
+	verifier.Denylist = oidcDeps.AccessDenylist
+
+	return verifier
+}
+
+func NewRevocationServiceFromDeps(oidcDeps *deps.OIDCDependencies) *RevocationService {
+	svc := &RevocationService{
+		Clients:       oidcDeps.Clients,
+		Clock:         systemClock{},
+		RefreshTokens: oidcDeps.RefreshTokens,
+		AccessTokens:  newAccessTokenVerifier(oidcDeps),
+	}
+
+	if oidcDeps.Config.AccessTokenRevocation == config.AccessTokenRevocationDenylist {
+		svc.Denylist = oidcDeps.AccessDenylist
+	} else {
+		svc.Generations = oidcDeps.AccessGenerations
+	}
+
+	return svc
+}
+
+func NewIntrospectionServiceFromDeps(oidcDeps *deps.OIDCDependencies) *IntrospectionService {
+	return &IntrospectionService{
+		Clients:       oidcDeps.Clients,
+		Clock:         systemClock{},
+		RefreshTokens: oidcDeps.RefreshTokens,
+		AccessTokens:  newAccessTokenVerifier(oidcDeps),
+	}
+}
+
+func RegisterRoutes(r gin.IRoutes, oidcDeps *deps.OIDCDependencies) {
+	h := NewHandler(NewServiceFromDeps(oidcDeps), oidcDeps.Logger)
+	r.POST(Path, gin.WrapH(h))
+
+	rh := NewRevocationHandler(NewRevocationServiceFromDeps(oidcDeps), oidcDeps.Logger)
+	r.POST(RevokePath, gin.WrapH(rh))
+
+	ih := NewIntrospectionHandler(NewIntrospectionServiceFromDeps(oidcDeps), oidcDeps.Logger)
+	r.POST(IntrospectPath, gin.WrapH(ih))
+}
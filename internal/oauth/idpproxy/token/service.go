@@ -2,26 +2,115 @@ package token
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/accesstoken"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/idtoken"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/securityevent"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+const (
+	DefaultScope = "openid"
+	TokenType    = "Bearer"
+
+	DefaultAccessTokenTTL    = 15 * time.Minute
+	DefaultIDTokenTTL        = time.Hour
+	DefaultRefreshTokenTTL   = 30 * 24 * time.Hour
+	DefaultRefreshPurgeAfter = 37 * 24 * time.Hour
 )
 
 type AuthCode struct {
-	UserID    string
-	ClientID  string
-	ExpiresAt time.Time
+	UserID              string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	SessionID           string
+	AuthTime            time.Time
+	ExpiresAt           time.Time
 }
 
 type AuthCodeStore interface {
 	Consume(ctx context.Context, code string, clientID string) (*AuthCode, error)
+	BindFamily(ctx context.Context, code string, familyID string) error
+}
+
+type ClientRegistry interface {
+	Get(ctx context.Context, clientID string) (*client.Client, error)
 }
 
 type Clock interface {
 	Now() time.Time
 }
 
+type IDTokenIssuer interface {
+	Issue(ctx context.Context, in *idtoken.IDTokenInput) (token string, kid string, err error)
+}
+
+type AccessTokenIssuer interface {
+	Issue(ctx context.Context, in *accesstoken.AccessTokenInput) (token string, kid string, err error)
+}
+
+type RefreshTokenStore interface {
+	Create(ctx context.Context, rec *store.RefreshTokenRecord) error
+	GetByID(ctx context.Context, refreshID string) (*store.RefreshTokenRecord, error)
+	Replace(ctx context.Context, oldID string, newRec *store.RefreshTokenRecord, t time.Time) error
+	RevokeFamily(ctx context.Context, familyID, reason string, t time.Time) (int, error)
+}
+
+type SessionTracker interface {
+	AddClient(ctx context.Context, sessionID, clientID string) (*session.Session, error)
+}
+
+type UserRepository interface {
+	Get(ctx context.Context, id string) (*users.User, error)
+}
+
+type RefreshTokenGenerator func(ctx context.Context, userID string, ttl, purgeAfter time.Duration) (*store.RefreshTokenRecord, string, error)
+
 type Service struct {
-	Store AuthCodeStore
-	Clock Clock
+	Store   AuthCodeStore
+	Clock   Clock
+	Clients ClientRegistry
+
+	IDTokens      IDTokenIssuer
+	AccessTokens  AccessTokenIssuer
+	RefreshTokens RefreshTokenStore
+
+	// defaults to refresh.GenerateRefreshToken
+	NewRefreshToken RefreshTokenGenerator
+
+	// optional
+	Events   securityevent.Recorder
+	Sessions SessionTracker
+
+	// optional; cuts off the access tokens minted from a replayed code,
+	// which clients without the refresh_token grant have no family for
+	Generations AccessGenerationBumper
+
+	// optional; slides refresh token expiry the same way sessions slide
+	SessionPolicy session.Policy
+
+	// optional; GitHub membership claims added to ID tokens for the groups scope
+	Users        UserRepository
+	GitHubClaims []string
+
+	AccessTokenTTL    time.Duration
+	IDTokenTTL        time.Duration
+	RefreshTokenTTL   time.Duration
+	RefreshPurgeAfter time.Duration
 }
 
 func (s *Service) Exchange(
@@ -33,22 +122,393 @@ func (s *Service) Exchange(
 		return nil, ErrInvalidGrant
 	}
 
-	if req.GrantType != "authorization_code" {
+	if req.GrantType != "authorization_code" && req.GrantType != "refresh_token" {
 		return nil, ErrUnsupportedGrantType
 	}
 
-	ac, err := s.Store.Consume(ctx, req.Code, req.ClientID)
+	cl, err := authenticateClient(ctx, s.Clients, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cl.AllowsGrantType(req.GrantType) {
+		return nil, ErrUnauthorizedClient
+	}
+
+	if req.GrantType == "refresh_token" {
+		return s.exchangeRefreshToken(ctx, cl, req)
+	}
+
+	return s.exchangeAuthorizationCode(ctx, cl, req)
+}
+
+func authenticateClient(ctx context.Context, clients ClientRegistry, clientID, clientSecret string) (*client.Client, error) {
+	if clients == nil {
+		return nil, ErrServerError
+	}
+	if clientID == "" {
+		return nil, ErrInvalidClient
+	}
+
+	cl, err := clients.Get(ctx, clientID)
+	if errors.Is(err, client.ErrNotFound) {
+		return nil, ErrInvalidClient
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: load client: %w", ErrServerError, err)
+	}
+
+	if cl.IsPublic() {
+		if clientSecret != "" {
+			return nil, ErrInvalidClient
+		}
+		return cl, nil
+	}
+
+	if err := cl.VerifySecret(clientSecret); err != nil {
+		if errors.Is(err, client.ErrSecretMismatch) {
+			return nil, ErrInvalidClient
+		}
+		return nil, fmt.Errorf("%w: verify client secret: %w", ErrServerError, err)
+	}
+
+	return cl, nil
+}
+
+func (s *Service) exchangeAuthorizationCode(ctx context.Context, cl *client.Client, req TokenRequest) (*TokenResponse, error) {
+	ac, err := s.Store.Consume(ctx, req.Code, cl.ID)
+	var replay *AuthCodeReplayError
+	if errors.As(err, &replay) {
+		return nil, s.revokeOnCodeReplay(ctx, replay)
+	}
+	if err != nil {
+		return nil, ErrInvalidGrant
+	}
+
+	now := s.Clock.Now()
+	if now.After(ac.ExpiresAt) {
+		return nil, ErrInvalidGrant
+	}
+
+	// RFC 6749 §4.1.3: the redirect_uri must match the one the code was issued for
+	if ac.RedirectURI != "" && req.RedirectURI != ac.RedirectURI {
+		return nil, ErrInvalidGrant
+	}
+
+	// public clients cannot authenticate, so the code must be bound by PKCE
+	if cl.IsPublic() && ac.CodeChallenge == "" {
+		return nil, ErrInvalidGrant
+	}
+
+	if err := pkce.Verify(req.CodeVerifier, ac.CodeChallenge, ac.CodeChallengeMethod); err != nil {
+		return nil, ErrInvalidGrant
+	}
+
+	if ac.SessionID != "" && s.Sessions != nil {
+		if err := s.trackSession(ctx, ac.SessionID, cl.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	scope := ac.Scope
+	if scope == "" {
+		scope = DefaultScope
+	}
+
+	resp, familyID, err := s.issueTokens(ctx, cl, grant{
+		userID:    ac.UserID,
+		scope:     scope,
+		nonce:     ac.Nonce,
+		sessionID: ac.SessionID,
+		authTime:  ac.AuthTime,
+	}, now, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if familyID != "" {
+		err := s.Store.BindFamily(ctx, req.Code, familyID)
+		if errors.As(err, &replay) {
+			// the code was replayed before the family was bound, so the
+			// replay had nothing to revoke; revoke what was just minted
+			return nil, s.revokeOnCodeReplay(ctx, &AuthCodeReplayError{
+				UserID:   ac.UserID,
+				ClientID: cl.ID,
+				FamilyID: familyID,
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: bind refresh family to code: %w", ErrServerError, err)
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *Service) trackSession(ctx context.Context, sessionID, clientID string) error {
+	_, err := s.Sessions.AddClient(ctx, sessionID, clientID)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, session.ErrNotFound),
+		errors.Is(err, session.ErrExpiredSession),
+		errors.Is(err, session.ErrIdleSession),
+		errors.Is(err, session.ErrInactiveSession):
+		return fmt.Errorf("%w: session ended: %w", ErrInvalidGrant, err)
+	default:
+		return fmt.Errorf("%w: track session client: %w", ErrServerError, err)
+	}
+}
+
+func (s *Service) revokeOnCodeReplay(ctx context.Context, replay *AuthCodeReplayError) error {
+	now := s.Clock.Now()
+
+	revoked := 0
+	if replay.FamilyID != "" && s.RefreshTokens != nil {
+		n, err := s.RefreshTokens.RevokeFamily(ctx, replay.FamilyID, refresh.RevokeReasonCodeReplay, now)
+		if err != nil {
+			return fmt.Errorf("%w: revoke family after code replay: %w", ErrServerError, err)
+		}
+		revoked = n
+	}
+
+	if replay.UserID != "" && s.Generations != nil {
+		if _, err := s.Generations.Bump(ctx, replay.UserID, now); err != nil {
+			return fmt.Errorf("%w: bump access generation after code replay: %w", ErrServerError, err)
+		}
+	}
+
+	if s.Events != nil {
+		s.Events.Record(ctx, securityevent.Event{
+			Type:     securityevent.TypeAuthorizationCodeReplay,
+			UserID:   replay.UserID,
+			ClientID: replay.ClientID,
+			FamilyID: replay.FamilyID,
+			Revoked:  revoked,
+			At:       now,
+		})
+	}
+
+	return fmt.Errorf("%w: authorization code replay detected", ErrInvalidGrant)
+}
+
+func (s *Service) exchangeRefreshToken(ctx context.Context, cl *client.Client, req TokenRequest) (*TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, ErrInvalidRequest
+	}
+	if s.RefreshTokens == nil {
+		return nil, ErrServerError
+	}
+
+	refreshID, secret, err := refresh.ParseRefreshToken(req.RefreshToken)
+	if err != nil {
+		return nil, ErrInvalidGrant
+	}
+
+	rec, err := s.RefreshTokens.GetByID(ctx, refreshID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrInvalidGrant
+	}
 	if err != nil {
+		return nil, fmt.Errorf("%w: load refresh token: %w", ErrServerError, err)
+	}
+
+	if err := refresh.VerifyRefreshSecret(rec, secret); err != nil {
+		if errors.Is(err, refresh.ErrDigestMismatch) {
+			return nil, ErrInvalidGrant
+		}
+		return nil, fmt.Errorf("%w: verify refresh token: %w", ErrServerError, err)
+	}
+
+	if rec.ClientID != "" && rec.ClientID != cl.ID {
 		return nil, ErrInvalidGrant
 	}
 
-	if s.Clock.Now().After(ac.ExpiresAt) {
+	now := s.Clock.Now()
+
+	if rec.ReplacedBy != "" {
+		return nil, s.revokeOnReuse(ctx, rec, now)
+	}
+	if !rec.RevokedAt.IsZero() || (!rec.ExpiresAt.IsZero() && !now.Before(rec.ExpiresAt)) {
 		return nil, ErrInvalidGrant
 	}
 
-	idToken := "idtoken-for-" + ac.UserID
+	scope := rec.Scope
+	if scope == "" {
+		scope = DefaultScope
+	}
+	if req.Scope != "" {
+		if !isSubsetScope(req.Scope, scope) {
+			return nil, ErrInvalidScope
+		}
+		scope = req.Scope
+	}
+
+	resp, _, err := s.issueTokens(ctx, cl, grant{
+		userID:    rec.UserID,
+		scope:     scope,
+		sessionID: rec.SessionID,
+		authTime:  rec.AuthTime,
+	}, now, rec)
+	if errors.Is(err, store.ErrConflict) {
+		return nil, s.revokeOnReuse(ctx, rec, now)
+	}
+
+	return resp, err
+}
+
+func (s *Service) revokeOnReuse(ctx context.Context, rec *store.RefreshTokenRecord, now time.Time) error {
+	if _, err := s.RefreshTokens.RevokeFamily(ctx, rec.FamilyID, refresh.RevokeReasonReuse, now); err != nil {
+		return fmt.Errorf("%w: revoke family after reuse: %w", ErrServerError, err)
+	}
+
+	return fmt.Errorf("%w: refresh token reuse detected (family %s)", ErrInvalidGrant, rec.FamilyID)
+}
+
+func isSubsetScope(requested, granted string) bool {
+	have := strings.Fields(granted)
+	for _, sc := range strings.Fields(requested) {
+		if !slices.Contains(have, sc) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// grant is what a token response is minted from: the authorization code on
+// first issuance, the refresh token record on rotation.
+type grant struct {
+	userID    string
+	scope     string
+	nonce     string
+	sessionID string
+	authTime  time.Time
+}
+
+func (s *Service) issueTokens(ctx context.Context, cl *client.Client, g grant, now time.Time, rotated *store.RefreshTokenRecord) (*TokenResponse, string, error) {
+	if s.IDTokens == nil || s.AccessTokens == nil || s.RefreshTokens == nil {
+		return nil, "", ErrServerError
+	}
+
+	accessTTL := durationOr(cl.AccessTokenTTL, durationOr(s.AccessTokenTTL, DefaultAccessTokenTTL))
+
+	accessToken, _, err := s.AccessTokens.Issue(ctx, &accesstoken.AccessTokenInput{
+		UserID:   g.userID,
+		ClientID: cl.ID,
+		Scope:    g.scope,
+		Now:      now,
+		TTL:      accessTTL,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: issue access token: %w", ErrServerError, err)
+	}
+
+	idTokenInput := &idtoken.IDTokenInput{
+		UserID:      g.userID,
+		ClientID:    cl.ID,
+		Now:         now,
+		TTL:         durationOr(cl.IDTokenTTL, durationOr(s.IDTokenTTL, DefaultIDTokenTTL)),
+		AccessToken: accessToken,
+		Nonce:       g.nonce,
+		SessionID:   g.sessionID,
+	}
+	if !g.authTime.IsZero() {
+		idTokenInput.AuthTime = &g.authTime
+	}
+	if idTokenInput.Claims, err = s.userClaims(ctx, g); err != nil {
+		return nil, "", err
+	}
+
+	idToken, _, err := s.IDTokens.Issue(ctx, idTokenInput)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: issue id token: %w", ErrServerError, err)
+	}
+
+	resp := &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   TokenType,
+		ExpiresIn:   int64(accessTTL / time.Second),
+		IDToken:     idToken,
+		Scope:       g.scope,
+	}
+
+	if !cl.AllowsGrantType("refresh_token") {
+		return resp, "", nil
+	}
+
+	newRefreshToken := s.NewRefreshToken
+	if newRefreshToken == nil {
+		newRefreshToken = refresh.GenerateRefreshToken
+	}
+
+	refreshTTL := durationOr(cl.RefreshTokenTTL, durationOr(s.RefreshTokenTTL, DefaultRefreshTokenTTL))
+
+	rec, refreshToken, err := newRefreshToken(ctx, g.userID,
+		refreshTTL,
+		durationOr(s.RefreshPurgeAfter, DefaultRefreshPurgeAfter),
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: generate refresh token: %w", ErrServerError, err)
+	}
+
+	rec.ClientID = cl.ID
+	rec.Scope = g.scope
+	rec.SessionID = g.sessionID
+	rec.AuthTime = g.authTime
+	rec.FamilyCreatedAt = familyCreatedAt(rotated, now)
+	rec.ExpiresAt = s.SessionPolicy.ExpiresAt(rec.FamilyCreatedAt, now, refreshTTL)
+
+	if rotated != nil {
+		if err := s.RefreshTokens.Replace(ctx, rotated.RefreshID, rec, now); err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				return nil, "", err
+			}
+			return nil, "", fmt.Errorf("%w: rotate refresh token: %w", ErrServerError, err)
+		}
+	} else if err := s.RefreshTokens.Create(ctx, rec); err != nil {
+		return nil, "", fmt.Errorf("%w: store refresh token: %w", ErrServerError, err)
+	}
+
+	resp.RefreshToken = refreshToken
+
+	return resp, rec.FamilyID, nil
+}
+
+// familyCreatedAt is when the refresh token family was first issued; the
+// absolute lifetime of the family is measured from it.
+func familyCreatedAt(rotated *store.RefreshTokenRecord, now time.Time) time.Time {
+	switch {
+	case rotated == nil:
+		return now
+	case !rotated.FamilyCreatedAt.IsZero():
+		return rotated.FamilyCreatedAt
+	default:
+		return rotated.CreatedAt
+	}
+}
+
+func durationOr(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+
+	return def
+}
+
+// userClaims loads the user only when the grant can carry membership claims.
+func (s *Service) userClaims(ctx context.Context, g grant) (map[string]any, error) {
+	if s.Users == nil || len(s.GitHubClaims) == 0 || !slices.Contains(strings.Fields(g.scope), users.ScopeGroups) {
+		return nil, nil
+	}
+
+	u, err := s.Users.Get(ctx, g.userID)
+	if errors.Is(err, users.ErrNotFound) {
+		return nil, ErrInvalidGrant
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: load user: %w", ErrServerError, err)
+	}
 
-	return &TokenResponse{
-		IDToken: idToken,
-	}, nil
+	return u.GitHubClaims(g.scope, s.GitHubClaims), nil
 }
@@ -0,0 +1,204 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+)
+
+func TestService_Exchange_RefreshToken(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	ctx := context.Background()
+
+	newService := func(t *testing.T) (*Service, *fakeRefreshStore, *TokenResponse) {
+		t.Helper()
+
+		svc := withIssuers(&Service{
+			Store: &mockStore{code: &AuthCode{
+				UserID:              "user1",
+				ClientID:            "client-1",
+				CodeChallenge:       testChallenge,
+				CodeChallengeMethod: "S256",
+				Scope:               "openid email",
+				ExpiresAt:           time.Now().Add(time.Hour),
+			}},
+			Clock: fixedClock{t: time.Now()},
+		})
+		svc.NewRefreshToken = nil
+
+		first, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "client-1", CodeVerifier: testVerifier})
+		require.NoError(t, err)
+
+		return svc, svc.RefreshTokens.(*fakeRefreshStore), first
+	}
+
+	refreshReq := func(rt string) TokenRequest {
+		return TokenRequest{GrantType: "refresh_token", RefreshToken: rt, ClientID: "client-1"}
+	}
+
+	t.Run("rotates the refresh token", func(t *testing.T) {
+		svc, rs, first := newService(t)
+
+		second, err := svc.Exchange(ctx, refreshReq(first.RefreshToken))
+		require.NoError(t, err)
+		require.NotEqual(t, first.RefreshToken, second.RefreshToken)
+		require.NotEmpty(t, second.AccessToken)
+		require.NotEmpty(t, second.IDToken)
+		require.Equal(t, "openid email", second.Scope)
+
+		oldID, _, err := refresh.ParseRefreshToken(first.RefreshToken)
+		require.NoError(t, err)
+		newID, _, err := refresh.ParseRefreshToken(second.RefreshToken)
+		require.NoError(t, err)
+
+		require.Equal(t, newID, rs.records[oldID].ReplacedBy)
+		require.Equal(t, rs.records[oldID].FamilyID, rs.records[newID].FamilyID)
+		require.Equal(t, "client-1", rs.records[newID].ClientID)
+
+		third, err := svc.Exchange(ctx, refreshReq(second.RefreshToken))
+		require.NoError(t, err)
+		require.NotEmpty(t, third.RefreshToken)
+	})
+
+	t.Run("reuse of a replaced token revokes the family", func(t *testing.T) {
+		svc, rs, first := newService(t)
+
+		second, err := svc.Exchange(ctx, refreshReq(first.RefreshToken))
+		require.NoError(t, err)
+
+		_, err = svc.Exchange(ctx, refreshReq(first.RefreshToken))
+		require.ErrorIs(t, err, ErrInvalidGrant)
+
+		id, _, err := refresh.ParseRefreshToken(first.RefreshToken)
+		require.NoError(t, err)
+		require.Equal(t, []string{rs.records[id].FamilyID + ":" + refresh.RevokeReasonReuse}, rs.revoked)
+
+		_, err = svc.Exchange(ctx, refreshReq(second.RefreshToken))
+		require.ErrorIs(t, err, ErrInvalidGrant)
+	})
+
+	t.Run("wrong secret does not revoke the family", func(t *testing.T) {
+		svc, rs, first := newService(t)
+
+		id, _, err := refresh.ParseRefreshToken(first.RefreshToken)
+		require.NoError(t, err)
+
+		forged := "rt1." + id + ".AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+		_, err = svc.Exchange(ctx, refreshReq(forged))
+		require.ErrorIs(t, err, ErrInvalidGrant)
+		require.Empty(t, rs.revoked)
+
+		_, err = svc.Exchange(ctx, refreshReq(first.RefreshToken))
+		require.NoError(t, err)
+	})
+
+	t.Run("token bound to another client is rejected", func(t *testing.T) {
+		svc, _, first := newService(t)
+
+		req := refreshReq(first.RefreshToken)
+		req.ClientID = "client-2"
+
+		_, err := svc.Exchange(ctx, req)
+		require.ErrorIs(t, err, ErrInvalidGrant)
+	})
+
+	t.Run("expired token is rejected", func(t *testing.T) {
+		svc, _, first := newService(t)
+		svc.Clock = fixedClock{t: time.Now().Add(DefaultRefreshTokenTTL + time.Hour)}
+
+		_, err := svc.Exchange(ctx, refreshReq(first.RefreshToken))
+		require.ErrorIs(t, err, ErrInvalidGrant)
+	})
+
+	t.Run("session policy slides expiry up to the family lifetime", func(t *testing.T) {
+		start := time.Now().UTC().Truncate(time.Second)
+
+		svc := withIssuers(&Service{
+			Store: &mockStore{code: &AuthCode{
+				UserID:              "user1",
+				ClientID:            "client-1",
+				CodeChallenge:       testChallenge,
+				CodeChallengeMethod: "S256",
+				ExpiresAt:           start.Add(time.Hour),
+			}},
+			Clock:         fixedClock{t: start},
+			SessionPolicy: session.Policy{IdleTimeout: time.Hour, MaxLifetime: 2 * time.Hour},
+		})
+		svc.NewRefreshToken = nil
+		rs := svc.RefreshTokens.(*fakeRefreshStore)
+
+		expiresAt := func(t *testing.T, rt string) time.Time {
+			t.Helper()
+
+			id, _, err := refresh.ParseRefreshToken(rt)
+			require.NoError(t, err)
+			require.True(t, rs.records[id].FamilyCreatedAt.Equal(start))
+
+			return rs.records[id].ExpiresAt
+		}
+
+		first, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "client-1", CodeVerifier: testVerifier})
+		require.NoError(t, err)
+		require.Equal(t, start.Add(time.Hour), expiresAt(t, first.RefreshToken))
+
+		svc.Clock = fixedClock{t: start.Add(50 * time.Minute)}
+		second, err := svc.Exchange(ctx, refreshReq(first.RefreshToken))
+		require.NoError(t, err)
+		require.Equal(t, start.Add(110*time.Minute), expiresAt(t, second.RefreshToken))
+
+		svc.Clock = fixedClock{t: start.Add(100 * time.Minute)}
+		third, err := svc.Exchange(ctx, refreshReq(second.RefreshToken))
+		require.NoError(t, err)
+		require.Equal(t, start.Add(2*time.Hour), expiresAt(t, third.RefreshToken))
+
+		svc.Clock = fixedClock{t: start.Add(2 * time.Hour)}
+		_, err = svc.Exchange(ctx, refreshReq(third.RefreshToken))
+		require.ErrorIs(t, err, ErrInvalidGrant)
+	})
+
+	t.Run("idle refresh token is rejected", func(t *testing.T) {
+		svc, _, first := newService(t)
+		svc.SessionPolicy = session.Policy{IdleTimeout: time.Hour}
+
+		second, err := svc.Exchange(ctx, refreshReq(first.RefreshToken))
+		require.NoError(t, err)
+
+		svc.Clock = fixedClock{t: time.Now().Add(2 * time.Hour)}
+		_, err = svc.Exchange(ctx, refreshReq(second.RefreshToken))
+		require.ErrorIs(t, err, ErrInvalidGrant)
+	})
+
+	t.Run("scope can be narrowed but not widened", func(t *testing.T) {
+		svc, _, first := newService(t)
+
+		req := refreshReq(first.RefreshToken)
+		req.Scope = "openid profile"
+		_, err := svc.Exchange(ctx, req)
+		require.ErrorIs(t, err, ErrInvalidScope)
+
+		req.Scope = "openid"
+		resp, err := svc.Exchange(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, "openid", resp.Scope)
+	})
+
+	t.Run("malformed and unknown tokens are rejected", func(t *testing.T) {
+		svc, _, _ := newService(t)
+
+		_, err := svc.Exchange(ctx, refreshReq(""))
+		require.ErrorIs(t, err, ErrInvalidRequest)
+
+		_, err = svc.Exchange(ctx, refreshReq("not-a-refresh-token"))
+		require.ErrorIs(t, err, ErrInvalidGrant)
+
+		_, err = svc.Exchange(ctx, refreshReq("rt1.AAAAAAAAAAAAAAAAAAAAAA.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"))
+		require.ErrorIs(t, err, ErrInvalidGrant)
+	})
+}
@@ -2,11 +2,29 @@ package token
 
 import (
 	"context"
-	"strings"
+	"errors"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/accesstoken"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/idtoken"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/securityevent"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
+	"github.com/vinylhousegarage/idpproxy/internal/authcode"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
 )
 
+const testIssuer = "https://idpproxy.example.com"
+
 type fixedClock struct {
 	t time.Time
 }
@@ -27,10 +45,155 @@ func (m *mockStore) Consume(ctx context.Context, code, clientID string) (*AuthCo
 	return m.code, nil
 }
 
+func (m *mockStore) BindFamily(ctx context.Context, code, familyID string) error {
+	return nil
+}
+
+type fakeEvents struct {
+	mu     sync.Mutex
+	events []securityevent.Event
+}
+
+func (f *fakeEvents) Record(ctx context.Context, ev securityevent.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.events = append(f.events, ev)
+}
+
+type fakeRefreshStore struct {
+	mu      sync.Mutex
+	records map[string]*store.RefreshTokenRecord
+	created []*store.RefreshTokenRecord
+	revoked []string
+	err     error
+}
+
+func (f *fakeRefreshStore) Create(ctx context.Context, rec *store.RefreshTokenRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return f.err
+	}
+	f.created = append(f.created, rec)
+	if f.records == nil {
+		f.records = make(map[string]*store.RefreshTokenRecord)
+	}
+	cp := *rec
+	f.records[rec.RefreshID] = &cp
+	return nil
+}
+
+func (f *fakeRefreshStore) GetByID(ctx context.Context, refreshID string) (*store.RefreshTokenRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rec, ok := f.records[refreshID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	cp := *rec
+	return &cp, nil
+}
+
+func (f *fakeRefreshStore) Replace(ctx context.Context, oldID string, newRec *store.RefreshTokenRecord, t time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return f.err
+	}
+	old, ok := f.records[oldID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if old.ReplacedBy != "" || !old.RevokedAt.IsZero() {
+		return store.ErrConflict
+	}
+	old.ReplacedBy = newRec.RefreshID
+	old.RevokedAt = t
+	newRec.FamilyID = old.FamilyID
+	cp := *newRec
+	f.records[newRec.RefreshID] = &cp
+	return nil
+}
+
+func (f *fakeRefreshStore) RevokeFamily(ctx context.Context, familyID, reason string, t time.Time) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.revoked = append(f.revoked, familyID+":"+reason)
+	n := 0
+	for _, rec := range f.records {
+		if rec.FamilyID == familyID && rec.RevokedAt.IsZero() {
+			rec.RevokedAt = t
+			rec.RevokeReason = reason
+			n++
+		}
+	}
+	return n, nil
+}
+
+func fakeRefreshToken(ctx context.Context, userID string, ttl, purgeAfter time.Duration) (*store.RefreshTokenRecord, string, error) {
+	now := time.Now()
+	return &store.RefreshTokenRecord{
+		RefreshID: "rid-1",
+		UserID:    userID,
+		DigestB64: "digest",
+		KeyID:     "pepper-1",
+		FamilyID:  "family-1",
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		DeleteAt:  now.Add(purgeAfter),
+	}, "rt1.rid-1.secret", nil
+}
+
+var testSigner = signer.NewHMACSigner([]byte("secret"), "kid-1")
+
+const testClientSecret = "Y29uZmlkZW50aWFsLTEgdGVzdCBjbGllbnQgc2VjcmV0"
+
+// testVerifier and testChallenge bind fixture codes issued to public clients.
+const (
+	testVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+var testClients = newTestClients()
+
+func newTestClients() *client.MemoryRegistry {
+	hash, err := client.HashSecret(testClientSecret)
+	if err != nil {
+		panic(err)
+	}
+
+	return client.NewMemoryRegistry(
+		&client.Client{ID: "client-1", Type: client.TypePublic},
+		&client.Client{ID: "client-2", Type: client.TypePublic},
+		&client.Client{ID: "confidential-1", Type: client.TypeConfidential, SecretHash: hash},
+		&client.Client{ID: "resource-1", Type: client.TypeConfidential, SecretHash: hash, ResourceServer: true},
+		&client.Client{ID: "code-only", Type: client.TypePublic, GrantTypes: []string{"authorization_code"}},
+		&client.Client{ID: "short-lived", Type: client.TypePublic, AccessTokenTTL: time.Minute},
+	)
+}
+
+func withIssuers(s *Service) *Service {
+	jwtSigner := idtoken.NewSignerAdapter(testSigner)
+
+	s.Clients = testClients
+	s.IDTokens = &idtoken.IssueIDTokenUsecase{Issuer: testIssuer, Signer: jwtSigner}
+	s.AccessTokens = &accesstoken.IssueAccessTokenUsecase{Issuer: testIssuer, Signer: jwtSigner}
+	s.RefreshTokens = &fakeRefreshStore{}
+	s.NewRefreshToken = fakeRefreshToken
+
+	return s
+}
+
 func newTestService() *Service {
 	return &Service{
-		Store: &mockStore{err: ErrInvalidGrant},
-		Clock: fixedClock{t: time.Now()},
+		Store:   &mockStore{err: ErrInvalidGrant},
+		Clock:   fixedClock{t: time.Now()},
+		Clients: testClients,
 	}
 }
 
@@ -43,21 +206,24 @@ func newTestServiceWithExpiredCode() *Service {
 				ExpiresAt: time.Now().Add(-time.Hour),
 			},
 		},
-		Clock: fixedClock{t: time.Now()},
+		Clock:   fixedClock{t: time.Now()},
+		Clients: testClients,
 	}
 }
 
 func newTestServiceWithValidCode() *Service {
-	return &Service{
+	return withIssuers(&Service{
 		Store: &mockStore{
 			code: &AuthCode{
-				UserID:    "user1",
-				ClientID:  "client-1",
-				ExpiresAt: time.Now().Add(time.Hour),
+				UserID:              "user1",
+				ClientID:            "client-1",
+				CodeChallenge:       testChallenge,
+				CodeChallengeMethod: "S256",
+				ExpiresAt:           time.Now().Add(time.Hour),
 			},
 		},
 		Clock: fixedClock{t: time.Now()},
-	}
+	})
 }
 
 func TestService_Exchange(t *testing.T) {
@@ -111,31 +277,524 @@ func TestService_Exchange(t *testing.T) {
 		}
 	})
 
-	t.Run("valid request returns id_token", func(t *testing.T) {
+	t.Run("valid request returns signed tokens", func(t *testing.T) {
 		t.Parallel()
 
 		svc := newTestServiceWithValidCode()
 
 		resp, err := svc.Exchange(ctx, TokenRequest{
-			GrantType: "authorization_code",
-			Code:      "valid-code",
-			ClientID:  "client-1",
+			GrantType:    "authorization_code",
+			Code:         "valid-code",
+			ClientID:     "client-1",
+			CodeVerifier: testVerifier,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+
+		require.Equal(t, "Bearer", resp.TokenType)
+		require.EqualValues(t, DefaultAccessTokenTTL/time.Second, resp.ExpiresIn)
+		require.Equal(t, "openid", resp.Scope)
+		require.Equal(t, "rt1.rid-1.secret", resp.RefreshToken)
+
+		idt, err := testSigner.Verify(ctx, resp.IDToken, nil)
+		require.NoError(t, err)
+		require.Equal(t, testIssuer, idt.Claims["iss"])
+		require.Equal(t, "user1", idt.Claims["sub"])
+		require.Equal(t, "client-1", idt.Claims["aud"])
+		require.NotEmpty(t, idt.Claims["at_hash"])
+
+		at, err := testSigner.Verify(ctx, resp.AccessToken, nil)
+		require.NoError(t, err)
+		require.Equal(t, "user1", at.Claims["sub"])
+		require.Equal(t, "client-1", at.Claims["client_id"])
+		require.Equal(t, "openid", at.Claims["scope"])
+
+		refreshStore := svc.RefreshTokens.(*fakeRefreshStore)
+		require.Len(t, refreshStore.created, 1)
+		require.Equal(t, "user1", refreshStore.created[0].UserID)
+	})
+
+	t.Run("scope and nonce of the code are returned", func(t *testing.T) {
+		t.Parallel()
+
+		svc := withIssuers(&Service{
+			Store: &mockStore{code: &AuthCode{
+				UserID:              "user1",
+				ClientID:            "client-1",
+				CodeChallenge:       testChallenge,
+				CodeChallengeMethod: "S256",
+				Scope:               "openid email",
+				Nonce:               "n-0S6_WzA2Mj",
+				ExpiresAt:           time.Now().Add(time.Hour),
+			}},
+			Clock: fixedClock{t: time.Now()},
 		})
 
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
+		resp, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "client-1", CodeVerifier: testVerifier})
+		require.NoError(t, err)
+		require.Equal(t, "openid email", resp.Scope)
+
+		idt, err := testSigner.Verify(ctx, resp.IDToken, nil)
+		require.NoError(t, err)
+		require.Equal(t, "n-0S6_WzA2Mj", idt.Claims["nonce"])
+	})
+
+	t.Run("github claims are added for the groups scope", func(t *testing.T) {
+		t.Parallel()
+
+		repo := users.NewMemoryRepository()
+		require.NoError(t, repo.Upsert(ctx, &users.User{ID: "user1", GitHubOrgs: []string{"acme"}, GitHubTeams: []string{"acme/admins"}}))
+
+		exchange := func(t *testing.T, scope string) map[string]any {
+			t.Helper()
+
+			svc := withIssuers(&Service{
+				Store: &mockStore{code: &AuthCode{
+					UserID:              "user1",
+					ClientID:            "client-1",
+					CodeChallenge:       testChallenge,
+					CodeChallengeMethod: "S256",
+					Scope:               scope,
+					ExpiresAt:           time.Now().Add(time.Hour),
+				}},
+				Clock:        fixedClock{t: time.Now()},
+				Users:        repo,
+				GitHubClaims: []string{config.ClaimGroups},
+			})
+
+			resp, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "client-1", CodeVerifier: testVerifier})
+			require.NoError(t, err)
+
+			idt, err := testSigner.Verify(ctx, resp.IDToken, nil)
+			require.NoError(t, err)
+
+			return idt.Claims
 		}
 
-		if resp == nil {
-			t.Fatal("response should not be nil")
+		require.Equal(t, []any{"acme", "acme/admins"}, exchange(t, "openid groups")["groups"])
+		require.NotContains(t, exchange(t, "openid groups"), "github_orgs", "only enabled claims are added")
+		require.NotContains(t, exchange(t, "openid"), "groups")
+	})
+
+	t.Run("pkce verifier is checked against bound challenge", func(t *testing.T) {
+		t.Parallel()
+
+		newSvc := func(challenge, method string) *Service {
+			return withIssuers(&Service{
+				Store: &mockStore{code: &AuthCode{
+					UserID:              "user1",
+					ClientID:            "client-1",
+					CodeChallenge:       challenge,
+					CodeChallengeMethod: method,
+					ExpiresAt:           time.Now().Add(time.Hour),
+				}},
+				Clock: fixedClock{t: time.Now()},
+			})
 		}
 
-		if resp.IDToken == "" {
-			t.Fatal("id_token should not be empty")
+		req := TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "client-1"}
+
+		_, err := newSvc(testChallenge, "S256").Exchange(ctx, req)
+		require.ErrorIs(t, err, ErrInvalidGrant)
+
+		wrong := req
+		wrong.CodeVerifier = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+		_, err = newSvc(testChallenge, "S256").Exchange(ctx, wrong)
+		require.ErrorIs(t, err, ErrInvalidGrant)
+
+		ok := req
+		ok.CodeVerifier = testVerifier
+		resp, err := newSvc(testChallenge, "S256").Exchange(ctx, ok)
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.AccessToken)
+
+		resp, err = newSvc(testVerifier, "plain").Exchange(ctx, ok)
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.AccessToken)
+
+		_, err = newSvc("", "").Exchange(ctx, ok)
+		require.ErrorIs(t, err, ErrInvalidGrant)
+	})
+
+	t.Run("redirect_uri must match the one bound to the code", func(t *testing.T) {
+		t.Parallel()
+
+		newSvc := func() *Service {
+			return withIssuers(&Service{
+				Store: &mockStore{code: &AuthCode{
+					UserID:              "user1",
+					ClientID:            "client-1",
+					RedirectURI:         "https://app.example.com/cb",
+					CodeChallenge:       testChallenge,
+					CodeChallengeMethod: "S256",
+					ExpiresAt:           time.Now().Add(time.Hour),
+				}},
+				Clock: fixedClock{t: time.Now()},
+			})
 		}
 
-		if !strings.Contains(resp.IDToken, "user1") {
-			t.Fatalf("id_token should contain user info")
+		req := TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "client-1", CodeVerifier: testVerifier}
+
+		_, err := newSvc().Exchange(ctx, req)
+		require.ErrorIs(t, err, ErrInvalidGrant)
+
+		wrong := req
+		wrong.RedirectURI = "https://evil.example.com/cb"
+		_, err = newSvc().Exchange(ctx, wrong)
+		require.ErrorIs(t, err, ErrInvalidGrant)
+
+		ok := req
+		ok.RedirectURI = "https://app.example.com/cb"
+		resp, err := newSvc().Exchange(ctx, ok)
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.AccessToken)
+	})
+
+	t.Run("public client code without challenge is rejected", func(t *testing.T) {
+		t.Parallel()
+
+		newSvc := func(clientID string) *Service {
+			return withIssuers(&Service{
+				Store: &mockStore{code: &AuthCode{
+					UserID:    "user1",
+					ClientID:  clientID,
+					ExpiresAt: time.Now().Add(time.Hour),
+				}},
+				Clock: fixedClock{t: time.Now()},
+			})
 		}
+
+		_, err := newSvc("client-1").Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "client-1"})
+		require.ErrorIs(t, err, ErrInvalidGrant)
+
+		resp, err := newSvc("confidential-1").Exchange(ctx, TokenRequest{
+			GrantType:    "authorization_code",
+			Code:         "c",
+			ClientID:     "confidential-1",
+			ClientSecret: testClientSecret,
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.AccessToken)
+	})
+
+	t.Run("missing issuers returns ErrServerError", func(t *testing.T) {
+		t.Parallel()
+
+		svc := newTestServiceWithValidCode()
+		svc.IDTokens = nil
+
+		_, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "client-1", CodeVerifier: testVerifier})
+		require.ErrorIs(t, err, ErrServerError)
+	})
+
+	t.Run("refresh store failure returns ErrServerError", func(t *testing.T) {
+		t.Parallel()
+
+		svc := newTestServiceWithValidCode()
+		svc.RefreshTokens = &fakeRefreshStore{err: errors.New("firestore down")}
+
+		_, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "client-1", CodeVerifier: testVerifier})
+		require.ErrorIs(t, err, ErrServerError)
+	})
+}
+
+func TestService_ClientAuthentication(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	newSvc := func(clientID string) *Service {
+		return withIssuers(&Service{
+			Store: &mockStore{code: &AuthCode{
+				UserID:              "user1",
+				ClientID:            clientID,
+				CodeChallenge:       testChallenge,
+				CodeChallengeMethod: "S256",
+				ExpiresAt:           time.Now().Add(time.Hour),
+			}},
+			Clock: fixedClock{t: time.Now()},
+		})
+	}
+
+	tests := []struct {
+		name    string
+		req     TokenRequest
+		wantErr error
+	}{
+		{"confidential client with secret", TokenRequest{ClientID: "confidential-1", ClientSecret: testClientSecret}, nil},
+		{"confidential client with wrong secret", TokenRequest{ClientID: "confidential-1", ClientSecret: "wrong"}, ErrInvalidClient},
+		{"confidential client without secret", TokenRequest{ClientID: "confidential-1"}, ErrInvalidClient},
+		{"public client with secret", TokenRequest{ClientID: "client-1", ClientSecret: "anything"}, ErrInvalidClient},
+		{"unknown client", TokenRequest{ClientID: "nope"}, ErrInvalidClient},
+		{"missing client_id", TokenRequest{}, ErrInvalidClient},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := tt.req
+			req.GrantType = "authorization_code"
+			req.Code = "c"
+			req.CodeVerifier = testVerifier
+
+			_, err := newSvc(req.ClientID).Exchange(ctx, req)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("missing registry returns ErrServerError", func(t *testing.T) {
+		t.Parallel()
+
+		svc := newSvc("client-1")
+		svc.Clients = nil
+
+		_, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "client-1", CodeVerifier: testVerifier})
+		require.ErrorIs(t, err, ErrServerError)
+	})
+
+	t.Run("grant not allowed for client", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := newSvc("code-only").Exchange(ctx, TokenRequest{GrantType: "refresh_token", RefreshToken: "rt", ClientID: "code-only"})
+		require.ErrorIs(t, err, ErrUnauthorizedClient)
+	})
+
+	t.Run("client without refresh_token grant gets no refresh token", func(t *testing.T) {
+		t.Parallel()
+
+		svc := newSvc("code-only")
+
+		resp, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "code-only", CodeVerifier: testVerifier})
+		require.NoError(t, err)
+		require.Empty(t, resp.RefreshToken)
+		require.Empty(t, svc.RefreshTokens.(*fakeRefreshStore).created)
+	})
+
+	t.Run("client token ttl overrides default", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := newSvc("short-lived").Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "c", ClientID: "short-lived", CodeVerifier: testVerifier})
+		require.NoError(t, err)
+		require.EqualValues(t, 60, resp.ExpiresIn)
+	})
+}
+
+func TestService_AuthorizationCodeReplay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	newService := func(t *testing.T) (*Service, *fakeRefreshStore, *fakeEvents) {
+		t.Helper()
+
+		mem := authcodestore.NewMemoryStore()
+		require.NoError(t, mem.Save(ctx, authcode.ProxyCode{
+			Code:                "code-1",
+			UserID:              "user-1",
+			ClientID:            "client-1",
+			CodeChallenge:       testChallenge,
+			CodeChallengeMethod: "S256",
+			ExpiresAt:           time.Now().Add(time.Minute),
+		}))
+
+		events := &fakeEvents{}
+		svc := withIssuers(&Service{
+			Store:  NewProxyCodeStore(mem),
+			Clock:  fixedClock{t: time.Now()},
+			Events: events,
+		})
+
+		return svc, svc.RefreshTokens.(*fakeRefreshStore), events
+	}
+
+	t.Run("second redemption revokes the family minted from the code", func(t *testing.T) {
+		t.Parallel()
+
+		svc, refreshStore, events := newService(t)
+		req := TokenRequest{GrantType: "authorization_code", Code: "code-1", ClientID: "client-1", CodeVerifier: testVerifier}
+
+		first, err := svc.Exchange(ctx, req)
+		require.NoError(t, err)
+		require.NotEmpty(t, first.RefreshToken)
+		require.Len(t, refreshStore.created, 1)
+		familyID := refreshStore.created[0].FamilyID
+
+		_, err = svc.Exchange(ctx, req)
+		require.ErrorIs(t, err, ErrInvalidGrant)
+		require.Equal(t, []string{familyID + ":" + refresh.RevokeReasonCodeReplay}, refreshStore.revoked)
+
+		rec, err := refreshStore.GetByID(ctx, refreshStore.created[0].RefreshID)
+		require.NoError(t, err)
+		require.False(t, rec.RevokedAt.IsZero())
+
+		require.Len(t, events.events, 1)
+		ev := events.events[0]
+		require.Equal(t, securityevent.TypeAuthorizationCodeReplay, ev.Type)
+		require.Equal(t, "user-1", ev.UserID)
+		require.Equal(t, "client-1", ev.ClientID)
+		require.Equal(t, familyID, ev.FamilyID)
+		require.Equal(t, 1, ev.Revoked)
+	})
+
+	t.Run("replay from another client is still detected", func(t *testing.T) {
+		t.Parallel()
+
+		svc, refreshStore, events := newService(t)
+
+		_, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "code-1", ClientID: "client-1", CodeVerifier: testVerifier})
+		require.NoError(t, err)
+
+		_, err = svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "code-1", ClientID: "client-2", CodeVerifier: testVerifier})
+		require.ErrorIs(t, err, ErrInvalidGrant)
+		require.Len(t, refreshStore.revoked, 1)
+		require.Len(t, events.events, 1)
+	})
+
+	t.Run("replay before the family is bound revokes the minted family", func(t *testing.T) {
+		t.Parallel()
+
+		svc, refreshStore, events := newService(t)
+		svc.Store = replayBeforeBind{svc.Store.(*ProxyCodeStore)}
+
+		_, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "code-1", ClientID: "client-1", CodeVerifier: testVerifier})
+		require.ErrorIs(t, err, ErrInvalidGrant)
+
+		require.Len(t, refreshStore.created, 1)
+		familyID := refreshStore.created[0].FamilyID
+		require.Equal(t, []string{familyID + ":" + refresh.RevokeReasonCodeReplay}, refreshStore.revoked)
+
+		require.Len(t, events.events, 1)
+		require.Equal(t, familyID, events.events[0].FamilyID)
+		require.Equal(t, "user-1", events.events[0].UserID)
+	})
+
+	t.Run("replay by a client without refresh tokens revokes the access token", func(t *testing.T) {
+		t.Parallel()
+
+		mem := authcodestore.NewMemoryStore()
+		require.NoError(t, mem.Save(ctx, authcode.ProxyCode{
+			Code:                "code-2",
+			UserID:              "user-2",
+			ClientID:            "code-only",
+			CodeChallenge:       testChallenge,
+			CodeChallengeMethod: "S256",
+			ExpiresAt:           time.Now().Add(time.Minute),
+		}))
+
+		gens := &fakeGenerations{gens: map[string]int{}}
+		svc := withIssuers(&Service{
+			Store:       NewProxyCodeStore(mem),
+			Clock:       fixedClock{t: time.Now()},
+			Generations: gens,
+		})
+		svc.AccessTokens = &accesstoken.IssueAccessTokenUsecase{Issuer: testIssuer, Signer: accesstoken.NewSignerAdapter(testSigner), Generations: gens}
+		req := TokenRequest{GrantType: "authorization_code", Code: "code-2", ClientID: "code-only", CodeVerifier: testVerifier}
+
+		first, err := svc.Exchange(ctx, req)
+		require.NoError(t, err)
+		require.Empty(t, first.RefreshToken)
+
+		verifier := accesstoken.NewVerifier(testIssuer, testSigner)
+		verifier.Generations = gens
+		_, err = verifier.Verify(ctx, first.AccessToken)
+		require.NoError(t, err)
+
+		_, err = svc.Exchange(ctx, req)
+		require.ErrorIs(t, err, ErrInvalidGrant)
+		require.Empty(t, svc.RefreshTokens.(*fakeRefreshStore).revoked)
+		require.Equal(t, 1, gens.gens["user-2"])
+
+		_, err = verifier.Verify(ctx, first.AccessToken)
+		require.ErrorIs(t, err, accesstoken.ErrRevoked)
+	})
+}
+
+// replayBeforeBind presents the code again after tokens are minted but
+// before the refresh family is bound to it.
+type replayBeforeBind struct {
+	*ProxyCodeStore
+}
+
+func (s replayBeforeBind) BindFamily(ctx context.Context, code, familyID string) error {
+	if _, err := s.Consume(ctx, code, "client-1"); err == nil {
+		return errors.New("replay unexpectedly succeeded")
+	}
+
+	return s.ProxyCodeStore.BindFamily(ctx, code, familyID)
+}
+
+func TestService_SessionTracking(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	authTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	newService := func(t *testing.T, status string) (*Service, *session.MemoryRepository) {
+		t.Helper()
+
+		sessions := session.NewMemoryRepository()
+		require.NoError(t, sessions.Create(ctx, &session.Session{
+			SessionID: "sid-1",
+			UserID:    "user-1",
+			Status:    status,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}))
+
+		svc := withIssuers(&Service{
+			Store: &mockStore{
+				code: &AuthCode{
+					UserID:              "user-1",
+					ClientID:            "client-1",
+					CodeChallenge:       testChallenge,
+					CodeChallengeMethod: "S256",
+					SessionID:           "sid-1",
+					AuthTime:            authTime,
+					ExpiresAt:           time.Now().Add(time.Hour),
+				},
+			},
+			Clock:    fixedClock{t: time.Now()},
+			Sessions: &session.Usecase{Repo: sessions, Now: time.Now},
+		})
+
+		return svc, sessions
+	}
+
+	t.Run("records the client and stamps sid", func(t *testing.T) {
+		t.Parallel()
+
+		svc, sessions := newService(t, "active")
+
+		resp, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "code-1", ClientID: "client-1", CodeVerifier: testVerifier})
+		require.NoError(t, err)
+
+		s, err := sessions.FindByID(ctx, "sid-1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"client-1"}, s.ClientIDs)
+
+		res, err := testSigner.Verify(ctx, resp.IDToken, nil)
+		require.NoError(t, err)
+		require.Equal(t, "sid-1", res.Claims["sid"])
+		require.EqualValues(t, authTime.Unix(), res.Claims["auth_time"])
+
+		refreshStore := svc.RefreshTokens.(*fakeRefreshStore)
+		require.Len(t, refreshStore.created, 1)
+		require.Equal(t, "sid-1", refreshStore.created[0].SessionID)
+		require.True(t, refreshStore.created[0].AuthTime.Equal(authTime))
+	})
+
+	t.Run("ended session rejects the code", func(t *testing.T) {
+		t.Parallel()
+
+		svc, _ := newService(t, "inactive")
+
+		_, err := svc.Exchange(ctx, TokenRequest{GrantType: "authorization_code", Code: "code-1", ClientID: "client-1", CodeVerifier: testVerifier})
+		require.ErrorIs(t, err, ErrInvalidGrant)
+		require.Empty(t, svc.RefreshTokens.(*fakeRefreshStore).created)
 	})
 }
@@ -0,0 +1,43 @@
+package userinfo
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+var SupportedClaims = []string{
+	"name", "preferred_username", "picture", "email", "email_verified",
+}
+
+func BuildClaims(u *users.User, scope string) map[string]any {
+	scopes := strings.Fields(scope)
+
+	claims := map[string]any{"sub": u.ID}
+
+	if slices.Contains(scopes, ScopeProfile) {
+		putString(claims, "name", u.Name)
+		putString(claims, "preferred_username", u.Login)
+		putString(claims, "picture", u.Picture)
+	}
+
+	if slices.Contains(scopes, ScopeEmail) && u.Email != "" {
+		claims["email"] = u.Email
+		claims["email_verified"] = u.EmailVerified
+	}
+
+	return claims
+}
+
+func putString(claims map[string]any, key, value string) {
+	if value != "" {
+		claims[key] = value
+	}
+}
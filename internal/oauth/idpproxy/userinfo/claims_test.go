@@ -0,0 +1,74 @@
+package userinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+func TestBuildClaims(t *testing.T) {
+	t.Parallel()
+
+	u := &users.User{
+		ID:            "google:uid-1",
+		Login:         "jane",
+		Name:          "Jane Doe",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Picture:       "https://example.com/jane.png",
+	}
+
+	tests := []struct {
+		name  string
+		user  *users.User
+		scope string
+		want  map[string]any
+	}{
+		{
+			name:  "openid only",
+			user:  u,
+			scope: "openid",
+			want:  map[string]any{"sub": "google:uid-1"},
+		},
+		{
+			name:  "profile",
+			user:  u,
+			scope: "openid profile",
+			want: map[string]any{
+				"sub":                "google:uid-1",
+				"name":               "Jane Doe",
+				"preferred_username": "jane",
+				"picture":            "https://example.com/jane.png",
+			},
+		},
+		{
+			name:  "email",
+			user:  u,
+			scope: "openid email",
+			want: map[string]any{
+				"sub":            "google:uid-1",
+				"email":          "jane@example.com",
+				"email_verified": true,
+			},
+		},
+		{
+			name:  "empty values are omitted",
+			user:  &users.User{ID: "github:1", Login: "octocat"},
+			scope: "openid profile email",
+			want: map[string]any{
+				"sub":                "github:1",
+				"preferred_username": "octocat",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, BuildClaims(tt.user, tt.scope))
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package userinfo
+
+import "errors"
+
+var (
+	ErrInsufficientScope = errors.New("userinfo: insufficient scope")
+	ErrInvalidToken      = errors.New("userinfo: invalid token")
+)
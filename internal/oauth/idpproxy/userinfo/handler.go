@@ -0,0 +1,79 @@
+package userinfo
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/accesstoken"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+// Handler expects accesstoken.Middleware to have run first.
+type Handler struct {
+	Users  UserRepository
+	Logger *zap.Logger
+
+	// optional; GitHub membership claims released for the groups scope
+	GitHubClaims []string
+}
+
+func NewHandler(userRepo UserRepository, logger *zap.Logger) *Handler {
+	return &Handler{
+		Users:  userRepo,
+		Logger: logger,
+	}
+}
+
+func (h *Handler) Serve(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	claims, ok := accesstoken.ClaimsFromContext(c)
+	if !ok {
+		writeError(c, ErrInvalidToken)
+
+		return
+	}
+
+	if !slices.Contains(strings.Fields(claims.Scope), ScopeOpenID) {
+		writeError(c, ErrInsufficientScope)
+
+		return
+	}
+
+	u, err := h.Users.Get(c.Request.Context(), claims.Subject)
+	if errors.Is(err, users.ErrNotFound) {
+		h.Logger.Warn("userinfo subject not found", zap.String("sub", claims.Subject))
+		writeError(c, ErrInvalidToken)
+
+		return
+	}
+	if err != nil {
+		h.Logger.Error("failed to load user", zap.String("sub", claims.Subject), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+
+		return
+	}
+
+	out := BuildClaims(u, claims.Scope)
+	maps.Copy(out, u.GitHubClaims(claims.Scope, h.GitHubClaims))
+
+	c.JSON(http.StatusOK, out)
+}
+
+func writeError(c *gin.Context, err error) {
+	if errors.Is(err, ErrInsufficientScope) {
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient_scope"})
+
+		return
+	}
+
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+}
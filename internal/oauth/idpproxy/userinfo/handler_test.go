@@ -0,0 +1,144 @@
+package userinfo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/accesstoken"
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+type fakeVerifier struct {
+	claims *accesstoken.Claims
+}
+
+func (f *fakeVerifier) Verify(_ context.Context, token string) (*accesstoken.Claims, error) {
+	if token != "good-token" {
+		return nil, accesstoken.ErrInvalidToken
+	}
+	return f.claims, nil
+}
+
+type fakeUsers struct {
+	user *users.User
+	err  error
+}
+
+func (f *fakeUsers) Get(_ context.Context, id string) (*users.User, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.user == nil || f.user.ID != id {
+		return nil, users.ErrNotFound
+	}
+	return f.user, nil
+}
+
+func serve(t *testing.T, claims *accesstoken.Claims, repo UserRepository, authz string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET(Path, accesstoken.Middleware(&fakeVerifier{claims: claims}, zap.NewNop()), NewHandler(repo, zap.NewNop()).Serve)
+
+	req := httptest.NewRequest(http.MethodGet, Path, nil)
+	if authz != "" {
+		req.Header.Set("Authorization", authz)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestHandler_Serve(t *testing.T) {
+	t.Parallel()
+
+	user := &users.User{ID: "github:1", Login: "octocat", Email: "octo@example.com"}
+
+	t.Run("returns claims filtered by scope", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(t, &accesstoken.Claims{Subject: "github:1", ClientID: "client-1", Scope: "openid profile"}, &fakeUsers{user: user}, "Bearer good-token")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		require.JSONEq(t, `{"sub":"github:1","preferred_username":"octocat"}`, w.Body.String())
+	})
+
+	t.Run("adds enabled github claims for the groups scope", func(t *testing.T) {
+		t.Parallel()
+
+		member := &users.User{ID: "github:1", GitHubOrgs: []string{"acme"}, GitHubTeams: []string{"acme/admins"}}
+		h := NewHandler(&fakeUsers{user: member}, zap.NewNop())
+		h.GitHubClaims = []string{config.ClaimGroups, config.ClaimGitHubOrgs}
+
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		claims := &accesstoken.Claims{Subject: "github:1", Scope: "openid groups"}
+		r.GET(Path, accesstoken.Middleware(&fakeVerifier{claims: claims}, zap.NewNop()), h.Serve)
+
+		req := httptest.NewRequest(http.MethodGet, Path, nil)
+		req.Header.Set("Authorization", "Bearer good-token")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"sub":"github:1","groups":["acme","acme/admins"],"github_orgs":["acme"]}`, w.Body.String())
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(t, &accesstoken.Claims{Subject: "github:1", Scope: "openid"}, &fakeUsers{user: user}, "Bearer bad-token")
+
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Equal(t, `Bearer error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("token without openid scope", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(t, &accesstoken.Claims{Subject: "github:1", Scope: "email"}, &fakeUsers{user: user}, "Bearer good-token")
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Equal(t, `Bearer error="insufficient_scope"`, w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("unknown subject", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(t, &accesstoken.Claims{Subject: "github:2", Scope: "openid"}, &fakeUsers{user: user}, "Bearer good-token")
+
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("user store failure", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(t, &accesstoken.Claims{Subject: "github:1", Scope: "openid"}, &fakeUsers{err: errors.New("firestore down")}, "Bearer good-token")
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("without middleware claims", func(t *testing.T) {
+		t.Parallel()
+
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.GET(Path, NewHandler(&fakeUsers{user: user}, zap.NewNop()).Serve)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, Path, nil))
+
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,11 @@
+package userinfo
+
+import (
+	"context"
+
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+type UserRepository interface {
+	Get(ctx context.Context, id string) (*users.User, error)
+}
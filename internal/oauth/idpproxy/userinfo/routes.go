@@ -0,0 +1,23 @@
+package userinfo
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/accesstoken"
+	"github.com/vinylhousegarage/idpproxy/internal/deps"
+)
+
+const Path = "/userinfo"
+
+func RegisterRoutes(r gin.IRoutes, oidcDeps *deps.OIDCDependencies) {
+	verifier := accesstoken.NewVerifier(oidcDeps.Config.Issuer, oidcDeps.Signer)
+	verifier.Generations = oidcDeps.AccessGenerations
+	verifier.Denylist = oidcDeps.AccessDenylist
+
+	auth := accesstoken.Middleware(verifier, oidcDeps.Logger)
+	h := NewHandler(oidcDeps.Users, oidcDeps.Logger)
+	h.GitHubClaims = oidcDeps.Config.GitHubClaims
+
+	r.GET(Path, auth, h.Serve)
+	r.POST(Path, auth, h.Serve)
+}
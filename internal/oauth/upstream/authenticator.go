@@ -0,0 +1,71 @@
+package upstream
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Authenticator is the provider-independent part of an upstream login:
+// complete the upstream leg, link the identity to a local user and resume
+// the pending /authorize request.
+type Authenticator struct {
+	Provider Provider
+
+	// optional
+	Users          UserService
+	Authorizations AuthorizationCompleter
+	Links          LinkRequests
+}
+
+type Result struct {
+	Identity *Identity
+	UserID   string
+
+	// the identity was linked to the signed-in user rather than used to log in
+	Linked bool
+}
+
+func (a *Authenticator) Authenticate(w http.ResponseWriter, r *http.Request, t Transaction) (*Result, error) {
+	id, err := a.Provider.CompleteAuth(w, r, t)
+	if err != nil {
+		return nil, err
+	}
+
+	if id.Subject == "" {
+		return nil, ErrInvalidIdentity
+	}
+	id.Provider = a.Provider.ID()
+
+	if a.Users == nil {
+		return &Result{Identity: id, UserID: id.UserID()}, nil
+	}
+
+	if a.Links != nil {
+		userID, ok, err := a.Links.PendingLink(w, r, id.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrLink, err)
+		}
+		if ok {
+			if err := a.Users.LinkProfile(r.Context(), userID, id.Provider, id.Profile()); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrLink, err)
+			}
+			return &Result{Identity: id, UserID: userID, Linked: true}, nil
+		}
+	}
+
+	userID, err := a.Users.UpsertProfile(r.Context(), id.Provider, id.Profile())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLink, err)
+	}
+
+	return &Result{Identity: id, UserID: userID}, nil
+}
+
+// Resume reports ok=false when no authorization request is pending.
+func (a *Authenticator) Resume(w http.ResponseWriter, r *http.Request, res *Result) (string, bool, error) {
+	if a.Authorizations == nil {
+		return "", false, nil
+	}
+
+	return a.Authorizations.Complete(w, r, res)
+}
@@ -0,0 +1,151 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+type failingUsers struct{ err error }
+
+func (f failingUsers) UpsertProfile(context.Context, string, users.Profile) (string, error) {
+	return "", f.err
+}
+
+func (f failingUsers) LinkProfile(context.Context, string, string, users.Profile) error {
+	return f.err
+}
+
+type stubLinks struct {
+	userID string
+	ok     bool
+	err    error
+}
+
+func (s stubLinks) PendingLink(http.ResponseWriter, *http.Request, string) (string, bool, error) {
+	return s.userID, s.ok, s.err
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/cb", nil)
+
+	t.Run("stamps the provider id on the identity", func(t *testing.T) {
+		t.Parallel()
+
+		a := &Authenticator{Provider: &fakeProvider{identity: &Identity{Provider: "spoofed", Subject: "sub-1"}}}
+
+		res, err := a.Authenticate(httptest.NewRecorder(), req, Transaction{})
+		require.NoError(t, err)
+		require.Equal(t, "fake", res.Identity.Provider)
+		require.Equal(t, "fake:sub-1", res.UserID, "without a user service the identity id is used")
+		require.False(t, res.Linked)
+	})
+
+	t.Run("rejects identity without subject", func(t *testing.T) {
+		t.Parallel()
+
+		a := &Authenticator{Provider: &fakeProvider{identity: &Identity{}}}
+
+		_, err := a.Authenticate(httptest.NewRecorder(), req, Transaction{})
+		require.ErrorIs(t, err, ErrInvalidIdentity)
+	})
+
+	t.Run("wraps user service failure", func(t *testing.T) {
+		t.Parallel()
+
+		boom := errors.New("firestore down")
+		a := &Authenticator{
+			Provider: &fakeProvider{identity: &Identity{Subject: "sub-1"}},
+			Users:    failingUsers{err: boom},
+		}
+
+		_, err := a.Authenticate(httptest.NewRecorder(), req, Transaction{})
+		require.ErrorIs(t, err, ErrLink)
+		require.ErrorIs(t, err, boom)
+		require.Equal(t, ErrUserUpsert, AppError(err))
+	})
+}
+
+func TestAuthenticator_Authenticate_Link(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/cb", nil)
+	ctx := context.Background()
+
+	newAuth := func(t *testing.T, links LinkRequests) (*Authenticator, *users.Service, string) {
+		t.Helper()
+
+		svc := users.NewService(users.NewMemoryRepository())
+		userID, err := svc.UpsertProfile(ctx, "github", users.Profile{Subject: "42"})
+		require.NoError(t, err)
+
+		return &Authenticator{
+			Provider: &fakeProvider{identity: &Identity{Subject: "sub-1"}},
+			Users:    svc,
+			Links:    links,
+		}, svc, userID
+	}
+
+	t.Run("links the identity to the signed-in user", func(t *testing.T) {
+		t.Parallel()
+
+		a, svc, userID := newAuth(t, nil)
+		a.Links = stubLinks{userID: userID, ok: true}
+
+		res, err := a.Authenticate(httptest.NewRecorder(), req, Transaction{})
+		require.NoError(t, err)
+		require.True(t, res.Linked)
+		require.Equal(t, userID, res.UserID)
+
+		got, err := svc.UpsertProfile(ctx, "fake", users.Profile{Subject: "sub-1"})
+		require.NoError(t, err)
+		require.Equal(t, userID, got)
+	})
+
+	t.Run("logs in when no link is pending", func(t *testing.T) {
+		t.Parallel()
+
+		a, _, userID := newAuth(t, stubLinks{})
+
+		res, err := a.Authenticate(httptest.NewRecorder(), req, Transaction{})
+		require.NoError(t, err)
+		require.False(t, res.Linked)
+		require.NotEqual(t, userID, res.UserID)
+	})
+
+	t.Run("maps link failures", func(t *testing.T) {
+		t.Parallel()
+
+		a, _, _ := newAuth(t, stubLinks{err: ErrReauthenticationRequired})
+		_, err := a.Authenticate(httptest.NewRecorder(), req, Transaction{})
+		require.Equal(t, ErrReauthentication, AppError(err))
+
+		a, svc, userID := newAuth(t, nil)
+		_, err = svc.UpsertProfile(ctx, "fake", users.Profile{Subject: "sub-1"})
+		require.NoError(t, err)
+		a.Links = stubLinks{userID: userID, ok: true}
+
+		_, err = a.Authenticate(httptest.NewRecorder(), req, Transaction{})
+		require.ErrorIs(t, err, users.ErrIdentityLinked)
+		require.Equal(t, ErrIdentityConflict, AppError(err))
+	})
+}
+
+func TestAuthenticator_Resume_WithoutCompleter(t *testing.T) {
+	t.Parallel()
+
+	a := &Authenticator{Provider: &fakeProvider{}}
+
+	location, ok, err := a.Resume(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/cb", nil), &Result{UserID: "fake:sub-1"})
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Empty(t, location)
+}
@@ -0,0 +1,53 @@
+package upstream
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	transactionCookiePrefix = "upstream_txn_"
+	transactionCookieMaxAge = 600
+)
+
+func transactionCookieName(providerID string) string {
+	return transactionCookiePrefix + providerID
+}
+
+func buildTransactionCookie(providerID string, t Transaction) *http.Cookie {
+	return &http.Cookie{
+		Name:     transactionCookieName(providerID),
+		Value:    t.State + "." + t.Nonce + "." + t.Verifier,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   transactionCookieMaxAge,
+	}
+}
+
+func deleteTransactionCookie(providerID string) *http.Cookie {
+	return &http.Cookie{
+		Name:     transactionCookieName(providerID),
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+	}
+}
+
+func readTransactionCookie(r *http.Request, providerID string) (Transaction, bool) {
+	c, err := r.Cookie(transactionCookieName(providerID))
+	if err != nil {
+		return Transaction{}, false
+	}
+
+	parts := strings.Split(c.Value, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return Transaction{}, false
+	}
+
+	return Transaction{State: parts[0], Nonce: parts[1], Verifier: parts[2]}, true
+}
@@ -0,0 +1,59 @@
+package upstream
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/vinylhousegarage/idpproxy/internal/apperror"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+// Providers wrap their own errors with one of these so callers can map
+// failures without knowing the provider.
+var (
+	ErrDenied            = errors.New("upstream: login denied")
+	ErrInvalidResponse   = errors.New("upstream: invalid response")
+	ErrInvalidCredential = errors.New("upstream: invalid credential")
+	ErrUnavailable       = errors.New("upstream: provider unavailable")
+)
+
+var (
+	ErrInvalidIdentity = errors.New("upstream: identity has no subject")
+	ErrLink            = errors.New("upstream: user link failed")
+
+	// LinkRequests wrap this when the user must log in again before linking
+	ErrReauthenticationRequired = errors.New("upstream: reauthentication required")
+)
+
+// Handler
+var (
+	ErrInvalidAuthorizationRequest = apperror.New(http.StatusBadRequest, "invalid authorization request") // 400 Bad Request
+	ErrInvalidState                = apperror.New(http.StatusBadRequest, "invalid state")                 // 400 Bad Request
+	ErrInvalidCallback             = apperror.New(http.StatusBadRequest, "invalid callback")              // 400 Bad Request
+	ErrUpstreamDenied              = apperror.New(http.StatusUnauthorized, "upstream login failed")       // 401 Unauthorized
+	ErrUpstreamCredential          = apperror.New(http.StatusUnauthorized, "invalid upstream credential") // 401 Unauthorized
+	ErrReauthentication            = apperror.New(http.StatusUnauthorized, "reauthentication required")   // 401 Unauthorized
+	ErrIdentityConflict            = apperror.New(http.StatusConflict, "identity already linked")         // 409 Conflict
+	ErrUserUpsert                  = apperror.New(http.StatusInternalServerError, "user upsert failed")   // 500 Internal Server Error
+	ErrUpstreamUnavailable         = apperror.New(http.StatusBadGateway, "upstream provider unavailable") // 502 Bad Gateway
+)
+
+// AppError maps an Authenticate error to the response sent to the browser.
+func AppError(err error) *apperror.AppError {
+	switch {
+	case errors.Is(err, ErrDenied):
+		return ErrUpstreamDenied
+	case errors.Is(err, ErrInvalidResponse):
+		return ErrInvalidCallback
+	case errors.Is(err, ErrInvalidCredential), errors.Is(err, ErrInvalidIdentity):
+		return ErrUpstreamCredential
+	case errors.Is(err, ErrReauthenticationRequired):
+		return ErrReauthentication
+	case errors.Is(err, users.ErrIdentityLinked):
+		return ErrIdentityConflict
+	case errors.Is(err, ErrLink):
+		return ErrUserUpsert
+	default:
+		return ErrUpstreamUnavailable
+	}
+}
@@ -0,0 +1,100 @@
+package upstream
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/apperror"
+)
+
+const beginTimeout = 10 * time.Second
+
+// Handler serves the redirect-based login legs (login → provider →
+// callback) for any Provider. State, nonce and PKCE verifier live in one
+// short-lived cookie per provider.
+type Handler struct {
+	Auth   *Authenticator
+	Logger *zap.Logger
+}
+
+func NewHandler(auth *Authenticator, logger *zap.Logger) *Handler {
+	return &Handler{
+		Auth:   auth,
+		Logger: logger,
+	}
+}
+
+func (h *Handler) providerID() string {
+	return h.Auth.Provider.ID()
+}
+
+func (h *Handler) Login(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), beginTimeout)
+	defer cancel()
+
+	t := NewTransaction()
+	loginURL, err := h.Auth.Provider.BeginAuth(ctx, t)
+	if err != nil {
+		h.fail(c, ErrUpstreamUnavailable, err)
+
+		return
+	}
+
+	http.SetCookie(c.Writer, buildTransactionCookie(h.providerID(), t))
+
+	h.Logger.Info("redirecting to upstream login", zap.String("provider", h.providerID()))
+	c.Redirect(http.StatusFound, loginURL)
+}
+
+func (h *Handler) Callback(c *gin.Context) {
+	t, ok := readTransactionCookie(c.Request, h.providerID())
+	http.SetCookie(c.Writer, deleteTransactionCookie(h.providerID()))
+
+	qState := c.Query("state")
+	if !ok || qState == "" || subtle.ConstantTimeCompare([]byte(qState), []byte(t.State)) != 1 {
+		h.fail(c, ErrInvalidState, nil)
+
+		return
+	}
+
+	res, err := h.Auth.Authenticate(c.Writer, c.Request, t)
+	if err != nil {
+		h.fail(c, AppError(err), err)
+
+		return
+	}
+
+	location, ok, err := h.Auth.Resume(c.Writer, c.Request, res)
+	if err == nil && !ok && res.Linked {
+		c.JSON(http.StatusOK, LinkedBody(h.providerID()))
+
+		return
+	}
+	if err != nil || !ok {
+		h.fail(c, ErrInvalidAuthorizationRequest, err)
+
+		return
+	}
+
+	c.Redirect(http.StatusFound, location)
+}
+
+// LinkedBody answers an account link that finished outside an /authorize flow.
+func LinkedBody(provider string) gin.H {
+	return gin.H{"status": "linked", "provider": provider}
+}
+
+func (h *Handler) fail(c *gin.Context, appErr *apperror.AppError, cause error) {
+	h.Logger.Warn("upstream login failed",
+		zap.String("provider", h.providerID()),
+		zap.String("reason", appErr.Message),
+		zap.Error(cause),
+	)
+
+	c.AbortWithStatusJSON(appErr.StatusCode(), gin.H{"error": appErr.Message})
+}
@@ -0,0 +1,231 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+type fakeProvider struct {
+	beginErr    error
+	identity    *Identity
+	completeErr error
+	got         Transaction
+}
+
+func (p *fakeProvider) ID() string   { return "fake" }
+func (p *fakeProvider) Name() string { return "Fake" }
+
+func (p *fakeProvider) BeginAuth(_ context.Context, t Transaction) (string, error) {
+	if p.beginErr != nil {
+		return "", p.beginErr
+	}
+
+	return "https://idp.example.com/authorize?state=" + url.QueryEscape(t.State), nil
+}
+
+func (p *fakeProvider) CompleteAuth(_ http.ResponseWriter, _ *http.Request, t Transaction) (*Identity, error) {
+	p.got = t
+	if p.completeErr != nil {
+		return nil, p.completeErr
+	}
+
+	id := *p.identity
+	return &id, nil
+}
+
+type stubCompleter struct {
+	userID string
+	ok     bool
+	err    error
+}
+
+func (s *stubCompleter) Complete(_ http.ResponseWriter, _ *http.Request, res *Result) (string, bool, error) {
+	s.userID = res.UserID
+	return "https://app.example.com/cb?code=proxy-code", s.ok, s.err
+}
+
+func newTestHandler(p *fakeProvider, completer *stubCompleter) (*Handler, *users.MemoryRepository) {
+	repo := users.NewMemoryRepository()
+	auth := &Authenticator{
+		Provider:       p,
+		Users:          users.NewService(repo),
+		Authorizations: completer,
+	}
+
+	return NewHandler(auth, zap.NewNop()), repo
+}
+
+// login runs the login leg and returns the callback request the browser
+// would send once the provider redirects back.
+func login(t *testing.T, h *Handler) *http.Request {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/fake/login", nil)
+	h.Login(c)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+
+	var txn *http.Cookie
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name == "upstream_txn_fake" {
+			txn = ck
+		}
+	}
+	require.NotNil(t, txn)
+	require.True(t, txn.HttpOnly)
+	require.True(t, txn.Secure)
+
+	loc, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/fake/callback?"+url.Values{
+		"code":  {"upstream-code"},
+		"state": {loc.Query().Get("state")},
+	}.Encode(), nil)
+	req.AddCookie(txn)
+
+	return req
+}
+
+func serveCallback(h *Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	h.Callback(c)
+
+	return w
+}
+
+func TestHandler_Callback(t *testing.T) {
+	t.Parallel()
+
+	identity := &Identity{Subject: "sub-1", Username: "jane", Email: "jane@example.com", EmailVerified: true}
+
+	t.Run("links the identity and resumes the authorization", func(t *testing.T) {
+		t.Parallel()
+
+		p := &fakeProvider{identity: identity}
+		completer := &stubCompleter{ok: true}
+		h, repo := newTestHandler(p, completer)
+
+		w := serveCallback(h, login(t, h))
+		require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+		require.Equal(t, "https://app.example.com/cb?code=proxy-code", w.Header().Get("Location"))
+		require.NotEmpty(t, completer.userID)
+		require.NotEmpty(t, p.got.Nonce)
+		require.NotEmpty(t, p.got.Verifier)
+
+		u, err := repo.Get(context.Background(), completer.userID)
+		require.NoError(t, err)
+		require.Equal(t, "fake", u.Provider)
+		require.Equal(t, "jane", u.Login)
+		require.True(t, u.EmailVerified)
+	})
+
+	t.Run("rejects state mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		h, _ := newTestHandler(&fakeProvider{identity: identity}, &stubCompleter{ok: true})
+		req := login(t, h)
+
+		q := req.URL.Query()
+		q.Set("state", "forged")
+		req.URL.RawQuery = q.Encode()
+
+		w := serveCallback(h, req)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.JSONEq(t, `{"error":"invalid state"}`, w.Body.String())
+	})
+
+	t.Run("rejects callback without transaction cookie", func(t *testing.T) {
+		t.Parallel()
+
+		h, _ := newTestHandler(&fakeProvider{identity: identity}, &stubCompleter{ok: true})
+		req := login(t, h)
+		req.Header.Del("Cookie")
+
+		w := serveCallback(h, req)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	for _, tc := range []struct {
+		name   string
+		err    error
+		status int
+		body   string
+	}{
+		{"denied", ErrDenied, http.StatusUnauthorized, `{"error":"upstream login failed"}`},
+		{"invalid response", ErrInvalidResponse, http.StatusBadRequest, `{"error":"invalid callback"}`},
+		{"invalid credential", ErrInvalidCredential, http.StatusUnauthorized, `{"error":"invalid upstream credential"}`},
+		{"unavailable", ErrUnavailable, http.StatusBadGateway, `{"error":"upstream provider unavailable"}`},
+	} {
+		t.Run("maps "+tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h, _ := newTestHandler(&fakeProvider{completeErr: errors.Join(tc.err, errors.New("detail"))}, &stubCompleter{ok: true})
+
+			w := serveCallback(h, login(t, h))
+			require.Equal(t, tc.status, w.Code)
+			require.JSONEq(t, tc.body, w.Body.String())
+		})
+	}
+
+	t.Run("requires a pending authorization request", func(t *testing.T) {
+		t.Parallel()
+
+		h, _ := newTestHandler(&fakeProvider{identity: identity}, &stubCompleter{ok: false})
+
+		w := serveCallback(h, login(t, h))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.JSONEq(t, `{"error":"invalid authorization request"}`, w.Body.String())
+	})
+
+	t.Run("answers a finished account link", func(t *testing.T) {
+		t.Parallel()
+
+		h, repo := newTestHandler(&fakeProvider{identity: identity}, &stubCompleter{ok: false})
+		svc := users.NewService(repo)
+		userID, err := svc.UpsertProfile(context.Background(), "github", users.Profile{Subject: "42"})
+		require.NoError(t, err)
+		h.Auth.Links = stubLinks{userID: userID, ok: true}
+
+		w := serveCallback(h, login(t, h))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.JSONEq(t, `{"status":"linked","provider":"fake"}`, w.Body.String())
+	})
+
+	t.Run("completer failure", func(t *testing.T) {
+		t.Parallel()
+
+		h, _ := newTestHandler(&fakeProvider{identity: identity}, &stubCompleter{err: errors.New("boom")})
+
+		w := serveCallback(h, login(t, h))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestHandler_Login_ProviderUnavailable(t *testing.T) {
+	t.Parallel()
+
+	h, _ := newTestHandler(&fakeProvider{beginErr: ErrUnavailable}, &stubCompleter{ok: true})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/fake/login", nil)
+	h.Login(c)
+
+	require.Equal(t, http.StatusBadGateway, w.Code)
+	require.Empty(t, w.Header().Get("Location"))
+	require.Empty(t, w.Result().Cookies())
+}
@@ -0,0 +1,42 @@
+package upstream
+
+import "github.com/vinylhousegarage/idpproxy/internal/users"
+
+// Identity is what every upstream provider reduces a successful login to.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Username      string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+
+	// GitHub org logins and "<org>/<team-slug>" teams; nil when not fetched
+	GitHubOrgs  []string
+	GitHubTeams []string
+
+	// provider-specific claims as received, for claim mapping
+	Claims map[string]any
+
+	// raw upstream tokens, for callers that need to call the provider again; never persisted
+	AccessToken string
+	IDToken     string
+}
+
+func (i *Identity) UserID() string {
+	return users.UserID(i.Provider, i.Subject)
+}
+
+func (i *Identity) Profile() users.Profile {
+	return users.Profile{
+		Subject:       i.Subject,
+		Login:         i.Username,
+		Name:          i.Name,
+		Email:         i.Email,
+		EmailVerified: i.EmailVerified,
+		Picture:       i.Picture,
+		GitHubOrgs:    i.GitHubOrgs,
+		GitHubTeams:   i.GitHubTeams,
+	}
+}
@@ -0,0 +1,58 @@
+package oidc
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+)
+
+type Claims struct {
+	jwt.RegisteredClaims
+
+	Nonce             string `json:"nonce,omitempty"`
+	AuthorizedParty   string `json:"azp,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Name              string `json:"name,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     flag   `json:"email_verified,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+}
+
+func (c *Claims) Identity(raw map[string]any) *upstream.Identity {
+	return &upstream.Identity{
+		Subject:       c.Subject,
+		Username:      c.PreferredUsername,
+		Email:         c.Email,
+		EmailVerified: bool(c.EmailVerified),
+		Name:          c.Name,
+		Picture:       c.Picture,
+		Claims:        raw,
+	}
+}
+
+// flag accepts both JSON booleans and the "true"/"false" strings some providers emit.
+type flag bool
+
+func (f *flag) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		*f = flag(v)
+
+		return nil
+	}
+
+	var v bool
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*f = flag(v)
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package oidc
+
+import "errors"
+
+var (
+	ErrDiscovery      = errors.New("oidc: discovery failed")
+	ErrIssuerMismatch = errors.New("oidc: discovered issuer does not match configuration")
+	ErrJWKS           = errors.New("oidc: jwks fetch failed")
+	ErrUnknownKey     = errors.New("oidc: unknown signing key")
+	ErrTokenExchange  = errors.New("oidc: token exchange failed")
+	ErrMissingIDToken = errors.New("oidc: token response has no id_token")
+	ErrInvalidIDToken = errors.New("oidc: invalid id_token")
+	ErrNonceMismatch  = errors.New("oidc: nonce mismatch")
+	ErrMissingCode    = errors.New("oidc: callback has no code")
+)
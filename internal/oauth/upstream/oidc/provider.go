@@ -0,0 +1,333 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/httpclient"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+)
+
+const (
+	clockSkew       = time.Minute
+	jwksMinInterval = time.Minute
+	upstreamTimeout = 10 * time.Second
+)
+
+var supportedAlgs = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// Provider talks to one upstream OpenID Provider. Discovery metadata is
+// fetched once; the JWKS is refetched when an unknown kid shows up.
+type Provider struct {
+	Config     config.UpstreamOIDCConfig
+	HTTPClient httpclient.HTTPClient
+	Now        func() time.Time
+
+	mu            sync.Mutex
+	meta          *Metadata
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewProvider(cfg config.UpstreamOIDCConfig, client httpclient.HTTPClient) *Provider {
+	return &Provider{
+		Config:     cfg,
+		HTTPClient: client,
+		Now:        time.Now,
+	}
+}
+
+var _ upstream.Provider = (*Provider)(nil)
+
+func (p *Provider) ID() string {
+	return p.Config.ID
+}
+
+func (p *Provider) Name() string {
+	return p.Config.Name
+}
+
+func (p *Provider) BeginAuth(ctx context.Context, t upstream.Transaction) (string, error) {
+	loginURL, err := p.AuthCodeURL(ctx, t.State, t.Nonce, t.Verifier)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", upstream.ErrUnavailable, err)
+	}
+
+	return loginURL, nil
+}
+
+func (p *Provider) CompleteAuth(_ http.ResponseWriter, r *http.Request, t upstream.Transaction) (*upstream.Identity, error) {
+	if e := r.URL.Query().Get("error"); e != "" {
+		return nil, fmt.Errorf("%w: %s", upstream.ErrDenied, e)
+	}
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		return nil, fmt.Errorf("%w: %w", upstream.ErrInvalidResponse, ErrMissingCode)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), upstreamTimeout)
+	defer cancel()
+
+	tokens, err := p.Exchange(ctx, code, t.Verifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", upstream.ErrUnavailable, err)
+	}
+
+	claims, err := p.VerifyIDToken(ctx, tokens.IDToken, t.Nonce)
+	switch {
+	case errors.Is(err, ErrDiscovery), errors.Is(err, ErrJWKS):
+		return nil, fmt.Errorf("%w: %w", upstream.ErrUnavailable, err)
+	case err != nil:
+		return nil, fmt.Errorf("%w: %w", upstream.ErrInvalidCredential, err)
+	}
+
+	rawClaims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokens.IDToken, rawClaims); err != nil {
+		return nil, fmt.Errorf("%w: %w", upstream.ErrInvalidCredential, err)
+	}
+
+	id := claims.Identity(rawClaims)
+	id.AccessToken = tokens.AccessToken
+	id.IDToken = tokens.IDToken
+
+	return id, nil
+}
+
+func (p *Provider) Metadata(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var m Metadata
+	if err := fetchJSON(ctx, p.HTTPClient, discoveryURL(p.Config.Issuer), &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	if m.Issuer != p.Config.Issuer {
+		return nil, ErrIssuerMismatch
+	}
+
+	if m.AuthorizationEndpoint == "" || m.TokenEndpoint == "" || m.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete metadata", ErrDiscovery)
+	}
+
+	p.meta = &m
+
+	return p.meta, nil
+}
+
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	m, err := p.Metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(m.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.Config.ClientID)
+	q.Set("redirect_uri", p.Config.RedirectURI)
+	q.Set("scope", strings.Join(p.Config.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", pkce.S256Challenge(verifier))
+	q.Set("code_challenge_method", pkce.MethodS256)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+type Tokens struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+}
+
+// Exchange redeems the authorization code. The ID token is not verified yet.
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (*Tokens, error) {
+	m, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.Config.RedirectURI)
+	form.Set("code_verifier", verifier)
+
+	postAuth := p.Config.ClientSecret == "" ||
+		(len(m.TokenEndpointAuthMethodsSupported) > 0 &&
+			!slices.Contains(m.TokenEndpointAuthMethodsSupported, "client_secret_basic") &&
+			slices.Contains(m.TokenEndpointAuthMethodsSupported, "client_secret_post"))
+	if postAuth {
+		form.Set("client_id", p.Config.ClientID)
+		if p.Config.ClientSecret != "" {
+			form.Set("client_secret", p.Config.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !postAuth {
+		req.SetBasicAuth(url.QueryEscape(p.Config.ClientID), url.QueryEscape(p.Config.ClientSecret))
+	}
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: unexpected status %d", ErrTokenExchange, resp.StatusCode)
+	}
+
+	var tokens Tokens
+	if err := httpclient.DecodeJSON(resp, &tokens); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+
+	if tokens.IDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+
+	return &tokens, nil
+}
+
+// VerifyIDToken checks the signature against the provider's JWKS and
+// validates iss, aud, azp, exp, iat and nonce.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*Claims, error) {
+	m, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	algs := supportedAlgs
+	if len(m.IDTokenSigningAlgValuesSupported) > 0 {
+		algs = slices.DeleteFunc(slices.Clone(m.IDTokenSigningAlgValuesSupported), func(alg string) bool {
+			return !slices.Contains(supportedAlgs, alg)
+		})
+	}
+
+	var claims Claims
+	_, err = jwt.ParseWithClaims(raw, &claims,
+		func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			return p.key(ctx, kid)
+		},
+		jwt.WithValidMethods(algs),
+		jwt.WithIssuer(m.Issuer),
+		jwt.WithAudience(p.Config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+		jwt.WithTimeFunc(p.Now),
+	)
+	if err != nil {
+		if errors.Is(err, ErrJWKS) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.Config.ClientID {
+		return nil, fmt.Errorf("%w: azp does not match client_id", ErrInvalidIDToken)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, ErrNonceMismatch
+	}
+
+	return &claims, nil
+}
+
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if k, ok := lookupKey(p.keys, kid); ok {
+		return k, nil
+	}
+
+	if p.keys != nil && p.Now().Sub(p.keysFetchedAt) < jwksMinInterval {
+		return nil, ErrUnknownKey
+	}
+
+	var set signer.JWKS
+	if err := fetchJSON(ctx, p.HTTPClient, p.meta.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJWKS, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		pub, err := signer.ParsePublicJWK(jwk)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = pub
+	}
+
+	p.keys = keys
+	p.keysFetchedAt = p.Now()
+
+	if k, ok := lookupKey(p.keys, kid); ok {
+		return k, nil
+	}
+
+	return nil, ErrUnknownKey
+}
+
+func lookupKey(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if k, ok := keys[kid]; ok {
+		return k, true
+	}
+
+	// a token without kid is acceptable only when the set is unambiguous
+	if kid == "" && len(keys) == 1 {
+		for _, k := range keys {
+			return k, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,259 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+const testVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+func newTestProvider(t *testing.T, srv *testhelpers.FakeOIDCServer) *Provider {
+	t.Helper()
+
+	return NewProvider(config.UpstreamOIDCConfig{
+		ID:           "fake",
+		Name:         "Fake",
+		Issuer:       srv.Issuer(),
+		ClientID:     srv.ClientID,
+		ClientSecret: srv.ClientSecret,
+		RedirectURI:  "https://idpproxy.example.com/oidc/fake/callback",
+		Scopes:       []string{"openid", "email"},
+	}, srv.Client())
+}
+
+// authorizeCode drives the fake provider's /authorize endpoint and returns the issued code.
+func authorizeCode(t *testing.T, p *Provider, srv *testhelpers.FakeOIDCServer, nonce string) string {
+	t.Helper()
+
+	loginURL, err := p.AuthCodeURL(context.Background(), "st", nonce, testVerifier)
+	require.NoError(t, err)
+
+	client := *srv.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	resp, err := client.Get(loginURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "st", loc.Query().Get("state"))
+
+	return loc.Query().Get("code")
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	t.Parallel()
+
+	srv := testhelpers.NewFakeOIDCServer(t, "cid", "secret")
+	p := newTestProvider(t, srv)
+
+	loginURL, err := p.AuthCodeURL(context.Background(), "st", "n-1", testVerifier)
+	require.NoError(t, err)
+
+	u, err := url.Parse(loginURL)
+	require.NoError(t, err)
+	require.Equal(t, srv.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+
+	q := u.Query()
+	require.Equal(t, "code", q.Get("response_type"))
+	require.Equal(t, "cid", q.Get("client_id"))
+	require.Equal(t, "openid email", q.Get("scope"))
+	require.Equal(t, "st", q.Get("state"))
+	require.Equal(t, "n-1", q.Get("nonce"))
+	require.Equal(t, pkce.S256Challenge(testVerifier), q.Get("code_challenge"))
+	require.Equal(t, pkce.MethodS256, q.Get("code_challenge_method"))
+}
+
+func TestProvider_Metadata(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rejects issuer mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		srv := testhelpers.NewFakeOIDCServer(t, "cid", "secret")
+		p := newTestProvider(t, srv)
+		p.Config.Issuer = srv.URL + "/other"
+
+		_, err := p.Metadata(context.Background())
+		require.ErrorIs(t, err, ErrDiscovery)
+	})
+
+	t.Run("unreachable provider", func(t *testing.T) {
+		t.Parallel()
+
+		srv := testhelpers.NewFakeOIDCServer(t, "cid", "secret")
+		p := newTestProvider(t, srv)
+		srv.Close()
+
+		_, err := p.Metadata(context.Background())
+		require.ErrorIs(t, err, ErrDiscovery)
+	})
+}
+
+func TestProvider_ExchangeAndVerify(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid id_token", func(t *testing.T) {
+		t.Parallel()
+
+		srv := testhelpers.NewFakeOIDCServer(t, "cid", "secret")
+		p := newTestProvider(t, srv)
+		ctx := context.Background()
+
+		tokens, err := p.Exchange(ctx, authorizeCode(t, p, srv, "n-1"), testVerifier)
+		require.NoError(t, err)
+
+		claims, err := p.VerifyIDToken(ctx, tokens.IDToken, "n-1")
+		require.NoError(t, err)
+		require.Equal(t, "upstream-user-1", claims.Subject)
+		require.Equal(t, "jane@example.com", claims.Email)
+		require.True(t, bool(claims.EmailVerified))
+	})
+
+	t.Run("wrong verifier is rejected upstream", func(t *testing.T) {
+		t.Parallel()
+
+		srv := testhelpers.NewFakeOIDCServer(t, "cid", "secret")
+		p := newTestProvider(t, srv)
+
+		_, err := p.Exchange(context.Background(), authorizeCode(t, p, srv, "n-1"), testVerifier+"x")
+		require.ErrorIs(t, err, ErrTokenExchange)
+	})
+
+	t.Run("wrong client secret", func(t *testing.T) {
+		t.Parallel()
+
+		srv := testhelpers.NewFakeOIDCServer(t, "cid", "secret")
+		p := newTestProvider(t, srv)
+		p.Config.ClientSecret = "wrong"
+
+		_, err := p.Exchange(context.Background(), authorizeCode(t, p, srv, "n-1"), testVerifier)
+		require.ErrorIs(t, err, ErrTokenExchange)
+	})
+
+	for _, tc := range []struct {
+		name  string
+		hook  func(jwt.MapClaims)
+		nonce string
+		want  error
+	}{
+		{"nonce mismatch", nil, "other", ErrNonceMismatch},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "someone-else" }, "n-1", ErrInvalidIDToken},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, "n-1", ErrInvalidIDToken},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, "n-1", ErrInvalidIDToken},
+		{"missing sub", func(c jwt.MapClaims) { delete(c, "sub") }, "n-1", ErrInvalidIDToken},
+		{"azp mismatch", func(c jwt.MapClaims) { c["aud"] = []string{"cid", "other"} }, "n-1", ErrInvalidIDToken},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := testhelpers.NewFakeOIDCServer(t, "cid", "secret")
+			srv.ClaimsHook = tc.hook
+			p := newTestProvider(t, srv)
+			ctx := context.Background()
+
+			tokens, err := p.Exchange(ctx, authorizeCode(t, p, srv, "n-1"), testVerifier)
+			require.NoError(t, err)
+
+			_, err = p.VerifyIDToken(ctx, tokens.IDToken, tc.nonce)
+			require.ErrorIs(t, err, tc.want)
+		})
+	}
+
+	t.Run("refetches jwks on key rotation", func(t *testing.T) {
+		t.Parallel()
+
+		srv := testhelpers.NewFakeOIDCServer(t, "cid", "secret")
+		p := newTestProvider(t, srv)
+		ctx := context.Background()
+
+		now := time.Now()
+		p.Now = func() time.Time { return now }
+
+		tokens, err := p.Exchange(ctx, authorizeCode(t, p, srv, "n-1"), testVerifier)
+		require.NoError(t, err)
+		_, err = p.VerifyIDToken(ctx, tokens.IDToken, "n-1")
+		require.NoError(t, err)
+
+		srv.KeyID = "fake-2"
+		tokens, err = p.Exchange(ctx, authorizeCode(t, p, srv, "n-2"), testVerifier)
+		require.NoError(t, err)
+
+		_, err = p.VerifyIDToken(ctx, tokens.IDToken, "n-2")
+		require.ErrorIs(t, err, ErrInvalidIDToken, "refetch is rate limited")
+
+		now = now.Add(jwksMinInterval)
+		_, err = p.VerifyIDToken(ctx, tokens.IDToken, "n-2")
+		require.NoError(t, err)
+	})
+}
+
+func TestProvider_CompleteAuth(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns the normalized identity", func(t *testing.T) {
+		t.Parallel()
+
+		srv := testhelpers.NewFakeOIDCServer(t, "cid", "secret")
+		srv.ClaimsHook = func(c jwt.MapClaims) { c["groups"] = []string{"admins"} }
+		p := newTestProvider(t, srv)
+
+		req := httptest.NewRequest(http.MethodGet, "/cb?code="+authorizeCode(t, p, srv, "n-1"), nil)
+		id, err := p.CompleteAuth(httptest.NewRecorder(), req, upstream.Transaction{State: "st", Nonce: "n-1", Verifier: testVerifier})
+		require.NoError(t, err)
+		require.Equal(t, "upstream-user-1", id.Subject)
+		require.Equal(t, "jane", id.Username)
+		require.Equal(t, "Jane Doe", id.Name)
+		require.True(t, id.EmailVerified)
+		require.Equal(t, []any{"admins"}, id.Claims["groups"])
+		require.Equal(t, "upstream-access-token", id.AccessToken)
+		require.NotEmpty(t, id.IDToken)
+	})
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  error
+	}{
+		{"upstream error", "?error=access_denied", upstream.ErrDenied},
+		{"missing code", "", upstream.ErrInvalidResponse},
+		{"unknown code", "?code=bogus", upstream.ErrUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := testhelpers.NewFakeOIDCServer(t, "cid", "secret")
+			p := newTestProvider(t, srv)
+
+			req := httptest.NewRequest(http.MethodGet, "/cb"+tc.query, nil)
+			_, err := p.CompleteAuth(httptest.NewRecorder(), req, upstream.Transaction{Nonce: "n-1", Verifier: testVerifier})
+			require.ErrorIs(t, err, tc.want)
+		})
+	}
+
+	t.Run("nonce mismatch is an invalid credential", func(t *testing.T) {
+		t.Parallel()
+
+		srv := testhelpers.NewFakeOIDCServer(t, "cid", "secret")
+		p := newTestProvider(t, srv)
+
+		req := httptest.NewRequest(http.MethodGet, "/cb?code="+authorizeCode(t, p, srv, "n-1"), nil)
+		_, err := p.CompleteAuth(httptest.NewRecorder(), req, upstream.Transaction{Nonce: "other", Verifier: testVerifier})
+		require.ErrorIs(t, err, upstream.ErrInvalidCredential)
+		require.ErrorIs(t, err, ErrNonceMismatch)
+	})
+}
@@ -0,0 +1,11 @@
+package oidc
+
+const pathPrefix = "/oidc/"
+
+func LoginPath(providerID string) string {
+	return pathPrefix + providerID + "/login"
+}
+
+func CallbackPath(providerID string) string {
+	return pathPrefix + providerID + "/callback"
+}
@@ -0,0 +1,23 @@
+package upstream
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+)
+
+type AuthorizationCompleter interface {
+	Complete(w http.ResponseWriter, r *http.Request, res *Result) (string, bool, error)
+}
+
+type UserService interface {
+	UpsertProfile(ctx context.Context, provider string, p users.Profile) (string, error)
+	LinkProfile(ctx context.Context, userID, provider string, p users.Profile) error
+}
+
+// LinkRequests reports whether the signed-in user asked to link the
+// provider's identity to their account, returning that user.
+type LinkRequests interface {
+	PendingLink(w http.ResponseWriter, r *http.Request, provider string) (string, bool, error)
+}
@@ -0,0 +1,47 @@
+package upstream
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+)
+
+// 32 bytes gives a 43 character PKCE verifier, the RFC 7636 minimum
+const verifierBytes = 32
+
+// Provider is one upstream identity provider. BeginAuth returns where to
+// send the browser; CompleteAuth turns the provider's response into an
+// Identity. Providers that do not use a field of the Transaction ignore it.
+type Provider interface {
+	ID() string
+	Name() string
+	BeginAuth(ctx context.Context, t Transaction) (string, error)
+	CompleteAuth(w http.ResponseWriter, r *http.Request, t Transaction) (*Identity, error)
+}
+
+// Transaction carries the per-login secrets bound to the browser.
+type Transaction struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+func NewTransaction() Transaction {
+	return Transaction{
+		State:    randomString(config.OAuthStateBytes),
+		Nonce:    randomString(config.OAuthStateBytes),
+		Verifier: randomString(verifierBytes),
+	}
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("failed to generate secure random value: " + err.Error())
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,10 @@
+package upstream
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r gin.IRoutes, h *Handler, loginPath, callbackPath string) {
+	r.GET(loginPath, h.Login)
+	r.GET(callbackPath, h.Callback)
+}
@@ -15,6 +15,9 @@ type RouterDeps struct {
 	Google      *deps.GoogleDependencies
 	Logger      *zap.Logger
 	System      *deps.SystemDependencies
+
+	// optional
+	OIDC *deps.OIDCDependencies
 }
 
 func NewRouterDeps(
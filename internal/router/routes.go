@@ -2,15 +2,32 @@ package router
 
 import (
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/deps"
+	"github.com/vinylhousegarage/idpproxy/internal/loginpolicy"
 	"github.com/vinylhousegarage/idpproxy/internal/oauth/github/apierror"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/github/callback"
 	"github.com/vinylhousegarage/idpproxy/internal/oauth/github/login"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/github/membership"
 	"github.com/vinylhousegarage/idpproxy/internal/oauth/github/user"
 	"github.com/vinylhousegarage/idpproxy/internal/oauth/google/loginfirebase"
 	"github.com/vinylhousegarage/idpproxy/internal/oauth/google/me"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/idpproxy/accountlink"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/idpproxy/authorize"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/idpproxy/discovery"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/idpproxy/endsession"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/idpproxy/jwks"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/idpproxy/token"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/idpproxy/userinfo"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/upstream"
+	upstreamoidc "github.com/vinylhousegarage/idpproxy/internal/oauth/upstream/oidc"
 	"github.com/vinylhousegarage/idpproxy/internal/system/health"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
 )
 
 func RegisterRoutes(r *gin.Engine, d RouterDeps) {
@@ -26,14 +43,188 @@ func RegisterRoutes(r *gin.Engine, d RouterDeps) {
 		r.GET("/terms", func(c *gin.Context) { c.FileFromFS("terms.html", http.FS(d.FS)) })
 	}
 
+	// shared by every upstream leg so they resume and link through the same instances
+	completer := authorizationCompleter(d)
+	linkHandler := accountLinkHandler(d)
+	links := linkRequests(linkHandler)
+	var userSvc *users.Service
+	if d.OIDC != nil && d.OIDC.Users != nil {
+		userSvc = userService(d)
+	}
+
 	// GitHub
 	login.RegisterRoutes(r, d.GitHubOAuth)
 	user.RegisterRoutes(r, d.GitHubAPI)
+	if completer != nil && userSvc != nil {
+		h := callback.NewGitHubCallbackHandler(d.GitHubOAuth, d.GitHubAPI, userSvc, completer)
+		h.Links = links
+		callback.RegisterRoutes(r, h)
+	}
 
 	// Google
-	loginfirebase.RegisterRoutes(r, d.Google)
+	var googleAuthorizations loginfirebase.AuthorizationCompleter
+	if completer != nil {
+		googleAuthorizations = completer
+	}
+	var googleUsers loginfirebase.UserService
+	if userSvc != nil {
+		googleUsers = userSvc
+	}
+	loginfirebase.RegisterRoutes(r, d.Google, googleAuthorizations, googleUsers, links)
 	me.RegisterRoutes(r, d.Google)
 
 	// System
 	health.RegisterRoutes(r, d.System)
+
+	// OIDC
+	meta := discovery.Metadata{
+		Scopes: discovery.DefaultScopes,
+		Claims: discovery.DefaultClaims,
+	}
+
+	if d.OIDC != nil {
+		if d.OIDC.Signer != nil {
+			meta.SigningAlgs = []string{d.OIDC.Signer.Alg()}
+			if ring, ok := d.OIDC.Signer.(interface{ Algs() []string }); ok {
+				meta.SigningAlgs = ring.Algs()
+			}
+
+			if src, ok := d.OIDC.Signer.(signer.PublicKeySource); ok {
+				jwks.RegisterRoutes(r, src, d.OIDC.Logger)
+				meta.JWKSPath = jwks.Path
+			}
+		}
+
+		if d.OIDC.Signer != nil && d.OIDC.Users != nil {
+			userinfo.RegisterRoutes(r, d.OIDC)
+			meta.UserInfoPath = userinfo.Path
+			meta.Scopes = slices.Concat(meta.Scopes, []string{userinfo.ScopeProfile, userinfo.ScopeEmail})
+			meta.Claims = slices.Concat(meta.Claims, userinfo.SupportedClaims)
+
+			if githubClaims := d.OIDC.Config.GitHubClaims; len(githubClaims) > 0 {
+				meta.Scopes = append(meta.Scopes, users.ScopeGroups)
+				meta.Claims = slices.Concat(meta.Claims, githubClaims)
+			}
+		}
+
+		if d.OIDC.Signer != nil && d.OIDC.Clients != nil {
+			endsession.RegisterRoutes(r, d.OIDC)
+			meta.EndSessionPath = endsession.Path
+			meta.BackchannelLogout = d.OIDC.Sessions != nil
+		}
+
+		if tokenEnabled(d) {
+			token.RegisterRoutes(r, d.OIDC)
+			meta.TokenPath = token.Path
+			meta.RevocationPath = token.RevokePath
+			meta.IntrospectionPath = token.IntrospectPath
+			meta.GrantTypes = append(meta.GrantTypes, "authorization_code", "refresh_token")
+			meta.CodeChallengeMethods = []string{pkce.MethodS256, pkce.MethodPlain}
+			meta.TokenAuthMethods = []string{"client_secret_basic", "client_secret_post", "none"}
+
+			if authorizationEnabled(d) {
+				if linkHandler != nil {
+					accountlink.RegisterRoutes(r, linkHandler)
+				}
+				for _, h := range upstreamOIDCHandlers(d, userSvc, completer, links) {
+					id := h.Auth.Provider.ID()
+					upstream.RegisterRoutes(r, h, upstreamoidc.LoginPath(id), upstreamoidc.CallbackPath(id))
+				}
+				authorize.RegisterRoutes(r, d.OIDC, upstreamProviders(d.OIDC))
+				meta.AuthorizationPath = authorize.Path
+				meta.ResponseTypes = []string{"code"}
+			}
+		}
+
+		discovery.RegisterRoutes(r, d.OIDC, meta)
+	}
+}
+
+func tokenEnabled(d RouterDeps) bool {
+	return d.OIDC != nil &&
+		d.OIDC.Signer != nil && d.OIDC.ProxyCodes != nil && d.OIDC.RefreshTokens != nil &&
+		d.OIDC.Clients != nil
+}
+
+func authorizationEnabled(d RouterDeps) bool {
+	return tokenEnabled(d) && d.OIDC.Authorizations != nil
+}
+
+func authorizationCompleter(d RouterDeps) *authorize.Completer {
+	if !authorizationEnabled(d) {
+		return nil
+	}
+
+	c := authorize.NewCompleterFromDeps(d.OIDC)
+	c.Policy = loginpolicy.NewEngine(membership.NewClient(d.GitHubAPI.HTTPClient))
+
+	return c
+}
+
+func userService(d RouterDeps) *users.Service {
+	svc := users.NewService(d.OIDC.Users)
+	svc.Policy.AutoLinkProviders = d.OIDC.Config.AutoLinkProviders
+	if d.OIDC.RefreshTokens != nil {
+		svc.RefreshTokens = d.OIDC.RefreshTokens
+	}
+
+	return svc
+}
+
+func accountLinkHandler(d RouterDeps) *accountlink.Handler {
+	if !authorizationEnabled(d) || d.OIDC.Sessions == nil || d.OIDC.Users == nil {
+		return nil
+	}
+
+	return accountlink.NewHandlerFromDeps(d.OIDC, upstreamProviders(d.OIDC))
+}
+
+// linkRequests keeps the interface nil when account linking is disabled.
+func linkRequests(h *accountlink.Handler) upstream.LinkRequests {
+	if h != nil {
+		return h
+	}
+
+	return nil
+}
+
+func upstreamOIDCEnabled(oidcDeps *deps.OIDCDependencies) bool {
+	return oidcDeps.HTTPClient != nil && oidcDeps.Users != nil
+}
+
+func upstreamOIDCHandlers(d RouterDeps, userSvc *users.Service, completer *authorize.Completer, links upstream.LinkRequests) []*upstream.Handler {
+	if !upstreamOIDCEnabled(d.OIDC) {
+		return nil
+	}
+
+	handlers := make([]*upstream.Handler, 0, len(d.OIDC.Config.Upstreams))
+	for _, cfg := range d.OIDC.Config.Upstreams {
+		auth := &upstream.Authenticator{
+			Provider:       upstreamoidc.NewProvider(cfg, d.OIDC.HTTPClient),
+			Users:          userSvc,
+			Authorizations: completer,
+			Links:          links,
+		}
+		handlers = append(handlers, upstream.NewHandler(auth, d.OIDC.Logger))
+	}
+
+	return handlers
+}
+
+func upstreamProviders(oidcDeps *deps.OIDCDependencies) []authorize.Provider {
+	providers := []authorize.Provider{
+		{ID: "github", Name: "GitHub", LoginPath: login.Path},
+	}
+
+	if oidcDeps.Config.GoogleLoginURL != "" {
+		providers = append(providers, authorize.Provider{ID: "google", Name: "Google", LoginPath: oidcDeps.Config.GoogleLoginURL})
+	}
+
+	if upstreamOIDCEnabled(oidcDeps) {
+		for _, cfg := range oidcDeps.Config.Upstreams {
+			providers = append(providers, authorize.Provider{ID: cfg.ID, Name: cfg.Name, LoginPath: upstreamoidc.LoginPath(cfg.ID)})
+		}
+	}
+
+	return providers
 }
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 
+	"github.com/vinylhousegarage/idpproxy/internal/config"
 	"github.com/vinylhousegarage/idpproxy/internal/deps"
 )
 
@@ -43,3 +45,65 @@ func TestRouter_ErrorLoggerMiddlewareIsApplied(t *testing.T) {
 		t.Fatal("error log was not recorded")
 	}
 }
+
+func TestRouter_DiscoveryIsMountedOnlyWithOIDCDeps(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	newDeps := func() RouterDeps {
+		return RouterDeps{
+			GitHubAPI:   &deps.GitHubAPIDependencies{},
+			GitHubOAuth: &deps.GitHubOAuthDependencies{},
+			Google:      &deps.GoogleDependencies{},
+			Logger:      zap.NewNop(),
+			System:      &deps.SystemDependencies{},
+		}
+	}
+
+	t.Run("without OIDC deps", func(t *testing.T) {
+		t.Parallel()
+
+		r := gin.New()
+		RegisterRoutes(r, newDeps())
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("expected 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("with OIDC deps", func(t *testing.T) {
+		t.Parallel()
+
+		d := newDeps()
+		d.OIDC = deps.NewOIDCDeps(&config.OIDCConfig{Issuer: "https://idpproxy.com"}, zap.NewNop())
+
+		r := gin.New()
+		RegisterRoutes(r, d)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+
+		var doc map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+
+		if doc["issuer"] != "https://idpproxy.com" {
+			t.Fatalf("unexpected issuer: %v", doc["issuer"])
+		}
+
+		if _, ok := doc["token_endpoint"]; ok {
+			t.Fatalf("token_endpoint must not be advertised when /token is not mounted")
+		}
+	})
+}
@@ -1,6 +1,13 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/vinylhousegarage/idpproxy/internal/config"
 
 	"github.com/gin-gonic/gin"
@@ -8,12 +15,41 @@ import (
 	"go.uber.org/zap"
 )
 
-func StartServer(r *gin.Engine, logger *zap.Logger) {
+const shutdownTimeout = 30 * time.Second
+
+// StartServer serves until SIGINT or SIGTERM, then stops accepting requests,
+// waits for in-flight ones and runs onShutdown in order, e.g. to drain
+// background queues fed by those requests.
+func StartServer(r *gin.Engine, logger *zap.Logger, onShutdown ...func()) {
 	port := config.GetPort()
 
 	logger.Info("Starting server", zap.String("port", port))
 
-	if err := r.Run(":" + port); err != nil {
-		logger.Fatal("Server failed", zap.Error(err))
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("Server failed", zap.Error(err))
+		}
+	case <-ctx.Done():
+		logger.Info("Shutting down server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Server shutdown failed", zap.Error(err))
+		}
+	}
+
+	for _, fn := range onShutdown {
+		fn()
 	}
 }
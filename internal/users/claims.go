@@ -0,0 +1,32 @@
+package users
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+)
+
+const ScopeGroups = "groups"
+
+// GitHubClaims returns the enabled GitHub membership claims when scope
+// includes the groups scope. config.ClaimGroups lists orgs followed by
+// "<org>/<team-slug>" teams; config.ClaimGitHubOrgs lists orgs only.
+func (u *User) GitHubClaims(scope string, enabled []string) map[string]any {
+	claims := map[string]any{}
+	if !slices.Contains(strings.Fields(scope), ScopeGroups) {
+		return claims
+	}
+
+	if slices.Contains(enabled, config.ClaimGroups) {
+		if groups := slices.Concat(u.GitHubOrgs, u.GitHubTeams); len(groups) > 0 {
+			claims[config.ClaimGroups] = groups
+		}
+	}
+
+	if slices.Contains(enabled, config.ClaimGitHubOrgs) && len(u.GitHubOrgs) > 0 {
+		claims[config.ClaimGitHubOrgs] = slices.Clone(u.GitHubOrgs)
+	}
+
+	return claims
+}
@@ -0,0 +1,11 @@
+package users
+
+import "errors"
+
+var (
+	ErrNotFound      = errors.New("user not found")
+	ErrInvalidUserID = errors.New("invalid user id")
+
+	ErrIdentityNotFound = errors.New("linked identity not found")
+	ErrIdentityLinked   = errors.New("identity already linked to a user")
+)
@@ -0,0 +1,213 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	colUsers      = "users"
+	colIdentities = "user_identities"
+)
+
+type FirestoreRepository struct {
+	fs  *firestore.Client
+	now func() time.Time
+}
+
+func NewFirestoreRepository(fs *firestore.Client) *FirestoreRepository {
+	return &FirestoreRepository{fs: fs, now: time.Now}
+}
+
+func (r *FirestoreRepository) doc(id string) *firestore.DocumentRef {
+	return r.fs.Collection(colUsers).Doc(id)
+}
+
+func (r *FirestoreRepository) Get(ctx context.Context, id string) (*User, error) {
+	if id == "" || strings.Contains(id, "/") {
+		return nil, ErrNotFound
+	}
+
+	snap, err := r.doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var u User
+	if err := snap.DataTo(&u); err != nil {
+		return nil, err
+	}
+
+	u.ID = snap.Ref.ID
+	return &u, nil
+}
+
+func (r *FirestoreRepository) Upsert(ctx context.Context, u *User) error {
+	if u.ID == "" || strings.Contains(u.ID, "/") {
+		return ErrInvalidUserID
+	}
+
+	ref := r.doc(u.ID)
+
+	return r.fs.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		now := r.now()
+
+		snap, err := tx.Get(ref)
+		switch {
+		case status.Code(err) == codes.NotFound:
+			u.CreatedAt = now
+		case err != nil:
+			return err
+		default:
+			var existing User
+			if err := snap.DataTo(&existing); err != nil {
+				return err
+			}
+			existing.ID = u.ID
+			existing.mergeLogin(u)
+			*u = existing
+		}
+
+		u.UpdatedAt = now
+		u.LastLoginAt = now
+
+		return tx.Set(ref, u)
+	})
+}
+
+func (r *FirestoreRepository) FindByVerifiedEmail(ctx context.Context, email string) (*User, error) {
+	if email == "" {
+		return nil, ErrNotFound
+	}
+
+	iter := r.fs.Collection(colUsers).
+		Where("email", "==", email).
+		Where("email_verified", "==", true).
+		Limit(2).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var found *User
+	for {
+		snap, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if found != nil {
+			return nil, ErrNotFound
+		}
+
+		var u User
+		if err := snap.DataTo(&u); err != nil {
+			return nil, err
+		}
+		u.ID = snap.Ref.ID
+		found = &u
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+
+	return found, nil
+}
+
+func (r *FirestoreRepository) identityDoc(provider, subject string) *firestore.DocumentRef {
+	return r.fs.Collection(colIdentities).Doc(IdentityKey(provider, subject))
+}
+
+func (r *FirestoreRepository) GetIdentity(ctx context.Context, provider, subject string) (*LinkedIdentity, error) {
+	if provider == "" || subject == "" || strings.Contains(provider+subject, "/") {
+		return nil, ErrIdentityNotFound
+	}
+
+	snap, err := r.identityDoc(provider, subject).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var li LinkedIdentity
+	if err := snap.DataTo(&li); err != nil {
+		return nil, err
+	}
+
+	return &li, nil
+}
+
+func (r *FirestoreRepository) CreateIdentity(ctx context.Context, li *LinkedIdentity) error {
+	if li.Provider == "" || li.Subject == "" || li.UserID == "" || strings.Contains(li.Provider+li.Subject, "/") {
+		return ErrInvalidUserID
+	}
+
+	li.LinkedAt = r.now()
+
+	_, err := r.identityDoc(li.Provider, li.Subject).Create(ctx, li)
+	if status.Code(err) == codes.AlreadyExists {
+		return ErrIdentityLinked
+	}
+
+	return err
+}
+
+func (r *FirestoreRepository) MoveUser(ctx context.Context, fromID string, li *LinkedIdentity) error {
+	if fromID == "" || strings.Contains(fromID, "/") ||
+		li.Provider == "" || li.Subject == "" || li.UserID == "" ||
+		strings.Contains(li.Provider+li.Subject+li.UserID, "/") {
+		return ErrInvalidUserID
+	}
+
+	from := r.doc(fromID)
+	to := r.doc(li.UserID)
+	identity := r.identityDoc(li.Provider, li.Subject)
+
+	return r.fs.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(from)
+		if status.Code(err) == codes.NotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var u User
+		if err := snap.DataTo(&u); err != nil {
+			return err
+		}
+
+		_, err = tx.Get(identity)
+		if err == nil {
+			return ErrIdentityLinked
+		}
+		if status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		now := r.now()
+		li.LinkedAt = now
+		u.UpdatedAt = now
+
+		if err := tx.Create(identity, li); err != nil {
+			return err
+		}
+		if err := tx.Create(to, &u); err != nil {
+			return err
+		}
+
+		return tx.Delete(from)
+	})
+}
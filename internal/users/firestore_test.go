@@ -0,0 +1,123 @@
+package users
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+func newTestFirestoreRepository(t *testing.T) *FirestoreRepository {
+	t.Helper()
+
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("FIRESTORE_EMULATOR_HOST is not set; skipping Firestore emulator tests")
+	}
+
+	projectID := os.Getenv("TEST_FIRESTORE_PROJECT")
+	require.NotEmpty(t, projectID, "TEST_FIRESTORE_PROJECT is not set")
+
+	fs, err := firestore.NewClient(context.Background(), projectID, option.WithoutAuthentication())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fs.Close() })
+
+	return NewFirestoreRepository(fs)
+}
+
+func TestFirestoreRepository_Upsert(t *testing.T) {
+	t.Parallel()
+
+	r := newTestFirestoreRepository(t)
+	ctx := context.Background()
+
+	created := time.Unix(1_725_000_000, 0).UTC()
+	r.now = func() time.Time { return created }
+
+	id := GitHubUserID(time.Now().UnixNano())
+	require.NoError(t, r.Upsert(ctx, &User{ID: id, Provider: ProviderGitHub, Login: "octocat", Email: "octo@example.com", EmailVerified: true, GitHubOrgs: []string{"acme"}}))
+
+	updated := created.Add(time.Hour)
+	r.now = func() time.Time { return updated }
+	require.NoError(t, r.Upsert(ctx, &User{ID: id, Provider: ProviderGoogle, Login: "octocat2", Email: "other@example.com"}))
+
+	got, err := r.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, id, got.ID)
+	require.Equal(t, "octocat2", got.Login)
+	require.Equal(t, ProviderGoogle, got.Provider)
+	require.Equal(t, "octo@example.com", got.Email)
+	require.True(t, got.EmailVerified)
+	require.Equal(t, []string{"acme"}, got.GitHubOrgs)
+	require.True(t, got.CreatedAt.Equal(created))
+	require.True(t, got.UpdatedAt.Equal(updated))
+
+	_, err = r.Get(ctx, "github:missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.ErrorIs(t, r.Upsert(ctx, &User{ID: "a/b"}), ErrInvalidUserID)
+}
+
+func TestFirestoreRepository_Identities(t *testing.T) {
+	t.Parallel()
+
+	r := newTestFirestoreRepository(t)
+	ctx := context.Background()
+
+	subject := strconv.FormatInt(time.Now().UnixNano(), 10)
+	userID := uuid.NewString()
+
+	_, err := r.GetIdentity(ctx, ProviderGitHub, subject)
+	require.ErrorIs(t, err, ErrIdentityNotFound)
+
+	require.NoError(t, r.CreateIdentity(ctx, &LinkedIdentity{Provider: ProviderGitHub, Subject: subject, UserID: userID}))
+	require.ErrorIs(t, r.CreateIdentity(ctx, &LinkedIdentity{Provider: ProviderGitHub, Subject: subject, UserID: "other"}), ErrIdentityLinked)
+
+	li, err := r.GetIdentity(ctx, ProviderGitHub, subject)
+	require.NoError(t, err)
+	require.Equal(t, userID, li.UserID)
+	require.False(t, li.LinkedAt.IsZero())
+
+	email := subject + "@example.com"
+	require.NoError(t, r.Upsert(ctx, &User{ID: userID, Email: email, EmailVerified: true}))
+
+	u, err := r.FindByVerifiedEmail(ctx, email)
+	require.NoError(t, err)
+	require.Equal(t, userID, u.ID)
+
+	_, err = r.FindByVerifiedEmail(ctx, "missing-"+email)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFirestoreRepository_MoveUser(t *testing.T) {
+	t.Parallel()
+
+	r := newTestFirestoreRepository(t)
+	ctx := context.Background()
+
+	subject := strconv.FormatInt(time.Now().UnixNano(), 10)
+	legacyID := UserID(ProviderGitHub, subject)
+	require.NoError(t, r.Upsert(ctx, &User{ID: legacyID, Provider: ProviderGitHub, Login: "octocat"}))
+
+	li := &LinkedIdentity{Provider: ProviderGitHub, Subject: subject, UserID: uuid.NewString()}
+	require.NoError(t, r.MoveUser(ctx, legacyID, li))
+
+	got, err := r.Get(ctx, li.UserID)
+	require.NoError(t, err)
+	require.Equal(t, "octocat", got.Login)
+
+	_, err = r.Get(ctx, legacyID)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	linked, err := r.GetIdentity(ctx, ProviderGitHub, subject)
+	require.NoError(t, err)
+	require.Equal(t, li.UserID, linked.UserID)
+
+	err = r.MoveUser(ctx, legacyID, &LinkedIdentity{Provider: ProviderGitHub, Subject: subject, UserID: uuid.NewString()})
+	require.ErrorIs(t, err, ErrNotFound)
+}
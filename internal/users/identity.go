@@ -0,0 +1,15 @@
+package users
+
+import "time"
+
+// LinkedIdentity maps an upstream account to the internal user it signs in as.
+type LinkedIdentity struct {
+	Provider string    `firestore:"provider"`
+	Subject  string    `firestore:"subject"`
+	UserID   string    `firestore:"user_id"`
+	LinkedAt time.Time `firestore:"linked_at"`
+}
+
+func IdentityKey(provider, subject string) string {
+	return provider + ":" + subject
+}
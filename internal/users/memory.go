@@ -0,0 +1,143 @@
+package users
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type MemoryRepository struct {
+	mu         sync.Mutex
+	users      map[string]User
+	identities map[string]LinkedIdentity
+	now        func() time.Time
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		users:      make(map[string]User),
+		identities: make(map[string]LinkedIdentity),
+		now:        time.Now,
+	}
+}
+
+func (r *MemoryRepository) Get(_ context.Context, id string) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &u, nil
+}
+
+func (r *MemoryRepository) Upsert(_ context.Context, u *User) error {
+	if u.ID == "" {
+		return ErrInvalidUserID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if existing, ok := r.users[u.ID]; ok {
+		existing.mergeLogin(u)
+		*u = existing
+	} else {
+		u.CreatedAt = now
+	}
+	u.UpdatedAt = now
+	u.LastLoginAt = now
+
+	r.users[u.ID] = *u
+
+	return nil
+}
+
+func (r *MemoryRepository) FindByVerifiedEmail(_ context.Context, email string) (*User, error) {
+	if email == "" {
+		return nil, ErrNotFound
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found *User
+	for _, u := range r.users {
+		if !u.EmailVerified || u.Email != email {
+			continue
+		}
+		if found != nil {
+			return nil, ErrNotFound
+		}
+		found = &u
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+
+	return found, nil
+}
+
+func (r *MemoryRepository) GetIdentity(_ context.Context, provider, subject string) (*LinkedIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	li, ok := r.identities[IdentityKey(provider, subject)]
+	if !ok {
+		return nil, ErrIdentityNotFound
+	}
+
+	return &li, nil
+}
+
+func (r *MemoryRepository) CreateIdentity(_ context.Context, li *LinkedIdentity) error {
+	if li.Provider == "" || li.Subject == "" || li.UserID == "" {
+		return ErrInvalidUserID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := IdentityKey(li.Provider, li.Subject)
+	if _, ok := r.identities[key]; ok {
+		return ErrIdentityLinked
+	}
+
+	li.LinkedAt = r.now()
+	r.identities[key] = *li
+
+	return nil
+}
+
+func (r *MemoryRepository) MoveUser(_ context.Context, fromID string, li *LinkedIdentity) error {
+	if fromID == "" || li.Provider == "" || li.Subject == "" || li.UserID == "" {
+		return ErrInvalidUserID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[fromID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	key := IdentityKey(li.Provider, li.Subject)
+	if _, ok := r.identities[key]; ok {
+		return ErrIdentityLinked
+	}
+
+	now := r.now()
+	li.LinkedAt = now
+	r.identities[key] = *li
+
+	u.ID = li.UserID
+	u.UpdatedAt = now
+	r.users[li.UserID] = u
+	delete(r.users, fromID)
+
+	return nil
+}
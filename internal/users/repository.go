@@ -0,0 +1,27 @@
+package users
+
+import "context"
+
+type Repository interface {
+	Get(ctx context.Context, id string) (*User, error)
+	// Upsert creates the user or merges a login's profile into it, leaving u
+	// holding the stored result.
+	Upsert(ctx context.Context, u *User) error
+
+	// FindByVerifiedEmail returns ErrNotFound unless exactly one user has the
+	// address verified.
+	FindByVerifiedEmail(ctx context.Context, email string) (*User, error)
+
+	GetIdentity(ctx context.Context, provider, subject string) (*LinkedIdentity, error)
+	// CreateIdentity returns ErrIdentityLinked when the identity is already linked.
+	CreateIdentity(ctx context.Context, li *LinkedIdentity) error
+	// MoveUser atomically moves the user stored under fromID to li.UserID,
+	// removes fromID and links the identity. It returns ErrNotFound when
+	// fromID is gone and ErrIdentityLinked when the identity is already linked.
+	MoveUser(ctx context.Context, fromID string, li *LinkedIdentity) error
+}
+
+var (
+	_ Repository = (*MemoryRepository)(nil)
+	_ Repository = (*FirestoreRepository)(nil)
+)
@@ -0,0 +1,233 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+)
+
+type LinkPolicy struct {
+	// providers trusted to assert verified emails; a first-time identity from
+	// one of them is attached to the single user with the same verified email
+	AutoLinkProviders []string
+}
+
+// RefreshTokenRevoker revokes the refresh tokens issued to a user.
+type RefreshTokenRevoker interface {
+	RevokeUser(ctx context.Context, userID, reason string, t time.Time) (int, error)
+}
+
+type Service struct {
+	repo  Repository
+	newID func() string
+
+	// optional; zero only links identities explicitly
+	Policy LinkPolicy
+
+	// optional; revokes tokens still issued to a legacy subject once the user
+	// moves to an internal id
+	RefreshTokens RefreshTokenRevoker
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo, newID: uuid.NewString}
+}
+
+// Profile is the provider-neutral view of an upstream account.
+type Profile struct {
+	Subject       string
+	Login         string
+	Name          string
+	Email         string
+	EmailVerified bool
+	Picture       string
+	GitHubOrgs    []string
+	GitHubTeams   []string
+}
+
+func validProfile(provider string, p Profile) bool {
+	return provider != "" && p.Subject != "" && !strings.Contains(provider+p.Subject, "/")
+}
+
+// UpsertProfile resolves the internal user an upstream identity signs in as,
+// creating the user on first login, and refreshes its profile.
+func (s *Service) UpsertProfile(ctx context.Context, provider string, p Profile) (string, error) {
+	if !validProfile(provider, p) {
+		return "", ErrInvalidUserID
+	}
+
+	var userID string
+	li, err := s.repo.GetIdentity(ctx, provider, p.Subject)
+	switch {
+	case err == nil:
+		userID = li.UserID
+	case errors.Is(err, ErrIdentityNotFound):
+		userID, err = s.linkNew(ctx, provider, p)
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", err
+	}
+
+	u := &User{
+		ID:             userID,
+		Provider:       provider,
+		ProviderUserID: p.Subject,
+		Login:          p.Login,
+		Name:           p.Name,
+		Email:          p.Email,
+		EmailVerified:  p.EmailVerified,
+		Picture:        p.Picture,
+		GitHubOrgs:     p.GitHubOrgs,
+		GitHubTeams:    p.GitHubTeams,
+	}
+
+	if err := s.repo.Upsert(ctx, u); err != nil {
+		return "", err
+	}
+
+	return u.ID, nil
+}
+
+// linkNew links a first-time identity. A user created before the identity
+// table is moved to a new internal user so every issued subject is an
+// internal id.
+func (s *Service) linkNew(ctx context.Context, provider string, p Profile) (string, error) {
+	legacyID := UserID(provider, p.Subject)
+	_, err := s.repo.Get(ctx, legacyID)
+	switch {
+	case err == nil:
+		return s.moveLegacy(ctx, legacyID, provider, p)
+	case !errors.Is(err, ErrNotFound):
+		return "", err
+	}
+
+	userID, err := s.autoLinkUser(ctx, provider, p)
+	if err != nil {
+		return "", err
+	}
+	if userID == "" {
+		userID = s.newID()
+	}
+
+	err = s.repo.CreateIdentity(ctx, &LinkedIdentity{Provider: provider, Subject: p.Subject, UserID: userID})
+	if errors.Is(err, ErrIdentityLinked) {
+		return s.linkedUser(ctx, provider, p)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
+
+func (s *Service) moveLegacy(ctx context.Context, legacyID, provider string, p Profile) (string, error) {
+	userID := s.newID()
+
+	err := s.repo.MoveUser(ctx, legacyID, &LinkedIdentity{Provider: provider, Subject: p.Subject, UserID: userID})
+	if errors.Is(err, ErrIdentityLinked) || errors.Is(err, ErrNotFound) {
+		return s.linkedUser(ctx, provider, p)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// tokens issued to the legacy subject would outlive the user they name
+	if s.RefreshTokens != nil {
+		if _, err := s.RefreshTokens.RevokeUser(ctx, legacyID, refresh.RevokeReasonUserMoved, time.Now()); err != nil {
+			return "", err
+		}
+	}
+
+	return userID, nil
+}
+
+// linkedUser resolves the identity after losing a race with a concurrent
+// first login.
+func (s *Service) linkedUser(ctx context.Context, provider string, p Profile) (string, error) {
+	li, err := s.repo.GetIdentity(ctx, provider, p.Subject)
+	if err != nil {
+		return "", err
+	}
+
+	return li.UserID, nil
+}
+
+func (s *Service) autoLinkUser(ctx context.Context, provider string, p Profile) (string, error) {
+	if !slices.Contains(s.Policy.AutoLinkProviders, provider) || !p.EmailVerified || p.Email == "" {
+		return "", nil
+	}
+
+	u, err := s.repo.FindByVerifiedEmail(ctx, p.Email)
+	if errors.Is(err, ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return u.ID, nil
+}
+
+// LinkProfile attaches an upstream identity to an existing user. Callers are
+// responsible for having re-authenticated the user.
+func (s *Service) LinkProfile(ctx context.Context, userID, provider string, p Profile) error {
+	if userID == "" || !validProfile(provider, p) {
+		return ErrInvalidUserID
+	}
+
+	if _, err := s.repo.Get(ctx, userID); err != nil {
+		return err
+	}
+
+	li, err := s.repo.GetIdentity(ctx, provider, p.Subject)
+	switch {
+	case err == nil:
+		if li.UserID != userID {
+			return ErrIdentityLinked
+		}
+		return nil
+	case !errors.Is(err, ErrIdentityNotFound):
+		return err
+	}
+
+	return s.repo.CreateIdentity(ctx, &LinkedIdentity{Provider: provider, Subject: p.Subject, UserID: userID})
+}
+
+func (s *Service) UpsertFromGitHub(ctx context.Context, githubID int64, login, email string) (string, error) {
+	if githubID <= 0 {
+		return "", ErrInvalidUserID
+	}
+
+	return s.UpsertProfile(ctx, ProviderGitHub, Profile{
+		Subject: strconv.FormatInt(githubID, 10),
+		Login:   login,
+		Email:   email,
+	})
+}
+
+type GoogleProfile struct {
+	UID           string
+	Name          string
+	Email         string
+	EmailVerified bool
+	Picture       string
+}
+
+func (s *Service) UpsertFromGoogle(ctx context.Context, p GoogleProfile) (string, error) {
+	return s.UpsertProfile(ctx, ProviderGoogle, Profile{
+		Subject:       p.UID,
+		Name:          p.Name,
+		Email:         p.Email,
+		EmailVerified: p.EmailVerified,
+		Picture:       p.Picture,
+	})
+}
@@ -0,0 +1,350 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+)
+
+type failingRepo struct{ err error }
+
+func (r failingRepo) Get(context.Context, string) (*User, error) { return nil, r.err }
+func (r failingRepo) Upsert(context.Context, *User) error        { return r.err }
+func (r failingRepo) FindByVerifiedEmail(context.Context, string) (*User, error) {
+	return nil, r.err
+}
+func (r failingRepo) GetIdentity(context.Context, string, string) (*LinkedIdentity, error) {
+	return nil, r.err
+}
+func (r failingRepo) CreateIdentity(context.Context, *LinkedIdentity) error   { return r.err }
+func (r failingRepo) MoveUser(context.Context, string, *LinkedIdentity) error { return r.err }
+
+type fakeRevoker struct{ users []string }
+
+func (f *fakeRevoker) RevokeUser(_ context.Context, userID, reason string, _ time.Time) (int, error) {
+	f.users = append(f.users, userID+":"+reason)
+	return 1, nil
+}
+
+func TestService_UpsertFromGitHub(t *testing.T) {
+	t.Parallel()
+
+	t.Run("creates then updates the user", func(t *testing.T) {
+		t.Parallel()
+
+		repo := NewMemoryRepository()
+		created := time.Unix(1_725_000_000, 0).UTC()
+		repo.now = func() time.Time { return created }
+		svc := NewService(repo)
+		ctx := context.Background()
+
+		id, err := svc.UpsertFromGitHub(ctx, 42, "octocat", "octo@example.com")
+		require.NoError(t, err)
+		_, err = uuid.Parse(id)
+		require.NoError(t, err, "new users get an internal uuid subject")
+
+		li, err := repo.GetIdentity(ctx, ProviderGitHub, "42")
+		require.NoError(t, err)
+		require.Equal(t, id, li.UserID)
+		require.Equal(t, created, li.LinkedAt)
+
+		updated := created.Add(time.Hour)
+		repo.now = func() time.Time { return updated }
+
+		again, err := svc.UpsertFromGitHub(ctx, 42, "octocat2", "")
+		require.NoError(t, err)
+		require.Equal(t, id, again)
+
+		u, err := repo.Get(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, ProviderGitHub, u.Provider)
+		require.Equal(t, "42", u.ProviderUserID)
+		require.Equal(t, "octocat2", u.Login)
+		require.Equal(t, "octo@example.com", u.Email, "a login without an email keeps the stored one")
+		require.Equal(t, created, u.CreatedAt)
+		require.Equal(t, updated, u.UpdatedAt)
+		require.Equal(t, updated, u.LastLoginAt)
+	})
+
+	t.Run("rejects invalid github id", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewService(NewMemoryRepository()).UpsertFromGitHub(context.Background(), 0, "octocat", "")
+		require.ErrorIs(t, err, ErrInvalidUserID)
+	})
+
+	t.Run("propagates repository error", func(t *testing.T) {
+		t.Parallel()
+
+		boom := errors.New("boom")
+		_, err := NewService(failingRepo{err: boom}).UpsertFromGitHub(context.Background(), 1, "octocat", "")
+		require.ErrorIs(t, err, boom)
+	})
+}
+
+func TestService_UpsertFromGoogle(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMemoryRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	id, err := svc.UpsertFromGoogle(ctx, GoogleProfile{
+		UID:           "uid-1",
+		Name:          "Jane Doe",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Picture:       "https://example.com/jane.png",
+	})
+	require.NoError(t, err)
+
+	u, err := repo.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, ProviderGoogle, u.Provider)
+	require.Equal(t, "Jane Doe", u.Name)
+	require.True(t, u.EmailVerified)
+	require.Equal(t, "https://example.com/jane.png", u.Picture)
+
+	_, err = svc.UpsertFromGoogle(ctx, GoogleProfile{UID: "a/b"})
+	require.ErrorIs(t, err, ErrInvalidUserID)
+}
+
+func TestService_UpsertProfile(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMemoryRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	id, err := svc.UpsertProfile(ctx, "okta", Profile{
+		Subject:       "00u1",
+		Login:         "jane",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+	})
+	require.NoError(t, err)
+
+	u, err := repo.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "okta", u.Provider)
+	require.Equal(t, "00u1", u.ProviderUserID)
+	require.Equal(t, "jane", u.Login)
+	require.True(t, u.EmailVerified)
+
+	id, err = svc.UpsertProfile(ctx, ProviderGitHub, Profile{
+		Subject:     "42",
+		GitHubOrgs:  []string{"acme"},
+		GitHubTeams: []string{"acme/admins"},
+	})
+	require.NoError(t, err)
+
+	u, err = repo.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, []string{"acme"}, u.GitHubOrgs)
+	require.Equal(t, []string{"acme/admins"}, u.GitHubTeams)
+
+	_, err = svc.UpsertProfile(ctx, "okta", Profile{Subject: "a/b"})
+	require.ErrorIs(t, err, ErrInvalidUserID)
+
+	_, err = svc.UpsertProfile(ctx, "", Profile{Subject: "00u1"})
+	require.ErrorIs(t, err, ErrInvalidUserID)
+}
+
+func TestService_UpsertProfile_MovesLegacyUser(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMemoryRepository()
+	svc := NewService(repo)
+	revoker := &fakeRevoker{}
+	svc.RefreshTokens = revoker
+	svc.Policy.AutoLinkProviders = []string{"okta"}
+	ctx := context.Background()
+
+	require.NoError(t, repo.Upsert(ctx, &User{
+		ID:             "github:42",
+		Provider:       ProviderGitHub,
+		ProviderUserID: "42",
+		Email:          "octo@example.com",
+		EmailVerified:  true,
+		GitHubOrgs:     []string{"acme"},
+	}))
+
+	id, err := svc.UpsertFromGitHub(ctx, 42, "octocat", "")
+	require.NoError(t, err)
+	_, err = uuid.Parse(id)
+	require.NoError(t, err, "legacy users move to an internal uuid subject")
+
+	li, err := repo.GetIdentity(ctx, ProviderGitHub, "42")
+	require.NoError(t, err)
+	require.Equal(t, id, li.UserID)
+
+	u, err := repo.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "octocat", u.Login)
+	require.Equal(t, "octo@example.com", u.Email)
+	require.True(t, u.EmailVerified)
+	require.Equal(t, []string{"acme"}, u.GitHubOrgs)
+
+	_, err = repo.Get(ctx, "github:42")
+	require.ErrorIs(t, err, ErrNotFound, "the legacy user is removed")
+	require.Equal(t, []string{"github:42:" + refresh.RevokeReasonUserMoved}, revoker.users)
+
+	again, err := svc.UpsertFromGitHub(ctx, 42, "octocat", "")
+	require.NoError(t, err)
+	require.Equal(t, id, again)
+
+	linked, err := svc.UpsertProfile(ctx, "okta", Profile{Subject: "00u1", Email: "octo@example.com", EmailVerified: true})
+	require.NoError(t, err)
+	require.Equal(t, id, linked, "the moved user is the only match for its email")
+}
+
+func TestService_UpsertProfile_MergesLinkedLogins(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMemoryRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	userID, err := svc.UpsertProfile(ctx, ProviderGoogle, Profile{
+		Subject:       "uid-1",
+		Name:          "Octo Cat",
+		Email:         "octo@example.com",
+		EmailVerified: true,
+	})
+	require.NoError(t, err)
+
+	github := Profile{
+		Subject:     "42",
+		Login:       "octocat",
+		Email:       "octo@users.noreply.github.com",
+		GitHubOrgs:  []string{"acme"},
+		GitHubTeams: []string{"acme/admins"},
+	}
+	require.NoError(t, svc.LinkProfile(ctx, userID, ProviderGitHub, github))
+
+	_, err = svc.UpsertProfile(ctx, ProviderGitHub, github)
+	require.NoError(t, err)
+
+	u, err := repo.Get(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, "octo@example.com", u.Email, "an unverified email does not replace a verified one")
+	require.True(t, u.EmailVerified)
+	require.Equal(t, "Octo Cat", u.Name)
+	require.Equal(t, "octocat", u.Login)
+
+	_, err = svc.UpsertProfile(ctx, ProviderGoogle, Profile{Subject: "uid-1", Email: "octo@example.com", EmailVerified: true})
+	require.NoError(t, err)
+
+	u, err = repo.Get(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, ProviderGoogle, u.Provider)
+	require.Equal(t, "octocat", u.Login)
+	require.Equal(t, []string{"acme"}, u.GitHubOrgs)
+	require.Equal(t, []string{"acme/admins"}, u.GitHubTeams)
+
+	_, err = svc.UpsertProfile(ctx, ProviderGitHub, Profile{Subject: "42", GitHubOrgs: []string{}, GitHubTeams: []string{}})
+	require.NoError(t, err)
+
+	u, err = repo.Get(ctx, userID)
+	require.NoError(t, err)
+	require.Empty(t, u.GitHubOrgs, "fetched memberships replace the stored ones")
+	require.Empty(t, u.GitHubTeams)
+}
+
+func TestService_UpsertProfile_AutoLink(t *testing.T) {
+	t.Parallel()
+
+	verified := Profile{Subject: "uid-1", Email: "jane@example.com", EmailVerified: true}
+
+	for _, tc := range []struct {
+		name    string
+		trusted []string
+		second  Profile
+		linked  bool
+	}{
+		{"policy off", nil, Profile{Subject: "00u1", Email: "jane@example.com", EmailVerified: true}, false},
+		{"verified email", []string{"okta"}, Profile{Subject: "00u1", Email: "jane@example.com", EmailVerified: true}, true},
+		{"untrusted provider", []string{ProviderGoogle}, Profile{Subject: "00u1", Email: "jane@example.com", EmailVerified: true}, false},
+		{"unverified email", []string{"okta"}, Profile{Subject: "00u1", Email: "jane@example.com"}, false},
+		{"different email", []string{"okta"}, Profile{Subject: "00u1", Email: "john@example.com", EmailVerified: true}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := NewService(NewMemoryRepository())
+			svc.Policy.AutoLinkProviders = tc.trusted
+			ctx := context.Background()
+
+			first, err := svc.UpsertProfile(ctx, ProviderGoogle, verified)
+			require.NoError(t, err)
+
+			second, err := svc.UpsertProfile(ctx, "okta", tc.second)
+			require.NoError(t, err)
+			require.Equal(t, tc.linked, first == second)
+		})
+	}
+
+	t.Run("ambiguous email is not linked", func(t *testing.T) {
+		t.Parallel()
+
+		repo := NewMemoryRepository()
+		svc := NewService(repo)
+		ctx := context.Background()
+
+		a, err := svc.UpsertProfile(ctx, ProviderGoogle, verified)
+		require.NoError(t, err)
+		b, err := svc.UpsertProfile(ctx, "okta", Profile{Subject: "00u1", Email: "jane@example.com", EmailVerified: true})
+		require.NoError(t, err)
+		require.NotEqual(t, a, b)
+
+		svc.Policy.AutoLinkProviders = []string{"corp"}
+		c, err := svc.UpsertProfile(ctx, "corp", Profile{Subject: "c-1", Email: "jane@example.com", EmailVerified: true})
+		require.NoError(t, err)
+		require.NotEqual(t, a, c)
+		require.NotEqual(t, b, c)
+	})
+}
+
+func TestService_LinkProfile(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMemoryRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	userID, err := svc.UpsertFromGitHub(ctx, 42, "octocat", "")
+	require.NoError(t, err)
+
+	google := Profile{Subject: "uid-1", Email: "octo@example.com", EmailVerified: true}
+	require.NoError(t, svc.LinkProfile(ctx, userID, ProviderGoogle, google))
+	require.NoError(t, svc.LinkProfile(ctx, userID, ProviderGoogle, google), "relinking to the same user is a no-op")
+
+	got, err := svc.UpsertProfile(ctx, ProviderGoogle, google)
+	require.NoError(t, err)
+	require.Equal(t, userID, got, "either provider signs in as the same subject")
+
+	other, err := svc.UpsertProfile(ctx, "okta", Profile{Subject: "00u1"})
+	require.NoError(t, err)
+
+	err = svc.LinkProfile(ctx, other, ProviderGoogle, google)
+	require.ErrorIs(t, err, ErrIdentityLinked)
+
+	err = svc.LinkProfile(ctx, "missing", "corp", Profile{Subject: "c-1"})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	err = svc.LinkProfile(ctx, userID, "corp", Profile{Subject: "a/b"})
+	require.ErrorIs(t, err, ErrInvalidUserID)
+}
+
+func TestMemoryRepository_GetNotFound(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewMemoryRepository().Get(context.Background(), "github:1")
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,67 @@
+package users
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGoogle = "google"
+)
+
+type User struct {
+	ID             string    `firestore:"-"`
+	Provider       string    `firestore:"provider"`
+	ProviderUserID string    `firestore:"provider_user_id"`
+	Login          string    `firestore:"login"`
+	Name           string    `firestore:"name"`
+	Email          string    `firestore:"email"`
+	EmailVerified  bool      `firestore:"email_verified"`
+	Picture        string    `firestore:"picture"`
+	GitHubOrgs     []string  `firestore:"github_orgs,omitempty"`
+	GitHubTeams    []string  `firestore:"github_teams,omitempty"`
+	CreatedAt      time.Time `firestore:"created_at"`
+	UpdatedAt      time.Time `firestore:"updated_at"`
+	LastLoginAt    time.Time `firestore:"last_login_at"`
+}
+
+// mergeLogin folds the profile sent by a login into the stored user. Fields
+// the provider did not send are kept, a verified email is not replaced by an
+// unverified one, and GitHub memberships change only when they were fetched.
+func (u *User) mergeLogin(in *User) {
+	u.Provider = in.Provider
+	u.ProviderUserID = in.ProviderUserID
+
+	if in.Login != "" {
+		u.Login = in.Login
+	}
+	if in.Name != "" {
+		u.Name = in.Name
+	}
+	if in.Picture != "" {
+		u.Picture = in.Picture
+	}
+	if in.Email != "" && (in.EmailVerified || !u.EmailVerified) {
+		u.Email = in.Email
+		u.EmailVerified = in.EmailVerified
+	}
+	if in.GitHubOrgs != nil {
+		u.GitHubOrgs = in.GitHubOrgs
+	}
+	if in.GitHubTeams != nil {
+		u.GitHubTeams = in.GitHubTeams
+	}
+}
+
+func UserID(provider, subject string) string {
+	return provider + ":" + subject
+}
+
+func GitHubUserID(githubID int64) string {
+	return UserID(ProviderGitHub, strconv.FormatInt(githubID, 10))
+}
+
+func GoogleUserID(uid string) string {
+	return UserID(ProviderGoogle, uid)
+}
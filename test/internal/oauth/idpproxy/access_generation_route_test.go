@@ -0,0 +1,112 @@
+package idpproxy_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/authcode"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+func TestAccessToken_GenerationBumpRevokesOutstandingTokens(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	s, err := signer.NewEd25519Signer(newEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	userRepo := users.NewMemoryRepository()
+	userID, err := users.NewService(userRepo).UpsertFromGitHub(ctx, 12345, "octocat", "octo@example.com")
+	require.NoError(t, err)
+
+	proxyCodes := authcodestore.NewMemoryStore()
+	require.NoError(t, proxyCodes.Save(ctx, authcode.ProxyCode{
+		Code:                "proxy-code-1",
+		UserID:              userID,
+		ClientID:            "client-1",
+		CodeChallenge:       pkce.S256Challenge(testVerifier),
+		CodeChallengeMethod: pkce.MethodS256,
+		Scope:               "openid",
+		ExpiresAt:           time.Now().Add(time.Minute),
+	}))
+
+	generations := testhelpers.NewMockAccessGenRepo()
+	_, err = generations.Bump(ctx, userID, time.Now())
+	require.NoError(t, err)
+
+	d := router.NewRouterDeps(public.PublicFS,
+		testhelpers.NewMockGitHubAPIDeps(logger),
+		testhelpers.NewMockGitHubOAuthDeps(logger),
+		testhelpers.NewMockGoogleDeps(logger),
+		logger,
+		testhelpers.NewMockSystemDeps(logger),
+	)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Signer = s
+	d.OIDC.ProxyCodes = proxyCodes
+	d.OIDC.RefreshTokens = testhelpers.NewMockRefreshRepo()
+	d.OIDC.AccessGenerations = generations
+	d.OIDC.Clients = newPublicClients("client-1")
+	d.OIDC.Users = userRepo
+	r := router.NewRouter(d)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/token", strings.NewReader(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"proxy-code-1"},
+		"client_id":     {"client-1"},
+		"code_verifier": {testVerifier},
+	}.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var tokens map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	accessToken := tokens["access_token"].(string)
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(strings.Split(accessToken, ".")[0])
+	require.NoError(t, err)
+	var header map[string]any
+	require.NoError(t, json.Unmarshal(headerJSON, &header))
+	require.Equal(t, "at+jwt", header["typ"])
+
+	userinfo := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/userinfo", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w = userinfo()
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	_, err = generations.Bump(ctx, userID, time.Now())
+	require.NoError(t, err)
+
+	w = userinfo()
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, `Bearer error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+}
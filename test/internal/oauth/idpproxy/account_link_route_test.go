@@ -0,0 +1,191 @@
+package idpproxy_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	authorizestore "github.com/vinylhousegarage/idpproxy/internal/authorize/store"
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+type linkFixture struct {
+	router *gin.Engine
+	users  *users.MemoryRepository
+	corp   *testhelpers.FakeOIDCServer
+	other  *testhelpers.FakeOIDCServer
+}
+
+func newLinkFixture(t *testing.T) *linkFixture {
+	t.Helper()
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	logger := zap.NewNop()
+	f := &linkFixture{
+		users: users.NewMemoryRepository(),
+		corp:  testhelpers.NewFakeOIDCServer(t, "corp-client", "corp-secret"),
+		other: testhelpers.NewFakeOIDCServer(t, "partner-client", "partner-secret"),
+	}
+	f.other.Subject = "partner-user-9"
+
+	s, err := signer.NewEd25519Signer(newEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	upstreamConfig := func(id string, srv *testhelpers.FakeOIDCServer) config.UpstreamOIDCConfig {
+		return config.UpstreamOIDCConfig{
+			ID:           id,
+			Name:         id,
+			Issuer:       srv.Issuer(),
+			ClientID:     srv.ClientID,
+			ClientSecret: srv.ClientSecret,
+			RedirectURI:  "https://idpproxy.example.com/oidc/" + id + "/callback",
+			Scopes:       config.UpstreamOIDCDefaultScopes,
+		}
+	}
+
+	d := router.NewRouterDeps(public.PublicFS,
+		testhelpers.NewMockGitHubAPIDeps(logger),
+		testhelpers.NewMockGitHubOAuthDeps(logger),
+		testhelpers.NewMockGoogleDeps(logger),
+		logger,
+		testhelpers.NewMockSystemDeps(logger),
+	)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Config.Upstreams = []config.UpstreamOIDCConfig{upstreamConfig("corp", f.corp), upstreamConfig("partner", f.other)}
+	d.OIDC.HTTPClient = f.corp.Client()
+	d.OIDC.Signer = s
+	d.OIDC.ProxyCodes = authcodestore.NewMemoryStore()
+	d.OIDC.RefreshTokens = testhelpers.NewMockRefreshRepo()
+	d.OIDC.Authorizations = authorizestore.NewMemoryStore()
+	d.OIDC.Clients = newPublicClients("client-1")
+	d.OIDC.Users = f.users
+	d.OIDC.Sessions = session.NewMemoryRepository()
+	f.router = router.NewRouter(d)
+
+	return f
+}
+
+func (f *linkFixture) serve(t *testing.T, method, target string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(method, target, nil)
+	require.NoError(t, err)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	f.router.ServeHTTP(w, req)
+
+	return w
+}
+
+// upstreamLogin drives /oidc/<id>/login through the fake provider and returns
+// the callback response.
+func (f *linkFixture) upstreamLogin(t *testing.T, id string, srv *testhelpers.FakeOIDCServer, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+
+	w := f.serve(t, http.MethodGet, "/oidc/"+id+"/login", nil)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	cookies = append(cookies, w.Result().Cookies()...)
+
+	client := *srv.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.Get(w.Header().Get("Location"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callbackURL, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	return f.serve(t, http.MethodGet, callbackURL.RequestURI(), cookies)
+}
+
+// signIn completes an /authorize flow through corp and returns the session cookie.
+func (f *linkFixture) signIn(t *testing.T) *http.Cookie {
+	t.Helper()
+
+	w := f.serve(t, http.MethodGet, "/authorize?"+url.Values{
+		"client_id":             {"client-1"},
+		"redirect_uri":          {"https://app.example.com/cb"},
+		"response_type":         {"code"},
+		"scope":                 {"openid"},
+		"code_challenge":        {pkce.S256Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")},
+		"code_challenge_method": {"S256"},
+		"idp_hint":              {"corp"},
+	}.Encode(), nil)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+
+	w = f.upstreamLogin(t, "corp", f.corp, w.Result().Cookies())
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+
+	for _, c := range w.Result().Cookies() {
+		if c.Name == cookie.SessionCookieName {
+			return c
+		}
+	}
+	t.Fatal("session cookie not set")
+
+	return nil
+}
+
+func TestAccountLinkRoute_LinksSecondProvider(t *testing.T) {
+	f := newLinkFixture(t)
+	ctx := context.Background()
+
+	sessionCookie := f.signIn(t)
+	corp, err := f.users.GetIdentity(ctx, "corp", "upstream-user-1")
+	require.NoError(t, err)
+
+	w := f.serve(t, http.MethodPost, "/account/link/partner", []*http.Cookie{sessionCookie})
+	require.Equal(t, http.StatusSeeOther, w.Code, w.Body.String())
+	require.Equal(t, "/oidc/partner/login", w.Header().Get("Location"))
+
+	w = f.upstreamLogin(t, "partner", f.other, append(w.Result().Cookies(), sessionCookie))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.JSONEq(t, `{"status":"linked","provider":"partner"}`, w.Body.String())
+
+	partner, err := f.users.GetIdentity(ctx, "partner", "partner-user-9")
+	require.NoError(t, err)
+	require.Equal(t, corp.UserID, partner.UserID, "both providers resolve to one internal subject")
+}
+
+func TestAccountLinkRoute_RequiresSession(t *testing.T) {
+	f := newLinkFixture(t)
+
+	w := f.serve(t, http.MethodPost, "/account/link/partner", nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.JSONEq(t, `{"error":"reauthentication required"}`, w.Body.String())
+}
+
+func TestAccountLinkRoute_WithoutLinkLogsInSeparately(t *testing.T) {
+	f := newLinkFixture(t)
+	ctx := context.Background()
+
+	f.signIn(t)
+
+	w := f.upstreamLogin(t, "partner", f.other, nil)
+	require.Equal(t, http.StatusBadRequest, w.Code, "no pending authorization request")
+
+	corp, err := f.users.GetIdentity(ctx, "corp", "upstream-user-1")
+	require.NoError(t, err)
+	partner, err := f.users.GetIdentity(ctx, "partner", "partner-user-9")
+	require.NoError(t, err)
+	require.NotEqual(t, corp.UserID, partner.UserID)
+}
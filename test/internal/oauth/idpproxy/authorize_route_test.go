@@ -0,0 +1,154 @@
+package idpproxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	authorizestore "github.com/vinylhousegarage/idpproxy/internal/authorize/store"
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+func TestAuthorizeRoute_GoogleLegIssuesCodeForClient(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	s, err := signer.NewEd25519Signer(newEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	githubAPIDeps := testhelpers.NewMockGitHubAPIDeps(logger)
+	githubOAuthDeps := testhelpers.NewMockGitHubOAuthDeps(logger)
+	googleDeps := testhelpers.NewMockGoogleDeps(logger)
+	systemDeps := testhelpers.NewMockSystemDeps(logger)
+
+	d := router.NewRouterDeps(public.PublicFS, githubAPIDeps, githubOAuthDeps, googleDeps, logger, systemDeps)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Config.GoogleLoginURL = "https://idpproxy.example.com/google/login"
+	d.OIDC.Signer = s
+	d.OIDC.ProxyCodes = authcodestore.NewMemoryStore()
+	d.OIDC.RefreshTokens = testhelpers.NewMockRefreshRepo()
+	d.OIDC.Authorizations = authorizestore.NewMemoryStore()
+	d.OIDC.Clients = newPublicClients("client-1")
+	r := router.NewRouter(d)
+
+	q := url.Values{
+		"client_id":             {"client-1"},
+		"redirect_uri":          {"https://app.example.com/cb"},
+		"response_type":         {"code"},
+		"scope":                 {"openid"},
+		"state":                 {"client-state"},
+		"nonce":                 {"client-nonce"},
+		"code_challenge":        {pkce.S256Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/authorize?"+q.Encode(), nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Contains(t, w.Body.String(), `href="/github/login"`)
+	require.Contains(t, w.Body.String(), `href="https://idpproxy.example.com/google/login"`)
+
+	var authzCookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "idpproxy_authorize" {
+			authzCookie = c
+		}
+	}
+	require.NotNil(t, authzCookie)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodPost, "/google/login/firebase", strings.NewReader(`{"id_token":"dummy"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(authzCookie)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var completed struct {
+		RedirectTo string `json:"redirect_to"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &completed))
+
+	loc, err := url.Parse(completed.RedirectTo)
+	require.NoError(t, err)
+	require.Equal(t, "app.example.com", loc.Host)
+	require.Equal(t, "/cb", loc.Path)
+	require.Equal(t, "client-state", loc.Query().Get("state"))
+	code := loc.Query().Get("code")
+	require.NotEmpty(t, code)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodPost, "/token", strings.NewReader(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"client_id":     {"client-1"},
+		"code_verifier": {verifier},
+		"redirect_uri":  {"https://app.example.com/cb"},
+	}.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var tokens map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+
+	idt, err := s.Verify(context.Background(), tokens["id_token"].(string), nil)
+	require.NoError(t, err)
+	require.Equal(t, "google:test-user", idt.Claims["sub"])
+	require.Equal(t, "client-nonce", idt.Claims["nonce"])
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	require.Equal(t, "https://idpproxy.example.com/authorize", doc["authorization_endpoint"])
+	require.Equal(t, []any{"code"}, doc["response_types_supported"])
+}
+
+func TestAuthorizeRoute_NotMountedWithoutClients(t *testing.T) {
+	t.Parallel()
+
+	logger := zap.NewNop()
+
+	githubAPIDeps := testhelpers.NewMockGitHubAPIDeps(logger)
+	githubOAuthDeps := testhelpers.NewMockGitHubOAuthDeps(logger)
+	googleDeps := testhelpers.NewMockGoogleDeps(logger)
+	systemDeps := testhelpers.NewMockSystemDeps(logger)
+
+	d := router.NewRouterDeps(public.PublicFS, githubAPIDeps, githubOAuthDeps, googleDeps, logger, systemDeps)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	r := router.NewRouter(d)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/authorize", nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
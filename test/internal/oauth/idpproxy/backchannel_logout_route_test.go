@@ -0,0 +1,109 @@
+package idpproxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/backchannel"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/deps"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/idpproxy/endsession"
+)
+
+func TestBackchannelLogoutRoute_NotifiesSessionClients(t *testing.T) {
+	ctx := context.Background()
+
+	tokens := make(chan string, 1)
+	rp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens <- r.PostFormValue("logout_token")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(rp.Close)
+
+	sessions := session.NewMemoryRepository()
+	require.NoError(t, sessions.Create(ctx, &session.Session{
+		SessionID: "sid-1",
+		UserID:    fixtureUserID,
+		Status:    "active",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+	deliveries := backchannel.NewMemoryDeliveryLog()
+
+	var s signer.Signer
+	f := newRevokeFixture(t, func(d *deps.OIDCDependencies) {
+		d.Sessions = sessions
+		d.BackchannelDeliveries = deliveries
+		s = d.Signer
+
+		queue := backchannel.NewQueue(endsession.NewBackchannelNotifier(d), zap.NewNop(), 1, 1)
+		t.Cleanup(queue.Close)
+		d.Backchannel = queue
+
+		require.NoError(t, d.Clients.(*client.MemoryRegistry).Save(ctx, &client.Client{
+			ID:                   "client-1",
+			Type:                 client.TypePublic,
+			RedirectURIs:         []string{"https://app.example.com/cb"},
+			BackchannelLogoutURI: rp.URL,
+		}))
+	})
+
+	got, err := sessions.FindByID(ctx, "sid-1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"client-1"}, got.ClientIDs)
+
+	idToken, err := s.Verify(ctx, f.tokens["id_token"].(string), nil)
+	require.NoError(t, err)
+	require.Equal(t, "sid-1", idToken.Claims["sid"])
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/end_session?"+url.Values{
+		"id_token_hint": {f.tokens["id_token"].(string)},
+	}.Encode(), nil)
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{Name: cookie.SessionCookieName, Value: "sid-1"})
+	f.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// delivery runs in the background, after the logout response
+	var logoutToken string
+	select {
+	case logoutToken = <-tokens:
+	case <-time.After(5 * time.Second):
+		t.Fatal("relying party did not receive a logout token")
+	}
+
+	res, err := s.Verify(ctx, logoutToken, &signer.VerifyOptions{RequireTyp: true, ExpectTyp: signer.TypeLogoutToken})
+	require.NoError(t, err)
+	require.Equal(t, "client-1", res.Claims["aud"])
+	require.Equal(t, "sid-1", res.Claims["sid"])
+	require.Equal(t, idToken.Claims["sub"], res.Claims["sub"])
+	require.Contains(t, res.Claims["events"], backchannel.EventLogout)
+
+	require.Eventually(t, func() bool {
+		records := deliveries.Records()
+		return len(records) == 1 && records[0].Status == backchannel.StatusDelivered
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, rp.URL, deliveries.Records()[0].URI)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	require.NoError(t, err)
+	f.router.ServeHTTP(w, req)
+
+	var discovery map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &discovery))
+	require.Equal(t, true, discovery["backchannel_logout_supported"])
+	require.Equal(t, true, discovery["backchannel_logout_session_supported"])
+}
@@ -0,0 +1,46 @@
+package idpproxy_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+func TestDiscoveryRoute_Returns200AndDocument(t *testing.T) {
+	t.Parallel()
+
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	githubAPIDeps := testhelpers.NewMockGitHubAPIDeps(logger)
+	githubOAuthDeps := testhelpers.NewMockGitHubOAuthDeps(logger)
+	googleDeps := testhelpers.NewMockGoogleDeps(logger)
+	systemDeps := testhelpers.NewMockSystemDeps(logger)
+
+	d := router.NewRouterDeps(public.PublicFS, githubAPIDeps, githubOAuthDeps, googleDeps, logger, systemDeps)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	r := router.NewRouter(d)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	require.NoError(t, err)
+
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	require.Equal(t, "https://idpproxy.example.com", doc["issuer"])
+	require.Equal(t, []any{"public"}, doc["subject_types_supported"])
+	require.Contains(t, doc, "scopes_supported")
+	require.Contains(t, doc, "claims_supported")
+}
@@ -0,0 +1,145 @@
+package idpproxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/deps"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
+)
+
+func TestEndSessionRoute_LogsOutAndRevokesTokens(t *testing.T) {
+	ctx := context.Background()
+	sessions := session.NewMemoryRepository()
+	require.NoError(t, sessions.Create(ctx, &session.Session{
+		SessionID: "sid-1",
+		UserID:    fixtureUserID,
+		Status:    "active",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+
+	f := newRevokeFixture(t, func(d *deps.OIDCDependencies) {
+		cfg := *d.Config
+		cfg.LogoutRevokesTokens = true
+		d.Config = &cfg
+		d.Sessions = sessions
+
+		require.NoError(t, d.Clients.(*client.MemoryRegistry).Save(ctx, &client.Client{
+			ID:                     "client-1",
+			Type:                   client.TypePublic,
+			RedirectURIs:           []string{"https://app.example.com/cb"},
+			PostLogoutRedirectURIs: []string{"https://app.example.com/bye"},
+		}))
+	})
+	require.Equal(t, http.StatusOK, f.userinfo(t))
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/end_session?"+url.Values{
+		"id_token_hint":            {f.tokens["id_token"].(string)},
+		"post_logout_redirect_uri": {"https://app.example.com/bye"},
+		"state":                    {"s-1"},
+	}.Encode(), nil)
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{Name: cookie.SessionCookieName, Value: "sid-1"})
+	f.router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	require.Equal(t, "https://app.example.com/bye?state=s-1", w.Header().Get("Location"))
+
+	s, err := sessions.FindByID(ctx, "sid-1")
+	require.NoError(t, err)
+	require.Equal(t, "inactive", s.Status)
+
+	id, _, err := refresh.ParseRefreshToken(f.tokens["refresh_token"].(string))
+	require.NoError(t, err)
+	require.Equal(t, refresh.RevokeReasonLogout, f.refresh.Records[id].RevokeReason)
+
+	require.Equal(t, http.StatusUnauthorized, f.userinfo(t))
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/end_session?"+url.Values{
+		"client_id":                {"client-1"},
+		"post_logout_redirect_uri": {"https://evil.example.com/"},
+	}.Encode(), nil)
+	require.NoError(t, err)
+	f.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	require.NoError(t, err)
+	f.router.ServeHTTP(w, req)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	require.Equal(t, "https://idpproxy.example.com/end_session", doc["end_session_endpoint"])
+}
+
+func TestEndSessionRoute_KeepsTokensByDefault(t *testing.T) {
+	f := newRevokeFixture(t, nil)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/end_session?"+url.Values{"id_token_hint": {f.tokens["id_token"].(string)}}.Encode(), nil)
+	require.NoError(t, err)
+	f.router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, http.StatusOK, f.userinfo(t))
+}
+
+func TestEndSessionRoute_CrossSiteLogoutNeedsConfirmation(t *testing.T) {
+	ctx := context.Background()
+	sessions := session.NewMemoryRepository()
+	require.NoError(t, sessions.Create(ctx, &session.Session{
+		SessionID: "sid-1",
+		UserID:    fixtureUserID,
+		Status:    "active",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+
+	f := newRevokeFixture(t, func(d *deps.OIDCDependencies) {
+		cfg := *d.Config
+		cfg.LogoutRevokesTokens = true
+		d.Config = &cfg
+		d.Sessions = sessions
+	})
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/end_session", nil)
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{Name: cookie.SessionCookieName, Value: "sid-1"})
+	f.router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `method="post"`)
+
+	s, err := sessions.FindByID(ctx, "sid-1")
+	require.NoError(t, err)
+	require.Equal(t, "active", s.Status)
+	require.Equal(t, http.StatusOK, f.userinfo(t))
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/end_session?"+url.Values{"id_token_hint": {f.tokens["id_token"].(string)}}.Encode(), nil)
+	require.NoError(t, err)
+	f.router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `method="post"`, "a hint without the session cookie is not enough")
+
+	s, err = sessions.FindByID(ctx, "sid-1")
+	require.NoError(t, err)
+	require.Equal(t, "active", s.Status)
+	require.Equal(t, http.StatusOK, f.userinfo(t))
+}
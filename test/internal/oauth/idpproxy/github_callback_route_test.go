@@ -0,0 +1,201 @@
+package idpproxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	authorizestore "github.com/vinylhousegarage/idpproxy/internal/authorize/store"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+type githubHTTPClient struct{}
+
+func (githubHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	body := `{"message":"not found"}`
+	status := http.StatusNotFound
+
+	u := *req.URL
+	u.RawQuery = ""
+
+	switch u.String() {
+	case config.GitHubTokenURL:
+		body, status = `{"access_token":"gh-access-token","token_type":"bearer"}`, http.StatusOK
+	case config.GitHubUserURL:
+		body, status = `{"id":12345,"login":"octocat","email":"octo@example.com"}`, http.StatusOK
+	case config.GitHubEmailsURL:
+		body, status = `[{"email":"octo@example.com","primary":true,"verified":true}]`, http.StatusOK
+	case config.GitHubOrgsURL:
+		body, status = `[{"login":"acme"}]`, http.StatusOK
+	case config.GitHubTeamsURL:
+		body, status = `[{"slug":"admins","organization":{"login":"acme"}}]`, http.StatusOK
+	}
+
+	h := make(http.Header)
+	h.Set("Content-Type", "application/json")
+
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: h, Request: req}, nil
+}
+
+func TestGitHubCallbackRoute_CompletesAuthorization(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	s, err := signer.NewEd25519Signer(newEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	userRepo := users.NewMemoryRepository()
+
+	d := router.NewRouterDeps(public.PublicFS,
+		testhelpers.NewMockGitHubAPIDepsWithClient(logger, githubHTTPClient{}),
+		testhelpers.NewMockGitHubOAuthDeps(logger),
+		testhelpers.NewMockGoogleDeps(logger),
+		logger,
+		testhelpers.NewMockSystemDeps(logger),
+	)
+	d.GitHubOAuth.Config.ReadOrg = true
+	d.GitHubOAuth.Config.ReadEmail = true
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Config.GitHubClaims = []string{config.ClaimGroups, config.ClaimGitHubOrgs}
+	d.OIDC.Signer = s
+	d.OIDC.ProxyCodes = authcodestore.NewMemoryStore()
+	d.OIDC.RefreshTokens = testhelpers.NewMockRefreshRepo()
+	d.OIDC.Authorizations = authorizestore.NewMemoryStore()
+	d.OIDC.Clients = client.NewMemoryRegistry(&client.Client{
+		ID:           "client-1",
+		Type:         client.TypePublic,
+		RedirectURIs: []string{"https://app.example.com/cb"},
+		Scopes:       []string{"openid", "groups"},
+	})
+	d.OIDC.Users = userRepo
+	r := router.NewRouter(d)
+
+	get := func(path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/authorize?"+url.Values{
+		"client_id":             {"client-1"},
+		"redirect_uri":          {"https://app.example.com/cb"},
+		"response_type":         {"code"},
+		"scope":                 {"openid groups"},
+		"state":                 {"client-state"},
+		"nonce":                 {"client-nonce"},
+		"idp_hint":              {"github"},
+		"code_challenge":        {pkce.S256Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}.Encode(), nil)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	require.Equal(t, "/github/login", w.Header().Get("Location"))
+	cookies := w.Result().Cookies()
+
+	w = get("/github/login", nil)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	loginURL, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	state := loginURL.Query().Get("state")
+	require.NotEmpty(t, state)
+	cookies = append(cookies, w.Result().Cookies()...)
+
+	w = get("/github/callback?"+url.Values{"code": {"gh-code"}, "state": {state}}.Encode(), cookies)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+
+	loc, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "app.example.com", loc.Host)
+	require.Equal(t, "client-state", loc.Query().Get("state"))
+	code := loc.Query().Get("code")
+	require.NotEmpty(t, code)
+
+	linked, err := userRepo.GetIdentity(context.Background(), users.ProviderGitHub, "12345")
+	require.NoError(t, err)
+	stored, err := userRepo.Get(context.Background(), linked.UserID)
+	require.NoError(t, err)
+	require.Equal(t, "octocat", stored.Login)
+	require.True(t, stored.EmailVerified, "primary email is verified via /user/emails")
+
+	w = httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/token", strings.NewReader(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"client_id":     {"client-1"},
+		"code_verifier": {verifier},
+		"redirect_uri":  {"https://app.example.com/cb"},
+	}.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var tokens map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+
+	idt, err := s.Verify(context.Background(), tokens["id_token"].(string), nil)
+	require.NoError(t, err)
+	require.Equal(t, linked.UserID, idt.Claims["sub"], "tokens carry the internal subject")
+	require.Equal(t, []any{"acme", "acme/admins"}, idt.Claims["groups"])
+	require.Equal(t, []any{"acme"}, idt.Claims["github_orgs"])
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/userinfo", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+tokens["access_token"].(string))
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var userinfo map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &userinfo))
+	require.Equal(t, []any{"acme", "acme/admins"}, userinfo["groups"])
+}
+
+func TestGitHubCallbackRoute_NotMountedWithoutUsers(t *testing.T) {
+	t.Parallel()
+
+	logger := zap.NewNop()
+
+	d := router.NewRouterDeps(public.PublicFS,
+		testhelpers.NewMockGitHubAPIDeps(logger),
+		testhelpers.NewMockGitHubOAuthDeps(logger),
+		testhelpers.NewMockGoogleDeps(logger),
+		logger,
+		testhelpers.NewMockSystemDeps(logger),
+	)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.ProxyCodes = authcodestore.NewMemoryStore()
+	r := router.NewRouter(d)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/github/callback?code=x&state=y", nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,36 @@
+package idpproxy_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+)
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return key
+}
+
+// testVerifier is the PKCE verifier bound to proxy codes that tests save directly.
+const testVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+func newPublicClients(ids ...string) *client.MemoryRegistry {
+	clients := make([]*client.Client, 0, len(ids))
+	for _, id := range ids {
+		clients = append(clients, &client.Client{
+			ID:           id,
+			Type:         client.TypePublic,
+			RedirectURIs: []string{"https://app.example.com/cb"},
+		})
+	}
+
+	return client.NewMemoryRegistry(clients...)
+}
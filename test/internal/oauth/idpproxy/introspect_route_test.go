@@ -0,0 +1,89 @@
+package idpproxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/deps"
+)
+
+const apiSecret = "YXBpIHJlc291cmNlIHNlcnZlciB0ZXN0IHNlY3JldCE"
+
+func TestIntrospectRoute(t *testing.T) {
+	hash, err := client.HashSecret(apiSecret)
+	require.NoError(t, err)
+
+	f := newRevokeFixture(t, func(d *deps.OIDCDependencies) {
+		require.NoError(t, d.Clients.(*client.MemoryRegistry).Save(context.Background(), &client.Client{
+			ID:             "internal-api",
+			Type:           client.TypeConfidential,
+			SecretHash:     hash,
+			RedirectURIs:   []string{"https://api.example.com/cb"},
+			ResourceServer: true,
+		}))
+	})
+
+	introspect := func(token string, basic bool) (*httptest.ResponseRecorder, map[string]any) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/introspect", strings.NewReader(url.Values{"token": {token}}.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if basic {
+			req.SetBasicAuth("internal-api", apiSecret)
+		}
+		f.router.ServeHTTP(w, req)
+
+		var body map[string]any
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		}
+		return w, body
+	}
+
+	w, body := introspect(f.tokens["access_token"].(string), true)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, true, body["active"])
+	require.Equal(t, fixtureUserID, body["sub"])
+	require.Equal(t, "client-1", body["client_id"])
+	require.Equal(t, "openid", body["scope"])
+	require.Equal(t, "access_token", body["token_type"])
+	require.NotZero(t, body["exp"])
+	require.NotZero(t, body["iat"])
+
+	rt := f.tokens["refresh_token"].(string)
+	w, body = introspect(rt, true)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, true, body["active"])
+	require.Equal(t, "refresh_token", body["token_type"])
+	require.NotEmpty(t, body["family_id"])
+
+	w = f.post(t, "/revoke", url.Values{"token": {rt}, "client_id": {"client-1"}})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w, body = introspect(rt, true)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, map[string]any{"active": false}, body)
+
+	w, _ = introspect(f.tokens["access_token"].(string), false)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = f.post(t, "/introspect", url.Values{"token": {rt}, "client_id": {"client-1"}})
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	require.NoError(t, err)
+	f.router.ServeHTTP(w, req)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	require.Equal(t, "https://idpproxy.example.com/introspect", doc["introspection_endpoint"])
+}
@@ -0,0 +1,67 @@
+package idpproxy_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+func TestJWKSRoute_PublishesSigningKeyAndIsAdvertised(t *testing.T) {
+	t.Parallel()
+
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	s, err := signer.NewECDSASigner(key, "ec-1")
+	require.NoError(t, err)
+
+	githubAPIDeps := testhelpers.NewMockGitHubAPIDeps(logger)
+	githubOAuthDeps := testhelpers.NewMockGitHubOAuthDeps(logger)
+	googleDeps := testhelpers.NewMockGoogleDeps(logger)
+	systemDeps := testhelpers.NewMockSystemDeps(logger)
+
+	d := router.NewRouterDeps(public.PublicFS, githubAPIDeps, githubOAuthDeps, googleDeps, logger, systemDeps)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Signer = s
+	r := router.NewRouter(d)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var set signer.JWKS
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+	require.Len(t, set.Keys, 1)
+	require.Equal(t, "ec-1", set.Keys[0].Kid)
+	require.Equal(t, "P-256", set.Keys[0].Crv)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	require.Equal(t, "https://idpproxy.example.com/.well-known/jwks.json", doc["jwks_uri"])
+	require.Equal(t, []any{"ES256"}, doc["id_token_signing_alg_values_supported"])
+}
@@ -0,0 +1,116 @@
+package idpproxy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	authorizestore "github.com/vinylhousegarage/idpproxy/internal/authorize/store"
+	"github.com/vinylhousegarage/idpproxy/internal/client"
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+func TestLoginPolicyRoute_DeniedIdentityGetsAccessDenied(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	logger := zap.NewNop()
+	upstream := testhelpers.NewFakeOIDCServer(t, "upstream-client", "upstream-secret")
+	// a non-Google issuer cannot vouch for a Workspace domain
+	upstream.ClaimsHook = func(c jwt.MapClaims) { c["hd"] = "acme.com" }
+
+	s, err := signer.NewEd25519Signer(newEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	d := router.NewRouterDeps(public.PublicFS,
+		testhelpers.NewMockGitHubAPIDeps(logger),
+		testhelpers.NewMockGitHubOAuthDeps(logger),
+		testhelpers.NewMockGoogleDeps(logger),
+		logger,
+		testhelpers.NewMockSystemDeps(logger),
+	)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Config.Upstreams = []config.UpstreamOIDCConfig{{
+		ID:           "corp",
+		Name:         "Corp SSO",
+		Issuer:       upstream.Issuer(),
+		ClientID:     "upstream-client",
+		ClientSecret: "upstream-secret",
+		RedirectURI:  "https://idpproxy.example.com/oidc/corp/callback",
+		Scopes:       config.UpstreamOIDCDefaultScopes,
+	}}
+	d.OIDC.HTTPClient = upstream.Client()
+	d.OIDC.Signer = s
+	d.OIDC.ProxyCodes = authcodestore.NewMemoryStore()
+	d.OIDC.RefreshTokens = testhelpers.NewMockRefreshRepo()
+	d.OIDC.Authorizations = authorizestore.NewMemoryStore()
+	d.OIDC.Clients = client.NewMemoryRegistry(&client.Client{
+		ID:           "client-1",
+		Type:         client.TypePublic,
+		RedirectURIs: []string{"https://app.example.com/cb"},
+		LoginPolicy:  client.LoginPolicy{GoogleDomains: []string{"acme.com"}},
+	})
+	d.OIDC.Users = users.NewMemoryRepository()
+	r := router.NewRouter(d)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/authorize?"+url.Values{
+		"client_id":             {"client-1"},
+		"redirect_uri":          {"https://app.example.com/cb"},
+		"response_type":         {"code"},
+		"scope":                 {"openid"},
+		"state":                 {"client-state"},
+		"code_challenge":        {pkce.S256Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")},
+		"code_challenge_method": {"S256"},
+		"idp_hint":              {"corp"},
+	}.Encode(), nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	cookies := w.Result().Cookies()
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/oidc/corp/login", nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	cookies = append(cookies, w.Result().Cookies()...)
+
+	hc := *upstream.Client()
+	hc.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := hc.Get(w.Header().Get("Location"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callbackURL, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, callbackURL.RequestURI(), nil)
+	require.NoError(t, err)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	loc, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "app.example.com", loc.Host)
+	require.Equal(t, "access_denied", loc.Query().Get("error"))
+	require.Equal(t, "client-state", loc.Query().Get("state"))
+	require.Empty(t, loc.Query().Get("code"))
+}
@@ -0,0 +1,118 @@
+package idpproxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/authcode"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+func TestTokenRoute_PKCE(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	s, err := signer.NewEd25519Signer(newEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	proxyCodes := authcodestore.NewMemoryStore()
+	for _, code := range []string{"code-no-verifier", "code-with-verifier"} {
+		require.NoError(t, proxyCodes.Save(context.Background(), authcode.ProxyCode{
+			Code:                code,
+			UserID:              "user-1",
+			ClientID:            "client-1",
+			CodeChallenge:       pkce.S256Challenge(verifier),
+			CodeChallengeMethod: pkce.MethodS256,
+			ExpiresAt:           time.Now().Add(time.Minute),
+		}))
+	}
+
+	githubAPIDeps := testhelpers.NewMockGitHubAPIDeps(logger)
+	githubOAuthDeps := testhelpers.NewMockGitHubOAuthDeps(logger)
+	googleDeps := testhelpers.NewMockGoogleDeps(logger)
+	systemDeps := testhelpers.NewMockSystemDeps(logger)
+
+	d := router.NewRouterDeps(public.PublicFS, githubAPIDeps, githubOAuthDeps, googleDeps, logger, systemDeps)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Signer = s
+	d.OIDC.ProxyCodes = proxyCodes
+	d.OIDC.RefreshTokens = testhelpers.NewMockRefreshRepo()
+	d.OIDC.Clients = newPublicClients("client-1")
+	r := router.NewRouter(d)
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(url.Values{
+		"grant_type": {"authorization_code"},
+		"code":       {"code-no-verifier"},
+		"client_id":  {"client-1"},
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"error":"invalid_grant"}`, w.Body.String())
+
+	w = post(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"code-with-verifier"},
+		"client_id":     {"client-1"},
+		"code_verifier": {verifier},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	require.Equal(t, []any{"S256", "plain"}, doc["code_challenge_methods_supported"])
+}
+
+func TestLoginRoute_RejectsInvalidCodeChallenge(t *testing.T) {
+	t.Parallel()
+
+	logger := zap.NewNop()
+
+	githubAPIDeps := testhelpers.NewMockGitHubAPIDeps(logger)
+	githubOAuthDeps := testhelpers.NewMockGitHubOAuthDeps(logger)
+	googleDeps := testhelpers.NewMockGoogleDeps(logger)
+	systemDeps := testhelpers.NewMockSystemDeps(logger)
+
+	d := router.NewRouterDeps(public.PublicFS, githubAPIDeps, githubOAuthDeps, googleDeps, logger, systemDeps)
+	r := router.NewRouter(d)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/github/login?code_challenge=short&code_challenge_method=S256", nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "invalid_code_challenge")
+}
@@ -0,0 +1,200 @@
+package idpproxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/authcode"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/deps"
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+// fixtureUserID is the internal subject of the fixture's GitHub user.
+const fixtureUserID = "4b1c7a52-9a0e-4a53-8f0e-2f6c3d9b1e11"
+
+type revokeFixture struct {
+	router      *gin.Engine
+	refresh     *testhelpers.MockRefreshRepo
+	generations *testhelpers.MockAccessGenRepo
+	denylist    *testhelpers.MockAccessDenylist
+	tokens      map[string]any
+}
+
+func newRevokeFixture(t *testing.T, configure func(*deps.OIDCDependencies)) *revokeFixture {
+	t.Helper()
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	s, err := signer.NewEd25519Signer(newEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	userRepo := users.NewMemoryRepository()
+	require.NoError(t, userRepo.Upsert(ctx, &users.User{
+		ID:             fixtureUserID,
+		Provider:       users.ProviderGitHub,
+		ProviderUserID: "12345",
+		Login:          "octocat",
+		Email:          "octo@example.com",
+	}))
+
+	proxyCodes := authcodestore.NewMemoryStore()
+	require.NoError(t, proxyCodes.Save(ctx, authcode.ProxyCode{
+		Code:                "proxy-code-1",
+		UserID:              fixtureUserID,
+		ClientID:            "client-1",
+		CodeChallenge:       pkce.S256Challenge(testVerifier),
+		CodeChallengeMethod: pkce.MethodS256,
+		Scope:               "openid",
+		SessionID:           "sid-1",
+		ExpiresAt:           time.Now().Add(time.Minute),
+	}))
+
+	f := &revokeFixture{
+		refresh:     testhelpers.NewMockRefreshRepo(),
+		generations: testhelpers.NewMockAccessGenRepo(),
+		denylist:    testhelpers.NewMockAccessDenylist(),
+	}
+
+	d := router.NewRouterDeps(public.PublicFS,
+		testhelpers.NewMockGitHubAPIDeps(logger),
+		testhelpers.NewMockGitHubOAuthDeps(logger),
+		testhelpers.NewMockGoogleDeps(logger),
+		logger,
+		testhelpers.NewMockSystemDeps(logger),
+	)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Signer = s
+	d.OIDC.ProxyCodes = proxyCodes
+	d.OIDC.RefreshTokens = f.refresh
+	d.OIDC.AccessGenerations = f.generations
+	d.OIDC.AccessDenylist = f.denylist
+	d.OIDC.Clients = newPublicClients("client-1", "client-2")
+	d.OIDC.Users = userRepo
+	if configure != nil {
+		configure(d.OIDC)
+	}
+	f.router = router.NewRouter(d)
+
+	w := f.post(t, "/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"proxy-code-1"},
+		"client_id":     {"client-1"},
+		"code_verifier": {testVerifier},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &f.tokens))
+
+	return f
+}
+
+func (f *revokeFixture) post(t *testing.T, path string, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	f.router.ServeHTTP(w, req)
+
+	return w
+}
+
+func (f *revokeFixture) userinfo(t *testing.T) int {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/userinfo", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+f.tokens["access_token"].(string))
+	f.router.ServeHTTP(w, req)
+
+	return w.Code
+}
+
+func TestRevokeRoute_RefreshTokenRevokesFamily(t *testing.T) {
+	f := newRevokeFixture(t, nil)
+	rt := f.tokens["refresh_token"].(string)
+
+	w := f.post(t, "/revoke", url.Values{"token": {rt}, "token_type_hint": {"refresh_token"}, "client_id": {"client-1"}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	id, _, err := refresh.ParseRefreshToken(rt)
+	require.NoError(t, err)
+	require.Equal(t, refresh.RevokeReasonClient, f.refresh.Records[id].RevokeReason)
+
+	w = f.post(t, "/token", url.Values{"grant_type": {"refresh_token"}, "refresh_token": {rt}, "client_id": {"client-1"}})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRevokeRoute_AccessTokenBumpsGeneration(t *testing.T) {
+	f := newRevokeFixture(t, nil)
+	require.Equal(t, http.StatusOK, f.userinfo(t))
+
+	w := f.post(t, "/revoke", url.Values{"token": {f.tokens["access_token"].(string)}, "client_id": {"client-1"}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.Equal(t, http.StatusUnauthorized, f.userinfo(t))
+	require.Equal(t, 1, f.generations.Records[fixtureUserID].Gen)
+	require.Empty(t, f.denylist.Records)
+}
+
+func TestRevokeRoute_AccessTokenDenylist(t *testing.T) {
+	f := newRevokeFixture(t, func(d *deps.OIDCDependencies) {
+		cfg := *d.Config
+		cfg.AccessTokenRevocation = config.AccessTokenRevocationDenylist
+		d.Config = &cfg
+	})
+	require.Equal(t, http.StatusOK, f.userinfo(t))
+
+	w := f.post(t, "/revoke", url.Values{"token": {f.tokens["access_token"].(string)}, "client_id": {"client-1"}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.Equal(t, http.StatusUnauthorized, f.userinfo(t))
+	require.Len(t, f.denylist.Records, 1)
+	require.Empty(t, f.generations.Records)
+}
+
+func TestRevokeRoute_UnknownTokenAndClientAuth(t *testing.T) {
+	f := newRevokeFixture(t, nil)
+
+	w := f.post(t, "/revoke", url.Values{"token": {"not-a-token"}, "client_id": {"client-1"}})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = f.post(t, "/revoke", url.Values{"token": {"not-a-token"}})
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = f.post(t, "/revoke", url.Values{"token": {f.tokens["access_token"].(string)}, "client_id": {"client-2"}})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"error":"unauthorized_client"}`, w.Body.String())
+	require.Equal(t, http.StatusOK, f.userinfo(t))
+
+	w = httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	require.NoError(t, err)
+	f.router.ServeHTTP(w, req)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	require.Equal(t, "https://idpproxy.example.com/revoke", doc["revocation_endpoint"])
+}
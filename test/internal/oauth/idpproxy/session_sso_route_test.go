@@ -0,0 +1,153 @@
+package idpproxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/session"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	authorizestore "github.com/vinylhousegarage/idpproxy/internal/authorize/store"
+	"github.com/vinylhousegarage/idpproxy/internal/oauth/cookie"
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+func TestSessionSSORoute_SecondClientSkipsUpstreamLogin(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	s, err := signer.NewEd25519Signer(newEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	sessions := session.NewMemoryRepository()
+
+	d := router.NewRouterDeps(public.PublicFS,
+		testhelpers.NewMockGitHubAPIDeps(logger),
+		testhelpers.NewMockGitHubOAuthDeps(logger),
+		testhelpers.NewMockGoogleDeps(logger),
+		logger,
+		testhelpers.NewMockSystemDeps(logger),
+	)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Config.GoogleLoginURL = "https://idpproxy.example.com/google/login"
+	d.OIDC.Signer = s
+	d.OIDC.ProxyCodes = authcodestore.NewMemoryStore()
+	d.OIDC.RefreshTokens = testhelpers.NewMockRefreshRepo()
+	d.OIDC.Authorizations = authorizestore.NewMemoryStore()
+	d.OIDC.Clients = newPublicClients("client-1", "client-2")
+	d.OIDC.Sessions = sessions
+	r := router.NewRouter(d)
+
+	authorizeURL := func(clientID string, extra url.Values) string {
+		q := url.Values{
+			"client_id":             {clientID},
+			"redirect_uri":          {"https://app.example.com/cb"},
+			"response_type":         {"code"},
+			"scope":                 {"openid"},
+			"state":                 {"st-" + clientID},
+			"code_challenge":        {pkce.S256Challenge(verifier)},
+			"code_challenge_method": {"S256"},
+		}
+		for k, v := range extra {
+			q[k] = v
+		}
+
+		return "/authorize?" + q.Encode()
+	}
+
+	findCookie := func(w *httptest.ResponseRecorder, name string) *http.Cookie {
+		for _, c := range w.Result().Cookies() {
+			if c.Name == name {
+				return c
+			}
+		}
+		return nil
+	}
+
+	// first client: upstream login starts the session
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, authorizeURL("client-1", nil), nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	authzCookie := findCookie(w, "idpproxy_authorize")
+	require.NotNil(t, authzCookie)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodPost, "/google/login/firebase", strings.NewReader(`{"id_token":"dummy"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(authzCookie)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	sessionCookie := findCookie(w, cookie.SessionCookieName)
+	require.NotNil(t, sessionCookie)
+	require.True(t, sessionCookie.HttpOnly)
+
+	sess, err := sessions.FindByID(ctx, sessionCookie.Value)
+	require.NoError(t, err)
+	require.Equal(t, "google:test-user", sess.UserID)
+
+	// second client: the session cookie is enough
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, authorizeURL("client-2", url.Values{"prompt": {"none"}}), nil)
+	require.NoError(t, err)
+	req.AddCookie(sessionCookie)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+
+	loc, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "st-client-2", loc.Query().Get("state"))
+	code := loc.Query().Get("code")
+	require.NotEmpty(t, code)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodPost, "/token", strings.NewReader(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"client_id":     {"client-2"},
+		"code_verifier": {verifier},
+		"redirect_uri":  {"https://app.example.com/cb"},
+	}.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var tokens map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+
+	idt, err := s.Verify(ctx, tokens["id_token"].(string), nil)
+	require.NoError(t, err)
+	require.Equal(t, "google:test-user", idt.Claims["sub"])
+	require.Equal(t, "client-2", idt.Claims["aud"])
+	require.Equal(t, sessionCookie.Value, idt.Claims["sid"])
+	require.EqualValues(t, sess.CreatedAt.Unix(), idt.Claims["auth_time"])
+
+	// prompt=login ignores the session
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, authorizeURL("client-2", url.Values{"prompt": {"login"}}), nil)
+	require.NoError(t, err)
+	req.AddCookie(sessionCookie)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `href="/github/login"`)
+}
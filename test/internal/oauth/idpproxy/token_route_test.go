@@ -0,0 +1,167 @@
+package idpproxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/refresh"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/authcode"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+func TestTokenRoute_ExchangesProxyCodeForTokens(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	s, err := signer.NewEd25519Signer(newEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	proxyCodes := authcodestore.NewMemoryStore()
+	require.NoError(t, proxyCodes.Save(context.Background(), authcode.ProxyCode{
+		Code:                "proxy-code-1",
+		UserID:              "github:7f1c6a0e-5e7c-4a53-9c4f-0f4b7c2a1d11",
+		ClientID:            "client-1",
+		CodeChallenge:       pkce.S256Challenge(testVerifier),
+		CodeChallengeMethod: pkce.MethodS256,
+		ExpiresAt:           time.Now().Add(time.Minute),
+	}))
+	refreshRepo := testhelpers.NewMockRefreshRepo()
+
+	githubAPIDeps := testhelpers.NewMockGitHubAPIDeps(logger)
+	githubOAuthDeps := testhelpers.NewMockGitHubOAuthDeps(logger)
+	googleDeps := testhelpers.NewMockGoogleDeps(logger)
+	systemDeps := testhelpers.NewMockSystemDeps(logger)
+
+	d := router.NewRouterDeps(public.PublicFS, githubAPIDeps, githubOAuthDeps, googleDeps, logger, systemDeps)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Signer = s
+	d.OIDC.ProxyCodes = proxyCodes
+	d.OIDC.RefreshTokens = refreshRepo
+	d.OIDC.Clients = newPublicClients("client-1")
+	r := router.NewRouter(d)
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"proxy-code-1"},
+		"client_id":     {"client-1"},
+		"code_verifier": {testVerifier},
+	}
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(form)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, "Bearer", resp["token_type"])
+	require.Equal(t, "openid", resp["scope"])
+	require.NotEmpty(t, resp["access_token"])
+	require.NotEmpty(t, resp["expires_in"])
+	require.True(t, strings.HasPrefix(resp["refresh_token"].(string), "rt1."))
+
+	idt, err := s.Verify(context.Background(), resp["id_token"].(string), nil)
+	require.NoError(t, err)
+	require.Equal(t, "https://idpproxy.example.com", idt.Claims["iss"])
+	require.Equal(t, "github:7f1c6a0e-5e7c-4a53-9c4f-0f4b7c2a1d11", idt.Claims["sub"])
+	require.Equal(t, "client-1", idt.Claims["aud"])
+
+	require.Len(t, refreshRepo.Records, 1)
+
+	w = post(form)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"error":"invalid_grant"}`, w.Body.String())
+
+	for _, rec := range refreshRepo.Records {
+		require.False(t, rec.RevokedAt.IsZero(), "refresh token minted from a replayed code must be revoked")
+		require.Equal(t, refresh.RevokeReasonCodeReplay, rec.RevokeReason)
+	}
+}
+
+func TestTokenRoute_RefreshTokenRotationAndReuse(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	logger := zap.NewNop()
+
+	s, err := signer.NewEd25519Signer(newEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	proxyCodes := authcodestore.NewMemoryStore()
+	require.NoError(t, proxyCodes.Save(context.Background(), authcode.ProxyCode{
+		Code:                "proxy-code-1",
+		UserID:              "github:7f1c6a0e-5e7c-4a53-9c4f-0f4b7c2a1d11",
+		ClientID:            "mobile",
+		CodeChallenge:       pkce.S256Challenge(testVerifier),
+		CodeChallengeMethod: pkce.MethodS256,
+		ExpiresAt:           time.Now().Add(time.Minute),
+	}))
+
+	d := router.NewRouterDeps(public.PublicFS,
+		testhelpers.NewMockGitHubAPIDeps(logger),
+		testhelpers.NewMockGitHubOAuthDeps(logger),
+		testhelpers.NewMockGoogleDeps(logger),
+		logger,
+		testhelpers.NewMockSystemDeps(logger),
+	)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Signer = s
+	d.OIDC.ProxyCodes = proxyCodes
+	d.OIDC.RefreshTokens = testhelpers.NewMockRefreshRepo()
+	d.OIDC.Clients = newPublicClients("mobile")
+	r := router.NewRouter(d)
+
+	post := func(form url.Values) (int, map[string]any) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.ServeHTTP(w, req)
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+	refreshForm := func(rt any) url.Values {
+		return url.Values{"grant_type": {"refresh_token"}, "refresh_token": {rt.(string)}, "client_id": {"mobile"}}
+	}
+
+	code, first := post(url.Values{"grant_type": {"authorization_code"}, "code": {"proxy-code-1"}, "client_id": {"mobile"}, "code_verifier": {testVerifier}})
+	require.Equal(t, http.StatusOK, code)
+
+	code, second := post(refreshForm(first["refresh_token"]))
+	require.Equal(t, http.StatusOK, code)
+	require.NotEqual(t, first["refresh_token"], second["refresh_token"])
+
+	code, body := post(refreshForm(first["refresh_token"]))
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, "invalid_grant", body["error"])
+
+	code, body = post(refreshForm(second["refresh_token"]))
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, "invalid_grant", body["error"])
+}
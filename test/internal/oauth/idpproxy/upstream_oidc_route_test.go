@@ -0,0 +1,142 @@
+package idpproxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	authorizestore "github.com/vinylhousegarage/idpproxy/internal/authorize/store"
+	"github.com/vinylhousegarage/idpproxy/internal/config"
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+func TestUpstreamOIDCRoute_LoginThroughConfiguredProvider(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	logger := zap.NewNop()
+	upstream := testhelpers.NewFakeOIDCServer(t, "upstream-client", "upstream-secret")
+
+	s, err := signer.NewEd25519Signer(newEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	d := router.NewRouterDeps(public.PublicFS,
+		testhelpers.NewMockGitHubAPIDeps(logger),
+		testhelpers.NewMockGitHubOAuthDeps(logger),
+		testhelpers.NewMockGoogleDeps(logger),
+		logger,
+		testhelpers.NewMockSystemDeps(logger),
+	)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Config.Upstreams = []config.UpstreamOIDCConfig{{
+		ID:           "corp",
+		Name:         "Corp SSO",
+		Issuer:       upstream.Issuer(),
+		ClientID:     "upstream-client",
+		ClientSecret: "upstream-secret",
+		RedirectURI:  "https://idpproxy.example.com/oidc/corp/callback",
+		Scopes:       config.UpstreamOIDCDefaultScopes,
+	}}
+	d.OIDC.HTTPClient = upstream.Client()
+	d.OIDC.Signer = s
+	d.OIDC.ProxyCodes = authcodestore.NewMemoryStore()
+	d.OIDC.RefreshTokens = testhelpers.NewMockRefreshRepo()
+	d.OIDC.Authorizations = authorizestore.NewMemoryStore()
+	d.OIDC.Clients = newPublicClients("client-1")
+	d.OIDC.Users = users.NewMemoryRepository()
+	r := router.NewRouter(d)
+
+	q := url.Values{
+		"client_id":             {"client-1"},
+		"redirect_uri":          {"https://app.example.com/cb"},
+		"response_type":         {"code"},
+		"scope":                 {"openid"},
+		"state":                 {"client-state"},
+		"code_challenge":        {pkce.S256Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+		"idp_hint":              {"corp"},
+	}
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/authorize?"+q.Encode(), nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	require.Equal(t, "/oidc/corp/login", w.Header().Get("Location"))
+	cookies := w.Result().Cookies()
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/oidc/corp/login", nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	require.True(t, strings.HasPrefix(w.Header().Get("Location"), upstream.URL+"/authorize?"))
+	cookies = append(cookies, w.Result().Cookies()...)
+
+	client := *upstream.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.Get(w.Header().Get("Location"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callbackURL, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "/oidc/corp/callback", callbackURL.Path)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, callbackURL.RequestURI(), nil)
+	require.NoError(t, err)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	loc, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "app.example.com", loc.Host)
+	require.Equal(t, "client-state", loc.Query().Get("state"))
+	code := loc.Query().Get("code")
+	require.NotEmpty(t, code)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodPost, "/token", strings.NewReader(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"client_id":     {"client-1"},
+		"code_verifier": {verifier},
+		"redirect_uri":  {"https://app.example.com/cb"},
+	}.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var tokens map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+
+	idt, err := s.Verify(context.Background(), tokens["id_token"].(string), nil)
+	require.NoError(t, err)
+	linked, err := d.OIDC.Users.GetIdentity(context.Background(), "corp", "upstream-user-1")
+	require.NoError(t, err)
+	require.Equal(t, linked.UserID, idt.Claims["sub"])
+}
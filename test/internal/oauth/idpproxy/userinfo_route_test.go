@@ -0,0 +1,112 @@
+package idpproxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+	"github.com/vinylhousegarage/idpproxy/internal/authcode"
+	authcodestore "github.com/vinylhousegarage/idpproxy/internal/authcode/store"
+	"github.com/vinylhousegarage/idpproxy/internal/router"
+	"github.com/vinylhousegarage/idpproxy/internal/users"
+	"github.com/vinylhousegarage/idpproxy/public"
+	"github.com/vinylhousegarage/idpproxy/test/testhelpers"
+)
+
+func TestUserInfoRoute_ReturnsClaimsForAccessToken(t *testing.T) {
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_ID", "pepper-1")
+	t.Setenv("IDPPROXY_REFRESH_PEPPER_KEY_MATERIAL", "pepper-material")
+
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	s, err := signer.NewEd25519Signer(newEd25519Key(t), "ed-1")
+	require.NoError(t, err)
+
+	userRepo := users.NewMemoryRepository()
+	userID, err := users.NewService(userRepo).UpsertFromGoogle(ctx, users.GoogleProfile{
+		UID:           "uid-1",
+		Name:          "Jane Doe",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+	})
+	require.NoError(t, err)
+
+	proxyCodes := authcodestore.NewMemoryStore()
+	require.NoError(t, proxyCodes.Save(ctx, authcode.ProxyCode{
+		Code:                "proxy-code-1",
+		UserID:              userID,
+		ClientID:            "client-1",
+		CodeChallenge:       pkce.S256Challenge(testVerifier),
+		CodeChallengeMethod: pkce.MethodS256,
+		Scope:               "openid email",
+		ExpiresAt:           time.Now().Add(time.Minute),
+	}))
+
+	d := router.NewRouterDeps(public.PublicFS,
+		testhelpers.NewMockGitHubAPIDeps(logger),
+		testhelpers.NewMockGitHubOAuthDeps(logger),
+		testhelpers.NewMockGoogleDeps(logger),
+		logger,
+		testhelpers.NewMockSystemDeps(logger),
+	)
+	d.OIDC = testhelpers.NewMockOIDCDeps(logger)
+	d.OIDC.Signer = s
+	d.OIDC.ProxyCodes = proxyCodes
+	d.OIDC.RefreshTokens = testhelpers.NewMockRefreshRepo()
+	d.OIDC.Clients = newPublicClients("client-1")
+	d.OIDC.Users = userRepo
+	r := router.NewRouter(d)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/token", strings.NewReader(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"proxy-code-1"},
+		"client_id":     {"client-1"},
+		"code_verifier": {testVerifier},
+	}.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var tokens map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/userinfo", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+tokens["access_token"].(string))
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.JSONEq(t, `{"sub":"`+userID+`","email":"jane@example.com","email_verified":true}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/userinfo", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+tokens["id_token"].(string))
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	require.NoError(t, err)
+	r.ServeHTTP(w, req)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	require.Equal(t, "https://idpproxy.example.com/userinfo", doc["userinfo_endpoint"])
+	require.Contains(t, doc["scopes_supported"], "email")
+}
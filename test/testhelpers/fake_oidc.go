@@ -0,0 +1,190 @@
+package testhelpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/vinylhousegarage/idpproxy/internal/auth/pkce"
+	"github.com/vinylhousegarage/idpproxy/internal/auth/signer"
+)
+
+// ---- Fake upstream OpenID Provider ----
+
+// FakeOIDCServer is an in-process OpenID Provider with discovery, JWKS,
+// an auto-approving /authorize and a PKCE-checking /token endpoint.
+type FakeOIDCServer struct {
+	*httptest.Server
+
+	ClientID     string
+	ClientSecret string
+	Subject      string
+	KeyID        string
+
+	// mutate the ID token claims before signing
+	ClaimsHook func(claims jwt.MapClaims)
+
+	key   *ecdsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]fakeOIDCGrant
+}
+
+type fakeOIDCGrant struct {
+	nonce       string
+	challenge   string
+	redirectURI string
+}
+
+func NewFakeOIDCServer(t testing.TB, clientID, clientSecret string) *FakeOIDCServer {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	s := &FakeOIDCServer{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Subject:      "upstream-user-1",
+		KeyID:        "fake-1",
+		key:          key,
+		codes:        make(map[string]fakeOIDCGrant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/jwks", s.jwks)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *FakeOIDCServer) Issuer() string {
+	return s.URL
+}
+
+func (s *FakeOIDCServer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{signer.AlgES256},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic"},
+	})
+}
+
+func (s *FakeOIDCServer) jwks(w http.ResponseWriter, _ *http.Request) {
+	jwk, err := signer.NewPublicJWK(&s.key.PublicKey, signer.AlgES256, s.KeyID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, signer.JWKS{Keys: []signer.JWK{jwk}})
+}
+
+func (s *FakeOIDCServer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != s.ClientID || q.Get("response_type") != "code" ||
+		q.Get("code_challenge_method") != pkce.MethodS256 {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomCode()
+
+	s.mu.Lock()
+	s.codes[code] = fakeOIDCGrant{
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+		redirectURI: q.Get("redirect_uri"),
+	}
+	s.mu.Unlock()
+
+	v := url.Values{"code": {code}, "state": {q.Get("state")}}
+	http.Redirect(w, r, q.Get("redirect_uri")+"?"+v.Encode(), http.StatusFound)
+}
+
+func (s *FakeOIDCServer) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != s.ClientID || secret != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostFormValue("code")
+
+	s.mu.Lock()
+	grant, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" ||
+		r.PostFormValue("redirect_uri") != grant.redirectURI ||
+		pkce.Verify(r.PostFormValue("code_verifier"), grant.challenge, pkce.MethodS256) != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                s.URL,
+		"sub":                s.Subject,
+		"aud":                s.ClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              grant.nonce,
+		"email":              "jane@example.com",
+		"email_verified":     true,
+		"name":               "Jane Doe",
+		"preferred_username": "jane",
+	}
+	if s.ClaimsHook != nil {
+		s.ClaimsHook(claims)
+	}
+
+	tok := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	tok.Header["kid"] = s.KeyID
+
+	idToken, err := tok.SignedString(s.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "upstream-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func randomCode() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
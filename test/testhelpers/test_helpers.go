@@ -5,10 +5,13 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	firebaseauth "firebase.google.com/go/v4/auth"
 	"go.uber.org/zap"
 
+	"github.com/vinylhousegarage/idpproxy/internal/auth/store"
 	"github.com/vinylhousegarage/idpproxy/internal/config"
 	"github.com/vinylhousegarage/idpproxy/internal/deps"
 	"github.com/vinylhousegarage/idpproxy/internal/httpclient"
@@ -25,6 +28,18 @@ func NewMockSystemDeps(logger *zap.Logger) *deps.SystemDependencies {
 	}
 }
 
+// ---- OIDC deps mock ----
+
+func NewMockOIDCDeps(logger *zap.Logger) *deps.OIDCDependencies {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &deps.OIDCDependencies{
+		Config: &config.OIDCConfig{Issuer: "https://idpproxy.example.com"},
+		Logger: logger,
+	}
+}
+
 // ---- Firebase Verifier mock ----
 
 type MockVerifier struct {
@@ -127,3 +142,216 @@ func NewMockGitHubAPIDepsWithClient(logger *zap.Logger, client httpclient.HTTPCl
 		UserAgent:  "idpproxy-test",
 	}
 }
+
+// ---- Refresh token repo mock (implements store.RefreshRepo) ----
+
+var _ store.RefreshRepo = (*MockRefreshRepo)(nil)
+
+type MockRefreshRepo struct {
+	mu      sync.Mutex
+	Records map[string]*store.RefreshTokenRecord
+}
+
+func NewMockRefreshRepo() *MockRefreshRepo {
+	return &MockRefreshRepo{Records: make(map[string]*store.RefreshTokenRecord)}
+}
+
+func (m *MockRefreshRepo) Create(ctx context.Context, rec *store.RefreshTokenRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.Records[rec.RefreshID]; ok {
+		return store.ErrConflict
+	}
+	cp := *rec
+	m.Records[rec.RefreshID] = &cp
+	return nil
+}
+
+func (m *MockRefreshRepo) GetByID(ctx context.Context, refreshID string) (*store.RefreshTokenRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.Records[refreshID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	cp := *rec
+	return &cp, nil
+}
+
+func (m *MockRefreshRepo) MarkUsed(ctx context.Context, refreshID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.Records[refreshID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	rec.LastUsedAt = time.Now()
+	return nil
+}
+
+func (m *MockRefreshRepo) Revoke(ctx context.Context, refreshID, reason string, t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.Records[refreshID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if !rec.RevokedAt.IsZero() {
+		return store.ErrAlreadyRevoked
+	}
+	rec.RevokedAt = t
+	rec.RevokeReason = reason
+	return nil
+}
+
+func (m *MockRefreshRepo) Replace(ctx context.Context, oldID string, newRec *store.RefreshTokenRecord, t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.Records[oldID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if !old.RevokedAt.IsZero() || old.ReplacedBy != "" || old.UserID != newRec.UserID {
+		return store.ErrConflict
+	}
+	if _, ok := m.Records[newRec.RefreshID]; ok {
+		return store.ErrConflict
+	}
+
+	old.ReplacedBy = newRec.RefreshID
+	old.RevokedAt = t
+	newRec.FamilyID = old.FamilyID
+	newRec.CreatedAt = t
+	cp := *newRec
+	m.Records[newRec.RefreshID] = &cp
+	return nil
+}
+
+func (m *MockRefreshRepo) RevokeFamily(ctx context.Context, familyID, reason string, t time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, rec := range m.Records {
+		if rec.FamilyID != familyID || !rec.RevokedAt.IsZero() {
+			continue
+		}
+		rec.RevokedAt = t
+		rec.RevokeReason = reason
+		n++
+	}
+	return n, nil
+}
+
+func (m *MockRefreshRepo) RevokeUser(ctx context.Context, userID, reason string, t time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, rec := range m.Records {
+		if rec.UserID != userID || !rec.RevokedAt.IsZero() {
+			continue
+		}
+		rec.RevokedAt = t
+		rec.RevokeReason = reason
+		n++
+	}
+	return n, nil
+}
+
+func (m *MockRefreshRepo) DeleteExpired(ctx context.Context, until time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for id, rec := range m.Records {
+		if !rec.DeleteAt.IsZero() && !rec.DeleteAt.After(until) {
+			delete(m.Records, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// ---- Access generation repo mock (implements store.AccessGenRepo) ----
+
+var _ store.AccessGenRepo = (*MockAccessGenRepo)(nil)
+
+type MockAccessGenRepo struct {
+	mu      sync.Mutex
+	Records map[string]*store.AccessGenerationRecord
+}
+
+func NewMockAccessGenRepo() *MockAccessGenRepo {
+	return &MockAccessGenRepo{Records: make(map[string]*store.AccessGenerationRecord)}
+}
+
+func (m *MockAccessGenRepo) Get(ctx context.Context, userID string) (*store.AccessGenerationRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.Records[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	cp := *rec
+	return &cp, nil
+}
+
+func (m *MockAccessGenRepo) Set(ctx context.Context, rec *store.AccessGenerationRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cp := *rec
+	m.Records[rec.UserID] = &cp
+	return nil
+}
+
+func (m *MockAccessGenRepo) Bump(ctx context.Context, userID string, t time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.Records[userID]
+	if !ok {
+		rec = &store.AccessGenerationRecord{UserID: userID}
+		m.Records[userID] = rec
+	}
+	rec.Gen++
+	rec.UpdatedAt = t
+	return rec.Gen, nil
+}
+
+// ---- Access token denylist mock (implements store.AccessDenylist) ----
+
+var _ store.AccessDenylist = (*MockAccessDenylist)(nil)
+
+type MockAccessDenylist struct {
+	mu      sync.Mutex
+	Records map[string]*store.AccessDenylistRecord
+}
+
+func NewMockAccessDenylist() *MockAccessDenylist {
+	return &MockAccessDenylist{Records: make(map[string]*store.AccessDenylistRecord)}
+}
+
+func (m *MockAccessDenylist) Deny(ctx context.Context, rec *store.AccessDenylistRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cp := *rec
+	m.Records[rec.JTI] = &cp
+	return nil
+}
+
+func (m *MockAccessDenylist) IsDenied(ctx context.Context, jti string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.Records[jti]
+	return ok, nil
+}